@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"virusgame/game"
+	"virusgame/mcts"
 	"virusgame/search"
 	"virusgame/search/incumbent"
 )
@@ -23,11 +24,42 @@ func TelemetryNodeBudget(nodes uint64, frozen bool) TelemetryAgent {
 		if frozen {
 			result, ok := incumbent.ChooseNodeBudget(state, nodes)
 			legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
-			return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, BudgetShortfall: !result.BudgetExhausted && !result.SearchComplete}, ok
+			return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, BudgetShortfall: !result.BudgetExhausted && !result.SearchComplete, Score: result.Score, Depth: result.Depth}, ok
 		}
 		result, ok := search.ChooseNodeBudget(state, nodes)
 		legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
-		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, BudgetShortfall: !result.BudgetExhausted && !result.SearchComplete}, ok
+		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, BudgetShortfall: !result.BudgetExhausted && !result.SearchComplete, Score: result.Score, Depth: result.Depth, Alternatives: result.Alternatives}, ok
+	}
+}
+
+// TelemetryNodeBudgetSelective is the current engine under the same node
+// ceiling with the given selective-search techniques switched on, for
+// equal-node ladders against the plain TelemetryNodeBudget(nodes, false).
+func TelemetryNodeBudgetSelective(nodes uint64, sel search.Selectivity) TelemetryAgent {
+	return TelemetryNodeBudgetOptions(nodes, search.Options{Selectivity: sel})
+}
+
+// TelemetryNodeBudgetOptions is the current engine under a node ceiling with
+// per-search options (selectivity, multiplayer algorithm). The zero Options is
+// TelemetryNodeBudget(nodes, false).
+func TelemetryNodeBudgetOptions(nodes uint64, opts search.Options) TelemetryAgent {
+	return func(state game.State) (game.Action, DecisionTelemetry, bool) {
+		result, ok := search.ChooseNodeBudgetOptions(state, nodes, opts)
+		legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
+		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, BudgetShortfall: !result.BudgetExhausted && !result.SearchComplete, Score: result.Score, Depth: result.Depth, Alternatives: result.Alternatives}, ok
+	}
+}
+
+// TelemetryMCTS plays the Monte Carlo engine at a fixed iteration budget.
+// Each agent owns one engine; with cfg.ReuseTree on it carries its tree from
+// decision to decision, so it is neither goroutine-safe across games nor
+// order-independent — run such agents at workers=1, like Random.
+func TelemetryMCTS(cfg mcts.Config, iterations uint64) TelemetryAgent {
+	engine := mcts.New(cfg)
+	return func(state game.State) (game.Action, DecisionTelemetry, bool) {
+		result, ok := engine.ChooseIterations(state, iterations)
+		legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
+		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, Score: result.Score, Depth: result.Depth, Alternatives: result.Alternatives}, ok
 	}
 }
 
@@ -65,6 +97,7 @@ func TelemetryTournament(depth int) TelemetryAgent {
 			CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth),
 			LegalRootActions:   legal, SearchedRootActions: searched,
 			LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals,
+			Score: result.Score, Depth: result.Depth, Alternatives: result.Alternatives,
 		}, ok
 	}
 }
@@ -73,7 +106,7 @@ func TelemetryFrozenTournament(depth int) TelemetryAgent {
 	return func(state game.State) (game.Action, DecisionTelemetry, bool) {
 		result, ok := incumbent.ChooseDepth(context.Background(), state, depth)
 		legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
-		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals}, ok
+		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, Score: result.Score, Depth: result.Depth}, ok
 	}
 }
 
@@ -100,6 +133,7 @@ func TelemetryProduction() TelemetryAgent {
 			CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth),
 			LegalRootActions:   legal, SearchedRootActions: searched,
 			LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals,
+			Score: result.Score, Depth: result.Depth, Alternatives: result.Alternatives,
 		}, ok
 	}
 }
@@ -111,7 +145,7 @@ func TelemetryFrozenProduction() TelemetryAgent {
 		// run the "frozen" baseline at the contender's raised budget.
 		result, ok := incumbent.Choose(context.Background(), state)
 		legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
-		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals}, ok
+		return result.Action, DecisionTelemetry{Nodes: result.Nodes, Evaluations: result.Evaluations, CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth), LegalRootActions: legal, SearchedRootActions: searched, LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals, Score: result.Score, Depth: result.Depth}, ok
 	}
 }
 
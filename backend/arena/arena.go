@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"virusgame/game"
+	"virusgame/search"
 )
 
 type Agent func(game.State) (game.Action, bool)
@@ -17,6 +18,8 @@ type Agent func(game.State) (game.Action, bool)
 // authoritative root candidates covered by the last completed iteration; they
 // are zero when no iteration completed, even if an aborted iteration visited
 // some candidates. LegalRoot fields always describe the authoritative set.
+// Score, Depth (completed action plies) and Alternatives are the search's own
+// readout of the decision; game records keep them, the reports do not.
 type DecisionTelemetry struct {
 	Nodes                                   uint64
 	Evaluations                             uint64
@@ -24,6 +27,9 @@ type DecisionTelemetry struct {
 	LegalRootActions, SearchedRootActions   int
 	LegalRootNeutrals, SearchedRootNeutrals int
 	BudgetShortfall                         bool
+	Score                                   int
+	Depth                                   int
+	Alternatives                            []search.RootMove
 }
 
 type TelemetryAgent func(game.State) (game.Action, DecisionTelemetry, bool)
@@ -41,6 +47,8 @@ type Match struct {
 	Agents          []Agent
 	TelemetryAgents []TelemetryAgent
 	MaxActions      int
+	// Record keeps every applied decision in GameResult.Record.
+	Record bool
 }
 
 type GameResult struct {
@@ -52,6 +60,8 @@ type GameResult struct {
 	Maxed                                   bool
 	Stalled                                 bool
 	Latencies                               [4][]time.Duration
+	DecisionNodes                           [4][]uint64 // per decision, like Latencies
+	DecisionDepths                          [4][]int    // completed turn depth per decision
 	Nodes                                   [4]uint64
 	Evaluations                             [4]uint64
 	BudgetShortfalls                        [4]int
@@ -65,6 +75,9 @@ type GameResult struct {
 	// ascending player number so the result stays deterministic.
 	Placement [4]int
 	Elapsed   time.Duration
+	// Record is the game move by move when Match.Record (or VS_ARENA_RECORD)
+	// asked for it.
+	Record *GameRecord
 }
 
 type Report struct {
@@ -73,6 +86,8 @@ type Report struct {
 	Decisions                               int
 	Maxed, Stalled                          int
 	Latencies                               []time.Duration
+	DecisionNodes                           []uint64
+	DecisionDepths                          []int
 	Nodes                                   uint64
 	Evaluations                             uint64
 	BudgetShortfalls                        int
@@ -98,6 +113,8 @@ func Probe(boards []Board, agent TelemetryAgent) (Report, error) {
 		report.Decisions++
 		report.Elapsed += latency
 		report.Latencies = append(report.Latencies, latency)
+		report.DecisionNodes = append(report.DecisionNodes, telemetry.Nodes)
+		report.DecisionDepths = append(report.DecisionDepths, telemetry.CompletedTurnDepth)
 		report.Nodes += telemetry.Nodes
 		report.Evaluations += telemetry.Evaluations
 		report.LegalRootActions += telemetry.LegalRootActions
@@ -148,6 +165,9 @@ func Play(match Match) (GameResult, error) {
 		return GameResult{}, err
 	}
 	result := GameResult{}
+	if match.Record || recordDir != "" {
+		result.Record = &GameRecord{Rows: match.Rows, Cols: match.Cols, Players: agentCount, Start: match.Initial}
+	}
 	var elimOrder []game.Player
 	started := time.Now()
 	for !state.GameOver() && result.Actions < match.MaxActions {
@@ -165,6 +185,8 @@ func Play(match Match) (GameResult, error) {
 			action, ok = match.Agents[player-1](state)
 		}
 		result.Latencies[player-1] = append(result.Latencies[player-1], time.Since(decisionStart))
+		result.DecisionNodes[player-1] = append(result.DecisionNodes[player-1], telemetry.Nodes)
+		result.DecisionDepths[player-1] = append(result.DecisionDepths[player-1], telemetry.CompletedTurnDepth)
 		result.Nodes[player-1] += telemetry.Nodes
 		result.Evaluations[player-1] += telemetry.Evaluations
 		result.LegalRootActions[player-1] += telemetry.LegalRootActions
@@ -180,6 +202,9 @@ func Play(match Match) (GameResult, error) {
 		if !ok {
 			if len(legal) > 0 {
 				result.Illegal++
+				if result.Record != nil {
+					result.Record.Offender = player
+				}
 			}
 			result.Stalled = true
 			break
@@ -187,8 +212,14 @@ func Play(match Match) (GameResult, error) {
 		next, err := state.Apply(action)
 		if err != nil {
 			result.Illegal++
+			if result.Record != nil {
+				result.Record.Offender = player
+			}
 			break
 		}
+		if result.Record != nil {
+			result.Record.Decisions = append(result.Record.Decisions, RecordedDecision{Player: player, Action: action, Telemetry: telemetry})
+		}
 		result.Actions++
 		result.Eliminations += before - activeCount(next)
 		for p := game.Player(1); int(p) <= agentCount; p++ {
@@ -201,14 +232,19 @@ func Play(match Match) (GameResult, error) {
 	result.Elapsed = time.Since(started)
 	result.Winner = state.Winner()
 	result.Maxed = !state.GameOver() && result.Actions >= match.MaxActions
-	result.Placement = placements(agentCount, elimOrder, state)
+	result.Placement = Placements(agentCount, elimOrder, state)
+	if recordDir != "" {
+		if err := writeRecorded(result); err != nil {
+			return result, fmt.Errorf("record game: %w", err)
+		}
+	}
 	return result, nil
 }
 
-// placements assigns 1-based finishing places from elimination order and the
+// Placements assigns 1-based finishing places from elimination order and the
 // terminal state. Survivors rank first (ascending player number), then the
 // eliminated in reverse elimination order (last eliminated places higher).
-func placements(agentCount int, elimOrder []game.Player, state game.State) [4]int {
+func Placements(agentCount int, elimOrder []game.Player, state game.State) [4]int {
 	var place [4]int
 	next := 1
 	for p := game.Player(1); int(p) <= agentCount; p++ {
@@ -301,6 +337,8 @@ func (r *Report) Add(result GameResult, focus game.Player) {
 	r.Decisions += result.Decisions
 	r.Elapsed += result.Elapsed
 	r.Latencies = append(r.Latencies, result.Latencies[focus-1]...)
+	r.DecisionNodes = append(r.DecisionNodes, result.DecisionNodes[focus-1]...)
+	r.DecisionDepths = append(r.DecisionDepths, result.DecisionDepths[focus-1]...)
 	r.Nodes += result.Nodes[focus-1]
 	r.Evaluations += result.Evaluations[focus-1]
 	r.LegalRootActions += result.LegalRootActions[focus-1]
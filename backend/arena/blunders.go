@@ -0,0 +1,165 @@
+package arena
+
+import (
+	"fmt"
+	"strings"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+// vs-ai2.74: blunder mining. Holes in the engine used to surface only when a
+// human beat the bot in production. A BlunderMiner re-searches every decision
+// of a recorded game at a much larger node budget and flags the ones where
+// the played action scores Threshold or more below the deep search's best.
+// Both actions are scored the same way, by a deep search of the position each
+// leaves, so the comparison is like for like; the deep search's own root score
+// is not used. Flagged positions become suite cases (am the played action)
+// keyed by StateFingerprint; arena/cmd/blundermine plays the games.
+
+// BlunderMiner scores recorded decisions with a deep node-budget search.
+type BlunderMiner struct {
+	// Nodes is the deep search's node budget, per position searched.
+	Nodes uint64
+	// Threshold is the eval-unit loss that flags a decision.
+	Threshold int
+	// Options configure the deep search; the opening book is always off.
+	Options search.Options
+}
+
+// Blunder is one flagged decision.
+type Blunder struct {
+	// Fingerprint is the position's StateFingerprint.
+	Fingerprint string
+	State       game.State
+	Played      game.Action
+	Best        game.Action
+	// PlayedScore and BestScore are the deep scores, for the player to move,
+	// of the positions the two actions leave.
+	PlayedScore, BestScore int
+	Motifs                 []string
+	// Source names the game the decision came from.
+	Source string
+}
+
+// Loss is how far the played action fell below the best.
+func (b Blunder) Loss() int { return b.BestScore - b.PlayedScore }
+
+// SuiteCase writes the blunder as a suite case avoiding the played action,
+// tagged with its first motif.
+func (b Blunder) SuiteCase() SuiteCase {
+	c := SuiteCaseFor(b.State, "blunder-"+b.Fingerprint, b.Motifs[0])
+	c.Avoid = []game.Action{b.Played}
+	c.Comment = fmt.Sprintf("loss %d: best %s %+d, played %+d; %s; %s",
+		b.Loss(), FormatAction(b.Best), b.BestScore, b.PlayedScore, strings.Join(b.Motifs, ","), b.Source)
+	return c
+}
+
+// Check re-searches one decision. It reports a Blunder when played scores at
+// least Threshold below the deep search's choice; 1v1 positions only.
+func (m BlunderMiner) Check(state game.State, played game.Action) (Blunder, bool, error) {
+	if state.Players() != 2 {
+		return Blunder{}, false, fmt.Errorf("blunder mining is 1v1, the position has %d seats", state.Players())
+	}
+	opts := m.Options
+	opts.NoBook = true
+	deep, ok := search.ChooseNodeBudgetOptions(state, m.Nodes, opts)
+	if !ok || sameAction(deep.Action, played) {
+		return Blunder{}, false, nil
+	}
+	playedScore, err := m.scoreAfter(state, played, opts)
+	if err != nil {
+		return Blunder{}, false, err
+	}
+	bestScore, err := m.scoreAfter(state, deep.Action, opts)
+	if err != nil {
+		return Blunder{}, false, err
+	}
+	if bestScore-playedScore < m.Threshold {
+		return Blunder{}, false, nil
+	}
+	fingerprint, err := StateFingerprint(state)
+	if err != nil {
+		return Blunder{}, false, err
+	}
+	return Blunder{
+		Fingerprint: fingerprint, State: state, Played: played, Best: deep.Action,
+		PlayedScore: playedScore, BestScore: bestScore,
+		Motifs: ClassifyBlunder(state, played, deep.Action, m.params()),
+	}, true, nil
+}
+
+// scoreAfter is the deep score, for the player to move in state, of the
+// position action leaves.
+func (m BlunderMiner) scoreAfter(state game.State, action game.Action, opts search.Options) (int, error) {
+	player := state.CurrentPlayer()
+	next, err := state.Apply(action)
+	if err != nil {
+		return 0, err
+	}
+	if next.GameOver() {
+		return search.StaticEval(next, player), nil
+	}
+	result, ok := search.ChooseNodeBudgetOptions(next, m.Nodes, opts)
+	if !ok {
+		return search.StaticEval(next, player), nil
+	}
+	if next.CurrentPlayer() != player {
+		return -result.Score, nil
+	}
+	return result.Score, nil
+}
+
+// params is the eval weights the deep search plays: Options.Params, else the
+// process-wide weights.
+func (m BlunderMiner) params() search.EvalParams {
+	if m.Options.Params != nil {
+		return *m.Options.Params
+	}
+	return search.CurrentEvalParams()
+}
+
+// MineGame checks every decision of a recorded 1v1 game made by one of the
+// seats in mine (all seats when mine is empty) and returns the blunders in
+// game order, each with Source set to source.
+func (m BlunderMiner) MineGame(record *GameRecord, source string, mine ...game.Player) ([]Blunder, error) {
+	if record == nil {
+		return nil, fmt.Errorf("mine %s: the game was not recorded (Match.Record)", source)
+	}
+	var state game.State
+	var err error
+	if record.Start != nil {
+		state, err = game.FromSnapshot(*record.Start)
+	} else {
+		state, err = game.New(record.Rows, record.Cols, record.Players)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mine %s: %w", source, err)
+	}
+	var blunders []Blunder
+	for i, decision := range record.Decisions {
+		if len(mine) == 0 || containsPlayer(mine, decision.Player) {
+			blunder, found, err := m.Check(state, decision.Action)
+			if err != nil {
+				return nil, fmt.Errorf("mine %s decision %d: %w", source, i+1, err)
+			}
+			if found {
+				blunder.Source = fmt.Sprintf("%s #%d", source, i+1)
+				blunders = append(blunders, blunder)
+			}
+		}
+		if state, err = state.Apply(decision.Action); err != nil {
+			return nil, fmt.Errorf("mine %s decision %d: %w", source, i+1, err)
+		}
+	}
+	return blunders, nil
+}
+
+func containsPlayer(players []game.Player, player game.Player) bool {
+	for _, p := range players {
+		if p == player {
+			return true
+		}
+	}
+	return false
+}
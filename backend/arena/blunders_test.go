@@ -0,0 +1,84 @@
+package arena
+
+import (
+	"strings"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+func TestBlunderMinerFlagsTheWorstMove(t *testing.T) {
+	opening, err := RandomLegalOpening(8, 8, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := game.FromSnapshot(opening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	player := state.CurrentPlayer()
+	var worst game.Action
+	worstScore := 0
+	for i, action := range state.LegalActions() {
+		next, _ := state.Apply(action)
+		if score := search.StaticEval(next, player); i == 0 || score < worstScore {
+			worst, worstScore = action, score
+		}
+	}
+	miner := BlunderMiner{Nodes: 3000, Threshold: 1}
+	blunder, found, err := miner.Check(state, worst)
+	if err != nil || !found {
+		t.Fatalf("worst move not flagged: %v", err)
+	}
+	fingerprint, _ := StateFingerprint(state)
+	if blunder.Fingerprint != fingerprint || blunder.Loss() < 1 || len(blunder.Motifs) == 0 {
+		t.Fatalf("blunder %+v", blunder)
+	}
+	if _, found, _ := miner.Check(state, blunder.Best); found {
+		t.Fatal("the deep search's own choice flagged")
+	}
+
+	cases, err := ParseSuite(strings.NewReader(blunder.SuiteCase().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cases[0].Passes(worst) || !cases[0].Passes(blunder.Best) || cases[0].ID != "blunder-"+fingerprint {
+		t.Fatalf("suite case %s", cases[0])
+	}
+	if _, _, err := (BlunderMiner{Nodes: 100}).Check(mustNew(t, 12, 12, 3), worst); err == nil {
+		t.Fatal("3-seat position mined")
+	}
+}
+
+func TestMineGameWalksTheRecord(t *testing.T) {
+	result, err := Play(Match{Rows: 6, Cols: 6, Agents: []Agent{Greedy, Random(3)}, Record: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	miner := BlunderMiner{Nodes: 300, Threshold: 1}
+	blunders, err := miner.MineGame(result.Record, "greedy-random", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blunders) == 0 {
+		t.Fatal("random play mined no blunders")
+	}
+	for _, blunder := range blunders {
+		if blunder.State.CurrentPlayer() != 2 || !strings.HasPrefix(blunder.Source, "greedy-random #") {
+			t.Fatalf("blunder %+v", blunder)
+		}
+	}
+	if _, err := miner.MineGame(nil, "unrecorded"); err == nil {
+		t.Fatal("unrecorded game mined")
+	}
+}
+
+func mustNew(t *testing.T, rows, cols, players int) game.State {
+	t.Helper()
+	state, err := game.New(rows, cols, players)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
@@ -0,0 +1,291 @@
+// Command blundermine hunts for engine mistakes without waiting for a human to
+// find them: it plays the current engine against an opponent from many
+// starts, re-searches every engine decision with arena.BlunderMiner at a much
+// larger node budget, and appends the decisions that lose -threshold or more
+// to a suite file as "am" cases tagged with arena.ClassifyBlunder's motif.
+//
+//	go run ./arena/cmd/blundermine -out blunders.epd
+//	go run ./arena/cmd/blundermine -opponent ownerbot -games 50 -deep 500000 -out blunders.epd
+//	go run ./arena/cmd/blundermine -corpus arena/testdata/strength-corpus-v1.json -nodes 20000 -out blunders.epd
+//
+// The engine plays at the production budget (search.ProductionBudget of wall
+// clock, one game at a time) unless -nodes sets a node budget. Starts are
+// seeded balanced openings on -board, or with -corpus the 1v1 corpus
+// checkpoints; each is played with the engine in both seats. -opponent is
+// self (each start played once, both seats mined) or an engine spec
+// (engine.ParseSpec) such as
+// incumbent, ownerbot or search:v2?params=old.json; one without a budget plays
+// at the engine's.
+//
+// Positions are deduplicated by arena.StateFingerprint, against each other and
+// against the cases already in -out, so rerunning appends only new ones. Check
+// the new lines with cmd/suite before keeping them.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"virusgame/arena"
+	"virusgame/engine"
+	"virusgame/game"
+	"virusgame/search"
+)
+
+func main() {
+	out := flag.String("out", "blunders.epd", "suite file the new cases are appended to")
+	opponent := flag.String("opponent", "self", "self or an engine spec: incumbent, greedy, base, mobility, cutseeker, ownerbot, ...")
+	games := flag.Int("games", 20, "starts to play, each with the engine in both seats (once against itself)")
+	board := flag.String("board", "12x12", "board of the seeded openings")
+	corpusPath := flag.String("corpus", "", "start from this corpus's 1v1 checkpoints instead of seeded openings")
+	nodes := flag.Uint64("nodes", 0, "engine node budget; 0 plays the production wall-clock budget")
+	paramsPath := flag.String("params", "", "EvalParams JSON for the engine and the deep search")
+	deep := flag.Uint64("deep", 200000, "node budget of each deep re-search")
+	threshold := flag.Int("threshold", 1000, "eval-unit loss that flags a decision")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "concurrent games (node budgets) and re-searches")
+	flag.Parse()
+
+	var opts search.Options
+	var err error
+	if *paramsPath != "" {
+		params, err := search.LoadEvalParams(*paramsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Params = &params
+	}
+	engine := func() arena.TelemetryAgent { return arena.TelemetryNodeBudgetOptions(*nodes, opts) }
+	playWorkers := *workers
+	if *nodes == 0 {
+		if *paramsPath != "" {
+			log.Fatal("-params needs -nodes: the production agent plays the deployed weights")
+		}
+		engine, playWorkers = arena.TelemetryProduction, 1
+	}
+	rival := engine
+	if *opponent != "self" {
+		if rival, err = buildOpponent(*opponent, *nodes); err != nil {
+			log.Fatal(err)
+		}
+	}
+	starts, err := loadStarts(*corpusPath, *board, *games)
+	if err != nil {
+		log.Fatal(err)
+	}
+	known, err := knownFingerprints(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// A unit is one start with the engine in one seat.
+	type unit struct {
+		name   string
+		record *arena.GameRecord
+		mine   []game.Player
+	}
+	// Against itself the engine is already in both seats, and swapping them
+	// would replay the same game, so each start is played once.
+	seats := 2
+	if *opponent == "self" {
+		seats = 1
+	}
+	units := make([]unit, seats*len(starts))
+	err = parallel(len(units), playWorkers, func(i int) error {
+		start, seat := starts[i/seats], game.Player(i%seats+1)
+		agents := []arena.TelemetryAgent{engine(), rival()}
+		if seat == 2 {
+			agents[0], agents[1] = agents[1], agents[0]
+		}
+		snapshot := start.snapshot
+		result, err := arena.Play(arena.Match{Rows: snapshot.Rows, Cols: snapshot.Cols, Initial: &snapshot, TelemetryAgents: agents, Record: true})
+		if err != nil {
+			return fmt.Errorf("%s seat %d: %w", start.name, seat, err)
+		}
+		name, mine := fmt.Sprintf("%s seat %d vs %s", start.name, seat, *opponent), []game.Player{seat}
+		if *opponent == "self" {
+			name, mine = start.name+" self-play", nil
+		}
+		units[i] = unit{name: name, record: result.Record, mine: mine}
+		fmt.Fprintf(os.Stderr, "played %s: %d decisions, winner %d\n", units[i].name, len(result.Record.Decisions), result.Winner)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	miner := arena.BlunderMiner{Nodes: *deep, Threshold: *threshold, Options: opts}
+	found := make([][]arena.Blunder, len(units))
+	err = parallel(len(units), *workers, func(i int) error {
+		blunders, err := miner.MineGame(units[i].record, units[i].name, units[i].mine...)
+		found[i] = blunders
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var fresh []arena.Blunder
+	for _, blunders := range found {
+		for _, blunder := range blunders {
+			if known[blunder.Fingerprint] {
+				continue
+			}
+			known[blunder.Fingerprint] = true
+			fresh = append(fresh, blunder)
+		}
+	}
+	if err := appendCases(*out, fresh); err != nil {
+		log.Fatal(err)
+	}
+	motifs := map[string]int{}
+	for _, blunder := range fresh {
+		motifs[blunder.Motifs[0]]++
+		fmt.Printf("%s loss %d: played %s, best %s (%s)\n", blunder.Source, blunder.Loss(),
+			arena.FormatAction(blunder.Played), arena.FormatAction(blunder.Best), strings.Join(blunder.Motifs, ","))
+	}
+	names := make([]string, 0, len(motifs))
+	for motif := range motifs {
+		names = append(names, motif)
+	}
+	sort.Strings(names)
+	for _, motif := range names {
+		fmt.Printf("%-24s %d\n", motif, motifs[motif])
+	}
+	fmt.Printf("%d new blunders appended to %s\n", len(fresh), *out)
+}
+
+type start struct {
+	name     string
+	snapshot game.Snapshot
+}
+
+// loadStarts returns the first games seeded openings on board, or the first
+// games 1v1 checkpoints of the corpus at corpusPath.
+func loadStarts(corpusPath, board string, games int) ([]start, error) {
+	if games < 1 {
+		return nil, errors.New("-games must be positive")
+	}
+	var starts []start
+	if corpusPath != "" {
+		fixture, err := os.Open(corpusPath)
+		if err != nil {
+			return nil, err
+		}
+		defer fixture.Close()
+		corpus, err := arena.DecodeCorpus(fixture)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range corpus.Cases {
+			if c.Players == 2 && !c.State.GameOver() && len(starts) < games {
+				starts = append(starts, start{name: c.ID, snapshot: c.State.Snapshot()})
+			}
+		}
+		if len(starts) == 0 {
+			return nil, fmt.Errorf("%s has no 1v1 checkpoints", corpusPath)
+		}
+		return starts, nil
+	}
+	var rows, cols int
+	if _, err := fmt.Sscanf(board, "%dx%d", &rows, &cols); err != nil || rows < 2 || cols < 2 {
+		return nil, fmt.Errorf("invalid -board %q", board)
+	}
+	for i := 0; i < games; i++ {
+		snapshot, err := arena.RandomLegalOpening(rows, cols, uint64(i)+1)
+		if err != nil {
+			return nil, err
+		}
+		starts = append(starts, start{name: fmt.Sprintf("opening %d", i+1), snapshot: snapshot})
+	}
+	return starts, nil
+}
+
+// buildOpponent returns a factory for the opponent engine spec. An opponent
+// without a budget of its own plays at the engine's: -nodes, else production.
+func buildOpponent(text string, nodes uint64) (func() arena.TelemetryAgent, error) {
+	spec, err := engine.ParseSpec(text)
+	if err != nil {
+		return nil, fmt.Errorf("-opponent: %w", err)
+	}
+	built, err := arena.BuildEngineSpec(spec.WithNodes(nodes))
+	if err != nil {
+		return nil, fmt.Errorf("-opponent: %w", err)
+	}
+	return func() arena.TelemetryAgent { return built.New(1) }, nil
+}
+
+// knownFingerprints reads the blunder cases already in path, keyed by the
+// fingerprint in their id. A missing file has none.
+func knownFingerprints(path string) (map[string]bool, error) {
+	known := map[string]bool{}
+	cases, err := arena.ReadSuite(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cases {
+		if fingerprint, ok := strings.CutPrefix(c.ID, "blunder-"); ok {
+			known[fingerprint] = true
+		}
+	}
+	return known, nil
+}
+
+// appendCases appends blunders to the suite file at path as suite lines.
+func appendCases(path string, blunders []arena.Blunder) error {
+	if len(blunders) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, blunder := range blunders {
+		if _, err := fmt.Fprintln(file, blunder.SuiteCase()); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// parallel runs fn(0..n-1) on workers goroutines and returns the first error.
+func parallel(n, workers int, fn func(int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs <- fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"virusgame/arena"
+	"virusgame/game"
+)
+
+func TestAppendedCasesAreKnownOnRerun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blunders.epd")
+	if known, err := knownFingerprints(path); err != nil || len(known) != 0 {
+		t.Fatalf("missing file: %v %v", known, err)
+	}
+	starts, err := loadStarts("", "8x8", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := game.FromSnapshot(starts[0].snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, _ := arena.StateFingerprint(state)
+	blunder := arena.Blunder{Fingerprint: fingerprint, State: state, Played: state.LegalActions()[0],
+		Best: state.LegalActions()[1], Motifs: []string{"unclassified"}, Source: "test"}
+	if err := appendCases(path, []arena.Blunder{blunder}); err != nil {
+		t.Fatal(err)
+	}
+	if known, err := knownFingerprints(path); err != nil || !known[fingerprint] || len(known) != 1 {
+		t.Fatalf("after append: %v %v", known, err)
+	}
+
+	if _, err := buildOpponent("alphazero", 100); err == nil {
+		t.Error("unknown opponent accepted")
+	}
+	if _, err := loadStarts("", "8by8", 1); err == nil {
+		t.Error("bad board accepted")
+	}
+}
@@ -0,0 +1,407 @@
+// Command bookgen builds the opening book search consults for each seat's first
+// turns (search.Book, VS_OPENING_BOOK). It grows a tree of early-game positions
+// keyed by search.BookKey from two sources and writes them, with per-move play
+// counts and mean results, in the search/openingbook file format:
+//
+//   - self-play: node-budget search games on every -boards × -players
+//     combination. While the mover is inside the book (its first -turns own
+//     turns) it samples among the search's root candidates by a softmax over
+//     their scores at -temperature eval units, so the tree branches; after
+//     that it plays the search move to the end of the game.
+//   - human games: with -db, every finished 1v1 game in the server's SQLite
+//     store, replayed through the rules; only the human seats' moves are
+//     used, as arena.HumanDecisions extracts them.
+//
+// A move's result is 1 for the winner and 0 for a loser; self-play 3-4 player
+// games score by placement, (players − place)/(players − 1). Stored games only
+// record their winner, so a losing human seat scores 0. Moves played
+// fewer than -min-games times are dropped from the file.
+//
+// Each self-play game is seeded by -seed and its index, so the book is the same
+// for any -workers.
+//
+//	go run ./arena/cmd/bookgen -out book.vbok -boards 10x10,12x12 -players 2,4 \
+//	    -games 400 -db ../data/games.db
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"virusgame/arena"
+	"virusgame/arena/storedgames"
+	"virusgame/game"
+	"virusgame/search"
+	"virusgame/search/openingbook"
+)
+
+// Config parameterizes a build so tests can drive it in-process.
+type Config struct {
+	Boards  []arena.Board
+	Players []int
+	// Games is the self-play game count, spread round-robin over every board
+	// and seat count.
+	Games       int
+	Nodes       uint64
+	Turns       int
+	Temperature float64
+	Seed        uint64
+	Workers     int
+	// DB is a games.db path; "" skips human games.
+	DB       string
+	MinGames uint32
+}
+
+// stat accumulates one move's play count and summed results.
+type stat struct {
+	games uint32
+	total float64
+}
+
+type node struct {
+	turn  uint8
+	moves map[game.Action]*stat
+}
+
+// tree is the book under construction.
+type tree map[uint64]*node
+
+// play is one book move made during a game, scored once the game ends.
+type play struct {
+	key    uint64
+	turn   int
+	action game.Action
+	mover  game.Player
+}
+
+func (t tree) add(plays []play, result func(game.Player) float64) {
+	for _, p := range plays {
+		n := t[p.key]
+		if n == nil {
+			n = &node{turn: uint8(p.turn), moves: map[game.Action]*stat{}}
+			t[p.key] = n
+		}
+		n.turn = min(n.turn, uint8(p.turn))
+		s := n.moves[p.action]
+		if s == nil {
+			s = &stat{}
+			n.moves[p.action] = s
+		}
+		s.games++
+		s.total += result(p.mover)
+	}
+}
+
+func (t tree) merge(other tree) {
+	for key, o := range other {
+		n := t[key]
+		if n == nil {
+			t[key] = o
+			continue
+		}
+		n.turn = min(n.turn, o.turn)
+		for action, add := range o.moves {
+			if s := n.moves[action]; s != nil {
+				s.games += add.games
+				s.total += add.total
+			} else {
+				n.moves[action] = add
+			}
+		}
+	}
+}
+
+// book is the tree in file form, without moves under minGames or positions
+// left empty by that cut.
+func (t tree) book(minGames uint32) *openingbook.Book {
+	book := &openingbook.Book{Entries: map[uint64]openingbook.Entry{}}
+	for key, n := range t {
+		entry := openingbook.Entry{Turn: n.turn}
+		for action, s := range n.moves {
+			if s.games < max(minGames, 1) {
+				continue
+			}
+			entry.Moves = append(entry.Moves, openingbook.Move{Action: action, Games: s.games, Score: float32(s.total / float64(s.games))})
+		}
+		if len(entry.Moves) != 0 {
+			book.Entries[key] = entry
+		}
+	}
+	return book
+}
+
+// turnCounter tracks each seat's own-turn number as a game is replayed.
+type turnCounter [5]int
+
+// step counts the mover's turn as finished when the seat to move changes.
+func (c *turnCounter) step(before, after game.State) {
+	if after.GameOver() || after.CurrentPlayer() != before.CurrentPlayer() {
+		c[before.CurrentPlayer()]++
+	}
+}
+
+func next(rng *uint64) uint64 {
+	*rng ^= *rng << 13
+	*rng ^= *rng >> 7
+	*rng ^= *rng << 17
+	return *rng
+}
+
+// sample draws among the search's chosen move and its alternatives with
+// probability ∝ exp(score/temperature). A non-positive temperature plays the
+// search move.
+func sample(result search.Result, temperature float64, rng *uint64) game.Action {
+	if temperature <= 0 || len(result.Alternatives) == 0 {
+		return result.Action
+	}
+	candidates := append([]search.RootMove{{Action: result.Action, Score: result.Score}}, result.Alternatives...)
+	best := candidates[0].Score
+	for _, c := range candidates {
+		best = max(best, c.Score)
+	}
+	weights := make([]float64, len(candidates))
+	var sum float64
+	for i, c := range candidates {
+		weights[i] = math.Exp(float64(c.Score-best) / temperature)
+		sum += weights[i]
+	}
+	draw := float64(next(rng)>>11) / (1 << 53) * sum
+	for i, w := range weights {
+		if draw < w {
+			return candidates[i].Action
+		}
+		draw -= w
+	}
+	return candidates[len(candidates)-1].Action
+}
+
+// selfPlay plays game index i of cfg and returns its book moves.
+func selfPlay(cfg Config, i int) tree {
+	board := cfg.Boards[i%len(cfg.Boards)]
+	players := cfg.Players[(i/len(cfg.Boards))%len(cfg.Players)]
+	state, err := game.New(board.Rows, board.Cols, players)
+	if err != nil {
+		return tree{}
+	}
+	rng := (cfg.Seed + uint64(i)*0x9e3779b97f4a7c15) | 1
+	opts := search.Options{NoBook: true}
+	var plays []play
+	var turns turnCounter
+	var elimOrder []game.Player
+	maxPlies := board.Rows * board.Cols * 4
+	for plies := 0; !state.GameOver() && plies < maxPlies; plies++ {
+		mover := state.CurrentPlayer()
+		result, ok := search.ChooseNodeBudgetOptions(state, cfg.Nodes, opts)
+		if !ok {
+			break
+		}
+		action := result.Action
+		if turns[mover] < cfg.Turns {
+			action = sample(result, cfg.Temperature, &rng)
+			plays = append(plays, play{key: search.BookKey(state), turn: turns[mover], action: action, mover: mover})
+		}
+		next, err := state.Apply(action)
+		if err != nil {
+			break
+		}
+		for seat := game.Player(1); int(seat) <= players; seat++ {
+			if state.Active(seat) && !next.Active(seat) {
+				elimOrder = append(elimOrder, seat)
+			}
+		}
+		turns.step(state, next)
+		state = next
+	}
+	t := tree{}
+	if !state.GameOver() {
+		return t // ran out of plies: no result to credit
+	}
+	places := arena.Placements(players, elimOrder, state)
+	t.add(plays, func(seat game.Player) float64 {
+		return float64(players-places[seat-1]) / float64(players-1)
+	})
+	return t
+}
+
+// selfPlayAll plays cfg.Games games over cfg.Workers goroutines.
+func selfPlayAll(cfg Config) tree {
+	trees := make([]tree, cfg.Games)
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < max(cfg.Workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				trees[i] = selfPlay(cfg, i)
+			}
+		}()
+	}
+	for i := 0; i < cfg.Games; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	// Merging in game order keeps the float sums, and so the file, identical
+	// for any worker count.
+	out := tree{}
+	for _, t := range trees {
+		out.merge(t)
+	}
+	return out
+}
+
+// humanGames reads every decided 1v1 game in the games database at path and
+// returns the book moves of its human seats (arena.HumanDecisions; bot seats
+// are left out). Games that are not 1v1, no longer replay under the current
+// rules or have no human seat are counted and skipped.
+func humanGames(path string, turns int) (t tree, used, skipped int, err error) {
+	games, err := storedgames.Read(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	t = tree{}
+	for _, stored := range games {
+		if stored.Result != 1 && stored.Result != 2 {
+			skipped++ // undecided, abandoned or not 1v1
+			continue
+		}
+		replay, err := stored.Replay()
+		if err != nil {
+			skipped++
+			continue
+		}
+		decisions, err := arena.HumanDecisions(replay)
+		if err != nil || len(decisions) == 0 {
+			skipped++
+			continue
+		}
+		var plays []play
+		var counter turnCounter
+		for _, decision := range decisions {
+			state, mover := decision.State, decision.State.CurrentPlayer()
+			next, err := state.Apply(decision.Action)
+			if err != nil {
+				return nil, 0, 0, fmt.Errorf("game %s: %w", stored.ID, err)
+			}
+			if counter[mover] < turns {
+				plays = append(plays, play{key: search.BookKey(state), turn: counter[mover], action: decision.Action, mover: mover})
+			}
+			counter.step(state, next)
+		}
+		t.add(plays, func(seat game.Player) float64 {
+			if seat == replay.Winner {
+				return 1
+			}
+			return 0
+		})
+		used++
+	}
+	return t, used, skipped, nil
+}
+
+// Build runs cfg and returns the book.
+func Build(cfg Config) (*openingbook.Book, error) {
+	if len(cfg.Boards) == 0 {
+		cfg.Boards = []arena.Board{{Rows: 10, Cols: 10}}
+	}
+	if len(cfg.Players) == 0 {
+		cfg.Players = []int{2}
+	}
+	for _, players := range cfg.Players {
+		if players < 2 || players > 4 {
+			return nil, fmt.Errorf("self-play needs 2-4 players, got %d", players)
+		}
+	}
+	if cfg.Turns < 1 || cfg.Turns > math.MaxUint8 {
+		return nil, fmt.Errorf("-turns must be 1-%d, got %d", math.MaxUint8, cfg.Turns)
+	}
+	t := selfPlayAll(cfg)
+	if cfg.DB != "" {
+		human, used, skipped, err := humanGames(cfg.DB, cfg.Turns)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.DB, err)
+		}
+		fmt.Fprintf(os.Stderr, "human games: %d used, %d skipped\n", used, skipped)
+		t.merge(human)
+	}
+	return t.book(cfg.MinGames), nil
+}
+
+// parseBoards parses "8x8,12x12" into arena.Board specs.
+func parseBoards(spec string) ([]arena.Board, error) {
+	var boards []arena.Board
+	for _, part := range strings.Split(spec, ",") {
+		var board arena.Board
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%dx%d", &board.Rows, &board.Cols); err != nil {
+			return nil, fmt.Errorf("bad board %q (want RxC)", part)
+		}
+		boards = append(boards, board)
+	}
+	return boards, nil
+}
+
+func main() {
+	out := flag.String("out", "", "book file to write (required)")
+	boards := flag.String("boards", "10x10", "comma-separated self-play board sizes, e.g. 10x10,12x12")
+	players := flag.String("players", "2", "comma-separated self-play seat counts, e.g. 2,4")
+	games := flag.Int("games", 200, "self-play games")
+	nodes := flag.Uint64("nodes", 2000, "node budget per self-play move")
+	turns := flag.Int("turns", search.DefaultBookTurns, "own turns per seat the book covers")
+	temperature := flag.Float64("temperature", 150, "softmax temperature over root scores while in the book, eval units (0 = search move)")
+	seed := flag.Uint64("seed", 1, "self-play seed")
+	workers := flag.Int("workers", 1, "parallel self-play games")
+	db := flag.String("db", "", "SQLite games.db to add human games from")
+	minGames := flag.Uint("min-games", 1, "drop moves played fewer times than this")
+	flag.Parse()
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "-out is required")
+		os.Exit(2)
+	}
+	parsedBoards, err := parseBoards(*boards)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var seatCounts []int
+	for _, part := range strings.Split(*players, ",") {
+		count, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bad -players %q: %v\n", *players, err)
+			os.Exit(2)
+		}
+		seatCounts = append(seatCounts, count)
+	}
+	book, err := Build(Config{
+		Boards: parsedBoards, Players: seatCounts, Games: *games, Nodes: *nodes, Turns: *turns,
+		Temperature: *temperature, Seed: *seed, Workers: *workers, DB: *db, MinGames: uint32(*minGames),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := book.Encode(file); err != nil {
+		file.Close()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	moves := 0
+	for _, entry := range book.Entries {
+		moves += len(entry.Moves)
+	}
+	fmt.Printf("wrote %s: %d positions, %d moves\n", *out, len(book.Entries), moves)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"virusgame/arena"
+	"virusgame/game"
+	"virusgame/search"
+	"virusgame/search/openingbook"
+)
+
+func tinyConfig() Config {
+	return Config{
+		Boards: []arena.Board{{Rows: 6, Cols: 6}}, Players: []int{2, 3}, Games: 4,
+		Nodes: 200, Turns: 2, Temperature: 150, Seed: 3,
+	}
+}
+
+// TestSelfPlayBookRoundTripsAndPlays: a tiny build is the same for any
+// worker count, survives the file format, and search plays from it.
+func TestSelfPlayBookRoundTripsAndPlays(t *testing.T) {
+	cfg := tinyConfig()
+	cfg.Workers = 1
+	book, err := Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Workers = 3
+	parallel, err := Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a, b bytes.Buffer
+	if err := book.Encode(&a); err != nil {
+		t.Fatal(err)
+	}
+	if err := parallel.Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Fatal("worker count changed the book")
+	}
+	decoded, err := openingbook.Decode(bytes.NewReader(a.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Entries) != len(book.Entries) {
+		t.Fatalf("decoded %d entries, built %d", len(decoded.Entries), len(book.Entries))
+	}
+
+	start, err := game.New(6, 6, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := decoded.Entries[search.BookKey(start)]
+	if !ok || entry.Turn != 0 {
+		t.Fatalf("start position entry %+v, %t", entry, ok)
+	}
+	games := uint32(0)
+	for _, m := range entry.Moves {
+		games += m.Games
+		if m.Score < 0 || m.Score > 1 {
+			t.Fatalf("score %v out of range", m.Score)
+		}
+	}
+	if games == 0 {
+		t.Fatal("no 1v1 game credited the start position")
+	}
+	result, ok := search.ChooseNodeBudgetOptions(start, 1000, search.Options{Book: &search.Book{Positions: decoded, Turns: cfg.Turns}})
+	if !ok || result.Depth != 0 {
+		t.Fatalf("book not played: %+v", result)
+	}
+	for _, entry := range decoded.Entries {
+		if int(entry.Turn) >= cfg.Turns {
+			t.Fatalf("entry past -turns: %+v", entry)
+		}
+	}
+}
+
+// TestHumanGamesReplayStoredPGN writes a games table like the server's and
+// checks the winner's book moves score 1, the loser's 0, and a bot seat's are
+// left out.
+func TestHumanGamesReplayStoredPGN(t *testing.T) {
+	state, err := game.New(6, 6, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pgn []map[string]any
+	var actions []game.Action
+	for turn := 1; turn <= 4; turn++ {
+		var moves []map[string]any
+		player := state.CurrentPlayer()
+		for state.CurrentPlayer() == player && !state.GameOver() {
+			action, ok := arena.Greedy(state)
+			if !ok {
+				t.Fatal("greedy stalled")
+			}
+			moves = append(moves, map[string]any{"type": "place", "row": action.Target.Row, "col": action.Target.Col})
+			actions = append(actions, action)
+			if state, err = state.Apply(action); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pgn = append(pgn, map[string]any{"turn": turn, "player": player, "moves": moves})
+	}
+	encoded, err := json.Marshal(pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "games.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE games (id TEXT PRIMARY KEY, started_at DATETIME, ended_at DATETIME,
+		rows INTEGER, cols INTEGER, player1_name TEXT, player2_name TEXT, player3_name TEXT, player4_name TEXT,
+		result INTEGER, termination TEXT, pgn_content TEXT, rejected_attempt TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []struct {
+		id, second string
+		result     int
+		pgn        string
+	}{
+		{"won", "b", 2, string(encoded)}, {"bot", "Bot 7", 2, string(encoded)}, {"abandoned", "b", 0, string(encoded)},
+		{"broken", "b", 1, `[{"moves":[{"type":"place","row":5,"col":0}]}]`},
+	} {
+		if _, err := db.Exec(`INSERT INTO games (id, rows, cols, player1_name, player2_name, result, pgn_content) VALUES (?, 6, 6, 'a', ?, ?, ?)`,
+			row.id, row.second, row.result, row.pgn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	tr, used, skipped, err := humanGames(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 2 || skipped != 2 {
+		t.Fatalf("used %d, skipped %d", used, skipped)
+	}
+	book := tr.book(1)
+	if len(book.Entries) != 6 { // three moves each in both seats' first turn
+		t.Fatalf("%d entries, want 6", len(book.Entries))
+	}
+	replay, _ := game.New(6, 6, 2)
+	for i, action := range actions[:6] {
+		entry := book.Entries[search.BookKey(replay)]
+		// Seat 1 lost both games; seat 2 won "won" and was a bot in "bot".
+		want, games := float32(0), uint32(2)
+		if replay.CurrentPlayer() == 2 {
+			want, games = 1, 1
+		}
+		if len(entry.Moves) != 1 || entry.Moves[0].Action != action || entry.Moves[0].Score != want || entry.Moves[0].Games != games {
+			t.Fatalf("move %d: entry %+v, want %+v scoring %v", i, entry, action, want)
+		}
+		replay, _ = replay.Apply(action)
+	}
+}
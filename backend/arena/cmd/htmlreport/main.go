@@ -0,0 +1,68 @@
+// Command htmlreport turns JSON arena reports into one self-contained HTML
+// page (arena.WriteHTMLReport) that opens offline and can be attached to a PR:
+//
+//	go run ./cmd/arena -corpus arena/testdata/strength-corpus-v1.json -opponent greedy -json > new.json
+//	go run ./arena/cmd/htmlreport -out report.html new.json
+//	go run ./arena/cmd/htmlreport -out compare.html -title "tuned vs main" main=base.json tuned=new.json
+//
+// Each argument is a file of JSON reports, optionally prefixed by "name=" to
+// label its runs; unlabelled runs are named after the file. A file may hold
+// several reports (cmd/arena -opponent all prints one per opponent) and both
+// CorpusReport and bare Report values. With two or more runs the page
+// compares every run against the first.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"virusgame/arena"
+)
+
+func main() {
+	out := flag.String("out", "report.html", "HTML file to write")
+	title := flag.String("title", "Arena report", "page title")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("usage: htmlreport [-out report.html] [-title text] [name=]report.json...")
+	}
+	runs, err := readRuns(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	var page bytes.Buffer
+	if err := arena.WriteHTMLReport(&page, *title, runs); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, page.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d runs to %s\n", len(runs), *out)
+}
+
+// readRuns decodes the reports of every "[name=]path" argument.
+func readRuns(args []string) ([]arena.ReportRun, error) {
+	var runs []arena.ReportRun
+	for _, arg := range args {
+		name, path, labelled := strings.Cut(arg, "=")
+		if !labelled {
+			path, name = arg, strings.TrimSuffix(filepath.Base(arg), filepath.Ext(arg))
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := arena.DecodeReportRuns(name, file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, decoded...)
+	}
+	return runs, nil
+}
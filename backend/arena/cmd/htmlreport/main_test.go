@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"virusgame/arena"
+)
+
+func TestReadRunsNamesRunsByLabelOrFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tuned.json")
+	encoded, err := json.Marshal(arena.CorpusReport{Split: "train", Overall: arena.Report{Games: 1, Wins: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := readRuns([]string{path, "main=" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Name != "tuned" || runs[1].Name != "main" || runs[1].Report.Overall.Wins != 1 {
+		t.Fatalf("runs %+v", runs)
+	}
+	if _, err := readRuns([]string{filepath.Join(dir, "missing.json")}); err == nil {
+		t.Fatal("missing file read")
+	}
+}
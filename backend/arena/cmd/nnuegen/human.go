@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"virusgame/arena"
+	"virusgame/arena/storedgames"
+	"virusgame/game"
+)
+
+// humanShard is the shard -human writes, beside (or instead of) the sampled
+// ones; nnue-train reads every shard-*.jsonl.
+const humanShard = "shard-human.jsonl"
+
+// HumanStats summarizes a -human extraction.
+type HumanStats struct {
+	Games, Skipped, Decisions int
+	// NeutralTurns counts human turn starts that allowed neutrals, Neutrals
+	// how many of them placed them: the rate to give human?neutral=.
+	NeutralTurns, Neutrals int
+}
+
+// NeutralRate is Neutrals over NeutralTurns, 0 without any.
+func (s HumanStats) NeutralRate() float64 {
+	if s.NeutralTurns == 0 {
+		return 0
+	}
+	return float64(s.Neutrals) / float64(s.NeutralTurns)
+}
+
+// loadHumanReplays reads the stored games that patterns name, each a glob of
+// games databases (*.db, the server's SQLite file) or replay fixtures. Games
+// that are not 1v1 or no longer replay are skipped and counted.
+func loadHumanReplays(patterns []string) (replays []arena.Replay, skipped int, err error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(matches) == 0 {
+			return nil, 0, fmt.Errorf("-human %s: no such file", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".db") {
+			games, err := storedgames.Read(path)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, stored := range games {
+				replay, err := stored.Replay()
+				if err != nil {
+					skipped++
+					continue
+				}
+				replays = append(replays, replay)
+			}
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		replay, _, err := arena.DecodeReplay(bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", path, err)
+		}
+		replays = append(replays, replay)
+	}
+	return replays, skipped, nil
+}
+
+// humanRecord labels a human decision: the position and features as Label
+// writes them, the human's action as the policy target, and no deep score
+// (Budget 0, which nnue-train's value loader skips).
+func humanRecord(decision arena.HumanDecision, winner game.Player) (Record, error) {
+	state := decision.State
+	fingerprint, err := arena.StateFingerprint(state)
+	if err != nil {
+		return Record{}, err
+	}
+	feats := arena.NNUEFeatures(state)
+	var features [4][]float64
+	for seat := 0; seat < 4; seat++ {
+		if state.Active(game.Player(seat + 1)) {
+			features[seat] = feats[seat].Features()
+		}
+	}
+	mover := int(state.CurrentPlayer())
+	return Record{
+		SchemaVersion: schemaVersion,
+		Fingerprint:   fingerprint,
+		Position:      newPosition(state.Snapshot()),
+		Rows:          state.Rows(),
+		Cols:          state.Cols(),
+		CurrentPlayer: mover,
+		Features:      features,
+		Outcome:       Outcome{Winner: int(winner), Placement: placement(mover, int(winner))},
+		Policy:        newPolicyTarget(state, decision.Action),
+		Source:        "human",
+	}, nil
+}
+
+// allowsNeutrals reports whether the mover may place neutrals in state.
+func allowsNeutrals(state game.State) bool {
+	if state.MovesLeft() != 3 || state.NeutralUsed(state.CurrentPlayer()) {
+		return false
+	}
+	for _, action := range state.LegalActions() {
+		if action.Kind == game.PlaceNeutrals {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateHuman writes every human decision of replays to out's human shard,
+// replacing an earlier one. Positions are not deduplicated: two humans who
+// chose differently from one position are both part of the distribution.
+func GenerateHuman(out string, replays []arena.Replay) (HumanStats, error) {
+	var stats HumanStats
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return stats, err
+	}
+	if err := checkSchemaCompat(out); err != nil {
+		return stats, err
+	}
+	file, err := os.Create(filepath.Join(out, humanShard))
+	if err != nil {
+		return stats, err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	for _, replay := range replays {
+		decisions, err := arena.HumanDecisions(replay)
+		if err != nil {
+			return stats, err
+		}
+		if len(decisions) == 0 {
+			continue
+		}
+		stats.Games++
+		for _, decision := range decisions {
+			if allowsNeutrals(decision.State) {
+				stats.NeutralTurns++
+				if decision.Action.Kind == game.PlaceNeutrals {
+					stats.Neutrals++
+				}
+			}
+			record, err := humanRecord(decision, replay.Winner)
+			if err != nil {
+				return stats, err
+			}
+			encoded, err := json.Marshal(record)
+			if err != nil {
+				return stats, err
+			}
+			if _, err := writer.Write(append(encoded, '\n')); err != nil {
+				return stats, err
+			}
+			stats.Decisions++
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return stats, err
+	}
+	return stats, file.Close()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"virusgame/arena"
+)
+
+// humanFixtures are the replay fixtures under arena/testdata: production
+// games plus HappyOtter97's, each with one human seat.
+var humanFixtures = []string{"../../testdata/production-[0-9]*.json", "../../testdata/happyotter97-vs-bot1090.json"}
+
+// TestGenerateHumanLabelsHumanMoves pins the fixture extraction the committed
+// human-policy-v1.policy was trained on, and checks each record is a policy
+// label of a human action with no deep score.
+func TestGenerateHumanLabelsHumanMoves(t *testing.T) {
+	replays, skipped, err := loadHumanReplays(humanFixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	stats, err := GenerateHuman(dir, replays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 0 || stats != (HumanStats{Games: 40, Decisions: 1073, NeutralTurns: 329, Neutrals: 1}) {
+		t.Fatalf("extracted %+v (%d skipped)", stats, skipped)
+	}
+	lines := readShard(t, filepath.Join(dir, humanShard))
+	if len(lines) != stats.Decisions {
+		t.Fatalf("%d records for %d decisions", len(lines), stats.Decisions)
+	}
+	neutral := 0
+	for i, line := range lines {
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Source != "human" || record.Budget != 0 || record.Policy == nil || record.SchemaVersion != schemaVersion {
+			t.Fatalf("record %d: source %q budget %d policy %v", i, record.Source, record.Budget, record.Policy)
+		}
+		if record.Policy.Kind == "neutral" {
+			neutral++
+		}
+	}
+	if neutral != stats.Neutrals {
+		t.Fatalf("%d neutral labels, want %d", neutral, stats.Neutrals)
+	}
+
+	// A rerun replaces the shard rather than appending to it.
+	if _, err := GenerateHuman(dir, replays[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if again := readShard(t, filepath.Join(dir, humanShard)); len(again) >= len(lines) {
+		t.Fatalf("rerun left %d records", len(again))
+	}
+}
+
+// TestLoadHumanReplaysReadsGamesDB reads a server games table: 1v1 rows
+// replay, and a 4-player row is skipped.
+func TestLoadHumanReplaysReadsGamesDB(t *testing.T) {
+	fixture, err := os.Open("../../testdata/happyotter97-vs-bot1090.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, _, err := arena.DecodeReplay(fixture)
+	fixture.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pgn []arena.StoredTurn
+	for _, turn := range replay.Turns {
+		stored := arena.StoredTurn{Turn: turn.Number, Player: turn.Player}
+		for _, move := range turn.Actions {
+			kind := "place"
+			if move.Kind == "neutral" {
+				kind = "neutral"
+			}
+			stored.Moves = append(stored.Moves, arena.StoredMove{Type: kind, Row: move.Row, Col: move.Col, Cells: move.Neutrals})
+		}
+		pgn = append(pgn, stored)
+	}
+	encoded, err := json.Marshal(pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "games.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE games (
+		id TEXT PRIMARY KEY, started_at DATETIME, ended_at DATETIME,
+		rows INTEGER, cols INTEGER, player1_name TEXT, player2_name TEXT,
+		player3_name TEXT, player4_name TEXT, result INTEGER,
+		termination TEXT, pgn_content TEXT, rejected_attempt TEXT
+	)`); err != nil {
+		t.Fatal(err)
+	}
+	insert := `INSERT INTO games (id, started_at, rows, cols, player1_name, player2_name, player3_name, player4_name, result, termination, pgn_content)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := db.Exec(insert, "four", "2026-07-15 12:00:00", 10, 10, "A", "B", "C", "D", 1, "no_moves", "[]"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(insert, replay.SourceID, "2026-07-15 13:00:00", replay.Rows, replay.Cols,
+		replay.Players[0], replay.Players[1], nil, nil, int(replay.Winner), replay.Termination, string(encoded)); err != nil {
+		t.Fatal(err)
+	}
+
+	replays, skipped, err := loadHumanReplays([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 1 || len(replays) != 1 || replays[0].SourceID != replay.SourceID || len(replays[0].Turns) != len(replay.Turns) {
+		t.Fatalf("read %d replays (%d skipped)", len(replays), skipped)
+	}
+	if _, _, err := loadHumanReplays([]string{filepath.Join(t.TempDir(), "none-*.db")}); err == nil {
+		t.Fatal("an unmatched pattern loaded")
+	}
+}
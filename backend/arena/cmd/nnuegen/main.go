@@ -11,12 +11,24 @@
 //     replay's known winner.
 //   - "ladder": RandomLegalOpening seeds; no completed game, sentinel outcome.
 //
+// -human replaces the sampling with a fourth source, "human": every action a
+// human seat played in stored games (arena.HumanDecisions), written to
+// shard-human.jsonl with the human's action as the policy label and no deep
+// score (budget 0). Entries are globs of games databases (*.db, the server's
+// SQLite file) or replay fixtures. Train the behaviour-cloned policy on it and
+// play it as the human engine (arena.HumanLike):
+//
+//	go run ./arena/cmd/nnuegen -human ../data/games.db -out human-data
+//	(cd ../tools/nnue-train && go run . -data ../../backend/human-data -policy human.policy)
+//	go run ./arena/cmd/roundrobin -agents 'human?policy=../tools/nnue-train/human.policy',greedy,ownerbot
+//
 // JSONL schema — one Record per line:
 //
-//	schemaVersion  int           2. v2 stores the raw position (see position)
+//	schemaVersion  int           3. v2 stores the raw position (see position)
 //	                             so features are recomputable without re-searching
-//	                             when the extractor changes. A run refuses to mix
-//	                             v2 output with a v1 shard directory.
+//	                             when the extractor changes; v3 adds the policy
+//	                             label. A run refuses to mix versions in one
+//	                             shard directory.
 //	fingerprint    string        arena.StateFingerprint(state) — stable dedupe key
 //	position       Position      compact raw position (row-major cell string +
 //	                             per-player base/active/neutral + movesLeft/over/
@@ -39,7 +51,32 @@
 //	                             source game; winner 0 / placement 0 = unknown
 //	                             (ladder positions with no completed game).
 //	                             Placement is the mover's finishing rank (1=won).
+//	seatScores     []int         3-4 player positions only (omitted in 1v1): the
+//	                             maxN backed-up deep score of every seat
+//	                             (seat-1 indexed) from the same search as
+//	                             deepScore, which equals the mover's entry.
+//	                             Eliminated seats carry search's floor value.
+//	placements     []int         3-4 player self-play only: every seat's
+//	                             finishing rank, as arena.Placements assigns it.
+//	policy         PolicyTarget  the search's chosen action and what it chose
+//	                             among, as cell indices (row*cols+col): kind
+//	                             "move" or "neutral", cells (the target, or the
+//	                             two neutral cells), moves (every legal move
+//	                             target) and owned (the mover's Normal cells when
+//	                             it may place neutrals). Training data for the
+//	                             policy net (nnue-train -policy); records
+//	                             written before it omit the field.
 //	source         string        "selfplay" | "corpus" | "ladder"
+//
+// -players picks the seat count of self-play games (e.g. "2,4" mixes 1v1 and
+// 4-player games); ladder and corpus positions are always 1v1.
+//
+// -weights runs the labelling searches and the search self-play agents with a
+// weights file's net as the leaf eval, so a self-play loop (cmd/nnueloop)
+// generates each generation's data with its current best net.
+//
+// -roster replaces the self-play agents with engine specs (see
+// engine.ParseSpec), e.g. -roster search:v1?nodes=4000,nnue?nodes=4000&net=gen-003.nnue,ownerbot.
 package main
 
 import (
@@ -80,8 +117,10 @@ const mateMagnitude = 500_000_000
 // schemaVersion is the current shard format. v2 adds the raw Position so labels
 // stay reusable when the feature extractor changes; a run refuses to append to a
 // directory whose shards carry a different version (v1 shards have the zero
-// value 0). Bump this whenever the on-disk record shape changes incompatibly.
-const schemaVersion = 2
+// value 0). v3 adds the policy label to every searched record, so a policy
+// trainer never reads a directory where only some records carry one. Bump this
+// whenever the on-disk record shape changes incompatibly.
+const schemaVersion = 3
 
 // Outcome is the eventual game result attached to a sampled position. Zero
 // values (Winner 0, Placement 0) are the sentinel for "no completed game".
@@ -151,17 +190,54 @@ func (r Record) toSnapshot() game.Snapshot {
 
 // Record is one JSONL line. See the package doc for field semantics.
 type Record struct {
-	SchemaVersion int          `json:"schemaVersion"`
-	Fingerprint   string       `json:"fingerprint"`
-	Position      Position     `json:"position"`
-	Rows          int          `json:"rows"`
-	Cols          int          `json:"cols"`
-	CurrentPlayer int          `json:"currentPlayer"`
-	Features      [4][]float64 `json:"features"`
-	DeepScore     int          `json:"deepScore"`
-	Budget        uint64       `json:"budget"`
-	Outcome       Outcome      `json:"outcome"`
-	Source        string       `json:"source"`
+	SchemaVersion int           `json:"schemaVersion"`
+	Fingerprint   string        `json:"fingerprint"`
+	Position      Position      `json:"position"`
+	Rows          int           `json:"rows"`
+	Cols          int           `json:"cols"`
+	CurrentPlayer int           `json:"currentPlayer"`
+	Features      [4][]float64  `json:"features"`
+	DeepScore     int           `json:"deepScore"`
+	Budget        uint64        `json:"budget"`
+	Outcome       Outcome       `json:"outcome"`
+	SeatScores    []int         `json:"seatScores,omitempty"`
+	Placements    []int         `json:"placements,omitempty"`
+	Policy        *PolicyTarget `json:"policy,omitempty"`
+	Source        string        `json:"source"`
+}
+
+// PolicyTarget is a record's policy-training label. See the package doc.
+type PolicyTarget struct {
+	Kind  string `json:"kind"`
+	Cells []int  `json:"cells"`
+	Moves []int  `json:"moves"`
+	Owned []int  `json:"owned,omitempty"`
+}
+
+// newPolicyTarget labels state with the action search chose there.
+func newPolicyTarget(state game.State, chosen game.Action) *PolicyTarget {
+	index := func(pos game.Pos) int { return pos.Row*state.Cols() + pos.Col }
+	target := &PolicyTarget{Kind: "move", Cells: []int{index(chosen.Target)}}
+	if chosen.Kind == game.PlaceNeutrals {
+		target.Kind, target.Cells = "neutral", []int{index(chosen.Neutrals[0]), index(chosen.Neutrals[1])}
+	}
+	neutrals := false
+	for _, action := range state.LegalActions() {
+		if action.Kind == game.Move {
+			target.Moves = append(target.Moves, index(action.Target))
+		} else {
+			neutrals = true
+		}
+	}
+	for row := 0; neutrals && row < state.Rows(); row++ {
+		for col := 0; col < state.Cols(); col++ {
+			pos := game.Pos{Row: row, Col: col}
+			if cell, _ := state.At(pos); cell.Owner == state.CurrentPlayer() && cell.Kind == game.Normal {
+				target.Owned = append(target.Owned, index(pos))
+			}
+		}
+	}
+	return target
 }
 
 // Label builds a Record for a position: deep score from ChooseNodeBudget,
@@ -169,11 +245,17 @@ type Record struct {
 // outcome is filled by the caller (self-play/corpus backfill winner+placement;
 // ladder positions keep the zero sentinel).
 func Label(state game.State, budget uint64, source string) (Record, error) {
+	return LabelOptions(state, budget, source, search.Options{})
+}
+
+// LabelOptions is Label with the deep score searched under opts, e.g. with a
+// net's leaf eval (-weights). The zero Options is exactly Label.
+func LabelOptions(state game.State, budget uint64, source string, opts search.Options) (Record, error) {
 	fingerprint, err := arena.StateFingerprint(state)
 	if err != nil {
 		return Record{}, err
 	}
-	result, ok := search.ChooseNodeBudget(state, budget)
+	result, ok := search.ChooseNodeBudgetOptions(state, budget, opts)
 	// Depth 0 means no search-derived score: an opening-book hit (Depth/Nodes 0,
 	// Score 0) or a budget too small to finish depth 1. Both leave Score at the
 	// placeholder 0; recording that as a label would poison the target.
@@ -187,6 +269,15 @@ func Label(state game.State, budget uint64, source string) (Record, error) {
 	if result.Score >= mateMagnitude || result.Score <= -mateMagnitude {
 		return Record{}, errNoDeepScore
 	}
+	var seatScores []int
+	if state.Players() > 2 {
+		seatScores = result.SeatScores[:state.Players()]
+		for seat, score := range seatScores {
+			if state.Active(game.Player(seat+1)) && (score >= mateMagnitude || score <= -mateMagnitude) {
+				return Record{}, errNoDeepScore
+			}
+		}
+	}
 	feats := arena.NNUEFeatures(state)
 	var features [4][]float64
 	for seat := 0; seat < 4; seat++ {
@@ -204,6 +295,8 @@ func Label(state game.State, budget uint64, source string) (Record, error) {
 		Features:      features,
 		DeepScore:     result.Score,
 		Budget:        budget,
+		SeatScores:    seatScores,
+		Policy:        newPolicyTarget(state, result.Action),
 		Source:        source,
 	}, nil
 }
@@ -409,6 +502,14 @@ type Config struct {
 	Boards     []arena.Board
 	CorpusPath string // owner-corpus manifest; "" disables the corpus source
 	Resume     bool
+	// Players lists the self-play seat counts to draw from; empty means 1v1.
+	Players []int
+	// Search is the options labels run with; the zero value is the
+	// production search.
+	Search search.Options
+	// Roster is the self-play agents as engine specs; empty means
+	// defaultRoster("").
+	Roster []string
 }
 
 func next(rng *uint64) uint64 {
@@ -418,23 +519,35 @@ func next(rng *uint64) uint64 {
 	return *rng
 }
 
-// roster is the fixed set of deterministic self-play agents. Self-play only
-// needs the chosen action, so these are plain Agents (no telemetry).
-func roster() []arena.Agent {
-	budget := func(nodes uint64) arena.Agent {
-		return func(state game.State) (game.Action, bool) {
-			result, ok := search.ChooseNodeBudget(state, nodes)
-			return result.Action, ok
+// defaultRoster is the self-play roster as engine specs: two node-budget
+// searches (through the net when weights is set), a fixed-depth search and
+// three heuristic agents, all deterministic.
+func defaultRoster(weights string) []string {
+	budget := func(nodes int) string {
+		if weights != "" {
+			return fmt.Sprintf("nnue?nodes=%d&net=%s", nodes, weights)
 		}
+		return fmt.Sprintf("search?nodes=%d", nodes)
+	}
+	return []string{budget(2000), budget(8000), "search?depth=2", "greedy", "base", "mobility"}
+}
+
+// roster builds the self-play agents of specs, defaultRoster when empty.
+// Self-play only needs the chosen action, so these are plain Agents (no
+// telemetry).
+func roster(specs []string) ([]arena.Agent, error) {
+	if len(specs) == 0 {
+		specs = defaultRoster("")
 	}
-	return []arena.Agent{
-		budget(2000),
-		budget(8000),
-		arena.Tournament(2),
-		arena.Greedy,
-		arena.BaseAttacker,
-		arena.MobilityAttacker,
+	agents := make([]arena.Agent, len(specs))
+	for i, spec := range specs {
+		engine, err := arena.BuildEngine(spec)
+		if err != nil {
+			return nil, fmt.Errorf("roster %q: %w", spec, err)
+		}
+		agents[i] = arena.Strip(engine.New(1))
 	}
+	return agents, nil
 }
 
 // corpusPosition carries a replay position plus its game's known winner.
@@ -483,25 +596,23 @@ func loadCorpus(manifest string) ([]corpusPosition, error) {
 	return positions, nil
 }
 
-// selfPlay plays one 2-player game between agentA (seat 1) and agentB (seat 2),
-// recording every intermediate position and backfilling the game outcome.
-func selfPlay(board arena.Board, budget uint64, agentA, agentB arena.Agent) []Record {
-	state, err := game.New(board.Rows, board.Cols, 2)
+// selfPlay plays one game with agents[i] in seat i+1, recording every
+// intermediate position and backfilling the game outcome. 1v1 games use the
+// winner alone; 3-4 player games also record every seat's placement.
+func selfPlay(board arena.Board, budget uint64, opts search.Options, agents []arena.Agent) []Record {
+	state, err := game.New(board.Rows, board.Cols, len(agents))
 	if err != nil {
 		return nil
 	}
 	var records []Record
+	var elimOrder []game.Player
 	maxPlies := board.Rows * board.Cols * 4
 	for plies := 0; !state.GameOver() && plies < maxPlies; plies++ {
-		record, err := Label(state, budget, "selfplay")
+		record, err := LabelOptions(state, budget, "selfplay", opts)
 		if err == nil {
 			records = append(records, record)
 		}
-		agent := agentA
-		if state.CurrentPlayer() == 2 {
-			agent = agentB
-		}
-		action, ok := agent(state)
+		action, ok := agents[state.CurrentPlayer()-1](state)
 		if !ok {
 			break
 		}
@@ -509,11 +620,24 @@ func selfPlay(board arena.Board, budget uint64, agentA, agentB arena.Agent) []Re
 		if err != nil {
 			break
 		}
+		for seat := game.Player(1); int(seat) <= len(agents); seat++ {
+			if state.Active(seat) && !next.Active(seat) {
+				elimOrder = append(elimOrder, seat)
+			}
+		}
 		state = next
 	}
 	winner := int(state.Winner())
+	if len(agents) == 2 {
+		for i := range records {
+			records[i].Outcome = Outcome{Winner: winner, Placement: placement(records[i].CurrentPlayer, winner)}
+		}
+		return records
+	}
+	places := arena.Placements(len(agents), elimOrder, state)
 	for i := range records {
-		records[i].Outcome = Outcome{Winner: winner, Placement: placement(records[i].CurrentPlayer, winner)}
+		records[i].Outcome = Outcome{Winner: winner, Placement: places[records[i].CurrentPlayer-1]}
+		records[i].Placements = places[:len(agents)]
 	}
 	return records
 }
@@ -541,7 +665,10 @@ func generateWorker(cfg Config, worker, target int, corpus []corpusPosition, see
 		}
 	}()
 
-	agents := roster()
+	agents, err := roster(cfg.Roster)
+	if err != nil {
+		return 0, err
+	}
 	rng := (cfg.Seed + uint64(worker)*0x9e3779b97f4a7c15) | 1
 	emit := func(record Record) error {
 		if existing+written >= target {
@@ -561,9 +688,15 @@ func generateWorker(cfg Config, worker, target int, corpus []corpusPosition, see
 		board := cfg.Boards[int(next(&rng)%uint64(len(cfg.Boards)))]
 		switch next(&rng) % 3 {
 		case 0: // self-play
-			agentA := agents[int(next(&rng)%uint64(len(agents)))]
-			agentB := agents[int(next(&rng)%uint64(len(agents)))]
-			for _, record := range selfPlay(board, cfg.Budget, agentA, agentB) {
+			players := cfg.Players[0]
+			if len(cfg.Players) > 1 {
+				players = cfg.Players[int(next(&rng)%uint64(len(cfg.Players)))]
+			}
+			seated := make([]arena.Agent, players)
+			for i := range seated {
+				seated[i] = agents[int(next(&rng)%uint64(len(agents)))]
+			}
+			for _, record := range selfPlay(board, cfg.Budget, cfg.Search, seated) {
 				if err := emit(record); err != nil {
 					return written, err
 				}
@@ -577,7 +710,7 @@ func generateWorker(cfg Config, worker, target int, corpus []corpusPosition, see
 			if err != nil {
 				continue
 			}
-			record, err := Label(state, cfg.Budget, "ladder")
+			record, err := LabelOptions(state, cfg.Budget, "ladder", cfg.Search)
 			if errors.Is(err, errNoDeepScore) {
 				continue
 			}
@@ -592,7 +725,7 @@ func generateWorker(cfg Config, worker, target int, corpus []corpusPosition, see
 				continue
 			}
 			position := corpus[int(next(&rng)%uint64(len(corpus)))]
-			record, err := Label(position.state, cfg.Budget, "corpus")
+			record, err := LabelOptions(position.state, cfg.Budget, "corpus", cfg.Search)
 			if errors.Is(err, errNoDeepScore) {
 				continue // decided terminal position — no meaningful deep score
 			}
@@ -615,6 +748,14 @@ func Generate(cfg Config) (int, error) {
 	if len(cfg.Boards) == 0 {
 		cfg.Boards = []arena.Board{{Rows: 8, Cols: 8}}
 	}
+	if len(cfg.Players) == 0 {
+		cfg.Players = []int{2}
+	}
+	for _, players := range cfg.Players {
+		if players < 2 || players > 4 {
+			return 0, fmt.Errorf("self-play needs 2-4 players, got %d", players)
+		}
+	}
 	if cfg.Workers < 1 {
 		cfg.Workers = 1
 	}
@@ -710,16 +851,61 @@ func main() {
 	boards := flag.String("boards", "8x8", "comma-separated board sizes, e.g. 8x8,12x12")
 	corpus := flag.String("corpus", "", "owner-corpus manifest path (enables the corpus source)")
 	resume := flag.Bool("resume", false, "scan existing shards and skip fingerprints already present")
+	players := flag.String("players", "2", "comma-separated self-play seat counts, e.g. 2,4")
+	weights := flag.String("weights", "", "NNUE weights file to label and self-play with (leaf eval through the net)")
+	human := flag.String("human", "", "comma list of games databases (*.db) or replay fixture globs: write their human decisions as policy labels instead of sampling")
+	rosterSpecs := flag.String("roster", "", "comma list of self-play engine specs (default: node-budget searches at 2000 and 8000, search?depth=2, greedy, base, mobility)")
 	flag.Parse()
 	if *out == "" {
 		fmt.Fprintln(os.Stderr, "-out is required")
 		os.Exit(2)
 	}
+	if *human != "" {
+		replays, skipped, err := loadHumanReplays(strings.Split(*human, ","))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		stats, err := GenerateHuman(*out, replays)
+		if err != nil {
+			panic(err)
+		}
+		stats.Skipped = skipped
+		fmt.Printf("wrote %d human decisions from %d games (%d skipped) to %s; neutral rate %d/%d = %.4f\n",
+			stats.Decisions, stats.Games, stats.Skipped, filepath.Join(*out, humanShard), stats.Neutrals, stats.NeutralTurns, stats.NeutralRate())
+		return
+	}
 	parsedBoards, err := parseBoards(*boards)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
+	var seatCounts []int
+	for _, part := range strings.Split(*players, ",") {
+		count, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bad -players %q: %v\n", *players, err)
+			os.Exit(2)
+		}
+		seatCounts = append(seatCounts, count)
+	}
+	var opts search.Options
+	if *weights != "" {
+		net, err := search.ReadNNUEWeights(*weights)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		opts = search.Options{NNUE: search.NNUEOn, Net: net}
+	}
+	specs := defaultRoster(*weights)
+	if *rosterSpecs != "" {
+		specs = strings.Split(*rosterSpecs, ",")
+	}
+	if _, err := roster(specs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	total, err := Generate(Config{
 		Out:        *out,
 		Workers:    *workers,
@@ -729,6 +915,9 @@ func main() {
 		Boards:     parsedBoards,
 		CorpusPath: *corpus,
 		Resume:     *resume,
+		Players:    seatCounts,
+		Search:     opts,
+		Roster:     specs,
 	})
 	if err != nil {
 		panic(err)
@@ -11,6 +11,8 @@ import (
 
 	"virusgame/arena"
 	"virusgame/game"
+	"virusgame/search"
+	"virusgame/search/nnueweights"
 )
 
 // TestRecordRoundTrip asserts a Record survives JSON marshal/unmarshal
@@ -145,6 +147,89 @@ func TestGenerateDeterministic(t *testing.T) {
 	}
 }
 
+// TestGenerateMultiplayer: 4-player self-play labels every seat, and the
+// mover's seat score is the deep score.
+func TestGenerateMultiplayer(t *testing.T) {
+	dir := t.TempDir()
+	cfg := tinyConfig(dir)
+	cfg.Players = []int{4}
+	if _, err := Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	multi := 0
+	for i, line := range readShard(t, filepath.Join(dir, "shard-000.jsonl")) {
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("line %d does not parse: %v", i, err)
+		}
+		if record.Source != "selfplay" {
+			continue
+		}
+		if len(record.SeatScores) != 4 || len(record.Placements) != 4 {
+			t.Fatalf("line %d: seat scores %v placements %v", i, record.SeatScores, record.Placements)
+		}
+		if record.SeatScores[record.CurrentPlayer-1] != record.DeepScore {
+			t.Fatalf("line %d: mover seat score %d, deep score %d", i, record.SeatScores[record.CurrentPlayer-1], record.DeepScore)
+		}
+		if record.Placements[record.CurrentPlayer-1] != record.Outcome.Placement {
+			t.Fatalf("line %d: placements %v disagree with outcome %+v", i, record.Placements, record.Outcome)
+		}
+		multi++
+	}
+	if multi == 0 {
+		t.Fatal("no 4-player self-play positions generated")
+	}
+}
+
+// TestLabelOptionsSearchesWithTheNet: -weights labels with the given net's
+// leaf eval, and the zero Options is exactly Label.
+func TestLabelOptionsSearchesWithTheNet(t *testing.T) {
+	snapshot, err := arena.RandomLegalOpening(8, 8, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := game.FromSnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := Label(state, 2000, "ladder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, err := LabelOptions(state, 2000, "ladder", search.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plain, same) {
+		t.Fatalf("zero Options labelled %+v, Label %+v", same, plain)
+	}
+	net := nnueweights.Compiled()
+	net.Mean += 5000
+	netted, err := LabelOptions(state, 2000, "ladder", search.Options{NNUE: search.NNUEOn, Net: net})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if netted.DeepScore == plain.DeepScore {
+		t.Fatalf("net label kept the hand-tuned deep score %d", plain.DeepScore)
+	}
+}
+
+// TestRosterBuildsEngineSpecs: the default roster keeps its six agents, a
+// -weights roster runs the search agents through the net, and a bad spec is
+// rejected before any game.
+func TestRosterBuildsEngineSpecs(t *testing.T) {
+	if got := defaultRoster("gen.nnue"); got[0] != "nnue?nodes=2000&net=gen.nnue" || got[2] != "search?depth=2" {
+		t.Fatalf("weights roster %v", got)
+	}
+	agents, err := roster(nil)
+	if err != nil || len(agents) != 6 {
+		t.Fatalf("default roster: %d agents, %v", len(agents), err)
+	}
+	if _, err := roster([]string{"greedy", "search?nodes=0"}); err == nil {
+		t.Fatal("bad roster spec accepted")
+	}
+}
+
 func TestResumeSkipsExisting(t *testing.T) {
 	dir := t.TempDir()
 	if _, err := Generate(tinyConfig(dir)); err != nil {
@@ -302,3 +387,51 @@ func readShard(t *testing.T, path string) [][]byte {
 	}
 	return lines
 }
+
+// TestPolicyTargetLabelsTheChoice: every smoke record carries a policy label
+// whose chosen cells are among its candidates, and a fresh position lists
+// every legal move target.
+func TestPolicyTargetLabelsTheChoice(t *testing.T) {
+	state, err := game.New(6, 6, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legal := state.LegalActions()
+	target := newPolicyTarget(state, legal[len(legal)-1])
+	want := legal[len(legal)-1].Target
+	if target.Kind != "move" || len(target.Cells) != 1 || target.Cells[0] != want.Row*6+want.Col ||
+		len(target.Moves) != len(legal) || target.Owned != nil {
+		t.Fatalf("start position label %+v for %d legal actions", target, len(legal))
+	}
+
+	neutral := 0
+	for i, line := range readShard(t, "testdata/smoke.jsonl") {
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		p := record.Policy
+		if p == nil {
+			t.Fatalf("smoke line %d has no policy label", i)
+		}
+		candidates := map[int]bool{}
+		for _, cell := range p.Moves {
+			candidates[cell] = true
+		}
+		if p.Kind == "neutral" {
+			neutral++
+			candidates = map[int]bool{}
+			for _, cell := range p.Owned {
+				candidates[cell] = true
+			}
+		}
+		for _, cell := range p.Cells {
+			if !candidates[cell] {
+				t.Fatalf("smoke line %d: chosen cell %d not among its %s candidates", i, cell, p.Kind)
+			}
+		}
+	}
+	if neutral == 0 {
+		t.Error("smoke fixture has no neutral-pair labels")
+	}
+}
@@ -0,0 +1,466 @@
+// Command nnueloop runs the NNUE self-play reinforcement loop: each generation
+// generates positions by self-play with the current best net (nnuegen
+// -weights), trains a candidate on them (tools/nnue-train), gates the
+// candidate against the incumbent with arena.PlaySequentialOpenings, and
+// promotes it only when the gate's Wilson interval clears -gate-threshold.
+//
+// CANARY-FIRST: the loop only produces weights files. best.nnue is a candidate
+// for a VS_NNUE_WEIGHTS canary, never a production default; the gate is the
+// same sanity floor the ladder applies, not promotion proof.
+//
+// Everything a run produces lives under -run:
+//
+//	run.json             manifest: format version, the run's settings, the
+//	                     current best net and every generation's progress
+//	nets/000.nnue        generation 0's incumbent: -init, else the compiled net
+//	gen-NNN/data/        nnuegen shards (generate.log beside them)
+//	gen-NNN/candidate.nnue, train.json, train.ckpt, train.log
+//	                     the trainer's output, report and checkpoint
+//	nets/NNN.nnue        generation NNN's candidate, once promoted
+//	best.nnue            a copy of the current best net
+//
+// run.json is rewritten (atomically) after every stage, so a killed run picks
+// up with -resume at the first stage it had not finished: generation tops its
+// shards up (nnuegen -resume), training continues from its checkpoint, and the
+// gate, which is deterministic, replays. A resumed run keeps its recorded
+// settings; only -generations may be raised.
+//
+// The gate is 1v1 only (PlaySequentialOpenings); self-play may still mix seat
+// counts through -players.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"virusgame/arena"
+	"virusgame/search"
+	"virusgame/search/nnueweights"
+)
+
+// manifestVersion is run.json's format version; bump it when a field changes
+// meaning, and refuse to resume runs of another version.
+const manifestVersion = 1
+
+// Stages a generation completes, in order; Generation.Stage is the last one.
+const (
+	stageGenerated = "generated"
+	stageTrained   = "trained"
+	stageGated     = "gated"
+	stageDone      = "done"
+)
+
+// Config is a run's settings, recorded in run.json.
+type Config struct {
+	Generations int    `json:"generations"`
+	Positions   int    `json:"positions"`
+	Budget      uint64 `json:"budget"`
+	Boards      string `json:"boards"`
+	Players     string `json:"players"`
+	Seed        uint64 `json:"seed"`
+	Workers     int    `json:"workers"`
+	// TrainArgs are passed to the trainer after the loop's own flags.
+	TrainArgs     []string `json:"trainArgs,omitempty"`
+	GateRows      int      `json:"gateRows"`
+	GateCols      int      `json:"gateCols"`
+	GateNodes     uint64   `json:"gateNodes"`
+	GateOpenings  int      `json:"gateOpenings"`
+	GateThreshold float64  `json:"gateThreshold"`
+	GateMinGames  int      `json:"gateMinGames"`
+}
+
+func (c Config) check() error {
+	switch {
+	case c.Generations < 1:
+		return fmt.Errorf("-generations %d < 1", c.Generations)
+	case c.Positions < 1:
+		return fmt.Errorf("-positions %d < 1", c.Positions)
+	case c.GateRows < 1 || c.GateCols < 1:
+		return fmt.Errorf("bad gate board %dx%d", c.GateRows, c.GateCols)
+	case c.GateOpenings < 1:
+		return fmt.Errorf("-gate-openings %d < 1", c.GateOpenings)
+	}
+	return nil
+}
+
+// Gate is a generation's candidate-vs-incumbent verdict. Win percentages count
+// the candidate's wins only, as arena.Wilson95 does.
+type Gate struct {
+	Games    int            `json:"games"`
+	Wins     int            `json:"wins"`
+	Losses   int            `json:"losses"`
+	Draws    int            `json:"draws"`
+	WinPct   float64        `json:"winPct"`
+	Interval arena.Interval `json:"wilson95"`
+	Stopped  bool           `json:"stopped"`
+	Passed   bool           `json:"passed"`
+}
+
+// Generation is one generation's progress. Paths are relative to the run
+// directory.
+type Generation struct {
+	N     int    `json:"n"`
+	Stage string `json:"stage,omitempty"`
+	// Incumbent is the net this generation self-played with and gated against.
+	Incumbent string `json:"incumbent"`
+	Gate      *Gate  `json:"gate,omitempty"`
+	Promoted  bool   `json:"promoted"`
+}
+
+// Manifest is run.json.
+type Manifest struct {
+	Version     int          `json:"version"`
+	Config      Config       `json:"config"`
+	Best        string       `json:"best"`
+	Generations []Generation `json:"generations"`
+}
+
+// steps are the loop's stages that leave the process or take minutes; tests
+// swap in fakes. Paths are absolute.
+type steps struct {
+	// generate writes cfg-sized self-play shards into data, labelling and
+	// playing with the net in weights. resume tops up an existing directory.
+	generate func(cfg Config, n int, weights, data, log string, resume bool) error
+	// train fits a candidate on data and writes it to dir/candidate.nnue.
+	train func(cfg Config, data, dir, log string, resume bool) error
+	// gate plays candidate against incumbent.
+	gate func(cfg Config, candidate, incumbent *nnueweights.Net) (arena.SequentialResult, error)
+}
+
+// loop drives a run directory.
+type loop struct {
+	dir      string
+	manifest Manifest
+	steps    steps
+	out      io.Writer
+}
+
+// openRun creates dir's run for cfg, installing init (or the compiled net) as
+// generation 0's incumbent, or with resume reopens the run already there.
+// generations, when positive, raises a resumed run's generation count.
+func openRun(dir string, cfg Config, init string, resume bool, generations int) (*loop, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := &loop{dir: dir, out: io.Discard}
+	data, err := os.ReadFile(l.path("run.json"))
+	switch {
+	case err == nil && !resume:
+		return nil, fmt.Errorf("%s already holds a run; pass -resume to continue it", dir)
+	case err == nil:
+		if err := json.Unmarshal(data, &l.manifest); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.path("run.json"), err)
+		}
+		if l.manifest.Version != manifestVersion {
+			return nil, fmt.Errorf("%s: manifest version %d, this build writes %d", l.path("run.json"), l.manifest.Version, manifestVersion)
+		}
+		if generations > 0 {
+			if generations < len(l.manifest.Generations) {
+				return nil, fmt.Errorf("-generations %d is below the %d already started", generations, len(l.manifest.Generations))
+			}
+			l.manifest.Config.Generations = generations
+		}
+		return l, l.manifest.Config.check()
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	case resume:
+		return nil, fmt.Errorf("-resume: no run.json in %s", dir)
+	}
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	net := nnueweights.Compiled()
+	if init != "" {
+		if net, err = search.ReadNNUEWeights(init); err != nil {
+			return nil, err
+		}
+	}
+	best := filepath.Join("nets", "000.nnue")
+	if err := os.MkdirAll(l.path("nets"), 0o755); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := net.Encode(&buf); err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(l.path(best), buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(l.path("best.nnue"), buf.Bytes()); err != nil {
+		return nil, err
+	}
+	l.manifest = Manifest{Version: manifestVersion, Config: cfg, Best: best}
+	return l, l.save()
+}
+
+func (l *loop) path(rel string) string { return filepath.Join(l.dir, rel) }
+
+// save rewrites run.json atomically.
+func (l *loop) save() error {
+	data, err := json.MarshalIndent(l.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(l.path("run.json"), append(data, '\n'))
+}
+
+// run finishes every generation, resuming the last one at its first
+// unfinished stage.
+func (l *loop) run() error {
+	for {
+		gens := l.manifest.Generations
+		if len(gens) > 0 && gens[len(gens)-1].Stage != stageDone {
+			if err := l.generation(&l.manifest.Generations[len(gens)-1]); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(gens) >= l.manifest.Config.Generations {
+			return nil
+		}
+		l.manifest.Generations = append(gens, Generation{N: len(gens) + 1, Incumbent: l.manifest.Best})
+		if err := l.save(); err != nil {
+			return err
+		}
+	}
+}
+
+// generation runs g's remaining stages, saving the manifest after each.
+func (l *loop) generation(g *Generation) error {
+	cfg := l.manifest.Config
+	dir := l.path(fmt.Sprintf("gen-%03d", g.N))
+	data := filepath.Join(dir, "data")
+	candidate := filepath.Join(dir, "candidate.nnue")
+	advance := func(stage string) error {
+		g.Stage = stage
+		return l.save()
+	}
+	if g.Stage == "" {
+		_, err := os.Stat(data)
+		resume := err == nil
+		fmt.Fprintf(l.out, "gen %d: self-play with %s\n", g.N, g.Incumbent)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := l.steps.generate(cfg, g.N, l.path(g.Incumbent), data, filepath.Join(dir, "generate.log"), resume); err != nil {
+			return fmt.Errorf("gen %d: generate: %w", g.N, err)
+		}
+		if err := advance(stageGenerated); err != nil {
+			return err
+		}
+	}
+	if g.Stage == stageGenerated {
+		_, err := os.Stat(filepath.Join(dir, "train.ckpt"))
+		fmt.Fprintf(l.out, "gen %d: training\n", g.N)
+		if err := l.steps.train(cfg, data, dir, filepath.Join(dir, "train.log"), err == nil); err != nil {
+			return fmt.Errorf("gen %d: train: %w", g.N, err)
+		}
+		if _, err := search.ReadNNUEWeights(candidate); err != nil {
+			return fmt.Errorf("gen %d: trained candidate: %w", g.N, err)
+		}
+		if err := advance(stageTrained); err != nil {
+			return err
+		}
+	}
+	if g.Stage == stageTrained {
+		cand, err := search.ReadNNUEWeights(candidate)
+		if err != nil {
+			return err
+		}
+		inc, err := search.ReadNNUEWeights(l.path(g.Incumbent))
+		if err != nil {
+			return err
+		}
+		result, err := l.steps.gate(cfg, cand, inc)
+		if err != nil {
+			return fmt.Errorf("gen %d: gate: %w", g.N, err)
+		}
+		g.Gate = &Gate{
+			Games: result.Games, Wins: result.Wins, Losses: result.Losses, Draws: result.Draws,
+			WinPct: result.WinRate(), Interval: arena.Wilson95(result.Wins, result.Games),
+			Stopped: result.Stopped, Passed: result.Stopped && result.Above,
+		}
+		fmt.Fprintf(l.out, "gen %d: gate %d/%d wins (%.1f%%, w95 [%.1f, %.1f]) passed=%t\n", g.N,
+			g.Gate.Wins, g.Gate.Games, g.Gate.WinPct, g.Gate.Interval.Low, g.Gate.Interval.High, g.Gate.Passed)
+		if err := advance(stageGated); err != nil {
+			return err
+		}
+	}
+	if g.Stage == stageGated {
+		if g.Gate.Passed {
+			if err := l.promote(g, candidate); err != nil {
+				return err
+			}
+		}
+		return advance(stageDone)
+	}
+	return nil
+}
+
+// promote makes g's candidate the run's best net. The copies are idempotent,
+// so a crash before the manifest is saved just repeats them.
+func (l *loop) promote(g *Generation, candidate string) error {
+	data, err := os.ReadFile(candidate)
+	if err != nil {
+		return err
+	}
+	best := filepath.Join("nets", fmt.Sprintf("%03d.nnue", g.N))
+	if err := writeAtomic(l.path(best), data); err != nil {
+		return err
+	}
+	if err := writeAtomic(l.path("best.nnue"), data); err != nil {
+		return err
+	}
+	g.Promoted = true
+	l.manifest.Best = best
+	fmt.Fprintf(l.out, "gen %d: promoted to %s\n", g.N, best)
+	return nil
+}
+
+// gate plays the candidate net against the incumbent, both on the NNUE path
+// at cfg.GateNodes per decision.
+func gate(cfg Config, candidate, incumbent *nnueweights.Net) (arena.SequentialResult, error) {
+	a := arena.TelemetryNodeBudgetOptions(cfg.GateNodes, search.Options{NNUE: search.NNUEOn, Net: candidate})
+	b := arena.TelemetryNodeBudgetOptions(cfg.GateNodes, search.Options{NNUE: search.NNUEOn, Net: incumbent})
+	return arena.PlaySequentialOpenings(nil, cfg.GateRows, cfg.GateCols, cfg.GateOpenings, cfg.GateThreshold, cfg.GateMinGames, a, b, cfg.Workers)
+}
+
+// tools runs nnuegen and the trainer as subprocesses.
+type tools struct {
+	nnuegen    []string
+	trainer    []string
+	trainerDir string
+}
+
+func (t tools) steps() steps {
+	return steps{generate: t.generate, train: t.train, gate: gate}
+}
+
+func (t tools) generate(cfg Config, n int, weights, data, log string, resume bool) error {
+	args := []string{
+		"-out", data,
+		"-weights", weights,
+		"-positions", fmt.Sprint(cfg.Positions),
+		"-budget", fmt.Sprint(cfg.Budget),
+		"-boards", cfg.Boards,
+		"-players", cfg.Players,
+		"-seed", fmt.Sprint(cfg.Seed + uint64(n)),
+		"-workers", fmt.Sprint(cfg.Workers),
+	}
+	if resume {
+		args = append(args, "-resume")
+	}
+	return runTool("", t.nnuegen, args, log)
+}
+
+func (t tools) train(cfg Config, data, dir, log string, resume bool) error {
+	args := []string{
+		"-data", data,
+		"-export", "",
+		"-export-bin", filepath.Join(dir, "candidate.nnue"),
+		"-report", filepath.Join(dir, "train.json"),
+		"-checkpoint", filepath.Join(dir, "train.ckpt"),
+	}
+	if resume {
+		args = append(args, "-resume")
+	}
+	return runTool(t.trainerDir, t.trainer, append(args, cfg.TrainArgs...), log)
+}
+
+// runTool runs command plus args in dir, appending its output to log.
+func runTool(dir string, command, args []string, log string) error {
+	file, err := os.OpenFile(log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	cmd := exec.Command(command[0], append(command[1:], args...)...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = file, file
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w (output in %s)", strings.Join(command, " "), err, log)
+	}
+	return nil
+}
+
+// writeAtomic replaces path with data via a rename, so a crash leaves either
+// the old file or the new one.
+func writeAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func main() {
+	runDir := flag.String("run", "", "run directory (required)")
+	resume := flag.Bool("resume", false, "continue the run in -run from its last completed stage")
+	generations := flag.Int("generations", 5, "generations to run (on -resume, raises the recorded count)")
+	init := flag.String("init", "", "generation 0's weights file ('' = the compiled net)")
+	positions := flag.Int("positions", 20000, "self-play positions per generation")
+	budget := flag.Uint64("budget", 2000, "node budget for self-play labels")
+	boards := flag.String("boards", "8x8", "self-play board sizes, e.g. 8x8,12x12")
+	players := flag.String("players", "2", "self-play seat counts, e.g. 2,4")
+	seed := flag.Uint64("seed", 1, "base seed; generation N self-plays with seed+N")
+	workers := flag.Int("workers", 1, "self-play shard writers and gate game workers")
+	trainArgs := flag.String("train-args", "", "extra trainer flags, space-separated (e.g. \"-hidden 64,32 -epochs 60\")")
+	gateBoard := flag.String("gate-board", "8x8", "gate board size")
+	gateNodes := flag.Uint64("gate-nodes", 2000, "gate node budget per decision")
+	gateOpenings := flag.Int("gate-openings", 100, "most gate openings (both seats each)")
+	gateThreshold := flag.Float64("gate-threshold", 50, "candidate win% the gate's Wilson lower bound must clear")
+	gateMinGames := flag.Int("gate-min-games", 20, "gate games before the Wilson stop rule may decide")
+	nnuegen := flag.String("nnuegen", "go run ./arena/cmd/nnuegen", "nnuegen command, run from the working directory")
+	trainer := flag.String("trainer", "go run .", "trainer command, run from -trainer-dir")
+	trainerDir := flag.String("trainer-dir", "../tools/nnue-train", "trainer working directory")
+	flag.Parse()
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, "nnueloop:", err)
+		os.Exit(1)
+	}
+	if *runDir == "" {
+		fmt.Fprintln(os.Stderr, "-run is required")
+		os.Exit(2)
+	}
+	var rows, cols int
+	if _, err := fmt.Sscanf(*gateBoard, "%dx%d", &rows, &cols); err != nil {
+		fmt.Fprintf(os.Stderr, "bad -gate-board %q (want RxC)\n", *gateBoard)
+		os.Exit(2)
+	}
+	cfg := Config{
+		Generations: *generations, Positions: *positions, Budget: *budget,
+		Boards: *boards, Players: *players, Seed: *seed, Workers: *workers,
+		TrainArgs: strings.Fields(*trainArgs),
+		GateRows:  rows, GateCols: cols, GateNodes: *gateNodes, GateOpenings: *gateOpenings,
+		GateThreshold: *gateThreshold, GateMinGames: *gateMinGames,
+	}
+	raise := 0
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "generations" {
+			raise = *generations
+		}
+	})
+	l, err := openRun(*runDir, cfg, *init, *resume, raise)
+	if err != nil {
+		fail(err)
+	}
+	absTrainerDir, err := filepath.Abs(*trainerDir)
+	if err != nil {
+		fail(err)
+	}
+	l.steps = tools{nnuegen: strings.Fields(*nnuegen), trainer: strings.Fields(*trainer), trainerDir: absTrainerDir}.steps()
+	l.out = os.Stdout
+	if err := l.run(); err != nil {
+		fail(err)
+	}
+	fmt.Printf("best net: %s\n", l.path(l.manifest.Best))
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"virusgame/arena"
+	"virusgame/search/nnueweights"
+)
+
+func tinyConfig(generations int) Config {
+	return Config{
+		Generations: generations, Positions: 10, Budget: 500, Boards: "6x6", Players: "2", Seed: 1, Workers: 1,
+		GateRows: 6, GateCols: 6, GateNodes: 300, GateOpenings: 3, GateThreshold: 50, GateMinGames: 4,
+	}
+}
+
+// fakes counts stage calls per generation and stands in for nnuegen, the
+// trainer and (unless realGate) the gate.
+type fakes struct {
+	generated, trained map[int]int
+	resumedTrain       map[int]bool
+	failTrain          int // generation whose next train call fails
+	pass               map[float64]bool
+	realGate           bool
+}
+
+func newFakes() *fakes {
+	return &fakes{generated: map[int]int{}, trained: map[int]int{}, resumedTrain: map[int]bool{}, pass: map[float64]bool{}}
+}
+
+// candidateMean tags generation n's candidate so the fake gate can tell
+// candidates apart.
+func candidateMean(n int) float64 { return nnueweights.Compiled().Mean + float64(n) }
+
+func (f *fakes) steps() steps {
+	s := steps{
+		generate: func(cfg Config, n int, weights, data, log string, resume bool) error {
+			f.generated[n]++
+			if _, err := os.Stat(weights); err != nil {
+				return err
+			}
+			return os.MkdirAll(data, 0o755)
+		},
+		train: func(cfg Config, data, dir, log string, resume bool) error {
+			var n int
+			if _, err := fmt.Sscanf(filepath.Base(dir), "gen-%d", &n); err != nil {
+				return err
+			}
+			f.trained[n]++
+			f.resumedTrain[n] = resume
+			if err := os.WriteFile(filepath.Join(dir, "train.ckpt"), nil, 0o644); err != nil {
+				return err
+			}
+			if f.failTrain == n {
+				f.failTrain = 0
+				return errors.New("killed")
+			}
+			net := nnueweights.Compiled()
+			net.Mean = candidateMean(n)
+			var buf bytes.Buffer
+			if err := net.Encode(&buf); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(dir, "candidate.nnue"), buf.Bytes(), 0o644)
+		},
+		gate: func(cfg Config, candidate, incumbent *nnueweights.Net) (arena.SequentialResult, error) {
+			result := arena.SequentialResult{Report: arena.Report{Games: 20, Wins: 4, Losses: 16}, Stopped: true}
+			if f.pass[candidate.Mean] {
+				result.Wins, result.Losses, result.Above = 16, 4, true
+			}
+			return result, nil
+		},
+	}
+	if f.realGate {
+		s.gate = gate
+	}
+	return s
+}
+
+func readManifest(t *testing.T, dir string) Manifest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "run.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// TestLoopPromotesOnlyPassingCandidates: a failed gate keeps the incumbent,
+// which the next generation then self-plays with and gates against.
+func TestLoopPromotesOnlyPassingCandidates(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openRun(dir, tinyConfig(3), "", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFakes()
+	f.pass[candidateMean(1)], f.pass[candidateMean(3)] = true, true
+	l.steps = f.steps()
+	if err := l.run(); err != nil {
+		t.Fatal(err)
+	}
+	m := readManifest(t, dir)
+	if m.Best != filepath.Join("nets", "003.nnue") || len(m.Generations) != 3 {
+		t.Fatalf("manifest %+v", m)
+	}
+	wantIncumbent := []string{"000", "001", "001"}
+	for i, g := range m.Generations {
+		if g.Stage != stageDone || g.Gate == nil || g.Promoted != (i != 1) {
+			t.Fatalf("generation %d: %+v", g.N, g)
+		}
+		if g.Incumbent != filepath.Join("nets", wantIncumbent[i]+".nnue") {
+			t.Fatalf("generation %d incumbent %s, want %s", g.N, g.Incumbent, wantIncumbent[i])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nets", "002.nnue")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("rejected candidate was installed: %v", err)
+	}
+	best, err := os.ReadFile(filepath.Join(dir, "best.nnue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "gen-003", "candidate.nnue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(best, want) {
+		t.Fatal("best.nnue is not the last promoted candidate")
+	}
+	if _, err := openRun(dir, tinyConfig(3), "", false, 0); err == nil {
+		t.Fatal("a second run reused a run directory without -resume")
+	}
+}
+
+// TestLoopResumesAtLastCompletedStage kills training in generation 2: the
+// resumed run trains again from the checkpoint without regenerating, and no
+// finished generation repeats.
+func TestLoopResumesAtLastCompletedStage(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openRun(dir, tinyConfig(2), "", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFakes()
+	f.failTrain = 2
+	l.steps = f.steps()
+	if err := l.run(); err == nil {
+		t.Fatal("killed training did not stop the run")
+	}
+	if m := readManifest(t, dir); m.Generations[1].Stage != stageGenerated {
+		t.Fatalf("generation 2 recorded %+v", m.Generations[1])
+	}
+
+	l, err = openRun(dir, Config{}, "", true, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.steps = f.steps()
+	if err := l.run(); err != nil {
+		t.Fatal(err)
+	}
+	for n, want := range map[int]int{1: 1, 2: 1, 3: 1} {
+		if f.generated[n] != want {
+			t.Fatalf("generation %d generated %d times, want %d", n, f.generated[n], want)
+		}
+	}
+	if f.trained[1] != 1 || f.trained[2] != 2 || f.trained[3] != 1 {
+		t.Fatalf("train calls %v", f.trained)
+	}
+	if !f.resumedTrain[2] || f.resumedTrain[3] {
+		t.Fatalf("checkpoint resumes %v, want generation 2 only", f.resumedTrain)
+	}
+	if m := readManifest(t, dir); len(m.Generations) != 3 || m.Config.Generations != 3 {
+		t.Fatalf("resumed manifest %+v", m)
+	}
+}
+
+// TestGateKeepsAnEqualNet runs the real gate: a candidate identical to the
+// incumbent cannot clear 50%.
+func TestGateKeepsAnEqualNet(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openRun(dir, tinyConfig(1), "", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFakes()
+	f.realGate = true
+	l.steps = f.steps()
+	l.steps.train = func(cfg Config, data, dir, log string, resume bool) error {
+		return copyFile(filepath.Join(filepath.Dir(dir), "nets", "000.nnue"), filepath.Join(dir, "candidate.nnue"))
+	}
+	if err := l.run(); err != nil {
+		t.Fatal(err)
+	}
+	g := readManifest(t, dir).Generations[0]
+	if g.Gate == nil || g.Gate.Games == 0 || g.Gate.Passed || g.Promoted {
+		t.Fatalf("equal net gate %+v promoted=%t", g.Gate, g.Promoted)
+	}
+}
+
+func copyFile(from, to string) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(to, data, 0o644)
+}
@@ -0,0 +1,327 @@
+// Command openinggen writes a balanced opening suite (arena.OpeningSuite).
+// For every board and player count it samples random openings, keeps those a
+// deep search rates level, and picks the set that differs most in shape:
+//
+//	go run ./arena/cmd/openinggen -boards 8x8,12x12 -per 24 -out arena/suites/openings-v1.json
+//	go run ./arena/cmd/openinggen -boards 12x12,15x15 -players 2,3,4 -per 64 -deep 50000 -out suite.json
+//
+// A candidate is the empty board plus a random number of uniformly random
+// legal plies in -plies. Its deep score is a node-budget search (max^n in
+// 3-4 player games, no opening book) at -deep nodes; the spread is the best
+// seat's backed-up score less the worst's, and a candidate is kept when that
+// is at most -window. Shape is the nnuefeat input vector, standardized over
+// the kept candidates; the suite takes the first kept candidate, then
+// repeatedly the one farthest from everything already taken. The output is a
+// pure function of the flags apart from generated_utc.
+//
+// Use the file with the -opening-suite flag of cmd/arena, roundrobin or
+// spsatune.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"virusgame/arena"
+	"virusgame/game"
+	"virusgame/nnuefeat"
+	"virusgame/search"
+)
+
+func main() {
+	out := flag.String("out", "", "output suite JSON")
+	boards := flag.String("boards", "8x8,10x10,12x12", "comma list of boards")
+	players := flag.String("players", "2", "comma list of player counts")
+	per := flag.Int("per", 32, "openings per board and player count")
+	candidates := flag.Int("candidates", 16, "candidates sampled per opening kept")
+	plies := flag.String("plies", "4-12", "random plies per candidate, min-max")
+	deep := flag.Uint64("deep", 20000, "node budget of each candidate's deep search")
+	window := flag.Int("window", 3000, "largest seat-score spread kept")
+	seed := flag.Int64("seed", 1, "sampling seed")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "concurrent deep searches")
+	flag.Parse()
+	if *out == "" {
+		log.Fatal("-out is required")
+	}
+	cfg := config{per: *per, candidates: *candidates, deep: *deep, window: *window, seed: *seed, workers: *workers}
+	var err error
+	if cfg.minPlies, cfg.maxPlies, err = parseRange(*plies); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.boards, err = parseBoards(*boards); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.players, err = parseInts(*players); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.per < 1 || cfg.candidates < 1 {
+		log.Fatal("-per and -candidates must be positive")
+	}
+
+	suite := arena.OpeningSuite{
+		Version: arena.OpeningSuiteVersion,
+		Generator: fmt.Sprintf("openinggen-v1: plies %d-%d, deep %d nodes, window %d, %d candidates per opening, seed %d",
+			cfg.minPlies, cfg.maxPlies, cfg.deep, cfg.window, cfg.candidates, cfg.seed),
+		GeneratedUTC: time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, board := range cfg.boards {
+		for _, count := range cfg.players {
+			openings, sampled, err := generate(cfg, board, count)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Fprintf(os.Stderr, "%dx%d %dp: %d balanced of %d sampled, kept %d\n",
+				board.Rows, board.Cols, count, sampled.balanced, sampled.total, len(openings))
+			suite.Openings = append(suite.Openings, openings...)
+		}
+	}
+	suite.Checksum = arena.OpeningSuiteChecksum(suite.Openings)
+	encoded, err := json.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(encoded, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type config struct {
+	boards             []arena.Board
+	players            []int
+	per, candidates    int
+	minPlies, maxPlies int
+	deep               uint64
+	window             int
+	seed               int64
+	workers            int
+}
+
+// candidate is one sampled opening.
+type candidate struct {
+	actions []game.Action
+	state   game.State
+	hash    string
+	spread  int
+	scored  bool
+}
+
+type tally struct{ total, balanced int }
+
+// generate returns the per openings of one board and player count.
+func generate(cfg config, board arena.Board, players int) ([]arena.SuiteOpening, tally, error) {
+	if _, err := game.New(board.Rows, board.Cols, players); err != nil {
+		return nil, tally{}, fmt.Errorf("%dx%d with %d players: %w", board.Rows, board.Cols, players, err)
+	}
+	rng := rand.New(rand.NewSource(cfg.seed ^ int64(board.Rows*1_000_003+board.Cols*1009+players)))
+	var pool []candidate
+	seen := map[string]bool{}
+	for attempt := 0; len(pool) < cfg.per*cfg.candidates && attempt < 4*cfg.per*cfg.candidates; attempt++ {
+		c, ok := sample(rng, board, players, cfg.minPlies+rng.Intn(cfg.maxPlies-cfg.minPlies+1))
+		if ok && !seen[c.hash] {
+			seen[c.hash] = true
+			pool = append(pool, c)
+		}
+	}
+	scoreAll(pool, cfg.deep, cfg.workers)
+
+	var balanced []candidate
+	for _, c := range pool {
+		if c.scored && c.spread <= cfg.window {
+			balanced = append(balanced, c)
+		}
+	}
+	var openings []arena.SuiteOpening
+	for i, index := range diverse(balanced, cfg.per) {
+		c := balanced[index]
+		opening := arena.SuiteOpening{
+			ID:   fmt.Sprintf("%dx%d-%dp-%03d", board.Rows, board.Cols, players, i+1),
+			Rows: board.Rows, Cols: board.Cols, Players: players, Spread: c.spread, Hash: c.hash,
+		}
+		for _, action := range c.actions {
+			opening.Actions = append(opening.Actions, replayMove(action))
+		}
+		openings = append(openings, opening)
+	}
+	return openings, tally{total: len(pool), balanced: len(balanced)}, nil
+}
+
+// sample plays plies uniformly random legal actions from the empty board. It
+// fails when the game ends on the way.
+func sample(rng *rand.Rand, board arena.Board, players, plies int) (candidate, bool) {
+	state, err := game.New(board.Rows, board.Cols, players)
+	if err != nil {
+		return candidate{}, false
+	}
+	c := candidate{}
+	for ply := 0; ply < plies; ply++ {
+		actions := state.LegalActions()
+		if len(actions) == 0 || state.GameOver() {
+			return candidate{}, false
+		}
+		action := actions[rng.Intn(len(actions))]
+		if state, err = state.Apply(action); err != nil {
+			return candidate{}, false
+		}
+		c.actions = append(c.actions, action)
+	}
+	if state.GameOver() {
+		return candidate{}, false
+	}
+	c.state = state
+	c.hash, err = arena.SnapshotHash(state.Snapshot())
+	return c, err == nil
+}
+
+// scoreAll fills each candidate's spread from a deep search.
+func scoreAll(pool []candidate, deep uint64, workers int) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(1, workers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pool[i].spread, pool[i].scored = spread(pool[i].state, deep)
+			}
+		}()
+	}
+	for i := range pool {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// spread is the deep search's best active-seat score less its worst.
+func spread(state game.State, deep uint64) (int, bool) {
+	result, ok := search.ChooseNodeBudgetOptions(state, deep, search.Options{NoBook: true})
+	if !ok || result.Depth == 0 {
+		return 0, false
+	}
+	low, high := math.MaxInt, math.MinInt
+	for seat := game.Player(1); int(seat) <= state.Players(); seat++ {
+		if state.Active(seat) {
+			low, high = min(low, result.SeatScores[seat-1]), max(high, result.SeatScores[seat-1])
+		}
+	}
+	return high - low, true
+}
+
+// diverse picks up to n candidates by farthest-point sampling over their
+// standardized nnuefeat inputs, starting from the first.
+func diverse(pool []candidate, n int) []int {
+	if len(pool) == 0 {
+		return nil
+	}
+	vectors := make([][]float64, len(pool))
+	for i, c := range pool {
+		vectors[i] = nnuefeat.Input(c.state)
+	}
+	standardize(vectors)
+	distance := make([]float64, len(pool))
+	for i := range distance {
+		distance[i] = math.Inf(1)
+	}
+	picked := []int{0}
+	for len(picked) < min(n, len(pool)) {
+		last := vectors[picked[len(picked)-1]]
+		next := -1
+		for i, vector := range vectors {
+			distance[i] = min(distance[i], squaredDistance(vector, last))
+			if distance[i] > 0 && (next < 0 || distance[i] > distance[next]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break // every remaining candidate has an identical shape
+		}
+		picked = append(picked, next)
+		distance[next] = 0
+	}
+	return picked
+}
+
+// standardize rescales every dimension to zero mean and unit variance;
+// constant dimensions become zero.
+func standardize(vectors [][]float64) {
+	for d := range vectors[0] {
+		mean, variance := 0.0, 0.0
+		for _, v := range vectors {
+			mean += v[d]
+		}
+		mean /= float64(len(vectors))
+		for _, v := range vectors {
+			variance += (v[d] - mean) * (v[d] - mean)
+		}
+		deviation := math.Sqrt(variance / float64(len(vectors)))
+		for _, v := range vectors {
+			if deviation == 0 {
+				v[d] = 0
+			} else {
+				v[d] = (v[d] - mean) / deviation
+			}
+		}
+	}
+}
+
+func squaredDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return sum
+}
+
+func replayMove(action game.Action) arena.ReplayMove {
+	if action.Kind == game.PlaceNeutrals {
+		return arena.ReplayMove{Kind: "neutral", Neutrals: []game.Pos{action.Neutrals[0], action.Neutrals[1]}}
+	}
+	return arena.ReplayMove{Kind: "move", Row: action.Target.Row, Col: action.Target.Col}
+}
+
+func parseBoards(list string) ([]arena.Board, error) {
+	var boards []arena.Board
+	for _, field := range strings.Split(list, ",") {
+		var board arena.Board
+		if _, err := fmt.Sscanf(strings.TrimSpace(field), "%dx%d", &board.Rows, &board.Cols); err != nil || board.Rows < 2 || board.Cols < 2 {
+			return nil, fmt.Errorf("invalid board %q", field)
+		}
+		boards = append(boards, board)
+	}
+	return boards, nil
+}
+
+func parseInts(list string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || value < 2 || value > 4 {
+			return nil, fmt.Errorf("invalid player count %q", field)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func parseRange(text string) (int, int, error) {
+	low, high, ok := strings.Cut(text, "-")
+	if !ok {
+		high = low
+	}
+	lo, err1 := strconv.Atoi(low)
+	hi, err2 := strconv.Atoi(high)
+	if err1 != nil || err2 != nil || lo < 1 || hi < lo {
+		return 0, 0, fmt.Errorf("invalid -plies %q, want min-max", text)
+	}
+	return lo, hi, nil
+}
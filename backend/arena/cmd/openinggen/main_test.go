@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"virusgame/arena"
+)
+
+func TestDiversePicksDistinctShapesFirstCandidateFirst(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var pool []candidate
+	for len(pool) < 12 {
+		if c, ok := sample(rng, arena.Board{Rows: 8, Cols: 8}, 2, 4+rng.Intn(6)); ok {
+			pool = append(pool, c, c) // every shape twice
+		}
+	}
+	picked := diverse(pool, 12)
+	if len(picked) != 6 || picked[0] != 0 {
+		t.Fatalf("picked %v from 6 shapes, each twice", picked)
+	}
+	seen := map[string]bool{}
+	for _, i := range picked {
+		if seen[pool[i].hash] {
+			t.Fatalf("picked %v repeats a position", picked)
+		}
+		seen[pool[i].hash] = true
+	}
+	if got := diverse(pool, 3); len(got) != 3 {
+		t.Fatalf("asked for 3, got %v", got)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	if lo, hi, err := parseRange("4-12"); err != nil || lo != 4 || hi != 12 {
+		t.Fatalf("4-12 = %d, %d, %v", lo, hi, err)
+	}
+	if lo, hi, err := parseRange("6"); err != nil || lo != 6 || hi != 6 {
+		t.Fatalf("6 = %d, %d, %v", lo, hi, err)
+	}
+	for _, bad := range []string{"12-4", "0-3", "x"} {
+		if _, _, err := parseRange(bad); err == nil {
+			t.Errorf("-plies %q accepted", bad)
+		}
+	}
+	if _, err := parseInts("2,5"); err == nil {
+		t.Error("5 players accepted")
+	}
+	if boards, err := parseBoards("8x8, 10x12"); err != nil || len(boards) != 2 || boards[1].Cols != 12 {
+		t.Fatalf("boards %v, %v", boards, err)
+	}
+}
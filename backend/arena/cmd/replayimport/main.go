@@ -28,38 +28,12 @@ import (
 	"strconv"
 
 	"virusgame/arena"
-	"virusgame/game"
 )
 
 const defaultLastGamesURL = "https://vs.wandergeek.org/last_games"
 
 type recentResponse struct {
-	Games []recentGame `json:"games"`
-}
-
-type recentGame struct {
-	ID          string       `json:"id"`
-	Player1     string       `json:"player1_name"`
-	Player2     string       `json:"player2_name"`
-	Player3     string       `json:"player3_name"`
-	Termination string       `json:"termination"`
-	Rows        int          `json:"rows"`
-	Cols        int          `json:"cols"`
-	Result      int          `json:"result"`
-	PGN         []recentTurn `json:"pgn_content"`
-}
-
-type recentTurn struct {
-	Turn   int          `json:"turn"`
-	Player game.Player  `json:"player"`
-	Moves  []recentMove `json:"moves"`
-}
-
-type recentMove struct {
-	Type  string     `json:"type"`
-	Row   int        `json:"row"`
-	Col   int        `json:"col"`
-	Cells []game.Pos `json:"cells"`
+	Games []arena.StoredGame `json:"games"`
 }
 
 func main() {
@@ -92,7 +66,7 @@ func importFile(input, output string) {
 	}
 	response := decode(data)
 	for _, source := range response.Games {
-		replay, err := reconstruct(source)
+		replay, err := source.Replay()
 		if err != nil {
 			panic(err)
 		}
@@ -123,7 +97,7 @@ func harvest(limit int, endpoint, output string) {
 		if source.Result != 1 || source.Player3 != "" || have[source.ID] {
 			continue // not a fresh human-won 1v1 game
 		}
-		replay, err := reconstruct(source)
+		replay, err := source.Replay()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "skip %s: reconstruct: %v\n", source.ID, err)
 			continue
@@ -178,51 +152,6 @@ func decode(data []byte) recentResponse {
 	return response
 }
 
-// reconstruct replays one recorded game through the authoritative rules and
-// returns its immutable fixture form. It returns an error (rather than
-// panicking) so the harvester can skip a game that no longer replays cleanly.
-func reconstruct(source recentGame) (arena.Replay, error) {
-	replay := arena.Replay{SourceID: source.ID, Players: [2]string{source.Player1, source.Player2}, Rows: source.Rows, Cols: source.Cols, Winner: game.Player(source.Result), Termination: source.Termination, ObservedTurns: len(source.PGN)}
-	state, err := game.New(source.Rows, source.Cols, 2)
-	if err != nil {
-		return arena.Replay{}, err
-	}
-	for _, sourceTurn := range source.PGN {
-		turn := arena.ReplayTurn{Number: sourceTurn.Turn, Player: sourceTurn.Player}
-		for _, sourceMove := range sourceTurn.Moves {
-			if state.GameOver() {
-				replay.OmittedMoves++
-				continue
-			}
-			move := arena.ReplayMove{Row: sourceMove.Row, Col: sourceMove.Col}
-			var action game.Action
-			switch sourceMove.Type {
-			case "place", "attack":
-				move.Kind = "move"
-				action = game.Action{Kind: game.Move, Target: game.Pos{Row: move.Row, Col: move.Col}}
-			case "neutral":
-				move.Kind, move.Neutrals = "neutral", sourceMove.Cells
-				if len(move.Neutrals) != 2 {
-					return arena.Replay{}, fmt.Errorf("game %s: neutral needs two cells", source.ID)
-				}
-				action = game.Action{Kind: game.PlaceNeutrals, Neutrals: [2]game.Pos{move.Neutrals[0], move.Neutrals[1]}}
-			default:
-				return arena.Replay{}, fmt.Errorf("game %s: unknown move type %q", source.ID, sourceMove.Type)
-			}
-			state, err = state.Apply(action)
-			if err != nil {
-				return arena.Replay{}, fmt.Errorf("game %s turn %d: %w", source.ID, sourceTurn.Turn, err)
-			}
-			turn.Actions = append(turn.Actions, move)
-		}
-		if len(turn.Actions) != 0 {
-			turn.Number = len(replay.Turns) + 1
-			replay.Turns = append(replay.Turns, turn)
-		}
-	}
-	return replay, nil
-}
-
 // terminalFingerprint validates the reconstructed replay through DecodeReplay
 // (so a broken game is never written) and pins its terminal position.
 func terminalFingerprint(replay arena.Replay) (string, error) {
@@ -0,0 +1,166 @@
+// Command roundrobin rates arena agents on one Elo scale: it plays every pair
+// of entrants over the same balanced seeded openings and fits a Bradley-Terry
+// model (arena.FitRatings) with 95% intervals to all the games.
+//
+//	go run ./arena/cmd/roundrobin -ratings ratings.json -agents 'random,greedy,base,ownerbot,search?nodes=2000,incumbent?nodes=2000'
+//	go run ./arena/cmd/roundrobin -ratings ratings.json -agents 'nnue?nodes=2000&net=candidate.nnue'
+//
+// -ratings is persistent: a run loads it, enters -agents alongside everything
+// already entered, plays only the pairs not yet played and refits, so a new
+// candidate costs its own pairs and nothing else. The file is rewritten after
+// every pair, so a killed run picks up at the first unplayed pair. The board
+// and openings are fixed when the file is created; a later -board, -openings
+// or -opening-suite must match them. -opening-suite plays the board's
+// openings from an openinggen suite instead of seeded ones, and the file
+// records the suite checksum so games over different openings never merge.
+//
+// An entrant is named by its engine spec (engine.ParseSpec), which is
+// also how a later run rebuilds it:
+//
+//	random, legacy        seeded baselines (one game at a time)
+//	greedy, base, mobility, mobility-base, cutseeker, ownerbot
+//	                      the scripted sparring agents
+//	production            the deployed anytime search at its wall-clock budget
+//	                      (one game at a time)
+//	search:v2?nodes=N&params=tuned.json
+//	                      the current engine at N nodes, with EvalParams
+//	search:v1?nodes=N     the frozen incumbent
+//	nnue?nodes=N&net=net.nnue
+//	                      the current engine on the NNUE eval
+//	mcts?nodes=N          Monte Carlo search at N iterations
+//
+// A spec without a budget takes -nodes and is recorded with it. Paths are
+// part of the name: keep the files where they were rated.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"virusgame/arena"
+	"virusgame/engine"
+)
+
+func main() {
+	ratingsPath := flag.String("ratings", "ratings.json", "persistent ratings file, created if missing")
+	agents := flag.String("agents", "", "comma list of entrant specs to add (see the package doc)")
+	nodes := flag.Uint64("nodes", 2000, "node budget of a bare search, incumbent or nnue spec")
+	board := flag.String("board", "12x12", "board of the seeded openings (new ratings file only)")
+	openings := flag.Int("openings", 20, "balanced opening pairs per entrant pair (new ratings file only)")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "concurrent openings per pair")
+	openingSuite := flag.String("opening-suite", "", "opening suite file (openinggen) to play instead of seeded openings (new ratings file only)")
+	flag.Parse()
+
+	var rows, cols int
+	if _, err := fmt.Sscanf(*board, "%dx%d", &rows, &cols); err != nil || rows < 2 || cols < 2 {
+		log.Fatalf("invalid -board %q", *board)
+	}
+	if *openings < 1 {
+		log.Fatal("-openings must be positive")
+	}
+	var suite *arena.OpeningSuite
+	if *openingSuite != "" {
+		loaded, err := arena.ReadOpeningSuite(*openingSuite)
+		if err != nil {
+			log.Fatal(err)
+		}
+		suite = &loaded
+	}
+	file, err := arena.ReadRatingsFile(*ratingsPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		file = arena.NewRatingsFile(rows, cols, *openings, suite)
+	case err != nil:
+		log.Fatal(err)
+	default:
+		set := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if set["board"] && (rows != file.Rows || cols != file.Cols) {
+			log.Fatalf("-board %s: %s was rated on %dx%d", *board, *ratingsPath, file.Rows, file.Cols)
+		}
+		if set["openings"] && *openings != file.Openings {
+			log.Fatalf("-openings %d: %s plays %d per pair", *openings, *ratingsPath, file.Openings)
+		}
+		if suite.Source() != file.Source {
+			log.Fatalf("-opening-suite %s: %s was rated on openings %s", suite.Source(), *ratingsPath, file.Source)
+		}
+	}
+	if *agents != "" {
+		for _, spec := range strings.Split(*agents, ",") {
+			name, err := normalizeSpec(strings.TrimSpace(spec), *nodes)
+			if err != nil {
+				log.Fatal(err)
+			}
+			file.AddEntrant(name)
+		}
+	}
+	if len(file.Entrants) < 2 {
+		log.Fatal("a round robin needs at least two entrants; pass -agents")
+	}
+
+	entrants := map[string]arena.RatingEntrant{}
+	for _, name := range file.Entrants {
+		entrant, err := buildEntrant(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		entrants[name] = entrant
+	}
+	missing := len(file.Missing())
+	fmt.Fprintf(os.Stderr, "%d entrants, %d pairs to play (%d openings each on %dx%d)\n",
+		len(file.Entrants), missing, file.Openings, file.Rows, file.Cols)
+	done := 0
+	err = arena.PlayRoundRobin(&file, suite, entrants, *workers, func(pair arena.RatingPair) error {
+		done++
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s vs %s: +%d =%d -%d %s\n",
+			done, missing, pair.A, pair.B, pair.WinsA, pair.Draws, pair.WinsB, pair.Pentanomial.Elo())
+		return arena.WriteRatingsFile(*ratingsPath, file)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Fit(); err != nil {
+		log.Fatal(err)
+	}
+	if err := arena.WriteRatingsFile(*ratingsPath, file); err != nil {
+		log.Fatal(err)
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "rank\tentrant\telo\t95% interval\tgames\tscore\t")
+	for i, r := range file.Ratings {
+		fmt.Fprintf(table, "%d\t%s\t%+.0f\t[%+.0f,%+.0f]\t%d\t%.1f%%\t\n", i+1, r.Name, r.Elo, r.Low, r.High, r.Games, 100*r.Score)
+	}
+	table.Flush()
+}
+
+// normalizeSpec checks spec and gives an unbudgeted engine that takes one its
+// node budget, so the recorded name rebuilds the same entrant.
+func normalizeSpec(spec string, nodes uint64) (string, error) {
+	parsed, err := engine.ParseSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	if budgeted := parsed.WithNodes(nodes); budgeted.String() != parsed.String() {
+		spec = budgeted.String()
+	}
+	if _, err := buildEntrant(spec); err != nil {
+		return "", err
+	}
+	return spec, nil
+}
+
+// buildEntrant turns a spec into its agent through the engine registry.
+func buildEntrant(spec string) (arena.RatingEntrant, error) {
+	engine, err := arena.BuildEngine(spec)
+	if err != nil {
+		return arena.RatingEntrant{}, err
+	}
+	return arena.RatingEntrant{Name: spec, New: func() arena.TelemetryAgent { return engine.New(1) }, Serial: engine.Serial}, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestSpecsNormalizeAndBuild(t *testing.T) {
+	for spec, want := range map[string]string{
+		"greedy": "greedy", "random": "random", "search": "search:v2?nodes=500",
+		"incumbent": "incumbent?nodes=500", "nnue": "nnue?nodes=500", "search?nodes=200": "search?nodes=200",
+		"search:v1": "search:v1?nodes=500", "mcts?reuse=0": "mcts?nodes=500&reuse=0", "search:v2?depth=2": "search:v2?depth=2",
+	} {
+		got, err := normalizeSpec(spec, 500)
+		if err != nil || got != want {
+			t.Fatalf("normalizeSpec(%q) = %q, %v; want %q", spec, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "alphazero", "search@200", "search?nodes=0", "incumbent?nodes=100&params=x.json", "search?nodes=100&params=missing.json"} {
+		if _, err := normalizeSpec(bad, 500); err == nil {
+			t.Fatalf("normalizeSpec(%q) accepted", bad)
+		}
+	}
+	if entrant, _ := buildEntrant("legacy"); !entrant.Serial {
+		t.Fatal("legacy carries an RNG and must play serially")
+	}
+}
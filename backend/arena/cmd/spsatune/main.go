@@ -4,17 +4,15 @@
 // win-rate over the 12x12 rungs {Greedy, Legacy, BaseAttacker,
 // MobilityAttacker, MobilityBaseAttacker, incumbent-h2h, OwnerBot}, weighted
 // toward the stranglers and heaviest on OwnerBot (the owner proxy) where eval
-// quality actually shows. Small-board strength floors
+// quality actually shows; repeated -rung weight*spec flags (engine specs, see
+// engine.ParseSpec) replace that ladder. Small-board strength floors
 // (Legacy >=85%, Greedy >=75%, incumbent h2h >=50%) are hard REJECT
 // constraints; CutSeeker is held out for validation only and never enters the
 // fitness sum.
 //
-// The evaluation weights are a process global (search.SetEvalParams), so the
-// two antithetic fitness evals per iteration run one after the other; the
-// worker pool parallelizes only the games within a single eval.
-//
-// ponytail: process-global eval params => serial antithetic evals; goroutine-
-// local params would allow full parallelism if the overnight run needs it.
+// Each candidate carries its own weights (search.Options.Params), so the two
+// antithetic fitness evals per iteration run concurrently, each with its own
+// worker pool over the games of that eval.
 package main
 
 import (
@@ -27,8 +25,12 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 
 	"virusgame/arena"
+	"virusgame/engine"
 	"virusgame/search"
 )
 
@@ -77,6 +79,11 @@ type configRecord struct {
 	Nodes         uint64 `json:"nodes"`
 	Seed          int64  `json:"seed"`
 	Workers       int    `json:"workers"`
+	// Rungs replaces defaultRungs when set (-rung weight*spec).
+	Rungs []string `json:"rungs,omitempty"`
+	// OpeningSuite is the checksum of the -opening-suite every rung played,
+	// empty for seeded openings.
+	OpeningSuite string `json:"openingSuite,omitempty"`
 }
 
 type output struct {
@@ -93,7 +100,9 @@ type optimizer struct {
 	seed                                    int64
 	scale                                   []float64
 	verbose                                 bool
-	initTheta                               *search.EvalParams // warm start, nil => default
+	initTheta                               *search.EvalParams  // warm start, nil => default
+	rungs                                   []string            // weight*spec, nil => defaultRungs
+	suite                                   *arena.OpeningSuite // nil => seeded openings
 }
 
 func newOptimizer(c configRecord, verbose bool) *optimizer {
@@ -107,7 +116,7 @@ func newOptimizer(c configRecord, verbose bool) *optimizer {
 	}
 	return &optimizer{
 		iters: c.Iters, openings: c.Openings, floorOpenings: c.FloorOpenings,
-		workers: c.Workers, nodes: c.Nodes, seed: c.Seed, scale: scale, verbose: verbose,
+		workers: c.Workers, nodes: c.Nodes, seed: c.Seed, scale: scale, verbose: verbose, rungs: c.Rungs,
 	}
 }
 
@@ -149,18 +158,18 @@ func (o *optimizer) play(rows, cols, openings int, threshold float64, a, b arena
 	if serial {
 		w = 1
 	}
-	res, err := arena.PlaySequentialOpenings(rows, cols, openings, threshold, ladderMinGames, a, b, w)
+	res, err := arena.PlaySequentialOpenings(o.suite, rows, cols, openings, threshold, ladderMinGames, a, b, w)
 	if err != nil {
 		return 0, err
 	}
 	return res.Report.WinRate(), nil
 }
 
-// fitness injects params, enforces the three floors (reject on breach), then
-// returns the stranglers-weighted average win% over the 12x12 ladder rungs.
+// fitness plays a candidate weighted by params, enforces the three floors
+// (reject on breach), then returns the stranglers-weighted average win% over
+// the 12x12 ladder rungs.
 func (o *optimizer) fitness(p search.EvalParams) (score float64, floorsOK bool, breached string, err error) {
-	search.SetEvalParams(p)
-	cand := arena.TelemetryNodeBudget(o.nodes, false)
+	cand := o.candidate(p)
 
 	// Floors first (any breach => reject). Legacy carries RNG state => serial.
 	type floor struct {
@@ -189,32 +198,14 @@ func (o *optimizer) fitness(p search.EvalParams) (score float64, floorsOK bool,
 		}
 	}
 
-	// Ladder: weighted average win% over the 12x12 rungs. Stranglers
-	// (BaseAttacker/MobilityAttacker/MobilityBaseAttacker) are weighted heavier
-	// because that is where eval quality separates (see project memory).
-	type rung struct {
-		opp    arena.TelemetryAgent
-		weight float64
-		serial bool
-	}
-	rungs := []rung{
-		{arena.Instrument(arena.Greedy), 1, false},
-		{arena.Instrument(arena.Legacy(1)), 1, true},
-		{arena.Instrument(arena.BaseAttacker), 2, false},
-		{arena.Instrument(arena.MobilityAttacker), 2, false},
-		{arena.Instrument(arena.MobilityBaseAttacker), 2, false},
-		{arena.TelemetryNodeBudget(o.nodes, true), 1, false}, // incumbent h2h
-		// OwnerBot is the owner proxy distilled from the loss corpus and the
-		// current eval loses to it badly from empty; weight it 3x so the search
-		// optimizes primarily against the opponent we actually care about beating.
-		// Pure function of position (slice-order scan, map lookups) => parallel-safe
-		// and order-independent like the other heuristic rungs. CutSeeker stays a
-		// held-out validation opponent (never a rung).
-		{arena.Instrument(arena.OwnerBot), 3, false},
+	// Ladder: weighted average win% over the 12x12 rungs.
+	rungs, err := o.ladder()
+	if err != nil {
+		return 0, false, "", err
 	}
 	var sum, wsum float64
 	for _, r := range rungs {
-		win, e := o.play(12, 12, o.openings, ladderThresh, cand, r.opp, r.serial)
+		win, e := o.play(12, 12, o.openings, ladderThresh, cand, r.engine.New(1), r.engine.Serial)
 		if e != nil {
 			return 0, false, "", e
 		}
@@ -224,19 +215,108 @@ func (o *optimizer) fitness(p search.EvalParams) (score float64, floorsOK bool,
 	return sum / wsum, true, "", nil
 }
 
+// defaultRungs is the ladder as weight*spec. Stranglers (BaseAttacker/
+// MobilityAttacker/MobilityBaseAttacker) are weighted heavier because that is
+// where eval quality separates (see project memory). OwnerBot is the owner
+// proxy distilled from the loss corpus and the current eval loses to it badly
+// from empty; weight it 3x so the search optimizes primarily against the
+// opponent we actually care about beating. CutSeeker stays a held-out
+// validation opponent (never a rung).
+var defaultRungs = []string{"1*greedy", "1*legacy", "2*base", "2*mobility", "2*mobility-base", "1*incumbent", "3*ownerbot"}
+
+// ladderRung is one built rung of the ladder.
+type ladderRung struct {
+	weight float64
+	engine arena.Engine
+}
+
+// parseRung splits "weight*spec"; a bare spec weighs 1.
+func parseRung(text string) (float64, engine.Spec, error) {
+	weight := 1.0
+	if prefix, rest, ok := strings.Cut(text, "*"); ok {
+		var err error
+		if weight, err = strconv.ParseFloat(prefix, 64); err != nil || weight <= 0 {
+			return 0, engine.Spec{}, fmt.Errorf("rung %q: weight must be a positive number", text)
+		}
+		text = rest
+	}
+	spec, err := engine.ParseSpec(text)
+	return weight, spec, err
+}
+
+// checkRung parses and builds a -rung, so a spec no engine can play (a
+// missing params file, options the frozen engine lacks) fails at startup.
+func checkRung(text string) error {
+	_, spec, err := parseRung(text)
+	if err != nil {
+		return err
+	}
+	if _, err := spec.Build(); err != nil {
+		return fmt.Errorf("rung %q: %w", text, err)
+	}
+	return nil
+}
+
+// ladder builds the configured rungs, or defaultRungs; a rung without a budget
+// of its own plays at -nodes like the candidate.
+func (o *optimizer) ladder() ([]ladderRung, error) {
+	texts := o.rungs
+	if len(texts) == 0 {
+		texts = defaultRungs
+	}
+	rungs := make([]ladderRung, len(texts))
+	for i, text := range texts {
+		weight, spec, err := parseRung(text)
+		if err != nil {
+			return nil, err
+		}
+		built, err := arena.BuildEngineSpec(spec.WithNodes(o.nodes))
+		if err != nil {
+			return nil, fmt.Errorf("rung %q: %w", text, err)
+		}
+		rungs[i] = ladderRung{weight: weight, engine: built}
+	}
+	return rungs, nil
+}
+
 // holdout measures CutSeeker win% for params — recorded per iteration but never
 // summed into fitness (validation only).
 func (o *optimizer) holdout(p search.EvalParams) (float64, error) {
-	search.SetEvalParams(p)
-	cand := arena.TelemetryNodeBudget(o.nodes, false)
+	cand := o.candidate(p)
 	return o.play(12, 12, o.openings, ladderThresh, cand, arena.Instrument(arena.CutSeeker), false)
 }
 
-// run executes the SPSA loop and returns the trace + summary. It restores the
-// process-global eval params on return so callers (and tests) are unaffected.
-func (o *optimizer) run() (trace []iterRecord, summary summaryRecord, err error) {
-	defer search.SetEvalParams(search.DefaultEvalParams())
+// candidate is the node-budget search agent playing with params.
+func (o *optimizer) candidate(p search.EvalParams) arena.TelemetryAgent {
+	return arena.TelemetryNodeBudgetOptions(o.nodes, search.Options{Params: &p})
+}
 
+// fitnessResult is one fitness evaluation's outcome.
+type fitnessResult struct {
+	score    float64
+	floorsOK bool
+	breached string
+	err      error
+}
+
+// fitnessPair evaluates the antithetic pair concurrently.
+func (o *optimizer) fitnessPair(plus, minus search.EvalParams) (fitnessResult, fitnessResult) {
+	var results [2]fitnessResult
+	var wg sync.WaitGroup
+	for i, p := range []search.EvalParams{plus, minus} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &results[i]
+			r.score, r.floorsOK, r.breached, r.err = o.fitness(p)
+		}()
+	}
+	wg.Wait()
+	return results[0], results[1]
+}
+
+// run executes the SPSA loop and returns the trace + summary.
+func (o *optimizer) run() (trace []iterRecord, summary summaryRecord, err error) {
 	n := len(o.scale)
 	theta := make([]float64, n)
 	if o.initTheta != nil {
@@ -285,14 +365,15 @@ func (o *optimizer) run() (trace []iterRecord, summary summaryRecord, err error)
 		pPlus := o.realParams(plus)
 		pMinus := o.realParams(minus)
 
-		fPlus, okPlus, brPlus, e := o.fitness(pPlus)
-		if e != nil {
-			return nil, summary, e
+		rPlus, rMinus := o.fitnessPair(pPlus, pMinus)
+		if rPlus.err != nil {
+			return nil, summary, rPlus.err
 		}
-		fMinus, okMinus, brMinus, e := o.fitness(pMinus)
-		if e != nil {
-			return nil, summary, e
+		if rMinus.err != nil {
+			return nil, summary, rMinus.err
 		}
+		fPlus, okPlus, brPlus := rPlus.score, rPlus.floorsOK, rPlus.breached
+		fMinus, okMinus, brMinus := rMinus.score, rMinus.floorsOK, rMinus.breached
 
 		floorsOK := okPlus && okMinus
 		breached := brPlus
@@ -347,6 +428,15 @@ func main() {
 	workers := flag.Int("workers", 0, "game workers per eval (0 => GOMAXPROCS)")
 	out := flag.String("out", "", "results JSON path (stdout summary if empty)")
 	init := flag.String("init", "", "warm-start theta: path to a results JSON (uses summary.bestTheta) or a bare EvalParams map")
+	openingSuite := flag.String("opening-suite", "", "opening suite file (openinggen) every rung plays instead of seeded openings")
+	var rungs []string
+	flag.Func("rung", "ladder rung weight*spec, e.g. 2*search:v2?params=old.json; repeat to replace the default ladder", func(text string) error {
+		if err := checkRung(text); err != nil {
+			return err
+		}
+		rungs = append(rungs, text)
+		return nil
+	})
 	flag.Parse()
 
 	w := *workers
@@ -355,9 +445,24 @@ func main() {
 	}
 	cfg := configRecord{
 		Iters: *iters, Openings: *openings, FloorOpenings: *floorOpenings,
-		Nodes: *nodes, Seed: *seed, Workers: w,
+		Nodes: *nodes, Seed: *seed, Workers: w, Rungs: rungs,
+	}
+	var suite *arena.OpeningSuite
+	if *openingSuite != "" {
+		loaded, err := arena.ReadOpeningSuite(*openingSuite)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "spsatune: -opening-suite:", err)
+			os.Exit(1)
+		}
+		suite = &loaded
+		cfg.OpeningSuite = suite.Source()
 	}
 	o := newOptimizer(cfg, true)
+	o.suite = suite
+	if _, err := o.ladder(); err != nil {
+		fmt.Fprintln(os.Stderr, "spsatune:", err)
+		os.Exit(1)
+	}
 	if *init != "" {
 		theta, err := loadInitTheta(*init)
 		if err != nil {
@@ -89,6 +89,52 @@ func TestWarmStartDefaultIsColdStart(t *testing.T) {
 	}
 }
 
+// TestLadderRungs checks the default ladder keeps its weights and serial
+// Legacy rung, and that -rung specs parse with an optional weight.
+func TestLadderRungs(t *testing.T) {
+	o := newOptimizer(configRecord{Nodes: 200}, false)
+	rungs, err := o.ladder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		spec   string
+		weight float64
+		serial bool
+	}{
+		{"greedy", 1, false}, {"legacy", 1, true}, {"base", 2, false}, {"mobility", 2, false},
+		{"mobility-base", 2, false}, {"incumbent?nodes=200", 1, false}, {"ownerbot", 3, false},
+	}
+	if len(rungs) != len(want) {
+		t.Fatalf("%d default rungs, want %d", len(rungs), len(want))
+	}
+	for i, r := range rungs {
+		if r.engine.Spec != want[i].spec || r.weight != want[i].weight || r.engine.Serial != want[i].serial {
+			t.Errorf("rung %d = %s weight %g serial %v, want %+v", i, r.engine.Spec, r.weight, r.engine.Serial, want[i])
+		}
+	}
+
+	if weight, spec, err := parseRung("2.5*search:v1?nodes=300"); err != nil || weight != 2.5 || spec.String() != "search:v1?nodes=300" {
+		t.Fatalf("parseRung = %g %s %v", weight, spec, err)
+	}
+	if weight, spec, err := parseRung("cutseeker"); err != nil || weight != 1 || spec.Name != "cutseeker" {
+		t.Fatalf("bare rung = %g %s %v", weight, spec, err)
+	}
+	for _, bad := range []string{"0*greedy", "x*greedy", "2*alphazero"} {
+		if _, _, err := parseRung(bad); err == nil {
+			t.Errorf("parseRung(%q) accepted", bad)
+		}
+	}
+	if err := checkRung("2*search:v2?nodes=300"); err != nil {
+		t.Fatalf("checkRung: %v", err)
+	}
+	for _, bad := range []string{"2*search:v1?params=old.json", "search?params=" + filepath.Join(t.TempDir(), "missing.json")} {
+		if err := checkRung(bad); err == nil {
+			t.Errorf("checkRung(%q) accepted", bad)
+		}
+	}
+}
+
 func mustJSON(t *testing.T, v any) []byte {
 	t.Helper()
 	b, err := json.Marshal(v)
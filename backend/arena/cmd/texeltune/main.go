@@ -0,0 +1,427 @@
+// Command texeltune fits the hand-set evaluation weights (search.EvalParams)
+// to game outcomes, Texel style: it minimises the logistic loss between
+// sigmoid(StaticEval/K) and the result of the game each position came from,
+// over nnuegen's labelled shards, by local search over the integer weights.
+// Where spsatune pays minutes of ladder games per iteration, one pass here is
+// a few static evals per position.
+//
+// Positions come from nnuegen's schema-v2 JSONL (the raw position plus the
+// outcome); positions without a known outcome (ladder seeds) and terminal
+// positions are skipped. The result is the mover's: 1 for a win, 0 for a
+// loss, and (players-placement)/(players-1) for a 3-4 player finish.
+//
+// K, the sigmoid's width in eval units, is fitted once to the starting weights
+// (-k overrides it) and then held, so the weights cannot shrink the loss by
+// rescaling the eval. The local search tries each weight up and down by its
+// step, keeps any move that lowers the training loss, and halves the steps
+// once a pass finds nothing, stopping when unit steps find nothing either.
+// Weights stay non-negative and PredatoryCutLossDiv stays >= 1, as in
+// spsatune.
+//
+// -out writes a bare EvalParams object, which search.LoadEvalParams (cmd/arena
+// -params, bot-hoster personalities) and spsatune -init read, so the fit can
+// be confirmed in games. Losses only rank weights on positions; games decide.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+// shardSchema is the nnuegen record version this reader understands.
+const shardSchema = 3
+
+// record is the subset of nnuegen's Record the tuner reads; nnuegen owns the
+// schema (see its package doc).
+type record struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Position      position `json:"position"`
+	Rows          int      `json:"rows"`
+	Cols          int      `json:"cols"`
+	CurrentPlayer int      `json:"currentPlayer"`
+	Outcome       struct {
+		Winner    int `json:"winner"`
+		Placement int `json:"placement"`
+	} `json:"outcome"`
+}
+
+type position struct {
+	Cells       string `json:"cells"`
+	Bases       []int  `json:"bases"`
+	Active      []bool `json:"active"`
+	NeutralUsed []bool `json:"neutralUsed"`
+	MovesLeft   int    `json:"movesLeft"`
+	GameOver    bool   `json:"gameOver"`
+	Winner      int    `json:"winner"`
+}
+
+// snapshot rebuilds the position exactly as nnuegen's Record.toSnapshot does.
+func (r record) snapshot() game.Snapshot {
+	p := r.Position
+	board := make([][]game.Cell, r.Rows)
+	for row := range board {
+		board[row] = make([]game.Cell, r.Cols)
+		for col := range board[row] {
+			code := p.Cells[row*r.Cols+col] - 'A'
+			board[row][col] = game.Cell{Owner: game.Player(code / 5), Kind: game.CellKind(code % 5)}
+		}
+	}
+	bases := make([]game.Pos, len(p.Bases))
+	for i, idx := range p.Bases {
+		bases[i] = game.Pos{Row: idx / r.Cols, Col: idx % r.Cols}
+	}
+	return game.Snapshot{
+		Rows: r.Rows, Cols: r.Cols, Board: board, Bases: bases,
+		Active: p.Active, NeutralUsed: p.NeutralUsed,
+		Current: game.Player(r.CurrentPlayer), MovesLeft: p.MovesLeft,
+		GameOver: p.GameOver, Winner: game.Player(p.Winner),
+	}
+}
+
+// result is the mover's game result in [0, 1], false when unknown.
+func (r record) result() (float64, bool) {
+	players := len(r.Position.Active)
+	place := r.Outcome.Placement
+	if r.Outcome.Winner == 0 || place < 1 || place > players || players < 2 {
+		return 0, false
+	}
+	return float64(players-place) / float64(players-1), true
+}
+
+// sample is one tuning position and its mover's result.
+type sample struct {
+	state  game.State
+	result float64
+}
+
+// loadSamples reads every shard-*.jsonl in dir, in name order, keeping
+// positions with a known result that are still in play.
+func loadSamples(dir string) ([]sample, error) {
+	shards, err := filepath.Glob(filepath.Join(dir, "shard-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no shard-*.jsonl in %s", dir)
+	}
+	sort.Strings(shards)
+	var samples []sample
+	for _, shard := range shards {
+		if samples, err = readShard(shard, samples); err != nil {
+			return nil, err
+		}
+	}
+	return samples, nil
+}
+
+func readShard(path string, samples []sample) ([]sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1<<20), 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if r.SchemaVersion != shardSchema {
+			return nil, fmt.Errorf("%s:%d: shard schema v%d, texeltune reads v%d", path, line, r.SchemaVersion, shardSchema)
+		}
+		result, ok := r.result()
+		if !ok || r.Position.GameOver {
+			continue
+		}
+		state, err := game.FromSnapshot(r.snapshot())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		samples = append(samples, sample{state: state, result: result})
+	}
+	return samples, scanner.Err()
+}
+
+// split holds back every valEvery-th sample for validation (none when
+// valEvery < 2).
+func split(samples []sample, valEvery int) (train, val []sample) {
+	if valEvery < 2 {
+		return samples, nil
+	}
+	for i, s := range samples {
+		if i%valEvery == valEvery-1 {
+			val = append(val, s)
+		} else {
+			train = append(train, s)
+		}
+	}
+	return train, val
+}
+
+// scorer evaluates a sample set under candidate weights on a fixed worker
+// pool. Each worker fills its own index range and losses are summed serially,
+// so they do not depend on scheduling.
+type scorer struct {
+	workers int
+}
+
+// evals is the mover's StaticEval of every sample under params.
+func (s scorer) evals(samples []sample, params search.EvalParams) []float64 {
+	out := make([]float64, len(samples))
+	s.each(len(samples), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			state := samples[i].state
+			out[i] = float64(search.StaticEvalParams(state, state.CurrentPlayer(), params))
+		}
+	})
+	return out
+}
+
+func (s scorer) each(n int, run func(lo, hi int)) {
+	chunk := (n + s.workers - 1) / s.workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			run(lo, hi)
+		}(lo, min(lo+chunk, n))
+	}
+	wg.Wait()
+}
+
+// loss is the mean logistic loss of sigmoid(eval/k) against the results.
+func loss(samples []sample, evals []float64, k float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	const eps = 1e-12
+	sum := 0.0
+	for i, s := range samples {
+		p := min(max(1/(1+math.Exp(-evals[i]/k)), eps), 1-eps)
+		sum -= s.result*math.Log(p) + (1-s.result)*math.Log(1-p)
+	}
+	return sum / float64(len(samples))
+}
+
+// fitK finds the sigmoid width minimising the loss of fixed evals, by golden
+// section search on log K over [10, 1e5] eval units.
+func fitK(samples []sample, evals []float64) float64 {
+	const phi = 0.6180339887498949
+	lo, hi := math.Log(10), math.Log(1e5)
+	f := func(x float64) float64 { return loss(samples, evals, math.Exp(x)) }
+	a, b := hi-phi*(hi-lo), lo+phi*(hi-lo)
+	fa, fb := f(a), f(b)
+	for hi-lo > 1e-4 {
+		if fa < fb {
+			hi, b, fb = b, a, fa
+			a = hi - phi*(hi-lo)
+			fa = f(a)
+		} else {
+			lo, a, fa = a, b, fb
+			b = lo + phi*(hi-lo)
+			fb = f(b)
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// Pass is one local-search sweep's trace entry.
+type Pass struct {
+	Pass      int     `json:"pass"`
+	TrainLoss float64 `json:"trainLoss"`
+	ValLoss   float64 `json:"valLoss,omitempty"`
+	Accepted  int     `json:"accepted"`
+	MaxStep   int     `json:"maxStep"`
+}
+
+// Report is the -report JSON.
+type Report struct {
+	Train      int               `json:"train"`
+	Val        int               `json:"val"`
+	K          float64           `json:"k"`
+	Start      search.EvalParams `json:"start"`
+	StartTrain float64           `json:"startTrainLoss"`
+	StartVal   float64           `json:"startValLoss,omitempty"`
+	Passes     []Pass            `json:"passes"`
+	Best       search.EvalParams `json:"best"`
+}
+
+// tuner runs the local search.
+type tuner struct {
+	train, val []sample
+	k          float64
+	scorer     scorer
+	log        io.Writer
+}
+
+func (t *tuner) trainLoss(p search.EvalParams) float64 {
+	return loss(t.train, t.scorer.evals(t.train, p), t.k)
+}
+
+func (t *tuner) valLoss(p search.EvalParams) float64 {
+	return loss(t.val, t.scorer.evals(t.val, p), t.k)
+}
+
+// tune improves start for at most passes sweeps.
+func (t *tuner) tune(start search.EvalParams, passes int) (search.EvalParams, []Pass) {
+	best := start
+	bestLoss := t.trainLoss(best)
+	fields := reflect.ValueOf(best).NumField()
+	steps := make([]int, fields)
+	for i := range steps {
+		steps[i] = max(1, abs(field(best, i))/4)
+	}
+	var trace []Pass
+	for pass := 1; pass <= passes; pass++ {
+		accepted := 0
+		for i := 0; i < fields; i++ {
+			for _, dir := range []int{1, -1} {
+				candidate, ok := moved(best, i, dir*steps[i])
+				if !ok {
+					continue
+				}
+				if l := t.trainLoss(candidate); l < bestLoss {
+					best, bestLoss = candidate, l
+					accepted++
+					break
+				}
+			}
+		}
+		entry := Pass{Pass: pass, TrainLoss: bestLoss, Accepted: accepted, MaxStep: maxOf(steps)}
+		if len(t.val) > 0 {
+			entry.ValLoss = t.valLoss(best)
+		}
+		trace = append(trace, entry)
+		fmt.Fprintf(t.log, "pass %d: train %.6f val %.6f accepted %d max step %d\n",
+			pass, entry.TrainLoss, entry.ValLoss, accepted, entry.MaxStep)
+		if accepted > 0 {
+			continue
+		}
+		if entry.MaxStep == 1 {
+			break
+		}
+		for i := range steps {
+			steps[i] = max(1, steps[i]/2)
+		}
+	}
+	return best, trace
+}
+
+// moved is p with field i shifted by delta, false when the bounds leave it
+// unchanged.
+func moved(p search.EvalParams, i, delta int) (search.EvalParams, bool) {
+	floor := 0
+	if reflect.TypeOf(p).Field(i).Name == "PredatoryCutLossDiv" {
+		floor = 1
+	}
+	value := max(field(p, i)+delta, floor)
+	if value == field(p, i) {
+		return p, false
+	}
+	reflect.ValueOf(&p).Elem().Field(i).SetInt(int64(value))
+	return p, true
+}
+
+func field(p search.EvalParams, i int) int { return int(reflect.ValueOf(p).Field(i).Int()) }
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxOf(xs []int) int {
+	m := 0
+	for _, x := range xs {
+		m = max(m, x)
+	}
+	return m
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func main() {
+	data := flag.String("data", "", "directory of nnuegen shard-*.jsonl (required)")
+	out := flag.String("out", "", "write the tuned EvalParams JSON here")
+	init := flag.String("init", "", "starting EvalParams JSON ('' = the hand-tuned defaults)")
+	k := flag.Float64("k", 0, "sigmoid width in eval units (0 = fit to the starting weights)")
+	passes := flag.Int("passes", 50, "most local-search passes")
+	valEvery := flag.Int("val-every", 10, "hold out every Nth position for validation (0 = none)")
+	workers := flag.Int("workers", 0, "eval workers (0 => GOMAXPROCS)")
+	reportPath := flag.String("report", "", "write a JSON trace of the run here")
+	flag.Parse()
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, "texeltune:", err)
+		os.Exit(1)
+	}
+	if *data == "" {
+		fmt.Fprintln(os.Stderr, "-data is required")
+		os.Exit(2)
+	}
+	start := search.DefaultEvalParams()
+	if *init != "" {
+		params, err := search.LoadEvalParams(*init)
+		if err != nil {
+			fail(err)
+		}
+		start = params
+	}
+	samples, err := loadSamples(*data)
+	if err != nil {
+		fail(err)
+	}
+	if len(samples) == 0 {
+		fail(fmt.Errorf("no positions with a known outcome in %s", *data))
+	}
+	w := *workers
+	if w <= 0 {
+		w = runtime.GOMAXPROCS(0)
+	}
+	t := &tuner{scorer: scorer{workers: w}, log: os.Stdout}
+	t.train, t.val = split(samples, *valEvery)
+	t.k = *k
+	if t.k <= 0 {
+		t.k = fitK(t.train, t.scorer.evals(t.train, start))
+	}
+	report := Report{Train: len(t.train), Val: len(t.val), K: t.k, Start: start, StartTrain: t.trainLoss(start)}
+	if len(t.val) > 0 {
+		report.StartVal = t.valLoss(start)
+	}
+	fmt.Printf("%d train / %d val positions, K=%.1f, start train %.6f val %.6f\n",
+		report.Train, report.Val, report.K, report.StartTrain, report.StartVal)
+	report.Best, report.Passes = t.tune(start, *passes)
+	fmt.Printf("tuned: %+v\n", report.Best)
+	if *out != "" {
+		if err := writeJSON(*out, report.Best); err != nil {
+			fail(err)
+		}
+		fmt.Println("wrote", *out)
+	}
+	if *reportPath != "" {
+		if err := writeJSON(*reportPath, report); err != nil {
+			fail(err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"virusgame/search"
+)
+
+// smokeShards copies nnuegen's committed smoke fixture into a shard directory.
+func smokeShards(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "nnuegen", "testdata", "smoke.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "shard-000.jsonl"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadSamplesKeepsKnownOutcomes(t *testing.T) {
+	samples, err := loadSamples(smokeShards(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) == 0 {
+		t.Fatal("no samples with a known outcome")
+	}
+	for i, s := range samples {
+		if s.result != 0 && s.result != 1 {
+			t.Fatalf("sample %d: 1v1 result %v", i, s.result)
+		}
+		if s.state.GameOver() {
+			t.Fatalf("sample %d is terminal", i)
+		}
+	}
+	four := record{Position: position{Active: []bool{true, true, true, true}}}
+	four.Outcome.Winner, four.Outcome.Placement = 2, 3
+	if got, ok := four.result(); !ok || got != 1.0/3 {
+		t.Fatalf("third of four = %v, %t", got, ok)
+	}
+	if _, ok := (record{Position: position{Active: []bool{true, true}}}).result(); ok {
+		t.Fatal("unknown outcome produced a result")
+	}
+}
+
+// TestTuneLowersLossAndRoundTrips: every pass keeps or lowers the training
+// loss, and the written weights load back through search.LoadEvalParams.
+func TestTuneLowersLossAndRoundTrips(t *testing.T) {
+	samples, err := loadSamples(smokeShards(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn := &tuner{scorer: scorer{workers: 3}, log: io.Discard}
+	tn.train, tn.val = split(samples, 10)
+	start := search.DefaultEvalParams()
+	tn.k = fitK(tn.train, tn.scorer.evals(tn.train, start))
+	startLoss := tn.trainLoss(start)
+	for _, k := range []float64{tn.k / 2, tn.k * 2} {
+		if l := loss(tn.train, tn.scorer.evals(tn.train, start), k); l < startLoss {
+			t.Fatalf("K=%.1f beats the fitted K=%.1f: %v < %v", k, tn.k, l, startLoss)
+		}
+	}
+	best, trace := tn.tune(start, 3)
+	previous := startLoss
+	for _, pass := range trace {
+		if pass.TrainLoss > previous {
+			t.Fatalf("pass %d raised the loss to %v from %v", pass.Pass, pass.TrainLoss, previous)
+		}
+		previous = pass.TrainLoss
+	}
+	if previous >= startLoss {
+		t.Fatalf("tuning left the loss at %v", previous)
+	}
+	again, _ := (&tuner{train: tn.train, val: tn.val, k: tn.k, scorer: scorer{workers: 1}, log: io.Discard}).tune(start, 3)
+	if again != best {
+		t.Fatalf("worker count changed the fit: %+v vs %+v", again, best)
+	}
+
+	path := filepath.Join(t.TempDir(), "params.json")
+	if err := writeJSON(path, best); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := search.LoadEvalParams(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != best {
+		t.Fatalf("loaded %+v, wrote %+v", loaded, best)
+	}
+}
+
+func TestMovedKeepsWeightsInBounds(t *testing.T) {
+	p := search.DefaultEvalParams()
+	p.Normal, p.PredatoryCutLossDiv = 0, 1
+	index := func(name string) int {
+		f, ok := reflect.TypeOf(p).FieldByName(name)
+		if !ok {
+			t.Fatalf("no field %s", name)
+		}
+		return f.Index[0]
+	}
+	if _, ok := moved(p, index("Normal"), -5); ok {
+		t.Fatal("a weight went negative")
+	}
+	if _, ok := moved(p, index("PredatoryCutLossDiv"), -1); ok {
+		t.Fatal("the cut-loss divisor reached zero")
+	}
+	up, ok := moved(p, index("PredatoryCutLossDiv"), 3)
+	if !ok || up.PredatoryCutLossDiv != 4 || p.PredatoryCutLossDiv != 1 {
+		t.Fatalf("moved up to %d (ok %t), original %d", up.PredatoryCutLossDiv, ok, p.PredatoryCutLossDiv)
+	}
+	if clamped, _ := moved(p, index("Connected"), -100); clamped.Connected != 0 {
+		t.Fatalf("Connected clamped to %d, want 0", clamped.Connected)
+	}
+}
@@ -1,6 +1,7 @@
 package arena
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -194,38 +195,10 @@ func maxOwnedDegree(state game.State, player game.Player) int {
 	return best
 }
 
-// buildBotChain constructs a 12x12 position: the bot (P2) has a diagonal chain
-// of the given width running from near its base (11,11) inward to (5,5); the
-// cutter (P1, to move) has a foothold from its base to a spearhead at (4,4),
-// diagonally adjacent to the bot chain's head at (5,5).
-func buildBotChain(width int) game.Snapshot {
-	const rows, cols = 12, 12
-	board := make([][]game.Cell, rows)
-	for r := range board {
-		board[r] = make([]game.Cell, cols)
-	}
-	board[0][0] = game.Cell{Owner: 1, Kind: game.Base}
-	board[rows-1][cols-1] = game.Cell{Owner: 2, Kind: game.Base}
-	for k := 10; k >= 5; k-- {
-		board[k][k] = game.Cell{Owner: 2, Kind: game.Normal}
-		if width >= 2 && k-1 >= 0 {
-			board[k][k-1] = game.Cell{Owner: 2, Kind: game.Normal}
-		}
-	}
-	for k := 1; k <= 4; k++ {
-		board[k][k] = game.Cell{Owner: 1, Kind: game.Normal}
-	}
-	return game.Snapshot{
-		Rows: rows, Cols: cols, Board: board,
-		Bases:       []game.Pos{{Row: 0, Col: 0}, {Row: rows - 1, Col: cols - 1}},
-		Active:      []bool{true, true},
-		NeutralUsed: []bool{true, true},
-		Current:     1, MovesLeft: 3,
-	}
-}
-
 // TestConstructedCutWidthSensitivity is the vs-ai2.40 fragility gate. From the
-// constructed foothold+spearhead position, the maxCut cutter (P1) plays the live
+// constructed foothold+spearhead position (suites/constructed-cut.epd: the bot,
+// P2, has a diagonal chain of width 1 or 2 from near its base to (5,5); the
+// cutter, P1 to move, has a spearhead at (4,4) touching the chain's head), the maxCut cutter (P1) plays the live
 // bot (P2) at a deterministic 1000-node budget. It asserts the width sensitivity
 // that makes the gate meaningful: the cutter WINS against a width-1 chain (the
 // filament is severable — this is the current eval's fragility, the vs-ai2.38
@@ -248,11 +221,8 @@ func TestConstructedCutWidthSensitivity(t *testing.T) {
 		{1, true, "width-1 filament is severable (fragility)"},
 		{2, false, "width-2 front has no single cut point (fix invariant)"},
 	} {
-		snapshot := buildBotChain(tc.width)
-		state, err := game.FromSnapshot(snapshot)
-		if err != nil {
-			t.Fatalf("width %d snapshot invalid: %v", tc.width, err)
-		}
+		state := suitePosition(t, "constructed-cut.epd", fmt.Sprintf("cut-width-%d", tc.width))
+		snapshot := state.Snapshot()
 		if got := maxOwnedDegree(state, 2); (got <= 2) != (tc.width == 1) {
 			t.Fatalf("width %d bot chain max-degree=%d does not match expected filament shape", tc.width, got)
 		}
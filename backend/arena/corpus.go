@@ -154,7 +154,17 @@ type CorpusReport struct {
 type CorpusFilter struct {
 	Track      string
 	Rows, Cols int
+	// Journal, when set, supplies the games it already holds instead of
+	// playing them and records every game played.
+	Journal *Journal
 }
+
+// includes reports whether the filter plays testCase of split.
+func (f CorpusFilter) includes(testCase CorpusCase, split string) bool {
+	return testCase.Split == split && testCase.Track != "stress" && (f.Track == "" || testCase.Track == f.Track) &&
+		(f.Rows == 0 || testCase.State.Rows() == f.Rows && testCase.State.Cols() == f.Cols)
+}
+
 type CorpusProgress struct {
 	Board string
 	Games int
@@ -170,10 +180,21 @@ func CompareCorpus(corpus Corpus, split string, contender, incumbent func() Tele
 func CompareCorpusFiltered(corpus Corpus, split string, filter CorpusFilter, progress func(CorpusProgress), contender, incumbent func() TelemetryAgent) (CorpusReport, error) {
 	report := CorpusReport{Split: split, Buckets: make(map[string]Report)}
 	for _, testCase := range corpus.Cases {
-		if testCase.Split != split || testCase.Track == "stress" || filter.Track != "" && testCase.Track != filter.Track || filter.Rows > 0 && (testCase.State.Rows() != filter.Rows || testCase.State.Cols() != filter.Cols) {
+		if !filter.includes(testCase, split) {
 			continue
 		}
+		board := fmt.Sprintf("%dx%d", testCase.State.Rows(), testCase.State.Cols())
 		for seat := 0; seat < testCase.Players; seat++ {
+			focus := game.Player(seat + 1)
+			if filter.Journal != nil {
+				if entry, ok := filter.Journal.lookup(testCase.ID, focus); ok {
+					report.add(entry.Result, focus, entry.Keys)
+					if progress != nil {
+						progress(CorpusProgress{Board: board, Games: report.Overall.Games})
+					}
+					continue
+				}
+			}
 			agents := make([]TelemetryAgent, testCase.Players)
 			for player := range agents {
 				agents[player] = incumbent()
@@ -184,22 +205,15 @@ func CompareCorpusFiltered(corpus Corpus, split string, filter CorpusFilter, pro
 			if err != nil {
 				return report, fmt.Errorf("case %s seat %d: %w", testCase.ID, seat+1, err)
 			}
-			focus := game.Player(seat + 1)
-			report.Overall.Add(result, focus)
-			if progress != nil {
-				progress(CorpusProgress{Board: fmt.Sprintf("%dx%d", testCase.State.Rows(), testCase.State.Cols()), Games: report.Overall.Games})
-			}
-			board := fmt.Sprintf("board=%dx%d", testCase.State.Rows(), testCase.State.Cols())
-			seatKey := "seat=" + fmt.Sprint(seat+1)
-			phase := "phase=" + testCase.Phase
-			keys := []string{board, "track=" + testCase.Track, seatKey, phase, board + "/" + seatKey, board + "/" + seatKey + "/" + phase}
-			for _, stratum := range testCase.Strata {
-				keys = append(keys, "stratum="+stratum)
+			keys := corpusBucketKeys(testCase, focus)
+			if filter.Journal != nil {
+				if err := filter.Journal.append(JournalEntry{Case: testCase.ID, Seat: focus, Keys: keys, Result: result}); err != nil {
+					return report, fmt.Errorf("case %s seat %d: journal: %w", testCase.ID, seat+1, err)
+				}
 			}
-			for _, key := range keys {
-				bucket := report.Buckets[key]
-				bucket.Add(result, focus)
-				report.Buckets[key] = bucket
+			report.add(result, focus, keys)
+			if progress != nil {
+				progress(CorpusProgress{Board: board, Games: report.Overall.Games})
 			}
 		}
 	}
@@ -210,9 +224,33 @@ func CompareCorpusFiltered(corpus Corpus, split string, filter CorpusFilter, pro
 	return report, nil
 }
 
+// corpusBucketKeys are the buckets a game of testCase with the contender in
+// seat counts in.
+func corpusBucketKeys(testCase CorpusCase, seat game.Player) []string {
+	board := fmt.Sprintf("board=%dx%d", testCase.State.Rows(), testCase.State.Cols())
+	seatKey := "seat=" + fmt.Sprint(seat)
+	phase := "phase=" + testCase.Phase
+	keys := []string{board, "track=" + testCase.Track, seatKey, phase, board + "/" + seatKey, board + "/" + seatKey + "/" + phase}
+	for _, stratum := range testCase.Strata {
+		keys = append(keys, "stratum="+stratum)
+	}
+	return keys
+}
+
+// add counts one game in Overall and in each of keys.
+func (r *CorpusReport) add(result GameResult, focus game.Player, keys []string) {
+	r.Overall.Add(result, focus)
+	for _, key := range keys {
+		bucket := r.Buckets[key]
+		bucket.Add(result, focus)
+		r.Buckets[key] = bucket
+	}
+}
+
 // CompareCorpusBoards runs deterministic board shards with bounded parallelism
-// and returns their exact additive aggregate.
-func CompareCorpusBoards(corpus Corpus, split, track string, boards []Board, parallel int, progress func(CorpusProgress), contender, incumbent func() TelemetryAgent) (CorpusReport, error) {
+// and returns their exact additive aggregate. filter's Rows and Cols are
+// replaced by each shard's board.
+func CompareCorpusBoards(corpus Corpus, split string, filter CorpusFilter, boards []Board, parallel int, progress func(CorpusProgress), contender, incumbent func() TelemetryAgent) (CorpusReport, error) {
 	if parallel < 1 {
 		parallel = 1
 	}
@@ -234,7 +272,9 @@ func CompareCorpusBoards(corpus Corpus, split, track string, boards []Board, par
 			defer wg.Done()
 			for index := range jobs {
 				b := boards[index]
-				r, err := CompareCorpusFiltered(corpus, split, CorpusFilter{Track: track, Rows: b.Rows, Cols: b.Cols}, shardProgress, contender, incumbent)
+				shard := filter
+				shard.Rows, shard.Cols = b.Rows, b.Cols
+				r, err := CompareCorpusFiltered(corpus, split, shard, shardProgress, contender, incumbent)
 				results <- item{index, r, err}
 			}
 		}()
@@ -292,6 +332,8 @@ func addReport(dst *Report, src Report) {
 		dst.CompletedTurnDepth = src.CompletedTurnDepth
 	}
 	dst.Latencies = append(dst.Latencies, src.Latencies...)
+	dst.DecisionNodes = append(dst.DecisionNodes, src.DecisionNodes...)
+	dst.DecisionDepths = append(dst.DecisionDepths, src.DecisionDepths...)
 	dst.Elapsed += src.Elapsed
 }
 
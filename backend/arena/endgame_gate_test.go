@@ -0,0 +1,74 @@
+package arena
+
+import (
+	"math/rand"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+// TestEndgameSolverConvertsProvenWins is the vs-ai2.63 gate: from sparse 1v1
+// endgames the solver proves won for the mover, the Options.Endgame search
+// must convert every one against the plain search at the same node budget.
+// The plain search playing the same side is logged for comparison.
+func TestEndgameSolverConvertsProvenWins(t *testing.T) {
+	const nodes = 100_000
+	positions := envInt(t, "VS_ENDGAME_POSITIONS", 8)
+	plain := TelemetryNodeBudget(nodes, false)
+	contenders := []TelemetryAgent{TelemetryNodeBudgetOptions(nodes, search.Options{Endgame: true}), plain}
+	var converted [2]int
+	tried := 0
+	for seed := int64(1); tried < positions && seed < 40*int64(positions); seed++ {
+		state, ok := provenWin(t, seed, nodes/2)
+		if !ok {
+			continue
+		}
+		tried++
+		snapshot := state.Snapshot()
+		mover := state.CurrentPlayer()
+		for i, contender := range contenders {
+			agents := []TelemetryAgent{plain, plain}
+			agents[mover-1] = contender
+			result, err := Play(Match{Rows: state.Rows(), Cols: state.Cols(), Initial: &snapshot, TelemetryAgents: agents})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Illegal != 0 || result.Stalled {
+				t.Fatalf("seed %d: illegal=%d stalled=%v", seed, result.Illegal, result.Stalled)
+			}
+			if result.Winner == mover {
+				converted[i]++
+			} else if i == 0 {
+				t.Errorf("seed %d: proven win for player %d lost to the plain search", seed, mover)
+			}
+		}
+	}
+	if tried < positions {
+		t.Fatalf("found %d proven wins, want %d", tried, positions)
+	}
+	t.Logf("proven wins converted: endgame %d/%d, plain %d/%d", converted[0], tried, converted[1], tried)
+}
+
+// provenWin plays seeded random actions on a 7x7 board until the position is
+// an endgame candidate, and keeps it when the solver proves a mover win within
+// limit nodes.
+func provenWin(t *testing.T, seed int64, limit uint64) (game.State, bool) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(seed))
+	state, err := game.New(7, 7, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for !state.GameOver() && !search.EndgameCandidate(state) {
+		actions := state.LegalActions()
+		if state, err = state.Apply(actions[rng.Intn(len(actions))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state.GameOver() {
+		return state, false
+	}
+	result, ok := search.ChooseNodeBudgetOptions(state, 2*limit, search.Options{Endgame: true})
+	return state, ok && result.SearchComplete && result.Score > 0
+}
@@ -0,0 +1,279 @@
+package arena
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"virusgame/engine"
+	"virusgame/game"
+	"virusgame/search"
+)
+
+// vs-ai2.78: engine registry. Comparing against the past meant the one frozen
+// copy in search/incumbent plus a constructor and a flag switch per tool, each
+// with its own spelling (cmd/arena -opponent, cmd/suite -engine, blundermine
+// -opponent). Every engine version and heuristic agent now registers in
+// package engine under a stable name, and every tool builds its agents from
+// the same spec string (engine.ParseSpec). The registry and the live choosers
+// live there, so the bot-hoster plays a spec without this package; arena adds
+// each engine's per-game agent with its search telemetry, and registers the
+// scripted and seeded baselines, which exist only here.
+
+// Engine is a built engine spec with the agent arena matches play.
+type Engine struct {
+	engine.Engine
+	// New returns the agent for one game. The seeded baselines (random,
+	// legacy, human) take seed unless their spec fixes one, and the live
+	// search engines seed their weighted opening-book draws with it; other
+	// engines ignore it.
+	New func(seed uint64) TelemetryAgent
+}
+
+// agentBuilder builds the per-game agent of a built spec.
+type agentBuilder func(spec engine.Spec, built engine.Engine) (func(seed uint64) TelemetryAgent, error)
+
+var (
+	agentsMu sync.RWMutex
+	agents   = map[string]agentBuilder{}
+)
+
+func registerAgent(name string, build agentBuilder) {
+	agentsMu.Lock()
+	defer agentsMu.Unlock()
+	if _, ok := agents[name]; ok {
+		panic(fmt.Sprintf("arena: agent %q registered twice", name))
+	}
+	agents[name] = build
+}
+
+// RegisterEngine adds an engine that only arena can build to the engine
+// registry (engine.Register): build supplies both its live chooser and its
+// per-game agent.
+func RegisterEngine(name, summary string, versions, keys []string, build func(engine.Spec) (Engine, error)) {
+	engine.Register(name, summary, versions, keys, func(spec engine.Spec) (engine.Engine, error) {
+		built, err := build(spec)
+		return built.Engine, err
+	})
+	registerAgent(name, func(spec engine.Spec, _ engine.Engine) (func(uint64) TelemetryAgent, error) {
+		built, err := build(spec)
+		return built.New, err
+	})
+}
+
+// BuildEngine parses and builds spec.
+func BuildEngine(text string) (Engine, error) {
+	spec, err := engine.ParseSpec(text)
+	if err != nil {
+		return Engine{}, err
+	}
+	return BuildEngineSpec(spec)
+}
+
+// BuildEngineSpec builds the engine spec names along with its arena agent.
+func BuildEngineSpec(spec engine.Spec) (Engine, error) {
+	built, err := spec.Build()
+	if err != nil {
+		return Engine{}, err
+	}
+	agentsMu.RLock()
+	build, ok := agents[spec.Name]
+	agentsMu.RUnlock()
+	if !ok {
+		return Engine{}, fmt.Errorf("engine %s: no arena agent", spec)
+	}
+	newAgent, err := build(spec, built)
+	if err != nil {
+		return Engine{}, fmt.Errorf("engine %s: %w", spec, err)
+	}
+	return Engine{Engine: built, New: newAgent}, nil
+}
+
+// searchAgent plays the live search engines; v1 is the frozen incumbent.
+func searchAgent(spec engine.Spec, built engine.Engine) (func(uint64) TelemetryAgent, error) {
+	if built.Options == nil {
+		return incumbentAgent(spec, built)
+	}
+	budget, err := spec.Budget()
+	if err != nil {
+		return nil, err
+	}
+	// Like seededEngine, each agent draws weighted book moves from a stream
+	// seeded by its own seed, so its games vary and a seed replays them.
+	gameOptions := func(seed uint64) search.Options {
+		opts := *built.Options
+		opts.Book = search.GameBook(seed)
+		return opts
+	}
+	switch {
+	case budget.Nodes > 0:
+		return func(seed uint64) TelemetryAgent { return TelemetryNodeBudgetOptions(budget.Nodes, gameOptions(seed)) }, nil
+	case budget.Depth > 0:
+		return func(seed uint64) TelemetryAgent {
+			opts := gameOptions(seed)
+			return telemetrySearch(func(state game.State) (search.Result, bool) {
+				return search.ChooseDepthOptions(context.Background(), state, budget.Depth, opts)
+			})
+		}, nil
+	}
+	limit := budget.Movetime
+	if limit == 0 {
+		limit = search.ProductionBudget
+	}
+	return func(seed uint64) TelemetryAgent {
+		opts := gameOptions(seed)
+		return telemetrySearch(func(state game.State) (search.Result, bool) {
+			ctx, cancel := context.WithTimeout(context.Background(), limit)
+			defer cancel()
+			return search.ChooseOptions(ctx, state, opts)
+		})
+	}, nil
+}
+
+// incumbentAgent plays the frozen v1 engine.
+func incumbentAgent(spec engine.Spec, built engine.Engine) (func(uint64) TelemetryAgent, error) {
+	budget, err := spec.Budget()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case budget.Nodes > 0:
+		return func(uint64) TelemetryAgent { return TelemetryNodeBudget(budget.Nodes, true) }, nil
+	case budget.Depth > 0:
+		return func(uint64) TelemetryAgent { return TelemetryFrozenTournament(budget.Depth) }, nil
+	case budget.Movetime > 0:
+		return chooserAgent(built, 0), nil
+	}
+	// incumbent.Choose applies its own frozen budget to a ctx without a
+	// deadline; see TelemetryFrozenProduction.
+	return func(uint64) TelemetryAgent { return TelemetryFrozenProduction() }, nil
+}
+
+// mctsAgent plays the Monte Carlo engine.
+func mctsAgent(spec engine.Spec, built engine.Engine) (func(uint64) TelemetryAgent, error) {
+	budget, err := spec.Budget()
+	if err != nil {
+		return nil, err
+	}
+	if budget.Nodes > 0 {
+		cfg, err := spec.MCTSConfig()
+		if err != nil {
+			return nil, err
+		}
+		return func(uint64) TelemetryAgent { return TelemetryMCTS(cfg, budget.Nodes) }, nil
+	}
+	limit := budget.Movetime
+	if limit == 0 {
+		limit = search.ProductionBudget
+	}
+	return chooserAgent(built, limit), nil
+}
+
+// chooserAgent plays a fresh chooser per game, within limit per decision when
+// limit is set.
+func chooserAgent(built engine.Engine, limit time.Duration) func(uint64) TelemetryAgent {
+	return func(uint64) TelemetryAgent {
+		choose := built.Chooser()
+		return telemetrySearch(func(state game.State) (search.Result, bool) {
+			ctx := context.Background()
+			if limit > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, limit)
+				defer cancel()
+			}
+			return choose(ctx, state, search.Clock{})
+		})
+	}
+}
+
+// seededEngine builds a baseline that draws from a seeded RNG.
+func seededEngine(agent func(seed uint64) Agent) func(engine.Spec) (Engine, error) {
+	return func(spec engine.Spec) (Engine, error) {
+		var fixed uint64
+		if text := spec.Get("seed"); text != "" {
+			var err error
+			if fixed, err = strconv.ParseUint(text, 10, 64); err != nil {
+				return Engine{}, fmt.Errorf("seed=%s: %w", text, err)
+			}
+		}
+		return Engine{
+			Engine: engine.Engine{
+				Chooser: func() engine.Chooser { return agentChooser(agent(max(fixed, 1))) },
+				Serial:  true,
+			},
+			New: func(seed uint64) TelemetryAgent {
+				if fixed != 0 {
+					seed = fixed
+				}
+				return Instrument(agent(seed))
+			},
+		}, nil
+	}
+}
+
+// scriptedEngine builds a deterministic heuristic agent.
+func scriptedEngine(agent Agent) func(engine.Spec) (Engine, error) {
+	return func(engine.Spec) (Engine, error) {
+		return Engine{
+			Engine: engine.Engine{Chooser: func() engine.Chooser { return agentChooser(agent) }},
+			New:    func(uint64) TelemetryAgent { return Instrument(agent) },
+		}, nil
+	}
+}
+
+func agentChooser(agent Agent) engine.Chooser {
+	return func(_ context.Context, state game.State, _ search.Clock) (search.Result, bool) {
+		action, ok := agent(state)
+		return search.Result{Action: action}, ok
+	}
+}
+
+// telemetrySearch reports a package search result as DecisionTelemetry.
+func telemetrySearch(choose func(game.State) (search.Result, bool)) TelemetryAgent {
+	return func(state game.State) (game.Action, DecisionTelemetry, bool) {
+		result, ok := choose(state)
+		legal, searched, neutrals, searchedNeutrals := rootCoverage(state, result.Depth)
+		return result.Action, DecisionTelemetry{
+			Nodes:              result.Nodes,
+			Evaluations:        result.Evaluations,
+			CompletedTurnDepth: completedTurns(state.MovesLeft(), result.Depth),
+			LegalRootActions:   legal, SearchedRootActions: searched,
+			LegalRootNeutrals: neutrals, SearchedRootNeutrals: searchedNeutrals,
+			Score: result.Score, Depth: result.Depth, Alternatives: result.Alternatives,
+		}, ok
+	}
+}
+
+// Strip drops an agent's telemetry, for callers that need a plain Agent.
+func Strip(agent TelemetryAgent) Agent {
+	return func(state game.State) (game.Action, bool) {
+		action, _, ok := agent(state)
+		return action, ok
+	}
+}
+
+func init() {
+	for _, name := range []string{"search", "production", "nnue"} {
+		registerAgent(name, searchAgent)
+	}
+	registerAgent("incumbent", incumbentAgent)
+	registerAgent("mcts", mctsAgent)
+	RegisterEngine("random", "uniformly random legal actions", nil, []string{"seed"}, seededEngine(Random))
+	RegisterEngine("legacy", "captures first, else seeded random", nil, []string{"seed"}, seededEngine(Legacy))
+	RegisterEngine("human", "samples a behaviour-cloned human policy net", nil, []string{"policy", "temp", "neutral", "seed"}, buildHuman)
+	for _, scripted := range []struct {
+		name, summary string
+		agent         Agent
+	}{
+		{"greedy", "best immediate outcome", Greedy},
+		{"base", "pushes at the nearest opponent base", BaseAttacker},
+		{"mobility", "starves the opponent's mobility", MobilityAttacker},
+		{"mobility-base", "mobility strangler with base pressure", MobilityBaseAttacker},
+		{"cutseeker", "hunts articulation cuts", CutSeeker},
+		{"ownerbot", "the owner proxy from the loss corpus", OwnerBot},
+	} {
+		RegisterEngine(scripted.name, scripted.summary, nil, nil, scriptedEngine(scripted.agent))
+	}
+}
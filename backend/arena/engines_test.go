@@ -0,0 +1,101 @@
+package arena
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"virusgame/engine"
+	"virusgame/game"
+	"virusgame/search"
+)
+
+func TestEnginesPlayLegalMoves(t *testing.T) {
+	opening, err := RandomLegalOpening(8, 8, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := game.FromSnapshot(opening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{
+		"search?nodes=200", "search:v1?nodes=200", "search?depth=1", "incumbent?depth=1", "nnue?nodes=200",
+		"mcts?nodes=50", "random?seed=3", "legacy", "greedy", "base", "mobility", "mobility-base", "cutseeker", "ownerbot",
+	} {
+		built, err := BuildEngine(text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		action, _, ok := built.New(1)(state)
+		if !ok {
+			t.Fatalf("%s: no action", text)
+		}
+		if _, err := state.Apply(action); err != nil {
+			t.Fatalf("%s: New played %+v: %v", text, action, err)
+		}
+		result, ok := built.Chooser()(context.Background(), state, search.Clock{})
+		if !ok {
+			t.Fatalf("%s: chooser found no action", text)
+		}
+		if _, err := state.Apply(result.Action); err != nil {
+			t.Fatalf("%s: Chooser played %+v: %v", text, result.Action, err)
+		}
+	}
+}
+
+func TestEngineSpecsMatchTheirConstructors(t *testing.T) {
+	opening, err := RandomLegalOpening(8, 8, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := game.FromSnapshot(opening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		spec   string
+		frozen bool
+	}{{"search?nodes=300", false}, {"incumbent?nodes=300", true}} {
+		built, err := BuildEngine(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotAction, got, _ := built.New(1)(state)
+		wantAction, want, _ := TelemetryNodeBudget(300, tt.frozen)(state)
+		if gotAction != wantAction || got.Nodes != want.Nodes || got.Score != want.Score {
+			t.Errorf("%s played %+v (%d nodes), TelemetryNodeBudget %+v (%d nodes)", tt.spec, gotAction, got.Nodes, wantAction, want.Nodes)
+		}
+	}
+}
+
+func TestEngineSerialAndBudgetDefaults(t *testing.T) {
+	for text, serial := range map[string]bool{
+		"search?nodes=100": false, "search?depth=2": false, "search": true, "search?movetime=50ms": true,
+		"incumbent?nodes=100": false, "incumbent": true, "mcts?nodes=10&reuse=0": false, "mcts?nodes=10": true,
+		"random": true, "legacy": true, "greedy": false, "ownerbot": false,
+	} {
+		built, err := BuildEngine(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if built.Serial != serial {
+			t.Errorf("%s Serial = %v, want %v", text, built.Serial, serial)
+		}
+	}
+	for text, want := range map[string]string{
+		"greedy":        "greedy",
+		"random?seed=2": "random?seed=2",
+	} {
+		spec, err := engine.ParseSpec(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := spec.WithNodes(700).String(); got != want {
+			t.Errorf("%s.WithNodes(700) = %s, want %s", text, got, want)
+		}
+	}
+	if usage := engine.Usage(); !strings.Contains(usage, "search:v2|v1?nodes") || !strings.Contains(usage, "ownerbot") {
+		t.Fatalf("usage lacks engines:\n%s", usage)
+	}
+}
@@ -53,8 +53,7 @@ var preSPSAVector = search.EvalParams{
 // turn — the en-prise gift the symptom is made of.
 func enPriseGiftsNearBase(t *testing.T, pre game.State, p search.EvalParams) (game.Pos, bool) {
 	t.Helper()
-	search.SetEvalParams(p)
-	res, ok := search.ChooseNodeBudget(pre, enPriseBudget)
+	res, ok := search.ChooseNodeBudgetOptions(pre, enPriseBudget, search.Options{Params: &p})
 	if !ok {
 		t.Fatal("bot produced no move")
 	}
@@ -92,28 +91,6 @@ func capturableAt(state game.State, pos game.Pos, victim, attacker game.Player)
 	return false
 }
 
-func enPriseAnchorPre(t *testing.T, turn int) game.State {
-	t.Helper()
-	fixture, err := os.Open("testdata/production-12x12-no-moves-" + enPriseAnchor + ".json")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer fixture.Close()
-	replay, _, err := DecodeReplay(fixture)
-	if err != nil {
-		t.Fatalf("decode: %v", err)
-	}
-	positions, err := ReplayPositions(replay)
-	if err != nil {
-		t.Fatalf("positions: %v", err)
-	}
-	pre, ok := positions[ReplayPoint{Turn: turn}]
-	if !ok || pre.CurrentPlayer() != 2 {
-		t.Fatalf("turn %d is not a bot-to-move position", turn)
-	}
-	return pre
-}
-
 // TestEnPriseGate is the standing regression gate. At the anchor blunder turn
 // (T32, where the recorded pre-SPSA bot played the en-prise (0,3)), the current
 // production eval must decline the near-base gift, and the pre-SPSA vector must
@@ -125,8 +102,7 @@ func TestEnPriseGate(t *testing.T) {
 	// reactivates when an eval ships that beats BOTH exploits — do not delete.
 	t.Skip("known en-prise weakness of the hand-tuned vector; see vs-ai2.52/vs-ai2.57 and PR #112")
 	const blunderTurn = 32
-	defer search.SetEvalParams(search.DefaultEvalParams())
-	pre := enPriseAnchorPre(t, blunderTurn)
+	pre := suitePosition(t, "enprise.epd", "enprise-5efcac1a-t32")
 
 	prodTgt, prodGift := enPriseGiftsNearBase(t, pre, search.DefaultEvalParams())
 	if prodGift {
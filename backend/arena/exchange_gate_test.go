@@ -9,7 +9,8 @@ import (
 )
 
 // vs-ai2.47 constructed exchange gate. Two 12x12 positions with the bot (P2) to
-// move at a deterministic node budget. It documents the STANDING exchange-ratio
+// move at a deterministic node budget, kept in suites/exchange.epd with the
+// construction notes. It documents the STANDING exchange-ratio
 // blindness: (a) the bot takes a negative capture that leaves >=2 of its own
 // normals capturable next turn (the 1-for-N), while (b) it correctly takes a
 // favorable capture that severs >=2 enemy cells for at most one exposed cell.
@@ -111,58 +112,6 @@ func classifyExchange(state game.State, action game.Action, bot game.Player) (ex
 	}, nil
 }
 
-func exchangeBoard() [][]game.Cell {
-	b := make([][]game.Cell, 12)
-	for r := range b {
-		b[r] = make([]game.Cell, 12)
-	}
-	return b
-}
-
-func putCells(b [][]game.Cell, owner game.Player, cells ...[2]int) {
-	for _, c := range cells {
-		b[c[0]][c[1]] = game.Cell{Owner: owner, Kind: game.Normal}
-	}
-}
-
-func exchangeSnapshot(b [][]game.Cell) game.Snapshot {
-	b[0][0] = game.Cell{Owner: 1, Kind: game.Base}
-	b[11][11] = game.Cell{Owner: 2, Kind: game.Base}
-	return game.Snapshot{
-		Rows: 12, Cols: 12, Board: b,
-		Bases:       []game.Pos{{Row: 0, Col: 0}, {Row: 11, Col: 11}},
-		Active:      []bool{true, true},
-		NeutralUsed: []bool{true, true},
-		Current:     2, MovesLeft: 3,
-	}
-}
-
-// scenarioNegativeExchange is the declinable 1-for-N (anchor mechanism). The bot
-// (P2) has a DISCONNECTED forward group (5,8),(5,9),(5,10) pinned under a P1 wall
-// (4,8),(4,9),(4,10). The sole bridge to the bot body is the P1 cell La=(6,8).
-// Capturing La reconnects the forward group — a big eval lure (+connected,
-// -disconnected, +captured normal) — but makes all three cells connected and
-// capturable by P1 next turn. A quiet placement leaves them disconnected and safe.
-func scenarioNegativeExchange() game.Snapshot {
-	b := exchangeBoard()
-	putCells(b, 1, [2]int{1, 1}, [2]int{2, 2}, [2]int{3, 3}, [2]int{3, 4}, [2]int{3, 5}, [2]int{3, 6}, [2]int{3, 7})
-	putCells(b, 1, [2]int{4, 8}, [2]int{4, 9}, [2]int{4, 10}, [2]int{5, 7}, [2]int{6, 8}) // wall + bridge La=(6,8)
-	putCells(b, 2, [2]int{10, 10}, [2]int{9, 9}, [2]int{8, 8}, [2]int{7, 8})              // spine touching La
-	putCells(b, 2, [2]int{5, 8}, [2]int{5, 9}, [2]int{5, 10})                             // disconnected forward group
-	return exchangeSnapshot(b)
-}
-
-// scenarioFavorableExchange is the 2-for-1 the fix must preserve. The P1
-// articulation cell C=(5,5) is the sole link to a 2-cell pocket (4,6),(5,7).
-// The bot reaches C from (6,6): capturing it severs both pocket cells while
-// exposing no bot normal — a clear gain that must stay taken before and after.
-func scenarioFavorableExchange() game.Snapshot {
-	b := exchangeBoard()
-	putCells(b, 1, [2]int{1, 1}, [2]int{2, 2}, [2]int{3, 3}, [2]int{4, 4}, [2]int{5, 5}, [2]int{4, 6}, [2]int{5, 7})
-	putCells(b, 2, [2]int{10, 10}, [2]int{9, 9}, [2]int{8, 8}, [2]int{7, 7}, [2]int{6, 6})
-	return exchangeSnapshot(b)
-}
-
 // TestExchangeGate asserts the CURRENT eval's behavior: (a) the bot TAKES the
 // negative capture (>=2 own cells left exposed), (b) the bot TAKES the favorable
 // 2-for-1. A future exchange-aware fix flips (a) to the declined behavior
@@ -176,10 +125,7 @@ func TestExchangeGate(t *testing.T) {
 	const bot = game.Player(2)
 
 	t.Run("negative_1_for_n_taken_before_fix", func(t *testing.T) {
-		state, err := game.FromSnapshot(scenarioNegativeExchange())
-		if err != nil {
-			t.Fatalf("snapshot invalid: %v", err)
-		}
+		state := suitePosition(t, "exchange.epd", "exchange-negative-1-for-n")
 		res, ok := search.ChooseNodeBudget(state, exchangeNodeBudget)
 		if !ok {
 			t.Fatal("bot produced no move")
@@ -197,10 +143,7 @@ func TestExchangeGate(t *testing.T) {
 	})
 
 	t.Run("favorable_2_for_1_taken", func(t *testing.T) {
-		state, err := game.FromSnapshot(scenarioFavorableExchange())
-		if err != nil {
-			t.Fatalf("snapshot invalid: %v", err)
-		}
+		state := suitePosition(t, "exchange.epd", "exchange-favorable-2-for-1")
 		res, ok := search.ChooseNodeBudget(state, exchangeNodeBudget)
 		if !ok {
 			t.Fatal("bot produced no move")
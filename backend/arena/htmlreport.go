@@ -0,0 +1,500 @@
+package arena
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"time"
+)
+
+// vs-ai2.77: HTML reports. Report.String and CorpusReport.String are dense one
+// liners and -json output is raw data, so every comparison ended with someone
+// plotting by hand. WriteHTMLReport turns the JSON reports of one or more runs
+// into a single static page: Wilson and Elo intervals per board×seat×phase
+// bucket and per marginal, latency percentile and per-decision nodes/depth
+// charts, and a bucket-by-bucket comparison of every run against the first.
+// Styles and charts (inline SVG) are embedded, so the file works offline and
+// can be attached to a PR as is.
+
+// ReportRun is one named report on the page.
+type ReportRun struct {
+	Name   string
+	Report CorpusReport
+}
+
+// DecodeReportRuns reads every JSON value in reader: a CorpusReport as
+// cmd/arena -json prints it (one per opponent), or a bare Report. The runs are
+// called name, or name#1, name#2, ... when the stream holds several.
+func DecodeReportRuns(name string, reader io.Reader) ([]ReportRun, error) {
+	decoder := json.NewDecoder(reader)
+	var runs []ReportRun
+	for {
+		var raw map[string]json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		encoded, _ := json.Marshal(raw)
+		var report CorpusReport
+		switch {
+		case raw["Overall"] != nil:
+			if err := json.Unmarshal(encoded, &report); err != nil {
+				return nil, fmt.Errorf("%s: corpus report: %w", name, err)
+			}
+		case raw["Games"] != nil:
+			if err := json.Unmarshal(encoded, &report.Overall); err != nil {
+				return nil, fmt.Errorf("%s: report: %w", name, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s: value %d is not an arena report", name, len(runs)+1)
+		}
+		if report.Buckets == nil {
+			report.Buckets = map[string]Report{}
+		}
+		runs = append(runs, ReportRun{Name: name, Report: report})
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%s: no reports", name)
+	}
+	if len(runs) > 1 {
+		for i := range runs {
+			runs[i].Name = fmt.Sprintf("%s#%d", name, i+1)
+		}
+	}
+	return runs, nil
+}
+
+// WriteHTMLReport writes runs as one self-contained HTML page. With two or
+// more runs the page compares each against the first.
+func WriteHTMLReport(w io.Writer, title string, runs []ReportRun) error {
+	if len(runs) == 0 {
+		return fmt.Errorf("no runs to report")
+	}
+	page := htmlPage{Title: title, Runs: runs}
+	for i, run := range runs {
+		var section htmlRunSection
+		section.Name, section.Color = run.Name, htmlColor(i)
+		for _, key := range run.Report.SortedBuckets() {
+			row := htmlBucketRow{Key: key, Report: run.Report.Buckets[key]}
+			if parts := strings.Split(key, "/"); len(parts) == 3 {
+				row.Board, row.Seat, row.Phase = bucketValue(parts[0]), bucketValue(parts[1]), bucketValue(parts[2])
+				section.Joint = append(section.Joint, row)
+			} else if len(parts) == 1 {
+				section.Marginals = append(section.Marginals, row)
+			}
+		}
+		page.Sections = append(page.Sections, section)
+	}
+	for _, run := range runs[1:] {
+		page.Comparisons = append(page.Comparisons, compareRuns(runs[0], run))
+	}
+	page.Latency, page.Nodes, page.Depth = latencyChart(runs), nodesChart(runs), depthChart(runs)
+	return htmlReportTemplate.Execute(w, page)
+}
+
+type htmlPage struct {
+	Title                 string
+	Runs                  []ReportRun
+	Sections              []htmlRunSection
+	Comparisons           []htmlComparison
+	Latency, Nodes, Depth template.HTML
+}
+
+type htmlRunSection struct {
+	Name, Color      string
+	Joint, Marginals []htmlBucketRow
+}
+
+type htmlBucketRow struct {
+	Key                string
+	Board, Seat, Phase string
+	Report             Report
+}
+
+type htmlComparison struct {
+	Base, Other string
+	Rows        []htmlComparisonRow
+}
+
+type htmlComparisonRow struct {
+	Key  string
+	A, B Report
+}
+
+// bucketValue strips a bucket key's "name=" prefix.
+func bucketValue(part string) string {
+	_, value, _ := strings.Cut(part, "=")
+	return value
+}
+
+// compareRuns lines up other's overall result and buckets against base's.
+func compareRuns(base, other ReportRun) htmlComparison {
+	comparison := htmlComparison{Base: base.Name, Other: other.Name}
+	comparison.Rows = append(comparison.Rows, htmlComparisonRow{Key: "overall", A: base.Report.Overall, B: other.Report.Overall})
+	keys := map[string]bool{}
+	for key := range base.Report.Buckets {
+		keys[key] = true
+	}
+	for key := range other.Report.Buckets {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		comparison.Rows = append(comparison.Rows, htmlComparisonRow{Key: key, A: base.Report.Buckets[key], B: other.Report.Buckets[key]})
+	}
+	return comparison
+}
+
+var htmlPalette = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#17becf"}
+
+func htmlColor(i int) string { return htmlPalette[i%len(htmlPalette)] }
+
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// reportPercentiles are the latency chart's x axis.
+var reportPercentiles = []int{10, 25, 50, 75, 90, 95, 99, 100}
+
+func latencyChart(runs []ReportRun) template.HTML {
+	labels := make([]string, len(reportPercentiles))
+	for i, p := range reportPercentiles {
+		labels[i] = fmt.Sprintf("p%d", p)
+	}
+	labels[len(labels)-1] = "max"
+	var series []chartSeries
+	for i, run := range runs {
+		s := chartSeries{Name: run.Name, Color: htmlColor(i)}
+		if len(run.Report.Overall.Latencies) > 0 {
+			for _, p := range reportPercentiles {
+				s.Values = append(s.Values, float64(run.Report.Overall.Percentile(p))/float64(time.Millisecond))
+			}
+		}
+		series = append(series, s)
+	}
+	return svgLineChart("Decision latency by percentile", "ms", labels, series)
+}
+
+// nodesChart is the share of decisions per power-of-two node count.
+func nodesChart(runs []ReportRun) template.HTML {
+	low, high := 64, -1
+	for _, run := range runs {
+		for _, nodes := range run.Report.Overall.DecisionNodes {
+			low, high = min(low, bits.Len64(nodes)), max(high, bits.Len64(nodes))
+		}
+	}
+	var labels []string
+	for bin := low; bin <= high; bin++ {
+		if bin == 0 {
+			labels = append(labels, "0")
+		} else {
+			labels = append(labels, compactCount(uint64(1)<<(bin-1)))
+		}
+	}
+	var series []chartSeries
+	for i, run := range runs {
+		s := chartSeries{Name: run.Name, Color: htmlColor(i)}
+		if samples := run.Report.Overall.DecisionNodes; len(samples) > 0 {
+			s.Values = make([]float64, len(labels))
+			for _, nodes := range samples {
+				s.Values[bits.Len64(nodes)-low] += 100 / float64(len(samples))
+			}
+		}
+		series = append(series, s)
+	}
+	return svgLineChart("Nodes per decision (bin starts at the label, doubles)", "% of decisions", labels, series)
+}
+
+// depthChart is the share of decisions per completed turn depth.
+func depthChart(runs []ReportRun) template.HTML {
+	high := -1
+	for _, run := range runs {
+		for _, depth := range run.Report.Overall.DecisionDepths {
+			high = max(high, depth)
+		}
+	}
+	var labels []string
+	for depth := 0; depth <= high; depth++ {
+		labels = append(labels, fmt.Sprint(depth))
+	}
+	var series []chartSeries
+	for i, run := range runs {
+		s := chartSeries{Name: run.Name, Color: htmlColor(i)}
+		if samples := run.Report.Overall.DecisionDepths; len(samples) > 0 {
+			s.Values = make([]float64, len(labels))
+			for _, depth := range samples {
+				s.Values[max(depth, 0)] += 100 / float64(len(samples))
+			}
+		}
+		series = append(series, s)
+	}
+	return svgLineChart("Completed turn depth per decision", "% of decisions", labels, series)
+}
+
+// compactCount prints 1536 as 1.5k and 2097152 as 2.1M.
+func compactCount(n uint64) string {
+	switch {
+	case n >= 1_000_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e6), ".0") + "M"
+	case n >= 1000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e3), ".0") + "k"
+	}
+	return fmt.Sprint(n)
+}
+
+// svgLineChart draws every series with values as a polyline over the
+// categorical x axis labels, with a legend. Series without values are listed
+// as having no data.
+func svgLineChart(title, unit string, labels []string, series []chartSeries) template.HTML {
+	const width, height, left, right, top, bottom = 720, 280, 56, 16, 28, 40
+	var out bytes.Buffer
+	top0 := 0.0
+	for _, s := range series {
+		for _, v := range s.Values {
+			top0 = math.Max(top0, v)
+		}
+	}
+	fmt.Fprintf(&out, `<figure><figcaption>%s</figcaption>`, template.HTMLEscapeString(title))
+	if top0 == 0 || len(labels) == 0 {
+		out.WriteString(`<p class="muted">No per-decision data in these reports.</p></figure>`)
+		return template.HTML(out.String())
+	}
+	top0 = niceCeiling(top0)
+	plotW, plotH := float64(width-left-right), float64(height-top-bottom)
+	x := func(i int) float64 {
+		if len(labels) == 1 {
+			return left + plotW/2
+		}
+		return left + plotW*float64(i)/float64(len(labels)-1)
+	}
+	y := func(v float64) float64 { return top + plotH*(1-v/top0) }
+	fmt.Fprintf(&out, `<svg viewBox="0 0 %d %d" width="%d" height="%d" role="img">`, width, height, width, height)
+	for tick := 0; tick <= 4; tick++ {
+		v := top0 * float64(tick) / 4
+		fmt.Fprintf(&out, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" class="grid"/><text x="%d" y="%.1f" class="ytick">%s</text>`,
+			left, width-right, y(v), y(v), left-6, y(v)+4, strings.TrimSuffix(fmt.Sprintf("%.3g", v), ".0"))
+	}
+	step := max(1, len(labels)/16)
+	for i, label := range labels {
+		if i%step == 0 || i == len(labels)-1 {
+			fmt.Fprintf(&out, `<text x="%.1f" y="%d" class="xtick">%s</text>`, x(i), height-bottom+16, template.HTMLEscapeString(label))
+		}
+	}
+	fmt.Fprintf(&out, `<text x="12" y="%d" class="unit" transform="rotate(-90 12 %d)">%s</text>`, top+int(plotH)/2, top+int(plotH)/2, template.HTMLEscapeString(unit))
+	for _, s := range series {
+		if len(s.Values) == 0 {
+			continue
+		}
+		points := make([]string, len(s.Values))
+		for i, v := range s.Values {
+			points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(v))
+		}
+		fmt.Fprintf(&out, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), s.Color)
+		for i, v := range s.Values {
+			fmt.Fprintf(&out, `<circle cx="%.1f" cy="%.1f" r="2.5" fill="%s"><title>%s %s: %.3g</title></circle>`,
+				x(i), y(v), s.Color, template.HTMLEscapeString(s.Name), template.HTMLEscapeString(labels[i]), v)
+		}
+	}
+	out.WriteString(`</svg><ul class="legend">`)
+	for _, s := range series {
+		note := ""
+		if len(s.Values) == 0 {
+			note = " (no data)"
+		}
+		fmt.Fprintf(&out, `<li><span style="background:%s"></span>%s%s</li>`, s.Color, template.HTMLEscapeString(s.Name), note)
+	}
+	out.WriteString(`</ul></figure>`)
+	return template.HTML(out.String())
+}
+
+// niceCeiling rounds v up to 1, 2 or 5 times a power of ten.
+func niceCeiling(v float64) float64 {
+	scale := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if v <= step*scale {
+			return step * scale
+		}
+	}
+	return 10 * scale
+}
+
+// wilsonBar draws the Wilson 95% interval of a win rate on a 0-100% bar.
+func wilsonBar(r Report) template.HTML {
+	if r.Games == 0 {
+		return ""
+	}
+	interval := Wilson95(r.Wins, r.Games)
+	return template.HTML(fmt.Sprintf(
+		`<svg width="120" height="12" class="bar"><rect x="0" y="4" width="120" height="4" class="track"/><rect x="%.1f" y="2" width="%.1f" height="8" class="ci"/><line x1="%.1f" x2="%.1f" y1="0" y2="12" class="point"/><line x1="60" x2="60" y1="0" y2="12" class="even"/></svg>`,
+		1.2*math.Max(interval.Low, 0), math.Max(1.2*(interval.High-interval.Low), 1), 1.2*r.WinRate(), 1.2*r.WinRate()))
+}
+
+func formatElo(elo float64) string {
+	switch {
+	case math.IsInf(elo, 1):
+		return "+∞"
+	case math.IsInf(elo, -1):
+		return "−∞"
+	case math.Abs(elo) < 0.5:
+		return "0"
+	}
+	return fmt.Sprintf("%+.0f", elo)
+}
+
+var htmlReportFuncs = template.FuncMap{
+	"winRate": func(r Report) string { return fmt.Sprintf("%.1f%%", r.WinRate()) },
+	"wilson": func(r Report) string {
+		interval := Wilson95(r.Wins, r.Games)
+		return fmt.Sprintf("%.1f–%.1f%%", math.Max(interval.Low, 0), math.Min(interval.High, 100))
+	},
+	"wilsonBar": wilsonBar,
+	"elo": func(r Report) string {
+		estimate := r.Elo()
+		return fmt.Sprintf("%s [%s, %s]", formatElo(estimate.Elo), formatElo(estimate.Low), formatElo(estimate.High))
+	},
+	"latency": func(r Report, p int) string {
+		if len(r.Latencies) == 0 {
+			return "–"
+		}
+		return r.Percentile(p).Round(10 * time.Microsecond).String()
+	},
+	"nodesPerDecision": func(r Report) string {
+		if r.Decisions == 0 {
+			return "–"
+		}
+		return compactCount(r.Nodes / uint64(r.Decisions))
+	},
+	"winDelta": func(a, b Report) template.HTML {
+		if a.Games == 0 || b.Games == 0 {
+			return "–"
+		}
+		return signedCell(b.WinRate()-a.WinRate(), "%+.1f", separated(a, b))
+	},
+	"eloDelta": func(a, b Report) template.HTML {
+		if a.Games == 0 || b.Games == 0 {
+			return "–"
+		}
+		delta := b.Elo().Elo - a.Elo().Elo
+		if math.IsInf(delta, 0) || math.IsNaN(delta) {
+			return "–"
+		}
+		return signedCell(delta, "%+.0f", false)
+	},
+	"games": func(r Report) string {
+		if r.Games == 0 {
+			return "–"
+		}
+		return fmt.Sprint(r.Games)
+	},
+}
+
+// separated reports whether the Wilson intervals of a and b do not overlap.
+func separated(a, b Report) bool {
+	ia, ib := Wilson95(a.Wins, a.Games), Wilson95(b.Wins, b.Games)
+	return ia.High < ib.Low || ib.High < ia.Low
+}
+
+// signedCell colours a delta by sign and marks a significant one bold.
+func signedCell(delta float64, format string, strong bool) template.HTML {
+	class := "flat"
+	switch {
+	case delta > 0:
+		class = "up"
+	case delta < 0:
+		class = "down"
+	}
+	text := template.HTMLEscapeString(fmt.Sprintf(format, delta))
+	if strong {
+		text = "<strong>" + text + "</strong>"
+	}
+	return template.HTML(fmt.Sprintf(`<span class="%s">%s</span>`, class, text))
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(htmlReportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.6em; } h2 { margin-top: 2em; border-bottom: 1px solid #ccc; } h3 { margin-top: 1.5em; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { padding: 2px 8px; text-align: right; border-bottom: 1px solid #eee; white-space: nowrap; }
+th { background: #f4f4f4; } td.key, th.key { text-align: left; }
+.muted { color: #888; } .up { color: #2a7a2a; } .down { color: #b22; } .flat { color: #888; }
+.swatch { display: inline-block; width: .8em; height: .8em; margin-right: .4em; }
+figure { margin: 1em 0; } figcaption { font-weight: 600; }
+svg .grid { stroke: #eee; } svg .ytick { font-size: 11px; text-anchor: end; fill: #666; }
+svg .xtick { font-size: 11px; text-anchor: middle; fill: #666; } svg .unit { font-size: 11px; text-anchor: middle; fill: #666; }
+svg.bar .track { fill: #eee; } svg.bar .ci { fill: #9ec5e8; } svg.bar .point { stroke: #1f4e79; stroke-width: 2; } svg.bar .even { stroke: #999; stroke-dasharray: 2 2; }
+.legend { list-style: none; padding: 0; display: flex; flex-wrap: wrap; gap: 1em; }
+.legend span { display: inline-block; width: 1em; height: .3em; margin-right: .4em; vertical-align: middle; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">Win rate counts draws as non-wins; Wilson intervals are 95% on wins. Elo counts draws as half and treats games as independent; corpus checkpoints from one trajectory are correlated, so read intervals as descriptive.</p>
+
+<h2>Runs</h2>
+<table>
+<tr><th class="key">run</th><th>split</th><th>games</th><th>W</th><th>D</th><th>L</th><th>win</th><th>Wilson 95%</th><th></th><th>Elo [95%]</th><th>illegal</th><th>maxed</th><th>stalled</th><th>decisions</th><th>nodes/dec</th><th>max depth</th><th>p50</th><th>p95</th><th>max</th></tr>
+{{range $i, $s := .Sections}}{{with index $.Runs $i}}{{$r := .Report.Overall}}
+<tr><td class="key"><span class="swatch" style="background:{{$s.Color}}"></span>{{.Name}}</td><td>{{.Report.Split}}</td><td>{{$r.Games}}</td><td>{{$r.Wins}}</td><td>{{$r.Draws}}</td><td>{{$r.Losses}}</td><td>{{winRate $r}}</td><td>{{wilson $r}}</td><td>{{wilsonBar $r}}</td><td>{{elo $r}}</td><td>{{$r.Illegal}}</td><td>{{$r.Maxed}}</td><td>{{$r.Stalled}}</td><td>{{$r.Decisions}}</td><td>{{nodesPerDecision $r}}</td><td>{{$r.CompletedTurnDepth}}</td><td>{{latency $r 50}}</td><td>{{latency $r 95}}</td><td>{{latency $r 100}}</td></tr>
+{{end}}{{end}}
+</table>
+
+<h2>Search behaviour</h2>
+{{.Latency}}
+{{.Nodes}}
+{{.Depth}}
+
+{{range .Comparisons}}
+<h2>Comparison: {{.Other}} against {{.Base}}</h2>
+<p class="muted">Δ is {{.Other}} minus {{.Base}}. A bold win-rate Δ has non-overlapping Wilson intervals.</p>
+<table>
+<tr><th class="key">bucket</th><th>games</th><th>games</th><th>win</th><th>win</th><th>Δ win pts</th><th>Elo</th><th>Elo</th><th>Δ Elo</th><th>p95</th><th>p95</th></tr>
+<tr><th></th><th>{{.Base}}</th><th>{{.Other}}</th><th>{{.Base}}</th><th>{{.Other}}</th><th></th><th>{{.Base}}</th><th>{{.Other}}</th><th></th><th>{{.Base}}</th><th>{{.Other}}</th></tr>
+{{range .Rows}}
+<tr><td class="key">{{.Key}}</td><td>{{games .A}}</td><td>{{games .B}}</td><td>{{winRate .A}}</td><td>{{winRate .B}}</td><td>{{winDelta .A .B}}</td><td>{{elo .A}}</td><td>{{elo .B}}</td><td>{{eloDelta .A .B}}</td><td>{{latency .A 95}}</td><td>{{latency .B 95}}</td></tr>
+{{end}}
+</table>
+{{end}}
+
+{{range .Sections}}
+<h2><span class="swatch" style="background:{{.Color}}"></span>{{.Name}}</h2>
+{{if .Joint}}
+<h3>Board × seat × phase</h3>
+<table>
+<tr><th class="key">board</th><th class="key">seat</th><th class="key">phase</th><th>games</th><th>W</th><th>D</th><th>L</th><th>win</th><th>Wilson 95%</th><th></th><th>Elo [95%]</th><th>nodes/dec</th><th>p50</th><th>p95</th></tr>
+{{range .Joint}}{{$r := .Report}}
+<tr><td class="key">{{.Board}}</td><td class="key">{{.Seat}}</td><td class="key">{{.Phase}}</td><td>{{$r.Games}}</td><td>{{$r.Wins}}</td><td>{{$r.Draws}}</td><td>{{$r.Losses}}</td><td>{{winRate $r}}</td><td>{{wilson $r}}</td><td>{{wilsonBar $r}}</td><td>{{elo $r}}</td><td>{{nodesPerDecision $r}}</td><td>{{latency $r 50}}</td><td>{{latency $r 95}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{if .Marginals}}
+<h3>Marginals</h3>
+<table>
+<tr><th class="key">bucket</th><th>games</th><th>W</th><th>D</th><th>L</th><th>win</th><th>Wilson 95%</th><th></th><th>Elo [95%]</th><th>nodes/dec</th><th>p50</th><th>p95</th></tr>
+{{range .Marginals}}{{$r := .Report}}
+<tr><td class="key">{{.Key}}</td><td>{{$r.Games}}</td><td>{{$r.Wins}}</td><td>{{$r.Draws}}</td><td>{{$r.Losses}}</td><td>{{winRate $r}}</td><td>{{wilson $r}}</td><td>{{wilsonBar $r}}</td><td>{{elo $r}}</td><td>{{nodesPerDecision $r}}</td><td>{{latency $r 50}}</td><td>{{latency $r 95}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{if not (or .Joint .Marginals)}}<p class="muted">No buckets: this run reported only overall results.</p>{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,99 @@
+package arena
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeReportRunsReadsCorpusAndBareReports(t *testing.T) {
+	var stream bytes.Buffer
+	encoder := json.NewEncoder(&stream)
+	corpus := CorpusReport{Split: "train", Overall: Report{Games: 2, Wins: 1, Losses: 1}, Buckets: map[string]Report{"seat=1": {Games: 1, Wins: 1}}}
+	if err := encoder.Encode(corpus); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode(Report{Games: 3, Draws: 3}); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := DecodeReportRuns("base", &stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Name != "base#1" || runs[1].Name != "base#2" {
+		t.Fatalf("runs %+v", runs)
+	}
+	if runs[0].Report.Buckets["seat=1"].Wins != 1 || runs[1].Report.Overall.Draws != 3 || runs[1].Report.Buckets == nil {
+		t.Fatalf("decoded %+v", runs)
+	}
+	if _, err := DecodeReportRuns("bad", strings.NewReader(`{"ratings": []}`)); err == nil {
+		t.Fatal("non-report JSON decoded")
+	}
+}
+
+func TestHTMLReportIsSelfContainedAndCompares(t *testing.T) {
+	corpus, split := journalCorpus(t)
+	factory := func() TelemetryAgent { return TelemetryNodeBudget(300, false) }
+	greedy := func() TelemetryAgent { return Instrument(Greedy) }
+	base, err := CompareCorpus(corpus, split, greedy, greedy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searched, err := CompareCorpus(corpus, split, factory, greedy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Round-trip through JSON as cmd/arena -json output would.
+	var runs []ReportRun
+	for _, named := range []struct {
+		name   string
+		report CorpusReport
+	}{{"greedy", base}, {"search<300>", searched}} {
+		encoded, err := json.Marshal(named.report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeReportRuns(named.name, bytes.NewReader(encoded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, decoded...)
+	}
+	var page bytes.Buffer
+	if err := WriteHTMLReport(&page, "test", runs); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	for _, want := range []string{
+		"<svg", "Decision latency by percentile", "Nodes per decision", "Comparison: search&lt;300&gt; against greedy",
+		"Board × seat × phase", "Marginals", "<polyline",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(html, "search<300>") {
+		t.Error("run name not escaped")
+	}
+	for _, external := range []string{"src=", "href=", "http://", "https://", "@import"} {
+		if strings.Contains(html, external) {
+			t.Errorf("page references an external asset: %q", external)
+		}
+	}
+	if samples := len(searched.Overall.Latencies); samples == 0 || len(searched.Overall.DecisionNodes) != samples || len(searched.Overall.DecisionDepths) != samples {
+		t.Fatalf("per-decision samples %d nodes, %d depths for %d latencies",
+			len(searched.Overall.DecisionNodes), len(searched.Overall.DecisionDepths), samples)
+	}
+}
+
+func TestReportEloCountsDrawsHalf(t *testing.T) {
+	even := Report{Games: 4, Wins: 1, Draws: 2, Losses: 1}.Elo()
+	if even.Elo != 0 || even.Low >= 0 || even.High <= 0 || even.LOS != 0.5 {
+		t.Fatalf("even record %+v", even)
+	}
+	ahead := Report{Games: 10, Wins: 7, Draws: 2, Losses: 1}.Elo()
+	if ahead.Elo <= 0 || ahead.LOS <= 0.5 {
+		t.Fatalf("winning record %+v", ahead)
+	}
+}
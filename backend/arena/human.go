@@ -0,0 +1,283 @@
+package arena
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"virusgame/engine"
+	"virusgame/game"
+	"virusgame/search"
+	"virusgame/search/nnueweights"
+)
+
+// vs-ai2.79 behaviour cloning. OwnerBot imitates one strong human by hand; the
+// games table holds thousands of real human decisions. The pipeline:
+//
+//  1. HumanDecisions pulls every (position, action) a human seat played out of
+//     a stored game (StoredGame.Replay converts a games-table row or
+//     /last_games entry; replay fixtures work as they are).
+//  2. nnuegen -human writes them as policy-labelled records, and nnue-train
+//     -policy fits the small patch policy net (nnueweights.PolicyNet) to them.
+//  3. HumanLike samples that net's distribution instead of searching, so it
+//     plays the moves humans play, mistakes included.
+
+// StoredGame is one row of the server's games table (storage.go) in the shape
+// /last_games serves it; package storedgames reads them from the database.
+type StoredGame struct {
+	ID          string       `json:"id"`
+	Player1     string       `json:"player1_name"`
+	Player2     string       `json:"player2_name"`
+	Player3     string       `json:"player3_name"`
+	Player4     string       `json:"player4_name"`
+	Termination string       `json:"termination"`
+	Rows        int          `json:"rows"`
+	Cols        int          `json:"cols"`
+	Result      int          `json:"result"`
+	PGN         []StoredTurn `json:"pgn_content"`
+}
+
+// StoredTurn is one turn of a stored game's PGN (storage.go PGNTurn).
+type StoredTurn struct {
+	Turn   int          `json:"turn"`
+	Player game.Player  `json:"player"`
+	Moves  []StoredMove `json:"moves"`
+}
+
+// StoredMove is one recorded action: "place" and "attack" are moves,
+// "neutral" carries its two cells.
+type StoredMove struct {
+	Type  string     `json:"type"`
+	Row   int        `json:"row"`
+	Col   int        `json:"col"`
+	Cells []game.Pos `json:"cells"`
+}
+
+// Replay replays a stored 1v1 game through the authoritative rules and returns
+// its fixture form. Moves recorded after the game ended are counted as
+// omitted; an illegal one is an error.
+func (g StoredGame) Replay() (Replay, error) {
+	if g.Player3 != "" || g.Player4 != "" {
+		return Replay{}, fmt.Errorf("game %s: not a 1v1 game", g.ID)
+	}
+	replay := Replay{SourceID: g.ID, Players: [2]string{g.Player1, g.Player2}, Rows: g.Rows, Cols: g.Cols, Winner: game.Player(g.Result), Termination: g.Termination, ObservedTurns: len(g.PGN)}
+	state, err := game.New(g.Rows, g.Cols, 2)
+	if err != nil {
+		return Replay{}, err
+	}
+	for _, sourceTurn := range g.PGN {
+		turn := ReplayTurn{Number: sourceTurn.Turn, Player: sourceTurn.Player}
+		for _, sourceMove := range sourceTurn.Moves {
+			if state.GameOver() {
+				replay.OmittedMoves++
+				continue
+			}
+			move := ReplayMove{Row: sourceMove.Row, Col: sourceMove.Col}
+			switch sourceMove.Type {
+			case "place", "attack":
+				move.Kind = "move"
+			case "neutral":
+				move.Kind, move.Neutrals = "neutral", sourceMove.Cells
+			default:
+				return Replay{}, fmt.Errorf("game %s: unknown move type %q", g.ID, sourceMove.Type)
+			}
+			action, err := move.action()
+			if err != nil {
+				return Replay{}, fmt.Errorf("game %s turn %d: %w", g.ID, sourceTurn.Turn, err)
+			}
+			if state, err = state.Apply(action); err != nil {
+				return Replay{}, fmt.Errorf("game %s turn %d: %w", g.ID, sourceTurn.Turn, err)
+			}
+			turn.Actions = append(turn.Actions, move)
+		}
+		if len(turn.Actions) != 0 {
+			turn.Number = len(replay.Turns) + 1
+			replay.Turns = append(replay.Turns, turn)
+		}
+	}
+	return replay, nil
+}
+
+// IsBotName reports whether a stored player name is one the server gives its
+// bots: "Bot 1234", or "Canary Bot 1234" with a hoster's name prefix.
+func IsBotName(name string) bool {
+	fields := strings.Fields(name)
+	if len(fields) < 2 || fields[len(fields)-2] != "Bot" {
+		return false
+	}
+	for _, r := range fields[len(fields)-1] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// HumanDecision is one action a human played and the position it was played
+// from.
+type HumanDecision struct {
+	Game   string
+	Player string
+	State  game.State
+	Action game.Action
+}
+
+// HumanDecisions returns every action of the replay's human seats in play
+// order; seats with a bot name (IsBotName) are skipped.
+func HumanDecisions(replay Replay) ([]HumanDecision, error) {
+	positions, err := ReplayPositions(replay)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", replay.SourceID, err)
+	}
+	var decisions []HumanDecision
+	for _, turn := range replay.Turns {
+		name := replay.Players[turn.Player-1]
+		if IsBotName(name) {
+			continue
+		}
+		for index, move := range turn.Actions {
+			action, err := move.action()
+			if err != nil {
+				return nil, fmt.Errorf("%s turn %d: %w", replay.SourceID, turn.Number, err)
+			}
+			decisions = append(decisions, HumanDecision{
+				Game: replay.SourceID, Player: name,
+				State:  positions[ReplayPoint{Turn: turn.Number, AfterActions: index}],
+				Action: action,
+			})
+		}
+	}
+	return decisions, nil
+}
+
+// DefaultHumanNeutralRate is how often HumanLike opens a turn with neutrals
+// when it may. The policy net rates moves and neutral cells with separate
+// heads, so it cannot say which kind a human picks; this is the rate in the
+// replay fixtures under testdata (1 placement in 329 human turns that allowed
+// one). nnuegen -human prints the rate of the data it extracts.
+const DefaultHumanNeutralRate = 0.003
+
+// HumanConfig configures HumanLike.
+type HumanConfig struct {
+	// Policy is the behaviour-cloned net (nnue-train -policy on nnuegen
+	// -human data).
+	Policy *nnueweights.PolicyNet
+	// Temperature divides the net's logits before sampling: 1 plays its
+	// distribution, lower plays closer to its top choice, 0 always plays it.
+	Temperature float64
+	// NeutralRate is the chance of placing neutrals when the turn allows it.
+	NeutralRate float64
+	// Seed seeds the sampling; 0 reads as 1.
+	Seed uint64
+}
+
+// HumanLike is a human-like agent: it samples moves from cfg.Policy's
+// distribution rather than searching. Neutrals are drawn one cell at a time
+// from the neutral head. It carries an RNG, so give each game its own.
+func HumanLike(cfg HumanConfig) Agent {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = 1
+	}
+	random := func() float64 {
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		return float64(seed>>11) / (1 << 53)
+	}
+	// sample picks an index of logits, softmaxed at the configured temperature.
+	sample := func(logits []float64) int {
+		best := 0
+		for i, logit := range logits {
+			if logit > logits[best] {
+				best = i
+			}
+		}
+		if cfg.Temperature <= 0 {
+			return best
+		}
+		weights := make([]float64, len(logits))
+		total := 0.0
+		for i, logit := range logits {
+			weights[i] = math.Exp((logit - logits[best]) / cfg.Temperature)
+			total += weights[i]
+		}
+		draw := random() * total
+		for i, weight := range weights {
+			if draw -= weight; draw < 0 {
+				return i
+			}
+		}
+		return best
+	}
+	return func(state game.State) (game.Action, bool) {
+		actions := state.LegalActions()
+		if len(actions) == 0 {
+			return game.Action{}, false
+		}
+		var moves []game.Action
+		neutrals := false
+		for _, action := range actions {
+			if action.Kind == game.Move {
+				moves = append(moves, action)
+			} else {
+				neutrals = true
+			}
+		}
+		if neutrals && (len(moves) == 0 || random() < cfg.NeutralRate) {
+			var cells []game.Pos
+			var logits []float64
+			for row := 0; row < state.Rows(); row++ {
+				for col := 0; col < state.Cols(); col++ {
+					pos := game.Pos{Row: row, Col: col}
+					if cell, _ := state.At(pos); cell.Owner == state.CurrentPlayer() && cell.Kind == game.Normal {
+						_, neutral := search.PolicyLogits(cfg.Policy, state, pos)
+						cells, logits = append(cells, pos), append(logits, neutral)
+					}
+				}
+			}
+			first := sample(logits)
+			a := cells[first]
+			cells, logits = append(cells[:first:first], cells[first+1:]...), append(logits[:first:first], logits[first+1:]...)
+			b := cells[sample(logits)]
+			if b.Row < a.Row || b.Row == a.Row && b.Col < a.Col {
+				a, b = b, a
+			}
+			return game.Action{Kind: game.PlaceNeutrals, Neutrals: [2]game.Pos{a, b}}, true
+		}
+		logits := make([]float64, len(moves))
+		for i, move := range moves {
+			logits[i], _ = search.PolicyLogits(cfg.Policy, state, move.Target)
+		}
+		return moves[sample(logits)], true
+	}
+}
+
+// buildHuman builds HumanLike from a spec's policy, temp, neutral and seed.
+func buildHuman(spec engine.Spec) (Engine, error) {
+	path := spec.Get("policy")
+	if path == "" {
+		return Engine{}, fmt.Errorf("human needs policy=<weights file> (nnue-train -policy on nnuegen -human data)")
+	}
+	policy, err := engine.CachedFile("policy", path, func(path string) (any, error) { return search.ReadPolicyWeights(path) })
+	if err != nil {
+		return Engine{}, err
+	}
+	cfg := HumanConfig{Policy: policy.(*nnueweights.PolicyNet), Temperature: 1, NeutralRate: DefaultHumanNeutralRate}
+	if text := spec.Get("temp"); text != "" {
+		if cfg.Temperature, err = strconv.ParseFloat(text, 64); err != nil || cfg.Temperature < 0 {
+			return Engine{}, fmt.Errorf("temp=%s: want a number from 0", text)
+		}
+	}
+	if text := spec.Get("neutral"); text != "" {
+		if cfg.NeutralRate, err = strconv.ParseFloat(text, 64); err != nil || cfg.NeutralRate < 0 || cfg.NeutralRate > 1 {
+			return Engine{}, fmt.Errorf("neutral=%s: want a rate from 0 to 1", text)
+		}
+	}
+	return seededEngine(func(seed uint64) Agent {
+		cfg := cfg
+		cfg.Seed = seed
+		return HumanLike(cfg)
+	})(spec)
+}
@@ -0,0 +1,212 @@
+package arena
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+const humanPolicyFixture = "testdata/human-policy-v1.policy"
+
+func TestIsBotName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Bot 1090": true, "Canary Bot 42": true, "HappyOtter97": false, "Bot": false,
+		"Bot 12a": false, "Robot 12": false, "Bot Builder": false, "": false,
+	} {
+		if got := IsBotName(name); got != want {
+			t.Errorf("IsBotName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestStoredGameReplaysItsFixture(t *testing.T) {
+	want := decodeHumanFixture(t, "testdata/happyotter97-vs-bot1090.json")
+	stored := StoredGame{ID: want.SourceID, Player1: want.Players[0], Player2: want.Players[1],
+		Rows: want.Rows, Cols: want.Cols, Result: int(want.Winner), Termination: want.Termination}
+	for _, turn := range want.Turns {
+		storedTurn := StoredTurn{Turn: turn.Number, Player: turn.Player}
+		for _, move := range turn.Actions {
+			kind := "place"
+			if move.Kind == "neutral" {
+				kind = "neutral"
+			}
+			storedTurn.Moves = append(storedTurn.Moves, StoredMove{Type: kind, Row: move.Row, Col: move.Col, Cells: move.Neutrals})
+		}
+		stored.PGN = append(stored.PGN, storedTurn)
+	}
+	got, err := stored.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.ObservedTurns = len(want.Turns)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("stored game replays as\n%+v\nwant\n%+v", got, want)
+	}
+
+	stored.Player3 = "Bot 7"
+	if _, err := stored.Replay(); err == nil {
+		t.Fatal("a 3-player game replayed")
+	}
+	stored.Player3 = ""
+	stored.PGN[0].Moves[0].Type = "teleport"
+	if _, err := stored.Replay(); err == nil {
+		t.Fatal("an unknown move type replayed")
+	}
+}
+
+func TestHumanDecisionsSkipBotsAndStayLegal(t *testing.T) {
+	replay := decodeHumanFixture(t, "testdata/happyotter97-vs-bot1090.json")
+	decisions, err := HumanDecisions(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	for _, turn := range replay.Turns {
+		if turn.Player == 1 {
+			want += len(turn.Actions)
+		}
+	}
+	if len(decisions) != want || want == 0 {
+		t.Fatalf("%d decisions, want HappyOtter97's %d actions", len(decisions), want)
+	}
+	for _, decision := range decisions {
+		if decision.Player != "HappyOtter97" || decision.State.CurrentPlayer() != 1 {
+			t.Fatalf("decision of %s as seat %d", decision.Player, decision.State.CurrentPlayer())
+		}
+		if _, err := decision.State.Apply(decision.Action); err != nil {
+			t.Fatalf("%+v is not legal where it was recorded: %v", decision.Action, err)
+		}
+	}
+
+	replay.Players[0] = "Bot 1"
+	if decisions, err := HumanDecisions(replay); err != nil || len(decisions) != 0 {
+		t.Fatalf("bot-vs-bot game gave %d decisions (%v)", len(decisions), err)
+	}
+}
+
+func TestHumanLikePlaysLegalSeededMoves(t *testing.T) {
+	policy, err := search.ReadPolicyWeights(humanPolicyFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seed := range []uint64{1, 2, 3} {
+		state := humanOpening(t, 10, 1)
+		first, second := HumanLike(HumanConfig{Policy: policy, Temperature: 1, Seed: seed}), HumanLike(HumanConfig{Policy: policy, Temperature: 1, Seed: seed})
+		step := 0
+		for ; step < 20 && !state.GameOver(); step++ {
+			a, ok := first(state)
+			b, _ := second(state)
+			if !ok || a != b {
+				t.Fatalf("seed %d step %d: %+v vs %+v (%v)", seed, step, a, b, ok)
+			}
+			state = mustApplyHuman(t, state, a)
+		}
+		if step < 10 {
+			t.Fatalf("seed %d: game over after %d steps", seed, step)
+		}
+	}
+
+	state := humanOpening(t, 10, 1)
+	greedy := HumanLike(HumanConfig{Policy: policy})
+	action, ok := greedy(state)
+	if !ok || action.Kind != game.Move {
+		t.Fatalf("temperature 0 played %+v", action)
+	}
+	best, _ := search.PolicyLogits(policy, state, action.Target)
+	for _, legal := range state.LegalActions() {
+		if legal.Kind != game.Move {
+			continue
+		}
+		if logit, _ := search.PolicyLogits(policy, state, legal.Target); logit > best {
+			t.Fatalf("temperature 0 played %+v (%g) over %+v (%g)", action.Target, best, legal.Target, logit)
+		}
+	}
+}
+
+func TestHumanLikePlacesNeutralsAtItsRate(t *testing.T) {
+	policy, err := search.ReadPolicyWeights(humanPolicyFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := humanOpening(t, 8, 2)
+	for state.MovesLeft() != 3 || state.NeutralUsed(state.CurrentPlayer()) {
+		state = mustApplyHuman(t, state, state.LegalActions()[0])
+	}
+	for rate, kind := range map[float64]game.ActionKind{0: game.Move, 1: game.PlaceNeutrals} {
+		action, ok := HumanLike(HumanConfig{Policy: policy, Temperature: 1, NeutralRate: rate, Seed: 5})(state)
+		if !ok || action.Kind != kind {
+			t.Fatalf("neutral rate %g played %+v", rate, action)
+		}
+		mover := state.CurrentPlayer()
+		next := mustApplyHuman(t, state, action)
+		if kind == game.PlaceNeutrals && !next.NeutralUsed(mover) || kind == game.Move && (next.CurrentPlayer() != mover || next.MovesLeft() != 2) {
+			t.Fatalf("neutral rate %g: %+v left seat %d with %d moves", rate, action, next.CurrentPlayer(), next.MovesLeft())
+		}
+	}
+}
+
+func TestHumanEngineSpec(t *testing.T) {
+	engine, err := BuildEngine("human?policy=" + humanPolicyFixture + "&temp=0.5&neutral=0&seed=9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine.Spec != "human?neutral=0&policy="+humanPolicyFixture+"&seed=9&temp=0.5" {
+		t.Fatalf("built %s", engine.Spec)
+	}
+	state := humanOpening(t, 10, 3)
+	action, _, ok := engine.New(1)(state)
+	if !ok {
+		t.Fatal("human engine found no action")
+	}
+	mustApplyHuman(t, state, action)
+
+	for _, text := range []string{
+		"human", "human?policy=" + filepath.Join(t.TempDir(), "missing.policy"),
+		"human?policy=" + humanPolicyFixture + "&temp=-1", "human?policy=" + humanPolicyFixture + "&temp=hot",
+		"human?policy=" + humanPolicyFixture + "&neutral=2",
+	} {
+		if _, err := BuildEngine(text); err == nil {
+			t.Errorf("BuildEngine(%q) built", text)
+		}
+	}
+}
+
+func decodeHumanFixture(t *testing.T, path string) Replay {
+	t.Helper()
+	fixture, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixture.Close()
+	replay, _, err := DecodeReplay(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replay
+}
+
+func humanOpening(t *testing.T, size int, seed uint64) game.State {
+	t.Helper()
+	opening, err := RandomLegalOpening(size, size, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := game.FromSnapshot(opening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func mustApplyHuman(t *testing.T, state game.State, action game.Action) game.State {
+	t.Helper()
+	next, err := state.Apply(action)
+	if err != nil {
+		t.Fatalf("%+v: %v", action, err)
+	}
+	return next
+}
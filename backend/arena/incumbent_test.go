@@ -114,7 +114,7 @@ func TestSerialAndThreeWayCorpusAggregationMatch(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	parallel, err := CompareCorpusBoards(corpus, "train", "competitive_1v1", boards, 3, nil, factory, factory)
+	parallel, err := CompareCorpusBoards(corpus, "train", CorpusFilter{Track: "competitive_1v1"}, boards, 3, nil, factory, factory)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -129,7 +129,7 @@ func TestSerialAndThreeWayCorpusAggregationMatch(t *testing.T) {
 	if serial.Overall.Wins != 3 || serial.Overall.Losses != 3 || serial.Overall.Draws != 0 || serial.Overall.WinRate() != 50 {
 		t.Fatalf("unbalanced deterministic self comparison: %s", serial)
 	}
-	repeat, err := CompareCorpusBoards(corpus, "train", "competitive_1v1", boards, 3, nil, factory, factory)
+	repeat, err := CompareCorpusBoards(corpus, "train", CorpusFilter{Track: "competitive_1v1"}, boards, 3, nil, factory, factory)
 	if err != nil {
 		t.Fatal(err)
 	}
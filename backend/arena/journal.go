@@ -0,0 +1,218 @@
+package arena
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sync"
+
+	"virusgame/game"
+)
+
+// vs-ai2.76: run journals. A full heldout corpus run takes hours and a killed
+// process used to start again from zero. With CorpusFilter.Journal set, every
+// finished game is appended to a JSON-lines file as it completes. A rerun
+// against the same journal skips the (case, seat) games already there and
+// folds their recorded results into its report, so a resumed run reports
+// exactly what an uninterrupted one would. Entries carry their bucket keys,
+// so MergeJournals can rebuild a CorpusReport from the journals of several
+// processes (say one per -corpus-board shard); it takes the corpus only to
+// check that the journals cover the split.
+
+// JournalEntry is one finished corpus game.
+type JournalEntry struct {
+	// Run identifies the configuration (split, contender, opponent, ...); a
+	// journal holds one run.
+	Run  string      `json:"run"`
+	Case string      `json:"case"`
+	Seat game.Player `json:"seat"`
+	// Keys are the CorpusReport buckets the game counts in.
+	Keys   []string   `json:"keys"`
+	Result GameResult `json:"result"`
+}
+
+type journalUnit struct {
+	Case string
+	Seat game.Player
+}
+
+// Journal is an append-only run journal, safe for the concurrent board shards
+// of CompareCorpusBoards.
+type Journal struct {
+	mu      sync.Mutex
+	file    *os.File
+	run     string
+	entries map[journalUnit]JournalEntry
+}
+
+// OpenJournal opens the journal at path for run, creating it if missing. A
+// final line cut short by a killed process is dropped from the file; any other
+// bad line, or an entry of another run, is an error.
+func OpenJournal(path, run string) (*Journal, error) {
+	entries, valid, err := readJournal(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	journal := &Journal{run: run, entries: map[journalUnit]JournalEntry{}}
+	for _, entry := range entries {
+		if entry.Run != run {
+			return nil, fmt.Errorf("journal %s holds run %q, not %q", path, entry.Run, run)
+		}
+		journal.entries[journalUnit{entry.Case, entry.Seat}] = entry
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil && info.Size() > valid {
+		log.Printf("arena: journal %s: dropping %d bytes of an unfinished entry", path, info.Size()-valid)
+	}
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(valid, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	journal.file = file
+	return journal, nil
+}
+
+// Len is the number of games the journal holds.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.entries)
+}
+
+// lookup returns the recorded game of caseID with the contender in seat.
+func (j *Journal) lookup(caseID string, seat game.Player) (JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.entries[journalUnit{caseID, seat}]
+	return entry, ok
+}
+
+// append writes entry and syncs it to disk before returning.
+func (j *Journal) append(entry JournalEntry) error {
+	entry.Run = j.run
+	entry.Result.Record = nil
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(append(encoded, '\n')); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.entries[journalUnit{entry.Case, entry.Seat}] = entry
+	return nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// ReadJournal reads every complete entry of the journal at path.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	entries, _, err := readJournal(path)
+	return entries, err
+}
+
+// readJournal also returns the length of the file's complete lines.
+func readJournal(path string) ([]JournalEntry, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	var entries []JournalEntry
+	var valid int64
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		if int(valid)+len(text) >= len(data) {
+			break // no newline: an entry the writer did not finish
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(text, &entry); err != nil {
+			return nil, 0, fmt.Errorf("journal %s line %d: %w", path, line, err)
+		}
+		if entry.Case == "" || entry.Seat == 0 {
+			return nil, 0, fmt.Errorf("journal %s line %d: entry without case or seat", path, line)
+		}
+		entries = append(entries, entry)
+		valid += int64(len(text)) + 1
+	}
+	return entries, valid, scanner.Err()
+}
+
+// MergeJournals rebuilds one CorpusReport from the journals at paths. Every
+// entry must belong to the same run, and together the journals must hold each
+// (case, seat) game the filter selects from the corpus split exactly once: a
+// game missing from every journal, held twice, or outside the split is an
+// error, so a merge never reports (or gates on) a partial run.
+func MergeJournals(corpus Corpus, split string, filter CorpusFilter, paths ...string) (CorpusReport, error) {
+	want := map[journalUnit]bool{}
+	var order []journalUnit
+	for _, testCase := range corpus.Cases {
+		if !filter.includes(testCase, split) {
+			continue
+		}
+		for seat := 1; seat <= testCase.Players; seat++ {
+			unit := journalUnit{testCase.ID, game.Player(seat)}
+			want[unit] = true
+			order = append(order, unit)
+		}
+	}
+	if len(order) == 0 {
+		return CorpusReport{}, fmt.Errorf("corpus split %q has no cases", split)
+	}
+	report := CorpusReport{Split: split, Buckets: make(map[string]Report)}
+	seen := map[journalUnit]string{}
+	run, runPath := "", ""
+	for _, path := range paths {
+		entries, err := ReadJournal(path)
+		if err != nil {
+			return CorpusReport{}, err
+		}
+		for _, entry := range entries {
+			if runPath == "" {
+				run, runPath = entry.Run, path
+			}
+			if entry.Run != run {
+				return CorpusReport{}, fmt.Errorf("journal %s holds run %q, %s holds %q", path, entry.Run, runPath, run)
+			}
+			unit := journalUnit{entry.Case, entry.Seat}
+			if !want[unit] {
+				return CorpusReport{}, fmt.Errorf("journal %s: case %s seat %d is not in split %q", path, entry.Case, entry.Seat, split)
+			}
+			if first, ok := seen[unit]; ok {
+				return CorpusReport{}, fmt.Errorf("journal %s: case %s seat %d is already in %s", path, entry.Case, entry.Seat, first)
+			}
+			seen[unit] = path
+			report.add(entry.Result, entry.Seat, entry.Keys)
+		}
+	}
+	if missing := len(order) - len(seen); missing > 0 {
+		for _, unit := range order {
+			if seen[unit] == "" {
+				return CorpusReport{}, fmt.Errorf("journals %v miss %d of %d games of split %q, first case %s seat %d",
+					paths, missing, len(order), split, unit.Case, unit.Seat)
+			}
+		}
+	}
+	report.Interval = Wilson95(report.Overall.Wins, report.Overall.Games)
+	return report, nil
+}
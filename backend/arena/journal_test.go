@@ -0,0 +1,181 @@
+package arena
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// journalCorpus is the test corpus cut to its first two cases of one split.
+func journalCorpus(t *testing.T) (Corpus, string) {
+	t.Helper()
+	corpus := loadTestCorpus(t)
+	split := corpus.Cases[0].Split
+	var cases []CorpusCase
+	for _, c := range corpus.Cases {
+		if c.Split == split && len(cases) < 2 {
+			cases = append(cases, c)
+		}
+	}
+	corpus.Cases = cases
+	return corpus, split
+}
+
+func stripCorpusTiming(report *CorpusReport) {
+	report.Overall.Latencies, report.Overall.Elapsed = nil, 0
+	for key, bucket := range report.Buckets {
+		bucket.Latencies, bucket.Elapsed = nil, 0
+		report.Buckets[key] = bucket
+	}
+}
+
+func TestJournalResumeMatchesUninterruptedRun(t *testing.T) {
+	corpus, split := journalCorpus(t)
+	played := 0
+	factory := func() TelemetryAgent { return Instrument(Greedy) }
+	contender := func() TelemetryAgent { played++; return Instrument(Greedy) }
+	full, err := CompareCorpus(corpus, split, contender, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	journal, err := OpenJournal(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := corpus
+	first.Cases = corpus.Cases[:1]
+	if _, err := CompareCorpusFiltered(first, split, CorpusFilter{Journal: journal}, nil, contender, factory); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	journal, err = OpenJournal(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	if journal.Len() != corpus.Cases[0].Players {
+		t.Fatalf("journal holds %d games, want %d", journal.Len(), corpus.Cases[0].Players)
+	}
+	played = 0
+	var counts []int
+	progress := func(update CorpusProgress) { counts = append(counts, update.Games) }
+	resumed, err := CompareCorpusFiltered(corpus, split, CorpusFilter{Journal: journal}, progress, contender, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if played != corpus.Cases[1].Players {
+		t.Fatalf("resumed run played %d games, want %d for the second case only", played, corpus.Cases[1].Players)
+	}
+	// Journaled games report progress like played ones.
+	if len(counts) != full.Overall.Games || counts[len(counts)-1] != full.Overall.Games {
+		t.Fatalf("progress %v over %d games", counts, full.Overall.Games)
+	}
+	stripCorpusTiming(&full)
+	stripCorpusTiming(&resumed)
+	if !reflect.DeepEqual(full, resumed) {
+		t.Fatalf("resumed report differs:\n%+v\n%+v", full, resumed)
+	}
+}
+
+func TestJournalDropsTornTailAndRejectsOtherRuns(t *testing.T) {
+	corpus, split := journalCorpus(t)
+	corpus.Cases = corpus.Cases[:1]
+	factory := func() TelemetryAgent { return Instrument(Greedy) }
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	journal, err := OpenJournal(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompareCorpusFiltered(corpus, split, CorpusFilter{Journal: journal}, nil, factory, factory); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+	complete, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(complete, `{"run":"test","case":"x`...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	journal, err = OpenJournal(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(complete) || journal.Len() != corpus.Cases[0].Players {
+		t.Fatalf("torn tail kept: %d bytes, want %d; %d games", len(after), len(complete), journal.Len())
+	}
+	if _, err := OpenJournal(path, "other"); err == nil || !strings.Contains(err.Error(), `"test"`) {
+		t.Fatalf("journal of another run opened: %v", err)
+	}
+}
+
+func TestMergeJournalsEqualsSingleRun(t *testing.T) {
+	corpus, split := journalCorpus(t)
+	factory := func() TelemetryAgent { return Instrument(Greedy) }
+	full, err := CompareCorpus(corpus, split, factory, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var paths []string
+	for i := range corpus.Cases {
+		path := filepath.Join(dir, "shard"+string(rune('a'+i))+".jsonl")
+		journal, err := OpenJournal(path, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		shard := corpus
+		shard.Cases = corpus.Cases[i : i+1]
+		if _, err := CompareCorpusFiltered(shard, split, CorpusFilter{Journal: journal}, nil, factory, factory); err != nil {
+			t.Fatal(err)
+		}
+		journal.Close()
+		paths = append(paths, path)
+	}
+
+	merged, err := MergeJournals(corpus, split, CorpusFilter{}, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripCorpusTiming(&full)
+	stripCorpusTiming(&merged)
+	if !reflect.DeepEqual(full, merged) {
+		t.Fatalf("merged report differs:\n%+v\n%+v", full, merged)
+	}
+
+	other := filepath.Join(dir, "other.jsonl")
+	journal, err := OpenJournal(other, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shard := corpus
+	shard.Cases = corpus.Cases[:1]
+	if _, err := CompareCorpusFiltered(shard, split, CorpusFilter{Journal: journal}, nil, factory, factory); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+	if _, err := MergeJournals(corpus, split, CorpusFilter{}, paths[0], other); err == nil {
+		t.Fatal("journals of different runs merged")
+	}
+	if _, err := MergeJournals(corpus, split, CorpusFilter{}, append(paths, paths[0])...); err == nil || !strings.Contains(err.Error(), "already in") {
+		t.Fatalf("a game in two journals merged: %v", err)
+	}
+	if _, err := MergeJournals(corpus, split, CorpusFilter{}, paths[0]); err == nil || !strings.Contains(err.Error(), "miss") {
+		t.Fatalf("a partial merge succeeded: %v", err)
+	}
+	shard.Cases = corpus.Cases[1:]
+	if _, err := MergeJournals(shard, split, CorpusFilter{}, paths...); err == nil || !strings.Contains(err.Error(), "not in split") {
+		t.Fatalf("a game outside the split merged: %v", err)
+	}
+}
@@ -14,12 +14,14 @@ import (
 // used; the test-side sequentialOrderSeed constant aliases it.
 const ladderOrderSeed = 20260716
 
-// SequentialResult is a Report plus the SPRT verdict against ThresholdPct.
+// SequentialResult is a Report plus the Wilson verdict against ThresholdPct.
+// Pentanomial counts the same games by opening pair, for Elo and LOS.
 type SequentialResult struct {
 	Report
 	ThresholdPct float64
 	Stopped      bool
 	Above        bool
+	Pentanomial  Pentanomial
 }
 
 // RandomLegalOpening plays ~8 pseudo-random legal plies from the empty
@@ -83,7 +85,8 @@ type openingPair struct {
 // serial run for any order-independent (per-decision-deterministic) agents:
 // openings are computed across a pool of `workers` goroutines but folded and
 // decided strictly in permutation order. workers<=0 defaults to GOMAXPROCS.
-// It returns an error on any illegal/stalled/maxed game.
+// It returns an error on any illegal/stalled/maxed game. The openings are
+// suite's for the board, or RandomLegalOpening seeds when suite is nil.
 //
 // Determinism note: the node-budget and heuristic ladder agents (Greedy,
 // BaseAttacker, MobilityAttacker, MobilityBaseAttacker, TelemetryNodeBudget,
@@ -91,18 +94,42 @@ type openingPair struct {
 // order-independent — so parallel and serial runs agree exactly. Agents that
 // carry RNG state across games (Random, and Legacy which wraps it) are neither
 // goroutine-safe nor order-independent; run those at workers=1.
-func PlaySequentialOpenings(rows, cols, maxOpenings int, thresholdPct float64, minGames int, a, b TelemetryAgent, workers int) (SequentialResult, error) {
+func PlaySequentialOpenings(suite *OpeningSuite, rows, cols, maxOpenings int, thresholdPct float64, minGames int, a, b TelemetryAgent, workers int) (SequentialResult, error) {
 	result := SequentialResult{ThresholdPct: thresholdPct}
+	err := playOpeningPairs(suite, rows, cols, maxOpenings, a, b, workers, func(pair openingPair) bool {
+		result.Add(pair.seat[0], game.Player(1))
+		result.Add(pair.seat[1], game.Player(2))
+		result.Pentanomial.AddPair(pair.seat[0], pair.seat[1], [2]game.Player{1, 2})
+		if stop, above := WilsonDecision(result.Wins, result.Games, thresholdPct, minGames); stop {
+			result.Stopped, result.Above = true, above
+			return true
+		}
+		return false
+	})
+	return result, err
+}
+
+// playOpeningPairs plays both seats of each seeded opening in the fixed-seed
+// permutation over [0,maxOpenings), a in seat 1 of the first game and seat 2
+// of the second, and folds the pairs strictly in permutation order until fold
+// returns true. With a suite the openings are the suite's for the board, and
+// maxOpenings is capped at how many it has. See PlaySequentialOpenings for the
+// determinism contract.
+func playOpeningPairs(suite *OpeningSuite, rows, cols, maxOpenings int, a, b TelemetryAgent, workers int, fold func(openingPair) bool) error {
 	if maxOpenings <= 0 {
-		return result, nil
+		return nil
 	}
 	if workers <= 0 {
 		workers = runtime.GOMAXPROCS(0)
 	}
+	maxOpenings, opening, err := pairOpenings(suite, rows, cols, maxOpenings)
+	if err != nil {
+		return err
+	}
 	order := rand.New(rand.NewSource(ladderOrderSeed)).Perm(maxOpenings)
 
 	playPair := func(idx int) openingPair {
-		snapshot, err := RandomLegalOpening(rows, cols, uint64(idx)+1)
+		snapshot, err := opening(idx)
 		if err != nil {
 			return openingPair{err: err}
 		}
@@ -126,7 +153,7 @@ func PlaySequentialOpenings(rows, cols, maxOpenings int, thresholdPct float64, m
 
 	// Process in windows of `workers`: launch a window in parallel, fold its
 	// results strictly in permutation order, and short-circuit the instant the
-	// SPRT verdict resolves. Extra openings in the stop window are discarded.
+	// verdict resolves. Extra openings in the stop window are discarded.
 	for start := 0; start < maxOpenings; start += workers {
 		end := min(start+workers, maxOpenings)
 		chans := make([]chan openingPair, end-start)
@@ -138,15 +165,12 @@ func PlaySequentialOpenings(rows, cols, maxOpenings int, thresholdPct float64, m
 		for i := start; i < end; i++ {
 			pair := <-chans[i-start]
 			if pair.err != nil {
-				return result, pair.err
+				return pair.err
 			}
-			result.Add(pair.seat[0], game.Player(1))
-			result.Add(pair.seat[1], game.Player(2))
-			if stop, above := WilsonDecision(result.Wins, result.Games, thresholdPct, minGames); stop {
-				result.Stopped, result.Above = true, above
-				return result, nil
+			if fold(pair) {
+				return nil
 			}
 		}
 	}
-	return result, nil
+	return nil
 }
@@ -44,11 +44,11 @@ func TestPlaySequentialOpeningsParallelMatchesSerial(t *testing.T) {
 		{"mirror greedy vs greedy", Instrument(Greedy), Instrument(Greedy), false, true},
 	}
 	for _, tc := range cases {
-		serial, err := PlaySequentialOpenings(12, 12, maxOpenings, 50, minGames, tc.a, tc.b, 1)
+		serial, err := PlaySequentialOpenings(nil, 12, 12, maxOpenings, 50, minGames, tc.a, tc.b, 1)
 		if err != nil {
 			t.Fatalf("%s serial: %v", tc.name, err)
 		}
-		parallel, err := PlaySequentialOpenings(12, 12, maxOpenings, 50, minGames, tc.a, tc.b, 4)
+		parallel, err := PlaySequentialOpenings(nil, 12, 12, maxOpenings, 50, minGames, tc.a, tc.b, 4)
 		if err != nil {
 			t.Fatalf("%s parallel: %v", tc.name, err)
 		}
@@ -58,6 +58,9 @@ func TestPlaySequentialOpeningsParallelMatchesSerial(t *testing.T) {
 				tc.name, serial.Games, serial.Wins, serial.Stopped, serial.Above,
 				parallel.Games, parallel.Wins, parallel.Stopped, parallel.Above)
 		}
+		if serial.Pentanomial != parallel.Pentanomial || 2*serial.Pentanomial.Pairs() != serial.Games {
+			t.Fatalf("%s: pentanomial serial %v parallel %v over %d games", tc.name, serial.Pentanomial, parallel.Pentanomial, serial.Games)
+		}
 		if tc.wantStop && !(serial.Stopped && serial.Above) {
 			t.Fatalf("%s: expected early stop above threshold, got %+v", tc.name, serial)
 		}
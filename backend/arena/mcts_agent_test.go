@@ -0,0 +1,25 @@
+package arena
+
+import (
+	"testing"
+
+	"virusgame/mcts"
+)
+
+// TestMCTSAgentPlaysLegalGames wires the Monte Carlo engine through Play from
+// both seats; the arena rejects any illegal or stalled decision.
+func TestMCTSAgentPlaysLegalGames(t *testing.T) {
+	for seat := 0; seat < 2; seat++ {
+		agents := []TelemetryAgent{TelemetryMCTS(mcts.Config{ReuseTree: true}, 150), Instrument(Greedy)}
+		if seat == 1 {
+			agents[0], agents[1] = agents[1], agents[0]
+		}
+		result, err := Play(Match{Rows: 6, Cols: 6, TelemetryAgents: agents})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Illegal != 0 || result.Stalled {
+			t.Fatalf("seat %d: illegal=%d stalled=%v", seat, result.Illegal, result.Stalled)
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"sort"
+
+	"virusgame/game"
+	"virusgame/search"
 )
 
 type MotifManifest struct {
@@ -69,3 +72,110 @@ func DecodeMotifs(reader io.Reader, replays map[string]Replay) (MotifManifest, e
 	sort.Slice(manifest.Moments, func(i, j int) bool { return manifest.Moments[i].ID < manifest.Moments[j].ID })
 	return manifest, nil
 }
+
+// ClassifyBlunder tags a decision where player chose played over the deeper
+// search's best, in the production-motifs-v1 tag vocabulary so mined positions
+// group with the annotated production ones. params is the eval the articulation
+// and threat maps are read under, the deep search's own. It looks only one
+// action deep:
+//
+//	capturable_placement  played puts a cell the opponent can take at once
+//	proactive_capture     best captures and played does not
+//	base_pressure         best lands next to an opponent base and played does not
+//	thin_tendril          played leaves more threatened articulation cells than best
+//	neutral_available     best places the neutral pair and played does not;
+//	no_neutral_used       always together with neutral_available
+//
+// A decision matching none is "unclassified".
+func ClassifyBlunder(state game.State, played, best game.Action, params search.EvalParams) []string {
+	player := state.CurrentPlayer()
+	var tags []string
+	afterPlayed, err := state.Apply(played)
+	if err != nil {
+		return []string{"unclassified"}
+	}
+	afterBest, err := state.Apply(best)
+	if err != nil {
+		return []string{"unclassified"}
+	}
+	if played.Kind == game.Move && opponentCanTake(afterPlayed, player, played.Target) {
+		tags = append(tags, "capturable_placement")
+	}
+	if isCapture(state, best) && !isCapture(state, played) {
+		tags = append(tags, "proactive_capture")
+	}
+	if nextToOpponentBase(state, best) && !nextToOpponentBase(state, played) {
+		tags = append(tags, "base_pressure")
+	}
+	if threatenedCuts(afterPlayed, player, params) > threatenedCuts(afterBest, player, params) {
+		tags = append(tags, "thin_tendril")
+	}
+	if best.Kind == game.PlaceNeutrals && played.Kind != game.PlaceNeutrals {
+		tags = append(tags, "neutral_available", "no_neutral_used")
+	}
+	if len(tags) == 0 {
+		return []string{"unclassified"}
+	}
+	return tags
+}
+
+// opponentCanTake reports whether an opponent of player could move onto pos on
+// its next turn.
+func opponentCanTake(state game.State, player game.Player, pos game.Pos) bool {
+	if state.GameOver() {
+		return false
+	}
+	for seat := game.Player(1); int(seat) <= state.Players(); seat++ {
+		if seat == player || !state.Active(seat) {
+			continue
+		}
+		reply := state.HandTurnTo(seat)
+		for _, action := range reply.LegalActions() {
+			if action.Kind == game.Move && action.Target == pos {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isCapture(state game.State, action game.Action) bool {
+	if action.Kind != game.Move {
+		return false
+	}
+	cell, _ := state.At(action.Target)
+	return cell.Kind == game.Normal && cell.Owner != 0 && cell.Owner != state.CurrentPlayer()
+}
+
+func nextToOpponentBase(state game.State, action game.Action) bool {
+	if action.Kind != game.Move {
+		return false
+	}
+	for seat := game.Player(1); int(seat) <= state.Players(); seat++ {
+		if seat == state.CurrentPlayer() || !state.Active(seat) {
+			continue
+		}
+		base := basePosition(state, seat)
+		if abs(base.Row-action.Target.Row) <= 1 && abs(base.Col-action.Target.Col) <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// threatenedCuts counts player's articulation cells an opponent touches under
+// params.
+func threatenedCuts(state game.State, player game.Player, params search.EvalParams) int {
+	if state.GameOver() {
+		return 0
+	}
+	explained := search.ExplainEvalParams(state, player, params)
+	count := 0
+	for i, cut := range explained.Articulation {
+		cell, _ := state.At(game.Pos{Row: i / state.Cols(), Col: i % state.Cols()})
+		if cut && explained.Threatened[i] && cell.Owner == player {
+			count++
+		}
+	}
+	return count
+}
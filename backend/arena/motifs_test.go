@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"virusgame/game"
+	"virusgame/search"
 )
 
 func TestProductionMotifsAreFrozenAnnotatedReplayPositions(t *testing.T) {
@@ -103,3 +106,28 @@ func TestProductionMotifsAreFrozenAnnotatedReplayPositions(t *testing.T) {
 		t.Fatalf("motif polarity coverage is incomplete: moments=%d polarities=%v", len(manifest.Moments), polarities)
 	}
 }
+
+func TestClassifyBlunderTagsMissedCapture(t *testing.T) {
+	state, err := ParseBoard("A..../.11../..2../....B", 1, 1, "12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	capture := game.Action{Kind: game.Move, Target: game.Pos{Row: 2, Col: 2}}
+	quiet := game.Action{Kind: game.Move, Target: game.Pos{Row: 1, Col: 0}}
+	tags := ClassifyBlunder(state, quiet, capture, search.DefaultEvalParams())
+	if !containsTag(tags, "proactive_capture") || containsTag(tags, "capturable_placement") {
+		t.Fatalf("missed capture tagged %v", tags)
+	}
+	if tags := ClassifyBlunder(state, capture, capture, search.DefaultEvalParams()); len(tags) != 1 || tags[0] != "unclassified" {
+		t.Fatalf("best against itself tagged %v", tags)
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"virusgame/game"
+	"virusgame/search"
 )
 
 // randomLegalOpeningN is randomLegalOpening generalized to N players and any
@@ -176,6 +177,79 @@ func TestMultiplayerLadderReport(t *testing.T) {
 	t.Log("\n" + table.String())
 }
 
+// TestMultiplayerAlgorithmLadder compares the three 3-4 player searches —
+// production maxN, Paranoid and Best-Reply (vs-ai2.62) — at the SAME node
+// budget. Each algorithm plays the heuristic mixes, then sits at one seat
+// against maxN in the others, and finally all three share a 3-player table
+// (one row per focus seat, same seeded openings and rotations). Measurement
+// only: it fails solely on illegal games.
+//
+// Reproduce:
+//
+//	VS_MP_LADDER=1 go test ./arena -run TestMultiplayerAlgorithmLadder -v -timeout 240m
+//
+// Quick wiring check:
+//
+//	VS_MP_LADDER=1 VS_MP_LADDER_OPENINGS=2 go test ./arena -run TestMultiplayerAlgorithmLadder -v
+func TestMultiplayerAlgorithmLadder(t *testing.T) {
+	if os.Getenv("VS_MP_LADDER") != "1" {
+		t.Skip("set VS_MP_LADDER=1 to run the slow 3-4 player algorithm ladder")
+	}
+	openings := envInt(t, "VS_MP_LADDER_OPENINGS", 20)
+	nodes := uint64(envInt(t, "VS_MP_LADDER_NODES", 1000))
+	algorithms := []search.MultiplayerAlgorithm{search.MaxN, search.Paranoid, search.BestReply}
+	engine := func(alg search.MultiplayerAlgorithm) TelemetryAgent {
+		return TelemetryNodeBudgetOptions(nodes, search.Options{Multiplayer: alg})
+	}
+	maxn := engine(search.MaxN)
+	greedy, base, mob := Instrument(Greedy), Instrument(BaseAttacker), Instrument(MobilityAttacker)
+
+	type rung struct {
+		name   string
+		agents []TelemetryAgent
+		focus  []bool
+	}
+	var rungs []rung
+	for _, alg := range algorithms {
+		agent := engine(alg)
+		rungs = append(rungs,
+			rung{fmt.Sprintf("3p %s vs greedy+base", alg), []TelemetryAgent{agent, greedy, base}, []bool{true, false, false}},
+			rung{fmt.Sprintf("4p %s vs greedy+base+mob", alg), []TelemetryAgent{agent, greedy, base, mob}, []bool{true, false, false, false}},
+		)
+		if alg != search.MaxN {
+			rungs = append(rungs,
+				rung{fmt.Sprintf("3p %s vs 2x maxn", alg), []TelemetryAgent{agent, maxn, maxn}, []bool{true, false, false}},
+				rung{fmt.Sprintf("4p %s vs 3x maxn", alg), []TelemetryAgent{agent, maxn, maxn, maxn}, []bool{true, false, false, false}},
+			)
+		}
+	}
+	table3 := []TelemetryAgent{engine(search.MaxN), engine(search.Paranoid), engine(search.BestReply)}
+	for i, alg := range algorithms {
+		focus := make([]bool, len(table3))
+		focus[i] = true
+		rungs = append(rungs, rung{fmt.Sprintf("3p %s at maxn+paranoid+brs table", alg), table3, focus})
+	}
+
+	var table strings.Builder
+	fmt.Fprintf(&table, "multiplayer algorithm ladder (nodes=%d, openings=%d, cyclic seat rotation):\n", nodes, openings)
+	fmt.Fprintf(&table, "%-40s | %9s | %6s | %-16s | %-19s | %-7s | %s\n",
+		"rung", "wins/games", "1st%", "wilson95(1st)", "place 1/2/3/4", "share%", "term D/M/S")
+	for _, r := range rungs {
+		fair := 100 / float64(len(r.agents))
+		res := playMultiplayerRotations(t, r.name, Board{12, 12}, r.agents, r.focus, openings, fair)
+		if res.Illegal != 0 {
+			t.Fatalf("%s produced %d illegal games", r.name, res.Illegal)
+		}
+		iv := Wilson95(res.Wins, res.Games)
+		rate := 100 * float64(res.Wins) / float64(res.Games)
+		fmt.Fprintf(&table, "%-40s | %5d/%-3d | %5.1f%% | [%5.1f%%,%5.1f%%] | %4d/%4d/%4d/%4d | %5.1f%% | %d/%d/%d\n",
+			r.name, res.Wins, res.Games, rate, iv.Low, iv.High,
+			res.Place[1], res.Place[2], res.Place[3], res.Place[4], fair,
+			res.Decisive, res.Maxed, res.Stalled)
+	}
+	t.Log("\n" + table.String())
+}
+
 // TestMultiplayerPlacementInvariants is the always-on correctness check for the
 // placement machinery: across small deterministic 3- and 4-player games, every
 // finished game's placements must be a permutation of 1..N, and the winner (if
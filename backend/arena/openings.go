@@ -0,0 +1,162 @@
+package arena
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"virusgame/game"
+)
+
+// vs-ai2.75: balanced opening suites. RandomLegalOpening's eight uniform
+// plies often leave one seat clearly ahead, and then both games of a pair end
+// the same way whoever sits there: the pair measures the opening, not the
+// engines. An opening suite is a checked set of starts that a deep search
+// rated level (arena/cmd/openinggen), chosen to differ from each other in
+// shape. Each opening is stored as its action line from the empty board, like
+// a corpus trajectory, and pinned by SnapshotHash; Checksum covers them all.
+
+const OpeningSuiteVersion = "virusgame-opening-suite-v1"
+
+type OpeningSuite struct {
+	Version      string         `json:"version"`
+	Generator    string         `json:"generator"`
+	GeneratedUTC string         `json:"generated_utc"`
+	Openings     []SuiteOpening `json:"openings"`
+	Checksum     string         `json:"checksum"`
+}
+
+type SuiteOpening struct {
+	ID      string       `json:"id"`
+	Rows    int          `json:"rows"`
+	Cols    int          `json:"cols"`
+	Players int          `json:"players"`
+	Actions []ReplayMove `json:"actions"`
+	// Spread is the deep search's best seat score less its worst when the
+	// opening was generated; the generator's window bounds it.
+	Spread int        `json:"spread"`
+	Hash   string     `json:"hash"`
+	State  game.State `json:"-"`
+}
+
+// OpeningSuiteChecksum is the sha256 over the sorted "id:hash" lines of the
+// openings, the value OpeningSuite.Checksum pins.
+func OpeningSuiteChecksum(openings []SuiteOpening) string {
+	lines := make([]string, len(openings))
+	for i, opening := range openings {
+		lines[i] = opening.ID + ":" + opening.Hash
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(joinLines(lines)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DecodeOpeningSuite replays every opening's actions through State.Apply,
+// verifies its hash and the suite checksum, and rejects duplicate IDs or
+// positions and openings that are already over.
+func DecodeOpeningSuite(reader io.Reader) (OpeningSuite, error) {
+	var suite OpeningSuite
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&suite); err != nil {
+		return OpeningSuite{}, fmt.Errorf("decode opening suite: %w", err)
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return OpeningSuite{}, fmt.Errorf("decode opening suite: trailing content")
+	}
+	if suite.Version != OpeningSuiteVersion || suite.Generator == "" || len(suite.Openings) == 0 {
+		return OpeningSuite{}, fmt.Errorf("invalid opening suite metadata")
+	}
+	seenIDs, seenHashes := map[string]bool{}, map[string]bool{}
+	for i := range suite.Openings {
+		opening := &suite.Openings[i]
+		if opening.ID == "" || seenIDs[opening.ID] {
+			return OpeningSuite{}, fmt.Errorf("invalid or duplicate opening %q", opening.ID)
+		}
+		seenIDs[opening.ID] = true
+		state, err := game.New(opening.Rows, opening.Cols, opening.Players)
+		if err != nil {
+			return OpeningSuite{}, fmt.Errorf("opening %s: %w", opening.ID, err)
+		}
+		for index, move := range opening.Actions {
+			action, err := move.action()
+			if err == nil {
+				state, err = state.Apply(action)
+			}
+			if err != nil {
+				return OpeningSuite{}, fmt.Errorf("opening %s action %d: %w", opening.ID, index+1, err)
+			}
+		}
+		if state.GameOver() {
+			return OpeningSuite{}, fmt.Errorf("opening %s is over", opening.ID)
+		}
+		hash, err := SnapshotHash(state.Snapshot())
+		if err != nil || hash != opening.Hash || seenHashes[hash] {
+			return OpeningSuite{}, fmt.Errorf("opening %s: hash=%s want=%s duplicate=%v err=%v", opening.ID, hash, opening.Hash, seenHashes[hash], err)
+		}
+		seenHashes[hash] = true
+		opening.State = state
+	}
+	if got := OpeningSuiteChecksum(suite.Openings); got != suite.Checksum {
+		return OpeningSuite{}, fmt.Errorf("opening suite checksum=%s want=%s", got, suite.Checksum)
+	}
+	return suite, nil
+}
+
+// ReadOpeningSuite decodes the suite file at path.
+func ReadOpeningSuite(path string) (OpeningSuite, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return OpeningSuite{}, err
+	}
+	defer file.Close()
+	suite, err := DecodeOpeningSuite(file)
+	if err != nil {
+		return OpeningSuite{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return suite, nil
+}
+
+// For returns the suite's openings on a rows x cols board for players seats,
+// in file order.
+func (s *OpeningSuite) For(rows, cols, players int) []SuiteOpening {
+	var out []SuiteOpening
+	for _, opening := range s.Openings {
+		if opening.Rows == rows && opening.Cols == cols && opening.Players == players {
+			out = append(out, opening)
+		}
+	}
+	return out
+}
+
+// Source names where balanced opening pairs come from: the suite's checksum,
+// or "seeded" for RandomLegalOpening seeds when s is nil. Results from
+// different sources do not mix.
+func (s *OpeningSuite) Source() string {
+	if s == nil {
+		return "seeded"
+	}
+	return s.Checksum
+}
+
+// pairOpenings returns how many opening pairs to play on rows x cols and the
+// opening at each index: suite's two-player openings for the board, capped at
+// maxOpenings, else (nil suite) RandomLegalOpening seeded by index+1.
+func pairOpenings(suite *OpeningSuite, rows, cols, maxOpenings int) (int, func(idx int) (game.Snapshot, error), error) {
+	if suite == nil {
+		return maxOpenings, func(idx int) (game.Snapshot, error) {
+			return RandomLegalOpening(rows, cols, uint64(idx)+1)
+		}, nil
+	}
+	openings := suite.For(rows, cols, 2)
+	if len(openings) == 0 {
+		return 0, nil, fmt.Errorf("opening suite has no two-player %dx%d openings", rows, cols)
+	}
+	return min(maxOpenings, len(openings)), func(idx int) (game.Snapshot, error) {
+		return openings[idx].State.Snapshot(), nil
+	}, nil
+}
@@ -0,0 +1,108 @@
+package arena
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"virusgame/game"
+)
+
+// testOpeningSuite plays Greedy for 3, 4 and 5 plies on the empty 6x6 board.
+func testOpeningSuite(t *testing.T) OpeningSuite {
+	t.Helper()
+	suite := OpeningSuite{Version: OpeningSuiteVersion, Generator: "test", GeneratedUTC: "2026-10-18T00:00:00Z"}
+	for plies := 3; plies <= 5; plies++ {
+		state, _ := game.New(6, 6, 2)
+		opening := SuiteOpening{ID: strings.Repeat("g", plies), Rows: 6, Cols: 6, Players: 2}
+		for i := 0; i < plies; i++ {
+			action, _ := Greedy(state)
+			state, _ = state.Apply(action)
+			opening.Actions = append(opening.Actions, replayMove(action))
+		}
+		opening.Hash, _ = SnapshotHash(state.Snapshot())
+		suite.Openings = append(suite.Openings, opening)
+	}
+	suite.Checksum = OpeningSuiteChecksum(suite.Openings)
+	return suite
+}
+
+func TestOpeningSuiteDecodesAndRejectsTampering(t *testing.T) {
+	suite := testOpeningSuite(t)
+	encoded, _ := json.Marshal(suite)
+	decoded, err := DecodeOpeningSuite(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if openings := decoded.For(6, 6, 2); len(openings) != 3 || openings[2].State.CurrentPlayer() != 2 || len(decoded.For(6, 6, 3)) != 0 {
+		t.Fatalf("decoded %+v", decoded.Openings)
+	}
+
+	for name, tamper := range map[string]func(*OpeningSuite){
+		"checksum":  func(s *OpeningSuite) { s.Checksum = strings.Repeat("0", 64) },
+		"hash":      func(s *OpeningSuite) { s.Openings[0].Hash = s.Openings[1].Hash },
+		"duplicate": func(s *OpeningSuite) { s.Openings[1].ID = s.Openings[0].ID },
+		"version":   func(s *OpeningSuite) { s.Version = "virusgame-opening-suite-v0" },
+		"illegal":   func(s *OpeningSuite) { s.Openings[0].Actions[1] = s.Openings[0].Actions[0] },
+	} {
+		bad := testOpeningSuite(t)
+		tamper(&bad)
+		encoded, _ := json.Marshal(bad)
+		if _, err := DecodeOpeningSuite(bytes.NewReader(encoded)); err == nil {
+			t.Errorf("%s tampering decoded", name)
+		}
+	}
+}
+
+func TestOpeningSuiteReplacesSeededOpenings(t *testing.T) {
+	encoded, _ := json.Marshal(testOpeningSuite(t))
+	suite, err := DecodeOpeningSuite(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := PlaySequentialOpenings(&suite, 6, 6, 10, 50, 100, Instrument(Greedy), Instrument(BaseAttacker), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Games != 6 || result.Pentanomial.Pairs() != 3 {
+		t.Fatalf("played %d games in %d pairs from a 3-opening suite", result.Games, result.Pentanomial.Pairs())
+	}
+	if _, err := PlaySequentialOpenings(&suite, 8, 8, 10, 50, 100, Instrument(Greedy), Instrument(BaseAttacker), 1); err == nil {
+		t.Fatal("a board the suite lacks was played")
+	}
+	if seeded, err := PlaySequentialOpenings(nil, 8, 8, 3, 50, 100, Instrument(Greedy), Instrument(BaseAttacker), 2); err != nil || seeded.Games != 6 {
+		t.Fatalf("seeded run played %d games (%v)", seeded.Games, err)
+	}
+
+	// A ratings file keeps to the openings it started with.
+	entrants := map[string]RatingEntrant{
+		"greedy": {Name: "greedy", New: func() TelemetryAgent { return Instrument(Greedy) }},
+		"base":   {Name: "base", New: func() TelemetryAgent { return Instrument(BaseAttacker) }},
+	}
+	file := NewRatingsFile(6, 6, 2, nil)
+	file.AddEntrant("greedy")
+	file.AddEntrant("base")
+	if err := PlayRoundRobin(&file, &suite, entrants, 1, nil); err == nil {
+		t.Fatal("suite openings merged into a seeded ratings file")
+	}
+	file = NewRatingsFile(6, 6, 2, &suite)
+	file.AddEntrant("greedy")
+	file.AddEntrant("base")
+	if err := PlayRoundRobin(&file, &suite, entrants, 1, nil); err != nil || file.Source != suite.Checksum || len(file.Pairs) != 1 {
+		t.Fatalf("suite round robin: %v, source %q, %d pairs", err, file.Source, len(file.Pairs))
+	}
+}
+
+func TestCheckedInOpeningSuite(t *testing.T) {
+	suite, err := ReadOpeningSuite(filepath.Join("suites", "openings-v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, board := range []Board{{8, 8}, {12, 12}} {
+		if n := len(suite.For(board.Rows, board.Cols, 2)); n != 24 {
+			t.Errorf("%dx%d has %d openings, want 24", board.Rows, board.Cols, n)
+		}
+	}
+}
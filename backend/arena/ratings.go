@@ -0,0 +1,402 @@
+package arena
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"virusgame/game"
+)
+
+// vs-ai2.72: rating ladder. Every arena comparison so far is one contender
+// against one opponent; none places the baselines, the incumbent and the
+// search builds on one scale. The round robin plays every pair of entrants
+// over the same balanced opening pairs and fits a Bradley-Terry model to all
+// the games at once (BayesElo style: a prior of virtual draws keeps a perfect
+// record finite), so an entrant that never met another still has a rating
+// relative to it through their common opponents. The games are the record and
+// the ratings are derived: a RatingsFile keeps every pair's counts, so adding
+// an entrant plays only its pairs and refits.
+
+// RatingsVersion is the RatingsFile format version. Version 2 records the
+// opening source; version 1 files do not say which they played.
+const RatingsVersion = 2
+
+// ratingPriorDraws is the virtual draws the fit adds to every pair that
+// played, BayesElo's default prior.
+const ratingPriorDraws = 2
+
+// RatingPair is every game one pair of entrants played, from A's side.
+type RatingPair struct {
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Games int    `json:"games"`
+	WinsA int    `json:"winsA"`
+	WinsB int    `json:"winsB"`
+	Draws int    `json:"draws"`
+	// Pentanomial counts A's score over each opening's two games.
+	Pentanomial Pentanomial `json:"pentanomial"`
+}
+
+// Rating is one entrant's fitted Elo and 95% interval, both relative to the
+// mean of the rated entrants, with the games behind it.
+type Rating struct {
+	Name  string  `json:"name"`
+	Elo   float64 `json:"elo"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Games int     `json:"games"`
+	// Score is the entrant's mean score per game, draws counting half.
+	Score float64 `json:"score"`
+}
+
+// RatingsFile is a persistent round robin: the entrants, the board, opening
+// count and opening source (OpeningSuite.Source) every pair plays, each pair's
+// games and the ratings last fitted to them. Entrant names are engine specs, so a later run can rebuild
+// an old entrant to play a new one.
+type RatingsFile struct {
+	Version  int          `json:"version"`
+	Rows     int          `json:"rows"`
+	Cols     int          `json:"cols"`
+	Openings int          `json:"openings"`
+	Source   string       `json:"openingSource"`
+	Entrants []string     `json:"entrants"`
+	Pairs    []RatingPair `json:"pairs"`
+	Ratings  []Rating     `json:"ratings,omitempty"`
+}
+
+// NewRatingsFile starts an empty round robin of openings balanced pairs per
+// entrant pair on rows x cols, drawn from suite (nil: seeded openings).
+func NewRatingsFile(rows, cols, openings int, suite *OpeningSuite) RatingsFile {
+	return RatingsFile{Version: RatingsVersion, Rows: rows, Cols: cols, Openings: openings, Source: suite.Source()}
+}
+
+// ReadRatingsFile loads a RatingsFile written by WriteRatingsFile.
+func ReadRatingsFile(path string) (RatingsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RatingsFile{}, err
+	}
+	var file RatingsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return RatingsFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Version != RatingsVersion {
+		return RatingsFile{}, fmt.Errorf("%s: ratings version %d, want %d", path, file.Version, RatingsVersion)
+	}
+	return file, nil
+}
+
+// WriteRatingsFile replaces path with file via a rename, so a run killed
+// mid-write leaves the previous file.
+func WriteRatingsFile(path string, file RatingsFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// AddEntrant enters name unless it is already entered.
+func (f *RatingsFile) AddEntrant(name string) {
+	for _, entered := range f.Entrants {
+		if entered == name {
+			return
+		}
+	}
+	f.Entrants = append(f.Entrants, name)
+}
+
+// Played reports whether a and b have played, in either order.
+func (f *RatingsFile) Played(a, b string) bool {
+	for _, pair := range f.Pairs {
+		if (pair.A == a && pair.B == b) || (pair.A == b && pair.B == a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing lists the entrant pairs still to play, in entry order: every
+// earlier entrant against each later one.
+func (f *RatingsFile) Missing() [][2]string {
+	var missing [][2]string
+	for j, b := range f.Entrants {
+		for _, a := range f.Entrants[:j] {
+			if !f.Played(a, b) {
+				missing = append(missing, [2]string{a, b})
+			}
+		}
+	}
+	return missing
+}
+
+// Fit refits Ratings to every pair played.
+func (f *RatingsFile) Fit() error {
+	ratings, err := FitRatings(f.Entrants, f.Pairs)
+	if err != nil {
+		return err
+	}
+	f.Ratings = ratings
+	return nil
+}
+
+// RatingEntrant builds one round-robin entrant. New is called once per pair,
+// so an agent that carries state (Random's RNG, an MCTS tree) starts every
+// pair fresh; Serial plays its pairs one game at a time, for agents whose
+// moves depend on call order or on the wall clock.
+type RatingEntrant struct {
+	Name   string
+	New    func() TelemetryAgent
+	Serial bool
+}
+
+// PlayRoundRobin plays every pair file is missing, each over file.Openings
+// balanced opening pairs from suite exactly as PlaySequentialOpenings plays
+// them (no early stop), appends it to file.Pairs and hands it to progress, when
+// non-nil, before the next pair starts — the caller can save the file there
+// and a killed run resumes at the first unplayed pair. entrants must build
+// every entrant file names, and suite must be the source file recorded. It
+// does not refit; call Fit.
+func PlayRoundRobin(file *RatingsFile, suite *OpeningSuite, entrants map[string]RatingEntrant, workers int, progress func(RatingPair) error) error {
+	if source := suite.Source(); source != file.Source {
+		return fmt.Errorf("round robin: ratings played openings %s, not %s", file.Source, source)
+	}
+	for _, name := range file.Entrants {
+		if _, ok := entrants[name]; !ok {
+			return fmt.Errorf("round robin: no entrant %q", name)
+		}
+	}
+	for _, names := range file.Missing() {
+		a, b := entrants[names[0]], entrants[names[1]]
+		pairWorkers := workers
+		if a.Serial || b.Serial {
+			pairWorkers = 1
+		}
+		pair := RatingPair{A: a.Name, B: b.Name}
+		err := playOpeningPairs(suite, file.Rows, file.Cols, file.Openings, a.New(), b.New(), pairWorkers, func(played openingPair) bool {
+			for seat, result := range played.seat {
+				focus := game.Player(seat + 1)
+				pair.Games++
+				switch result.Winner {
+				case focus:
+					pair.WinsA++
+				case 0:
+					pair.Draws++
+				default:
+					pair.WinsB++
+				}
+			}
+			pair.Pentanomial.AddPair(played.seat[0], played.seat[1], [2]game.Player{1, 2})
+			return false
+		})
+		if err != nil {
+			return fmt.Errorf("round robin %s vs %s: %w", a.Name, b.Name, err)
+		}
+		file.Pairs = append(file.Pairs, pair)
+		if progress != nil {
+			if err := progress(pair); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FitRatings fits a Bradley-Terry model to the pairs' games, draws counting
+// half a win to each side and every pair that played carrying
+// ratingPriorDraws virtual draws, by Hunter's minorization-maximization. Elo
+// is relative to the mean of names; the interval is ±1.96 standard errors
+// from the inverse Fisher information under that constraint. Pairs naming an
+// entrant outside names are ignored. Ratings are returned strongest first.
+// Every entrant must be connected to every other through games played.
+func FitRatings(names []string, pairs []RatingPair) ([]Rating, error) {
+	n := len(names)
+	if n == 0 {
+		return nil, nil
+	}
+	index := make(map[string]int, n)
+	for i, name := range names {
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("ratings: entrant %q listed twice", name)
+		}
+		index[name] = i
+	}
+	// games[i][j] counts the games between i and j with the prior; points[i]
+	// is i's score with the prior, played[i] and scored[i] without it.
+	games := make([][]float64, n)
+	for i := range games {
+		games[i] = make([]float64, n)
+	}
+	points := make([]float64, n)
+	played := make([]int, n)
+	scored := make([]float64, n)
+	for _, pair := range pairs {
+		a, okA := index[pair.A]
+		b, okB := index[pair.B]
+		if !okA || !okB || a == b || pair.Games == 0 {
+			continue
+		}
+		total := float64(pair.Games) + ratingPriorDraws
+		games[a][b] += total
+		games[b][a] += total
+		scoreA := float64(pair.WinsA) + float64(pair.Draws)/2
+		scoreB := float64(pair.WinsB) + float64(pair.Draws)/2
+		points[a] += scoreA + ratingPriorDraws/2
+		points[b] += scoreB + ratingPriorDraws/2
+		played[a] += pair.Games
+		played[b] += pair.Games
+		scored[a] += scoreA
+		scored[b] += scoreB
+	}
+	if err := ratingsConnected(names, games); err != nil {
+		return nil, err
+	}
+	if n == 1 {
+		return []Rating{{Name: names[0]}}, nil
+	}
+
+	gamma := make([]float64, n)
+	for i := range gamma {
+		gamma[i] = 1
+	}
+	next := make([]float64, n)
+	for iteration := 0; iteration < 10000; iteration++ {
+		for i := range next {
+			var denominator float64
+			for j, count := range games[i] {
+				if count > 0 {
+					denominator += count / (gamma[i] + gamma[j])
+				}
+			}
+			next[i] = points[i] / denominator
+		}
+		// Pin the geometric mean to 1: the model only fixes ratios.
+		var logMean float64
+		for _, g := range next {
+			logMean += math.Log(g)
+		}
+		scale := math.Exp(logMean / float64(n))
+		change := 0.0
+		for i := range next {
+			next[i] /= scale
+			change = max(change, math.Abs(math.Log(next[i]/gamma[i])))
+		}
+		gamma, next = next, gamma
+		if change < 1e-10 {
+			break
+		}
+	}
+
+	// The Fisher information in natural-log strengths is a weighted graph
+	// Laplacian; with the ratings summing to zero its pseudo-inverse,
+	// (H + 11ᵀ/n)⁻¹ - 11ᵀ/n, is their covariance.
+	information := make([][]float64, n)
+	for i := range information {
+		information[i] = make([]float64, n)
+		for j := range information[i] {
+			information[i][j] = 1 / float64(n)
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j, count := range games[i] {
+			if j == i || count == 0 {
+				continue
+			}
+			p := gamma[i] / (gamma[i] + gamma[j])
+			w := count * p * (1 - p)
+			information[i][i] += w
+			information[i][j] -= w
+		}
+	}
+	covariance, err := invert(information)
+	if err != nil {
+		return nil, fmt.Errorf("ratings: %w", err)
+	}
+
+	const eloPerNat = 400 / math.Ln10
+	const z = 1.959963984540054
+	ratings := make([]Rating, n)
+	for i, name := range names {
+		elo := eloPerNat * math.Log(gamma[i])
+		se := eloPerNat * math.Sqrt(math.Max(0, covariance[i][i]-1/float64(n)))
+		ratings[i] = Rating{Name: name, Elo: elo, Low: elo - z*se, High: elo + z*se, Games: played[i]}
+		if played[i] > 0 {
+			ratings[i].Score = scored[i] / float64(played[i])
+		}
+	}
+	sort.SliceStable(ratings, func(i, j int) bool { return ratings[i].Elo > ratings[j].Elo })
+	return ratings, nil
+}
+
+// ratingsConnected rejects a game graph that leaves some entrant with no
+// path of games to the first: the model cannot compare the two sides.
+func ratingsConnected(names []string, games [][]float64) error {
+	seen := make([]bool, len(names))
+	seen[0] = true
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for j, count := range games[i] {
+			if count > 0 && !seen[j] {
+				seen[j] = true
+				stack = append(stack, j)
+			}
+		}
+	}
+	for i, ok := range seen {
+		if !ok {
+			return fmt.Errorf("ratings: %q has no games linking it to %q", names[i], names[0])
+		}
+	}
+	return nil
+}
+
+// invert is Gauss-Jordan elimination with partial pivoting.
+func invert(m [][]float64) ([][]float64, error) {
+	n := len(m)
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, 2*n)
+		copy(a[i], m[i])
+		a[i][n+i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, errors.New("singular information matrix")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		scale := a[col][col]
+		for k := range a[col] {
+			a[col][k] /= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			factor := a[row][col]
+			for k := range a[row] {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+	inverse := make([][]float64, n)
+	for i := range inverse {
+		inverse[i] = a[i][n:]
+	}
+	return inverse, nil
+}
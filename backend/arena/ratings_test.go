@@ -0,0 +1,134 @@
+package arena
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestFitRatingsRecoversOrderAndSymmetry(t *testing.T) {
+	// Three entrants 0, +100 and +200 Elo apart, 1000 games a pair at the
+	// model's expected scores.
+	strength := map[string]float64{"weak": 0, "mid": 100, "strong": 200}
+	names := []string{"weak", "mid", "strong"}
+	var pairs []RatingPair
+	for j, b := range names {
+		for _, a := range names[:j] {
+			wins := int(math.Round(1000 * eloScore(strength[a]-strength[b])))
+			pairs = append(pairs, RatingPair{A: a, B: b, Games: 1000, WinsA: wins, WinsB: 1000 - wins})
+		}
+	}
+	ratings, err := FitRatings(names, pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratings[0].Name != "strong" || ratings[1].Name != "mid" || ratings[2].Name != "weak" {
+		t.Fatalf("order = %+v", ratings)
+	}
+	// The prior pulls toward equality, slightly, so allow a few Elo.
+	if gap := ratings[0].Elo - ratings[2].Elo; math.Abs(gap-200) > 5 {
+		t.Fatalf("strong-weak gap %.1f, want ~200", gap)
+	}
+	if mean := (ratings[0].Elo + ratings[1].Elo + ratings[2].Elo) / 3; math.Abs(mean) > 1e-6 {
+		t.Fatalf("ratings mean %.6f, want 0", mean)
+	}
+	for _, r := range ratings {
+		if !(r.Low < r.Elo && r.Elo < r.High) || r.High-r.Low > 60 || r.Games != 2000 {
+			t.Fatalf("interval %+v", r)
+		}
+	}
+
+	// An even record rates both sides 0 with the same interval, and a
+	// perfect one stays finite.
+	even, err := FitRatings([]string{"a", "b"}, []RatingPair{{A: "a", B: "b", Games: 10, WinsA: 4, WinsB: 4, Draws: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(even[0].Elo) > 1e-9 || math.Abs(even[0].High-even[1].High) > 1e-9 || even[0].Score != 0.5 {
+		t.Fatalf("even = %+v", even)
+	}
+	perfect, err := FitRatings([]string{"a", "b"}, []RatingPair{{A: "a", B: "b", Games: 10, WinsA: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.IsInf(perfect[0].Elo, 0) || perfect[0].Elo <= 0 || perfect[0].Score != 1 {
+		t.Fatalf("perfect = %+v", perfect)
+	}
+}
+
+func TestFitRatingsRejectsDisconnectedEntrants(t *testing.T) {
+	pairs := []RatingPair{{A: "a", B: "b", Games: 4, WinsA: 2, WinsB: 2}, {A: "c", B: "d", Games: 4, WinsA: 3, WinsB: 1}}
+	if _, err := FitRatings([]string{"a", "b", "c", "d"}, pairs); err == nil {
+		t.Fatal("fitted two groups with no games between them")
+	}
+}
+
+func TestRoundRobinAddsEntrantsWithoutReplaying(t *testing.T) {
+	entrants := map[string]RatingEntrant{}
+	for name, agent := range map[string]Agent{"greedy": Greedy, "base": BaseAttacker, "ownerbot": OwnerBot} {
+		entrants[name] = RatingEntrant{Name: name, New: func() TelemetryAgent { return Instrument(agent) }}
+	}
+	entrants["random"] = RatingEntrant{Name: "random", New: func() TelemetryAgent { return Instrument(Random(1)) }, Serial: true}
+
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	file := NewRatingsFile(8, 8, 2, nil)
+	for _, name := range []string{"greedy", "base", "random"} {
+		file.AddEntrant(name)
+	}
+	var played []RatingPair
+	save := func(pair RatingPair) error {
+		played = append(played, pair)
+		return WriteRatingsFile(path, file)
+	}
+	if err := PlayRoundRobin(&file, nil, entrants, 2, save); err != nil {
+		t.Fatal(err)
+	}
+	if len(played) != 3 {
+		t.Fatalf("first run played %d pairs, want 3", len(played))
+	}
+	for _, pair := range played {
+		if pair.Games != 4 || pair.WinsA+pair.WinsB+pair.Draws != 4 || pair.Pentanomial.Pairs() != 2 {
+			t.Fatalf("pair %+v", pair)
+		}
+	}
+
+	reloaded, err := ReadRatingsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.AddEntrant("ownerbot")
+	reloaded.AddEntrant("greedy")
+	played = nil
+	file = reloaded
+	if err := PlayRoundRobin(&file, nil, entrants, 2, save); err != nil {
+		t.Fatal(err)
+	}
+	if len(played) != 3 {
+		t.Fatalf("second run played %d pairs, want ownerbot's 3", len(played))
+	}
+	for _, pair := range played {
+		if pair.B != "ownerbot" {
+			t.Fatalf("second run replayed %s vs %s", pair.A, pair.B)
+		}
+	}
+	if len(file.Missing()) != 0 || len(file.Pairs) != 6 {
+		t.Fatalf("missing %v after %d pairs", file.Missing(), len(file.Pairs))
+	}
+	if err := file.Fit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Ratings) != 4 {
+		t.Fatalf("ratings = %+v", file.Ratings)
+	}
+	for _, r := range file.Ratings {
+		if r.Games != 12 {
+			t.Fatalf("%s played %d games, want 12", r.Name, r.Games)
+		}
+	}
+
+	delete(entrants, "random")
+	file.AddEntrant("greedy")
+	if err := PlayRoundRobin(&file, nil, entrants, 1, nil); err == nil {
+		t.Fatal("played a file whose entrant cannot be built")
+	}
+}
@@ -0,0 +1,158 @@
+package arena
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"virusgame/game"
+)
+
+// GameRecord is every decision Play applied, in order, with the telemetry the
+// agent reported for it. Start is the Match's Initial snapshot; nil means the
+// empty rows x cols board.
+type GameRecord struct {
+	Rows, Cols int
+	Players    int
+	Start      *game.Snapshot
+	Decisions  []RecordedDecision
+	// Offender is the seat whose decision ended the game illegally (an
+	// illegal action or none with legal actions left), 0 otherwise. Its
+	// rejected decision is not in Decisions.
+	Offender game.Player
+}
+
+// RecordedDecision is one applied action and what its agent said about it.
+type RecordedDecision struct {
+	Player    game.Player
+	Action    game.Action
+	Telemetry DecisionTelemetry
+}
+
+// recordDir, when VS_ARENA_RECORD names a directory, makes every Play record
+// its game and write the ones that end illegally, stalled or at MaxActions
+// there as Replay fixtures, plus every VS_ARENA_RECORD_EVERY-th game played
+// in the process when that is positive. Read once at init; a failing gate
+// rerun with it set leaves the games that failed it on disk.
+var recordDir = os.Getenv("VS_ARENA_RECORD")
+
+var recordEvery = func() int64 {
+	v := os.Getenv("VS_ARENA_RECORD_EVERY")
+	if v == "" {
+		return 0
+	}
+	every, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || every < 0 {
+		log.Printf("arena: VS_ARENA_RECORD_EVERY=%q ignored, want a non-negative count", v)
+		return 0
+	}
+	return every
+}()
+
+// recordedGames counts games played with recordDir set, for sampling.
+var recordedGames atomic.Int64
+
+// writeRecorded writes result's game to recordDir when it failed or is
+// sampled.
+func writeRecorded(result GameResult) error {
+	failed := result.Illegal != 0 || result.Stalled || result.Maxed
+	sampled := recordEvery > 0 && recordedGames.Add(1)%recordEvery == 0
+	if !failed && !sampled || result.Record == nil || result.Record.Players != 2 {
+		return nil
+	}
+	replay, err := result.Replay("", [2]string{"seat 1", "seat 2"})
+	if err != nil {
+		return err
+	}
+	_, err = WriteReplay(recordDir, replay)
+	return err
+}
+
+// Replay converts a recorded two-player game into the fixture format
+// DecodeReplay and replayinspect read. An empty sourceID becomes "arena-"
+// and a hash of the game, so the same game always gets the same ID. The
+// termination is no_moves for a finished game, illegal_move (won by the other
+// seat) when a decision ended it, and max_actions (no winner) at the cap.
+func (result GameResult) Replay(sourceID string, players [2]string) (Replay, error) {
+	record := result.Record
+	if record == nil {
+		return Replay{}, errors.New("replay: the game was not recorded (Match.Record)")
+	}
+	if record.Players != 2 {
+		return Replay{}, fmt.Errorf("replay: fixtures are two-player, the game had %d", record.Players)
+	}
+	replay := Replay{
+		SourceID: sourceID, Players: players, Rows: record.Rows, Cols: record.Cols,
+		Winner: result.Winner, Start: record.Start,
+	}
+	switch {
+	case record.Offender != 0:
+		replay.Termination, replay.Winner = "illegal_move", 3-record.Offender
+	case result.Maxed:
+		replay.Termination, replay.Winner = "max_actions", 0
+	default:
+		replay.Termination = "no_moves"
+	}
+	for _, decision := range record.Decisions {
+		if n := len(replay.Turns); n == 0 || replay.Turns[n-1].Player != decision.Player {
+			replay.Turns = append(replay.Turns, ReplayTurn{Number: n + 1, Player: decision.Player})
+		}
+		move := replayMove(decision.Action)
+		move.Telemetry = replayTelemetry(decision.Telemetry)
+		turn := &replay.Turns[len(replay.Turns)-1]
+		turn.Actions = append(turn.Actions, move)
+	}
+	if replay.SourceID == "" {
+		encoded, err := json.Marshal(replay)
+		if err != nil {
+			return Replay{}, err
+		}
+		sum := sha256.Sum256(encoded)
+		replay.SourceID = "arena-" + hex.EncodeToString(sum[:8])
+	}
+	return replay, nil
+}
+
+// WriteReplay writes replay into dir as
+// arena-<rows>x<cols>-<termination>-<source id>.json and returns the path.
+func WriteReplay(dir string, replay Replay) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	encoded, err := json.MarshalIndent(replay, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("arena-%dx%d-%s-%s.json", replay.Rows, replay.Cols,
+		strings.ReplaceAll(replay.Termination, "_", "-"), strings.TrimPrefix(replay.SourceID, "arena-"))
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, append(encoded, '\n'), 0o644)
+}
+
+func replayMove(action game.Action) ReplayMove {
+	if action.Kind == game.PlaceNeutrals {
+		return ReplayMove{Kind: "neutral", Neutrals: []game.Pos{action.Neutrals[0], action.Neutrals[1]}}
+	}
+	return ReplayMove{Kind: "move", Row: action.Target.Row, Col: action.Target.Col}
+}
+
+// replayTelemetry keeps the deterministic part of a decision's telemetry;
+// nil for an agent that reported none.
+func replayTelemetry(telemetry DecisionTelemetry) *ReplayTelemetry {
+	if telemetry.Nodes == 0 && telemetry.Depth == 0 && len(telemetry.Alternatives) == 0 {
+		return nil
+	}
+	out := &ReplayTelemetry{Score: telemetry.Score, Depth: telemetry.Depth, Nodes: telemetry.Nodes}
+	for _, alternative := range telemetry.Alternatives {
+		out.Alternatives = append(out.Alternatives, ReplayAlternative{Action: replayMove(alternative.Action), Score: alternative.Score})
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package arena
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"virusgame/game"
+)
+
+func decodeRecorded(t *testing.T, replay Replay) (Replay, map[int]game.State) {
+	t.Helper()
+	encoded, err := json.Marshal(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, states, err := DecodeReplay(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("recorded game does not decode: %v", err)
+	}
+	return decoded, states
+}
+
+func TestRecordedGameReplaysFromItsOpening(t *testing.T) {
+	opening, err := RandomLegalOpening(8, 8, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match := Match{Rows: 8, Cols: 8, Initial: &opening, Record: true,
+		TelemetryAgents: []TelemetryAgent{TelemetryNodeBudget(300, false), Instrument(Greedy)}}
+	result, err := Play(match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Record == nil || len(result.Record.Decisions) != result.Actions {
+		t.Fatalf("recorded %v for %d actions", result.Record, result.Actions)
+	}
+	replay, err := result.Replay("", [2]string{"search", "greedy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := result.Replay("", [2]string{"search", "greedy"})
+	if replay.SourceID == "" || replay.SourceID != again.SourceID {
+		t.Fatalf("source IDs %q and %q", replay.SourceID, again.SourceID)
+	}
+	decoded, states := decodeRecorded(t, replay)
+	if decoded.Start == nil || decoded.Termination != "no_moves" || decoded.Winner != result.Winner {
+		t.Fatalf("decoded %s winner %d, played winner %d", decoded.Termination, decoded.Winner, result.Winner)
+	}
+	if last := states[len(decoded.Turns)]; !last.GameOver() {
+		t.Fatal("replayed game is not over")
+	}
+	for _, turn := range decoded.Turns {
+		for _, move := range turn.Actions {
+			if searched := turn.Player == 1; searched != (move.Telemetry != nil) {
+				t.Fatalf("turn %d seat %d telemetry %+v", turn.Number, turn.Player, move.Telemetry)
+			}
+			if move.Telemetry != nil && (move.Telemetry.Nodes == 0 || move.Telemetry.Nodes > 300) {
+				t.Fatalf("turn %d telemetry %+v", turn.Number, move.Telemetry)
+			}
+		}
+	}
+
+	unrecorded := match
+	unrecorded.Record = false
+	if plain, _ := Play(unrecorded); plain.Record != nil || plain.Winner != result.Winner || plain.Actions != result.Actions {
+		t.Fatal("recording changed the game or was kept without Match.Record")
+	}
+}
+
+func TestRecordedFailuresReplay(t *testing.T) {
+	illegal := func(state game.State) (game.Action, bool) {
+		if state.MovesLeft() == 1 {
+			return game.Action{Kind: game.Move, Target: game.Pos{Row: 7, Col: 7}}, true
+		}
+		return Greedy(state)
+	}
+	result, err := Play(Match{Rows: 8, Cols: 8, Agents: []Agent{Greedy, illegal}, Record: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := result.Replay("illegal", [2]string{"greedy", "illegal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _ := decodeRecorded(t, replay); decoded.Termination != "illegal_move" || decoded.Winner != 1 || result.Record.Offender != 2 {
+		t.Fatalf("illegal game recorded as %s winner %d", decoded.Termination, decoded.Winner)
+	}
+
+	result, err = Play(Match{Rows: 8, Cols: 8, Agents: []Agent{Greedy, Greedy}, MaxActions: 7, Record: true})
+	if err != nil || !result.Maxed {
+		t.Fatalf("maxed game: %+v, %v", result, err)
+	}
+	replay, err = result.Replay("maxed", [2]string{"greedy", "greedy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _ := decodeRecorded(t, replay); decoded.Termination != "max_actions" || decoded.Winner != 0 || len(decoded.Turns) != 3 {
+		t.Fatalf("maxed game recorded as %s winner %d in %d turns", decoded.Termination, decoded.Winner, len(decoded.Turns))
+	}
+}
+
+func TestRecordDirWritesFailedAndSampledGames(t *testing.T) {
+	dir := t.TempDir()
+	defer func(d string, every int64) { recordDir, recordEvery = d, every }(recordDir, recordEvery)
+	recordDir, recordEvery = dir, 0
+
+	if _, err := Play(Match{Rows: 8, Cols: 8, Agents: []Agent{Greedy, BaseAttacker}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Play(Match{Rows: 8, Cols: 8, Agents: []Agent{Greedy, BaseAttacker}, MaxActions: 5}); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(written) != 1 || !strings.HasPrefix(filepath.Base(written[0]), "arena-8x8-max-actions-") {
+		t.Fatalf("wrote %v, want only the maxed game", written)
+	}
+	fixture, err := os.Open(written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixture.Close()
+	if _, _, err := DecodeReplay(fixture); err != nil {
+		t.Fatalf("written fixture does not decode: %v", err)
+	}
+
+	recordEvery = 1
+	if _, err := Play(Match{Rows: 8, Cols: 8, Agents: []Agent{Greedy, BaseAttacker}}); err != nil {
+		t.Fatal(err)
+	}
+	if written, _ := filepath.Glob(filepath.Join(dir, "arena-8x8-no-moves-*.json")); len(written) != 1 {
+		t.Fatalf("sampled game files %v", written)
+	}
+}
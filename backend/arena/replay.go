@@ -10,16 +10,20 @@ import (
 
 // Replay is the compact, engine-independent form of a recorded game. It can
 // be checked into testdata without database timestamps or user metadata.
+// Start, when set, is the position the first turn is played from (an arena
+// game from a Match.Initial opening); otherwise play starts on the empty
+// board.
 type Replay struct {
-	SourceID      string       `json:"source_id"`
-	Players       [2]string    `json:"players"`
-	Rows          int          `json:"rows"`
-	Cols          int          `json:"cols"`
-	Winner        game.Player  `json:"winner"`
-	Termination   string       `json:"termination"`
-	ObservedTurns int          `json:"observed_turns,omitempty"`
-	OmittedMoves  int          `json:"omitted_moves,omitempty"`
-	Turns         []ReplayTurn `json:"turns"`
+	SourceID      string         `json:"source_id"`
+	Players       [2]string      `json:"players"`
+	Rows          int            `json:"rows"`
+	Cols          int            `json:"cols"`
+	Winner        game.Player    `json:"winner"`
+	Termination   string         `json:"termination"`
+	ObservedTurns int            `json:"observed_turns,omitempty"`
+	OmittedMoves  int            `json:"omitted_moves,omitempty"`
+	Start         *game.Snapshot `json:"start,omitempty"`
+	Turns         []ReplayTurn   `json:"turns"`
 }
 
 type ReplayTurn struct {
@@ -33,6 +37,23 @@ type ReplayMove struct {
 	Row      int        `json:"row,omitempty"`
 	Col      int        `json:"col,omitempty"`
 	Neutrals []game.Pos `json:"neutrals,omitempty"`
+	// Telemetry is the search's readout of an arena decision, when the
+	// agent reported one.
+	Telemetry *ReplayTelemetry `json:"telemetry,omitempty"`
+}
+
+// ReplayTelemetry is a recorded decision's score, completed action depth,
+// node count and next-best root alternatives (see search.Result).
+type ReplayTelemetry struct {
+	Score        int                 `json:"score"`
+	Depth        int                 `json:"depth"`
+	Nodes        uint64              `json:"nodes"`
+	Alternatives []ReplayAlternative `json:"alternatives,omitempty"`
+}
+
+type ReplayAlternative struct {
+	Action ReplayMove `json:"action"`
+	Score  int        `json:"score"`
 }
 
 type ReplayPoint struct {
@@ -72,6 +93,12 @@ func DecodeReplay(reader io.Reader) (Replay, map[int]game.State, error) {
 // copying brittle board snapshots into fixtures.
 func ReplayPositions(replay Replay) (map[ReplayPoint]game.State, error) {
 	state, err := game.New(replay.Rows, replay.Cols, 2)
+	if replay.Start != nil {
+		state, err = game.FromSnapshot(*replay.Start)
+		if err == nil && (state.Rows() != replay.Rows || state.Cols() != replay.Cols || state.Players() != 2) {
+			err = fmt.Errorf("start is %dx%d with %d players, want %dx%d with 2", state.Rows(), state.Cols(), state.Players(), replay.Rows, replay.Cols)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("new replay game: %w", err)
 	}
@@ -100,6 +127,13 @@ func validateReplayResult(replay Replay, state game.State) error {
 	if replay.ObservedTurns != 0 && replay.ObservedTurns < len(replay.Turns) || replay.OmittedMoves > 0 && replay.ObservedTurns <= len(replay.Turns) {
 		return fmt.Errorf("final result: inconsistent observed turns or omitted moves")
 	}
+	if replay.Termination == "max_actions" {
+		// An arena game stopped at Match.MaxActions: unfinished, no winner.
+		if replay.Winner != 0 || state.GameOver() {
+			return fmt.Errorf("final result: got over=%v winner=%d, want an unfinished max_actions game", state.GameOver(), replay.Winner)
+		}
+		return nil
+	}
 	if replay.Winner < 1 || replay.Winner > 2 {
 		return fmt.Errorf("final result: invalid winner %d", replay.Winner)
 	}
@@ -0,0 +1,48 @@
+package arena
+
+import (
+	"os"
+	"testing"
+
+	"virusgame/search"
+)
+
+// TestSelectiveSearchLadder measures each selective-search technique (vs-ai2.61)
+// against the plain search at the SAME node budget. Equal nodes is the only fair
+// axis: every technique exists to buy depth with nodes, so a wall-clock match
+// would also reward whichever variant happens to be cheaper per node.
+//
+// Each row plays the technique alone, then everything together, from the
+// seeded 12x12 openings with SPRT-style early stopping at 50%. It is a
+// measurement only and fails solely on illegal/stalled/maxed games; a technique
+// earns a default-on switch from this table, never from the unit tests.
+//
+// Reproduce:
+//
+//	VS_SELECTIVE_LADDER=1 go test ./arena -run TestSelectiveSearchLadder -v -timeout 120m
+//
+// Knobs: VS_SELECTIVE_NODES (default 20000), VS_SELECTIVE_OPENINGS (default 20).
+func TestSelectiveSearchLadder(t *testing.T) {
+	if os.Getenv("VS_SELECTIVE_LADDER") != "1" {
+		t.Skip("set VS_SELECTIVE_LADDER=1 to run the slow selective-search ladder")
+	}
+	nodes := uint64(envInt(t, "VS_SELECTIVE_NODES", 20_000))
+	openings := envInt(t, "VS_SELECTIVE_OPENINGS", 20)
+	plain := TelemetryNodeBudget(nodes, false)
+	for _, sel := range []search.Selectivity{
+		{Aspiration: true},
+		{LateMoveReductions: true},
+		{Futility: true},
+		{NullMove: true},
+		search.AllSelectivity(),
+	} {
+		result, err := PlaySequentialOpenings(nil, 12, 12, openings, 50, sequentialMinGames, TelemetryNodeBudgetSelective(nodes, sel), plain, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", sel, err)
+		}
+		interval := Wilson95(result.Wins, result.Games)
+		t.Logf("%-32s vs plain (nodes=%d): W-L-D %d-%d-%d win=%.1f%% %s wilson95=[%.1f%%, %.1f%%] stopped=%v",
+			sel, nodes, result.Wins, result.Losses, result.Draws, result.WinRate(), result.Report.Elo(),
+			interval.Low, interval.High, result.Stopped)
+	}
+}
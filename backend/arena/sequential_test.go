@@ -24,7 +24,7 @@ func wilsonDecision(wins, games int, thresholdPct float64, minGames int) (stop,
 // t.Fatalf's on a returned error, preserving the historical gate behavior.
 func playSequentialOpenings(t *testing.T, label string, maxOpenings int, thresholdPct float64, minGames int, a, b TelemetryAgent) SequentialResult {
 	t.Helper()
-	result, err := PlaySequentialOpenings(12, 12, maxOpenings, thresholdPct, minGames, a, b, 1)
+	result, err := PlaySequentialOpenings(nil, 12, 12, maxOpenings, thresholdPct, minGames, a, b, 1)
 	if err != nil {
 		t.Fatalf("%s: %v", label, err)
 	}
@@ -0,0 +1,257 @@
+package arena
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"virusgame/game"
+)
+
+// vs-ai2.71: SPRT and Elo. WilsonDecision answers "is the win rate above a
+// threshold", which is the right question for a superiority gate but gives no
+// Elo difference and no error-controlled early stop. The balanced seat pairs
+// PlaySequentialOpenings already plays are the unit here: both games of one
+// opening share its bias, so they are counted together as a pentanomial (the
+// contender's pair score 0, ½, 1, 1½ or 2) rather than as two independent
+// games, which keeps the variance honest when an opening favours one seat.
+
+// Pentanomial counts opening pairs by the contender's score over both seats:
+// index k holds the pairs it scored k/2 in (win 1, draw ½, loss 0 per game).
+type Pentanomial [5]int
+
+// AddPair records one opening's two games, the contender seated as
+// focus[0] in first and focus[1] in second.
+func (p *Pentanomial) AddPair(first, second GameResult, focus [2]game.Player) {
+	p[gamePoints(first, focus[0])+gamePoints(second, focus[1])]++
+}
+
+// gamePoints is the contender's score in half points: 2 win, 1 draw, 0 loss.
+func gamePoints(result GameResult, focus game.Player) int {
+	switch result.Winner {
+	case focus:
+		return 2
+	case 0:
+		return 1
+	}
+	return 0
+}
+
+// Pairs is the number of opening pairs counted.
+func (p Pentanomial) Pairs() int {
+	return p[0] + p[1] + p[2] + p[3] + p[4]
+}
+
+// moments returns the number of pairs, the mean per-game score and the
+// variance of a pair's mean score, with prior added to every count.
+func (p Pentanomial) moments(prior float64) (n, mean, variance float64) {
+	for _, count := range p {
+		n += float64(count) + prior
+	}
+	if p.Pairs() == 0 {
+		return 0, 0, 0
+	}
+	for k, count := range p {
+		mean += (float64(count) + prior) * float64(k) / 4
+	}
+	mean /= n
+	for k, count := range p {
+		d := float64(k)/4 - mean
+		variance += (float64(count) + prior) * d * d
+	}
+	return n, mean, variance / n
+}
+
+// llrPrior is the pseudo-count LLR adds to every pentanomial cell: one
+// pseudo-pair spread evenly. It keeps the variance of a short one-sided
+// record from collapsing (which would accept H1 after a single won pair)
+// while it still accumulates evidence, and washes out as pairs come in.
+const llrPrior = 0.2
+
+// Score is the contender's mean score per game, draws counting half.
+func (p Pentanomial) Score() float64 {
+	_, mean, _ := p.moments(0)
+	return mean
+}
+
+// EloEstimate is a logistic Elo difference with its 95% interval and the
+// likelihood of superiority (the probability the true difference is > 0).
+type EloEstimate struct {
+	Elo, Low, High float64
+	LOS            float64
+}
+
+func (e EloEstimate) String() string {
+	return fmt.Sprintf("elo=%+.1f [%+.1f,%+.1f] los=%.1f%%", e.Elo, e.Low, e.High, 100*e.LOS)
+}
+
+// Elo estimates the contender's Elo difference from the pair scores. A
+// one-sided record (every pair won, or lost) has an infinite point estimate.
+func (p Pentanomial) Elo() EloEstimate {
+	return eloEstimate(p.moments(0))
+}
+
+// Elo estimates the focus player's Elo difference from its games, draws
+// counting half. It treats games as independent; for paired openings
+// Pentanomial.Elo gives the sounder interval.
+func (r Report) Elo() EloEstimate {
+	n := float64(r.Games)
+	if n == 0 {
+		return EloEstimate{LOS: 0.5}
+	}
+	mean := (float64(r.Wins) + 0.5*float64(r.Draws)) / n
+	variance := (float64(r.Wins)*(1-mean)*(1-mean) + float64(r.Draws)*(0.5-mean)*(0.5-mean) + float64(r.Losses)*mean*mean) / n
+	return eloEstimate(n, mean, variance)
+}
+
+// eloEstimate is the estimate from n samples of a score with the given mean
+// and variance.
+func eloEstimate(n, mean, variance float64) EloEstimate {
+	if n == 0 {
+		return EloEstimate{LOS: 0.5}
+	}
+	se := math.Sqrt(variance / n)
+	const z = 1.959963984540054
+	estimate := EloEstimate{Elo: scoreElo(mean), Low: scoreElo(mean - z*se), High: scoreElo(mean + z*se)}
+	switch {
+	case se > 0:
+		estimate.LOS = 0.5 * (1 + math.Erf((mean-0.5)/(se*math.Sqrt2)))
+	case mean > 0.5:
+		estimate.LOS = 1
+	case mean < 0.5:
+		estimate.LOS = 0
+	default:
+		estimate.LOS = 0.5
+	}
+	return estimate
+}
+
+// scoreElo is the logistic Elo difference that scores s per game; scores at
+// or past 0 and 1 map to ∓Inf.
+func scoreElo(s float64) float64 {
+	if s <= 0 {
+		return math.Inf(-1)
+	}
+	if s >= 1 {
+		return math.Inf(1)
+	}
+	return -400 * math.Log10(1/s-1)
+}
+
+// eloScore is scoreElo's inverse.
+func eloScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// SPRT is a sequential probability ratio test of H0: the contender's logistic
+// Elo difference is Elo0 against H1: it is Elo1, with false-positive rate
+// Alpha (accepting H1 when H0 holds) and false-negative rate Beta.
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+}
+
+// SPRTDecision is where a test stands.
+type SPRTDecision int
+
+const (
+	// SPRTContinue: the LLR is between the bounds, keep playing.
+	SPRTContinue SPRTDecision = iota
+	// SPRTAcceptH0: the contender is no better than Elo0.
+	SPRTAcceptH0
+	// SPRTAcceptH1: the contender is at least Elo1.
+	SPRTAcceptH1
+)
+
+func (d SPRTDecision) String() string {
+	switch d {
+	case SPRTAcceptH0:
+		return "H0"
+	case SPRTAcceptH1:
+		return "H1"
+	}
+	return "continue"
+}
+
+// Validate rejects bounds the test cannot run with.
+func (s SPRT) Validate() error {
+	if !(s.Elo1 > s.Elo0) {
+		return fmt.Errorf("sprt: elo1 %.1f must exceed elo0 %.1f", s.Elo1, s.Elo0)
+	}
+	if s.Alpha <= 0 || s.Alpha >= 0.5 || s.Beta <= 0 || s.Beta >= 0.5 {
+		return errors.New("sprt: alpha and beta must lie in (0, 0.5)")
+	}
+	return nil
+}
+
+// Bounds are the LLR thresholds: at or below lower accept H0, at or above
+// upper accept H1 (Wald's approximations).
+func (s SPRT) Bounds() (lower, upper float64) {
+	return math.Log(s.Beta / (1 - s.Alpha)), math.Log((1 - s.Beta) / s.Alpha)
+}
+
+// LLR is the log-likelihood ratio of H1 over H0 given the pair scores, by the
+// normal approximation to the pentanomial model (the generalized SPRT): with
+// per-game score means s0, s1 under the hypotheses and the observed pair mean
+// m and variance v over N pairs, LLR = N (s1 - s0) (2m - s0 - s1) / (2v).
+// Counts carry a llrPrior pseudo-count; the LLR of no pairs is 0.
+func (s SPRT) LLR(p Pentanomial) float64 {
+	n, mean, variance := p.moments(llrPrior)
+	if n == 0 || variance <= 0 {
+		return 0
+	}
+	s0, s1 := eloScore(s.Elo0), eloScore(s.Elo1)
+	return n * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Decide is the test's verdict on the pairs so far, with the LLR it rests on.
+func (s SPRT) Decide(p Pentanomial) (SPRTDecision, float64) {
+	llr := s.LLR(p)
+	lower, upper := s.Bounds()
+	switch {
+	case llr >= upper:
+		return SPRTAcceptH1, llr
+	case llr <= lower:
+		return SPRTAcceptH0, llr
+	}
+	return SPRTContinue, llr
+}
+
+// SPRTResult is a Report plus the test that stopped it.
+type SPRTResult struct {
+	Report
+	SPRT        SPRT
+	Pentanomial Pentanomial
+	LLR         float64
+	Decision    SPRTDecision
+	Elo         EloEstimate
+}
+
+func (r SPRTResult) String() string {
+	lower, upper := r.SPRT.Bounds()
+	return fmt.Sprintf("%s pairs=%d penta=%v llr=%.2f [%.2f,%.2f] sprt=%s %s",
+		r.Report, r.Pentanomial.Pairs(), [5]int(r.Pentanomial), r.LLR, lower, upper, r.Decision, r.Elo)
+}
+
+// PlaySPRTOpenings plays balanced pairs of seeded openings exactly as
+// PlaySequentialOpenings does (same openings from suite or the seeds, same
+// permutation, same determinism across worker counts) and stops when sprt accepts either hypothesis or after
+// maxOpenings. progress, when non-nil, sees the result after every pair.
+func PlaySPRTOpenings(suite *OpeningSuite, rows, cols, maxOpenings int, sprt SPRT, a, b TelemetryAgent, workers int, progress func(SPRTResult)) (SPRTResult, error) {
+	result := SPRTResult{SPRT: sprt}
+	if err := sprt.Validate(); err != nil {
+		return result, err
+	}
+	err := playOpeningPairs(suite, rows, cols, maxOpenings, a, b, workers, func(pair openingPair) bool {
+		result.Add(pair.seat[0], game.Player(1))
+		result.Add(pair.seat[1], game.Player(2))
+		result.Pentanomial.AddPair(pair.seat[0], pair.seat[1], [2]game.Player{1, 2})
+		result.Decision, result.LLR = sprt.Decide(result.Pentanomial)
+		result.Elo = result.Pentanomial.Elo()
+		if progress != nil {
+			progress(result)
+		}
+		return result.Decision != SPRTContinue
+	})
+	return result, err
+}
@@ -0,0 +1,92 @@
+package arena
+
+import (
+	"math"
+	"testing"
+
+	"virusgame/game"
+)
+
+// TestPentanomialEloAndLLR pins the statistics to values worked by hand.
+func TestPentanomialEloAndLLR(t *testing.T) {
+	p := Pentanomial{1, 2, 4, 6, 3}
+	near := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-6 {
+			t.Fatalf("%s = %.9f, want %.9f", name, got, want)
+		}
+	}
+	near("score", p.Score(), 0.625)
+	elo := p.Elo()
+	near("elo", elo.Elo, 88.739499847)
+	near("low", elo.Low, -8.309913516)
+	near("high", elo.High, 202.109681187)
+	near("los", elo.LOS, 0.963180865)
+	sprt := SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+	near("llr", sprt.LLR(p), 0.330468524)
+	lower, upper := sprt.Bounds()
+	near("lower", lower, math.Log(0.05/0.95))
+	near("upper", upper, math.Log(0.95/0.05))
+	if decision, _ := sprt.Decide(p); decision != SPRTContinue {
+		t.Fatalf("16 pairs decided %s", decision)
+	}
+
+	// Even pairs say nothing about Elo; a long one-sided record is decisive.
+	even := Pentanomial{0, 0, 20, 0, 0}
+	if e := even.Elo(); e.Elo != 0 || e.LOS != 0.5 || e.Low != 0 || e.High != 0 {
+		t.Fatalf("even pairs %s", e)
+	}
+	// A single won pair is not; the prior keeps its variance from vanishing.
+	if decision, llr := sprt.Decide(Pentanomial{0, 0, 0, 0, 1}); decision != SPRTContinue {
+		t.Fatalf("one won pair: %s at llr %.2f", decision, llr)
+	}
+	if decision, llr := sprt.Decide(Pentanomial{0, 0, 0, 0, 30}); decision != SPRTAcceptH1 {
+		t.Fatalf("30 won pairs: %s at llr %.2f", decision, llr)
+	}
+	if decision, llr := sprt.Decide(Pentanomial{30, 0, 0, 0, 0}); decision != SPRTAcceptH0 {
+		t.Fatalf("30 lost pairs: %s at llr %.2f", decision, llr)
+	}
+	if err := (SPRT{Elo0: 5, Elo1: 5, Alpha: 0.05, Beta: 0.05}).Validate(); err == nil {
+		t.Fatal("elo0 == elo1 accepted")
+	}
+}
+
+// TestAddPairCountsBothSeats: the pair's cell is the contender's points over
+// both of its seats.
+func TestAddPairCountsBothSeats(t *testing.T) {
+	var p Pentanomial
+	p.AddPair(GameResult{Winner: 1}, GameResult{Winner: 1}, [2]game.Player{1, 2}) // win, loss
+	p.AddPair(GameResult{Winner: 1}, GameResult{Winner: 2}, [2]game.Player{1, 2}) // win, win
+	p.AddPair(GameResult{}, GameResult{Winner: 1}, [2]game.Player{1, 2})          // draw, loss
+	if p != (Pentanomial{0, 1, 1, 0, 1}) {
+		t.Fatalf("pentanomial %v", p)
+	}
+}
+
+// TestPlaySPRTOpeningsStopsDeterministically: a lopsided match accepts H1
+// after a few pairs, the same way for any worker count, and the running
+// progress ends on the returned result.
+func TestPlaySPRTOpeningsStopsDeterministically(t *testing.T) {
+	sprt := SPRT{Elo0: 0, Elo1: 50, Alpha: 0.05, Beta: 0.05}
+	var llrs []float64
+	serial, err := PlaySPRTOpenings(nil, 8, 8, 20, sprt, Instrument(Greedy), firstLegalAgent, 1, func(r SPRTResult) { llrs = append(llrs, r.LLR) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := PlaySPRTOpenings(nil, 8, 8, 20, sprt, Instrument(Greedy), firstLegalAgent, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial.Decision != SPRTAcceptH1 || serial.Pentanomial.Pairs() >= 20 {
+		t.Fatalf("lopsided match: %s", serial)
+	}
+	if serial.Pentanomial != parallel.Pentanomial || serial.LLR != parallel.LLR || serial.Games != parallel.Games {
+		t.Fatalf("workers changed the run: serial %s, parallel %s", serial, parallel)
+	}
+	if len(llrs) != serial.Pentanomial.Pairs() || llrs[len(llrs)-1] != serial.LLR {
+		t.Fatalf("progress saw %d pairs ending at %v, result %s", len(llrs), llrs, serial)
+	}
+	if serial.Elo.LOS < 0.99 {
+		t.Fatalf("lopsided LOS %.3f", serial.Elo.LOS)
+	}
+}
@@ -0,0 +1,58 @@
+// Package storedgames reads the server's games database into
+// arena.StoredGame rows. It lives apart from package arena so only the
+// commands that read the database (bookgen, nnuegen -human) link the SQLite
+// driver.
+package storedgames
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+
+	"virusgame/arena"
+)
+
+// Read reads every game of a games database (the server's SQLite file),
+// oldest first.
+func Read(path string) ([]arena.StoredGame, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+		SELECT id, rows, cols, player1_name, player2_name, player3_name, player4_name,
+		       result, termination, pgn_content
+		FROM games
+		ORDER BY started_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer rows.Close()
+	var games []arena.StoredGame
+	for rows.Next() {
+		var stored arena.StoredGame
+		var p1, p2, p3, p4, termination, pgn sql.NullString
+		var result sql.NullInt64
+		if err := rows.Scan(&stored.ID, &stored.Rows, &stored.Cols, &p1, &p2, &p3, &p4,
+			&result, &termination, &pgn); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		stored.Player1, stored.Player2, stored.Player3, stored.Player4 = p1.String, p2.String, p3.String, p4.String
+		stored.Result, stored.Termination = int(result.Int64), termination.String
+		if pgn.String != "" {
+			if err := json.Unmarshal([]byte(pgn.String), &stored.PGN); err != nil {
+				return nil, fmt.Errorf("%s: game %s pgn: %w", path, stored.ID, err)
+			}
+		}
+		games = append(games, stored)
+	}
+	return games, rows.Err()
+}
@@ -0,0 +1,503 @@
+package arena
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"virusgame/game"
+)
+
+// vs-ai2.73: tactical suites. The one-decision gates used to hand-build their
+// positions in Go, so adding a case meant writing a test. A suite file holds
+// them as data, one position per line in the manner of chess EPD: the board,
+// the side to move, and opcodes naming the actions a correct engine plays
+// (bm) or avoids (am), an id and a motif tag. RunSuite solves every case with
+// any TelemetryAgent and scores it per motif; cmd/suite is the command line.
+//
+// A line is
+//
+//	<board> <seat to move> <moves left> <neutral-used seats or -> <opcodes>
+//
+// with the board in the compact glyph form ParseBoard reads and each opcode
+// "name operands;". Opcodes:
+//
+//	bm <action>...   pass when the engine plays any of these
+//	am <action>...   pass when it plays none of these
+//	id "<text>"      the case's name, unique in its file
+//	motif "<text>"   the tag scores are grouped by
+//	nodes <n>        this case's node budget, instead of the runner's
+//	c0 "<text>"      free comment
+//
+// An action is a move "row,col" or a neutral placement "n:row,col:row,col".
+// A case with neither bm nor am is a reference position: other gates load it
+// by id and the runner skips it. Blank lines and lines starting with # are
+// ignored.
+
+// SuiteCase is one position of a suite.
+type SuiteCase struct {
+	ID, Motif, Comment string
+	// Board, ToMove, MovesLeft and NeutralUsed are the position as written.
+	Board       string
+	ToMove      game.Player
+	MovesLeft   int
+	NeutralUsed string
+	Best, Avoid []game.Action
+	// Nodes overrides the runner's node budget when positive.
+	Nodes uint64
+	// Line is the case's line in its file.
+	Line int
+}
+
+// Scored reports whether the case has a bm or am to score against.
+func (c SuiteCase) Scored() bool {
+	return len(c.Best) > 0 || len(c.Avoid) > 0
+}
+
+// State builds the case's position.
+func (c SuiteCase) State() (game.State, error) {
+	return ParseBoard(c.Board, c.ToMove, c.MovesLeft, c.NeutralUsed)
+}
+
+// Passes reports whether action solves the case: one of Best, when any are
+// given, and none of Avoid.
+func (c SuiteCase) Passes(action game.Action) bool {
+	if len(c.Best) > 0 && !containsAction(c.Best, action) {
+		return false
+	}
+	return !containsAction(c.Avoid, action)
+}
+
+func containsAction(actions []game.Action, action game.Action) bool {
+	for _, candidate := range actions {
+		if sameAction(candidate, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameAction compares actions with a neutral pair in either order.
+func sameAction(a, b game.Action) bool {
+	if a.Kind != b.Kind {
+		return false
+	}
+	if a.Kind == game.PlaceNeutrals {
+		return a.Neutrals == b.Neutrals || a.Neutrals == [2]game.Pos{b.Neutrals[1], b.Neutrals[0]}
+	}
+	return a.Target == b.Target
+}
+
+// String renders the case as a suite line.
+func (c SuiteCase) String() string {
+	neutral := c.NeutralUsed
+	if neutral == "" {
+		neutral = "-"
+	}
+	fields := []string{c.Board, strconv.Itoa(int(c.ToMove)), strconv.Itoa(c.MovesLeft), neutral}
+	opcode := func(name string, actions []game.Action) {
+		if len(actions) == 0 {
+			return
+		}
+		operands := make([]string, len(actions))
+		for i, action := range actions {
+			operands[i] = FormatAction(action)
+		}
+		fields = append(fields, name+" "+strings.Join(operands, " ")+";")
+	}
+	opcode("bm", c.Best)
+	opcode("am", c.Avoid)
+	if c.ID != "" {
+		fields = append(fields, fmt.Sprintf("id %q;", c.ID))
+	}
+	if c.Motif != "" {
+		fields = append(fields, fmt.Sprintf("motif %q;", c.Motif))
+	}
+	if c.Nodes > 0 {
+		fields = append(fields, fmt.Sprintf("nodes %d;", c.Nodes))
+	}
+	if c.Comment != "" {
+		fields = append(fields, fmt.Sprintf("c0 %q;", c.Comment))
+	}
+	return strings.Join(fields, " ")
+}
+
+// ReadSuite parses a suite file.
+func ReadSuite(path string) ([]SuiteCase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	cases, err := ParseSuite(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// ParseSuite parses suite lines, checking every position and that each bm
+// and am action is legal in it.
+func ParseSuite(reader io.Reader) ([]SuiteCase, error) {
+	var cases []SuiteCase
+	ids := map[string]int{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		c, err := parseSuiteLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		c.Line = line
+		if c.ID == "" {
+			c.ID = "line-" + strconv.Itoa(line)
+		}
+		if previous, dup := ids[c.ID]; dup {
+			return nil, fmt.Errorf("line %d: id %q already used on line %d", line, c.ID, previous)
+		}
+		ids[c.ID] = line
+		cases = append(cases, c)
+	}
+	return cases, scanner.Err()
+}
+
+func parseSuiteLine(text string) (SuiteCase, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 {
+		return SuiteCase{}, errors.New("want <board> <to move> <moves left> <neutral used> before the opcodes")
+	}
+	toMove, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return SuiteCase{}, fmt.Errorf("seat to move %q", fields[1])
+	}
+	movesLeft, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return SuiteCase{}, fmt.Errorf("moves left %q", fields[2])
+	}
+	c := SuiteCase{Board: fields[0], ToMove: game.Player(toMove), MovesLeft: movesLeft, NeutralUsed: fields[3]}
+	if c.NeutralUsed == "-" {
+		c.NeutralUsed = ""
+	}
+	state, err := c.State()
+	if err != nil {
+		return SuiteCase{}, err
+	}
+
+	// The opcodes are everything after the fourth field.
+	rest := text
+	for i := 0; i < 4; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		if cut := strings.IndexAny(rest, " \t"); cut >= 0 {
+			rest = rest[cut:]
+		} else {
+			rest = ""
+		}
+	}
+	for _, op := range splitOpcodes(rest) {
+		name, operands, _ := strings.Cut(op, " ")
+		operands = strings.TrimSpace(operands)
+		switch name {
+		case "bm", "am":
+			for _, operand := range strings.Fields(operands) {
+				action, err := ParseAction(operand)
+				if err != nil {
+					return SuiteCase{}, fmt.Errorf("%s: %w", name, err)
+				}
+				if _, err := state.Apply(action); err != nil {
+					return SuiteCase{}, fmt.Errorf("%s %s is not legal here: %w", name, operand, err)
+				}
+				if name == "bm" {
+					c.Best = append(c.Best, action)
+				} else {
+					c.Avoid = append(c.Avoid, action)
+				}
+			}
+		case "id", "motif", "c0":
+			value, err := strconv.Unquote(operands)
+			if err != nil {
+				return SuiteCase{}, fmt.Errorf("%s wants a quoted string, got %s", name, operands)
+			}
+			switch name {
+			case "id":
+				c.ID = value
+			case "motif":
+				c.Motif = value
+			default:
+				c.Comment = value
+			}
+		case "nodes":
+			nodes, err := strconv.ParseUint(operands, 10, 64)
+			if err != nil || nodes == 0 {
+				return SuiteCase{}, fmt.Errorf("nodes %q: want a positive count", operands)
+			}
+			c.Nodes = nodes
+		default:
+			return SuiteCase{}, fmt.Errorf("unknown opcode %q", name)
+		}
+	}
+	return c, nil
+}
+
+// splitOpcodes splits "a x; b \"y;z\";" at the semicolons outside quotes.
+func splitOpcodes(text string) []string {
+	var ops []string
+	quoted, start := false, 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				if op := strings.TrimSpace(text[start:i]); op != "" {
+					ops = append(ops, op)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if op := strings.TrimSpace(text[start:]); op != "" {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// ParseAction reads "row,col" (a move) or "n:row,col:row,col" (neutrals).
+func ParseAction(text string) (game.Action, error) {
+	if rest, ok := strings.CutPrefix(text, "n:"); ok {
+		first, second, ok := strings.Cut(rest, ":")
+		if !ok {
+			return game.Action{}, fmt.Errorf("neutral action %q wants two cells", text)
+		}
+		a, errA := parsePos(first)
+		b, errB := parsePos(second)
+		if errA != nil || errB != nil {
+			return game.Action{}, fmt.Errorf("neutral action %q", text)
+		}
+		return game.Action{Kind: game.PlaceNeutrals, Neutrals: [2]game.Pos{a, b}}, nil
+	}
+	pos, err := parsePos(text)
+	if err != nil {
+		return game.Action{}, fmt.Errorf("action %q: want row,col or n:row,col:row,col", text)
+	}
+	return game.Action{Kind: game.Move, Target: pos}, nil
+}
+
+func parsePos(text string) (game.Pos, error) {
+	row, col, ok := strings.Cut(text, ",")
+	if !ok {
+		return game.Pos{}, errors.New("want row,col")
+	}
+	r, errR := strconv.Atoi(row)
+	c, errC := strconv.Atoi(col)
+	if errR != nil || errC != nil {
+		return game.Pos{}, errors.New("want row,col")
+	}
+	return game.Pos{Row: r, Col: c}, nil
+}
+
+// FormatAction is ParseAction's inverse.
+func FormatAction(action game.Action) string {
+	if action.Kind == game.PlaceNeutrals {
+		a, b := action.Neutrals[0], action.Neutrals[1]
+		return fmt.Sprintf("n:%d,%d:%d,%d", a.Row, a.Col, b.Row, b.Col)
+	}
+	return fmt.Sprintf("%d,%d", action.Target.Row, action.Target.Col)
+}
+
+// ParseBoard builds a state from the compact board string: rows separated by
+// '/', one glyph per cell —
+//
+//	.  empty          #  neutral
+//	1-4  Normal cell of that seat
+//	A-D  base of seat 1-4 (bases sit in their corners)
+//	a-d  Fortified cell of seat 1-4
+//
+// The seat count is the highest seat with a base on the board; a seat whose
+// base is gone is out of play. neutralUsed lists the seats, as digits, that
+// have placed their neutrals.
+func ParseBoard(text string, toMove game.Player, movesLeft int, neutralUsed string) (game.State, error) {
+	lines := strings.Split(strings.TrimSpace(text), "/")
+	rows, cols := len(lines), len(lines[0])
+	board := make([][]game.Cell, rows)
+	players := 0
+	for row, line := range lines {
+		if len(line) != cols {
+			return game.State{}, fmt.Errorf("row %d has %d cells, row 0 has %d", row, len(line), cols)
+		}
+		board[row] = make([]game.Cell, cols)
+		for col, glyph := range line {
+			var cell game.Cell
+			switch {
+			case glyph == '.':
+			case glyph == '#':
+				cell.Kind = game.Neutral
+			case glyph >= '1' && glyph <= '4':
+				cell = game.Cell{Owner: game.Player(glyph - '0'), Kind: game.Normal}
+			case glyph >= 'A' && glyph <= 'D':
+				cell = game.Cell{Owner: game.Player(glyph-'A') + 1, Kind: game.Base}
+				players = max(players, int(cell.Owner))
+			case glyph >= 'a' && glyph <= 'd':
+				cell = game.Cell{Owner: game.Player(glyph-'a') + 1, Kind: game.Fortified}
+			default:
+				return game.State{}, fmt.Errorf("row %d col %d: unknown glyph %q", row, col, glyph)
+			}
+			board[row][col] = cell
+		}
+	}
+	if players < 2 {
+		return game.State{}, errors.New("position needs the bases of at least two seats")
+	}
+	snapshot := game.Snapshot{
+		Rows: rows, Cols: cols, Board: board,
+		Active: make([]bool, players), NeutralUsed: make([]bool, players),
+		Current: toMove, MovesLeft: movesLeft,
+	}
+	corners := []game.Pos{{Row: 0, Col: 0}, {Row: rows - 1, Col: cols - 1}, {Row: 0, Col: cols - 1}, {Row: rows - 1, Col: 0}}
+	for seat := 1; seat <= players; seat++ {
+		base := corners[seat-1]
+		snapshot.Bases = append(snapshot.Bases, base)
+		cell := board[base.Row][base.Col]
+		snapshot.Active[seat-1] = cell.Owner == game.Player(seat) && cell.Kind == game.Base
+	}
+	for _, glyph := range neutralUsed {
+		seat, err := strconv.Atoi(string(glyph))
+		if err != nil || seat < 1 || seat > players {
+			return game.State{}, fmt.Errorf("neutral-used %q: want seat digits 1-%d", neutralUsed, players)
+		}
+		snapshot.NeutralUsed[seat-1] = true
+	}
+	state, err := game.FromSnapshot(snapshot)
+	if err != nil {
+		return game.State{}, fmt.Errorf("position does not make a valid state: %w", err)
+	}
+	return state, nil
+}
+
+// FormatBoard is ParseBoard's inverse for the board; the seat to move, moves
+// left and neutral use come from the state's accessors.
+func FormatBoard(state game.State) string {
+	rows := make([]string, state.Rows())
+	for row := range rows {
+		line := make([]byte, state.Cols())
+		for col := range line {
+			cell, _ := state.At(game.Pos{Row: row, Col: col})
+			line[col] = CellGlyph(cell)
+		}
+		rows[row] = string(line)
+	}
+	return strings.Join(rows, "/")
+}
+
+// CellGlyph is a cell's glyph in the compact board form.
+func CellGlyph(cell game.Cell) byte {
+	switch cell.Kind {
+	case game.Normal:
+		return '0' + byte(cell.Owner)
+	case game.Base:
+		return 'A' + byte(cell.Owner) - 1
+	case game.Fortified:
+		return 'a' + byte(cell.Owner) - 1
+	case game.Neutral:
+		return '#'
+	}
+	return '.'
+}
+
+// SuiteCaseFor writes state as a suite case with the given id and motif; the
+// caller fills in Best or Avoid.
+func SuiteCaseFor(state game.State, id, motif string) SuiteCase {
+	var neutral strings.Builder
+	for seat := game.Player(1); int(seat) <= state.Players(); seat++ {
+		if state.NeutralUsed(seat) {
+			neutral.WriteByte('0' + byte(seat))
+		}
+	}
+	return SuiteCase{
+		ID: id, Motif: motif, Board: FormatBoard(state),
+		ToMove: state.CurrentPlayer(), MovesLeft: state.MovesLeft(), NeutralUsed: neutral.String(),
+	}
+}
+
+// SuiteResult is one case solved.
+type SuiteResult struct {
+	Case      SuiteCase
+	Action    game.Action
+	Telemetry DecisionTelemetry
+	Passed    bool
+}
+
+// MotifScore is the cases of one motif solved.
+type MotifScore struct {
+	Motif          string
+	Passed, Scored int
+}
+
+// SuiteReport is a suite run: every scored case and the per-motif totals,
+// motifs in name order.
+type SuiteReport struct {
+	Results []SuiteResult
+	Motifs  []MotifScore
+	// Skipped counts reference positions (no bm or am).
+	Skipped int
+}
+
+// Passed and Scored total the motifs.
+func (r SuiteReport) Passed() (passed, scored int) {
+	for _, m := range r.Motifs {
+		passed += m.Passed
+		scored += m.Scored
+	}
+	return passed, scored
+}
+
+// RunSuite solves every scored case with the agent engine builds for its node
+// budget — the case's Nodes, else nodes — and scores the chosen action. An
+// engine that returns no action fails the case.
+func RunSuite(cases []SuiteCase, nodes uint64, engine func(nodes uint64) TelemetryAgent) (SuiteReport, error) {
+	var report SuiteReport
+	motifs := map[string]*MotifScore{}
+	for _, c := range cases {
+		if !c.Scored() {
+			report.Skipped++
+			continue
+		}
+		state, err := c.State()
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", c.ID, err)
+		}
+		budget := nodes
+		if c.Nodes > 0 {
+			budget = c.Nodes
+		}
+		action, telemetry, ok := engine(budget)(state)
+		result := SuiteResult{Case: c, Action: action, Telemetry: telemetry, Passed: ok && c.Passes(action)}
+		report.Results = append(report.Results, result)
+		score := motifs[c.Motif]
+		if score == nil {
+			score = &MotifScore{Motif: c.Motif}
+			motifs[c.Motif] = score
+		}
+		score.Scored++
+		if result.Passed {
+			score.Passed++
+		}
+	}
+	for _, score := range motifs {
+		report.Motifs = append(report.Motifs, *score)
+	}
+	sort.Slice(report.Motifs, func(i, j int) bool { return report.Motifs[i].Motif < report.Motifs[j].Motif })
+	return report, nil
+}
@@ -0,0 +1,135 @@
+package arena
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"virusgame/game"
+)
+
+// suitePosition loads the position of case id from suites/<file>, for the
+// gates that keep their positions there.
+func suitePosition(t *testing.T, file, id string) game.State {
+	t.Helper()
+	cases, err := ReadSuite(filepath.Join("suites", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if c.ID == id {
+			state, err := c.State()
+			if err != nil {
+				t.Fatalf("%s %s: %v", file, id, err)
+			}
+			return state
+		}
+	}
+	t.Fatalf("%s has no case %q", file, id)
+	return game.State{}
+}
+
+func TestParseBoardRoundTrips(t *testing.T) {
+	text := "A1#.C/.1a../..3.3/.22../D...B"
+	state, err := ParseBoard(text, 2, 1, "13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Players() != 4 || state.CurrentPlayer() != 2 || state.MovesLeft() != 1 ||
+		!state.NeutralUsed(1) || state.NeutralUsed(2) || !state.NeutralUsed(3) {
+		t.Fatalf("state: %d seats, seat %d to move with %d left", state.Players(), state.CurrentPlayer(), state.MovesLeft())
+	}
+	if got := FormatBoard(state); got != text {
+		t.Fatalf("rendered %q, parsed %q", got, text)
+	}
+	for _, bad := range []string{"A1/..B", "A?/.B", "11/..", "A./.B/"} {
+		if _, err := ParseBoard(bad, 1, 3, ""); err == nil {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+	if _, err := ParseBoard("A./.B", 1, 3, "3"); err == nil {
+		t.Error("neutral-used accepted a seat the board lacks")
+	}
+}
+
+func TestSuiteLinesRoundTripAndScore(t *testing.T) {
+	const text = `# a comment
+
+A..../.11../..2../....B 2 3 - bm 2,3 3,3; id "take"; motif "capture"; nodes 50; c0 "semi; colon";
+A..../.11../..2../....B 1 3 2 am n:1,1:1,2 2,2; motif "neutral";
+A..../.1.../..2../....B 1 3 12 id "reference";
+`
+	cases, err := ParseSuite(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 3 {
+		t.Fatalf("%d cases", len(cases))
+	}
+	take, neutral, reference := cases[0], cases[1], cases[2]
+	if take.ID != "take" || take.Motif != "capture" || take.Nodes != 50 || take.Comment != "semi; colon" || len(take.Best) != 2 || take.Line != 3 {
+		t.Fatalf("take = %+v", take)
+	}
+	if neutral.ID != "line-4" || neutral.NeutralUsed != "2" || len(neutral.Avoid) != 2 || reference.Scored() {
+		t.Fatalf("neutral = %+v, reference = %+v", neutral, reference)
+	}
+	reparsed, err := ParseSuite(strings.NewReader(take.String() + "\n" + neutral.String()))
+	if err != nil || reparsed[0].String() != take.String() || reparsed[1].String() != neutral.String() {
+		t.Fatalf("round trip: %v\n%s\n%s", err, take, neutral)
+	}
+
+	swapped := game.Action{Kind: game.PlaceNeutrals, Neutrals: [2]game.Pos{{Row: 1, Col: 2}, {Row: 1, Col: 1}}}
+	if !take.Passes(game.Action{Kind: game.Move, Target: game.Pos{Row: 3, Col: 3}}) ||
+		take.Passes(game.Action{Kind: game.Move, Target: game.Pos{Row: 1, Col: 2}}) || neutral.Passes(swapped) {
+		t.Fatal("bm/am scoring")
+	}
+
+	var budgets []uint64
+	report, err := RunSuite(cases, 7, func(nodes uint64) TelemetryAgent {
+		budgets = append(budgets, nodes)
+		return Instrument(func(game.State) (game.Action, bool) {
+			return game.Action{Kind: game.Move, Target: game.Pos{Row: 2, Col: 3}}, true
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	passed, scored := report.Passed()
+	if passed != 2 || scored != 2 || report.Skipped != 1 || len(report.Motifs) != 2 || report.Motifs[0].Motif != "capture" ||
+		budgets[0] != 50 || budgets[1] != 7 {
+		t.Fatalf("report %+v, budgets %v", report, budgets)
+	}
+
+	for _, bad := range []string{
+		"A..../.1.../..2../....B 1 3 12 bm 3,3;",  // out of reach, not a legal move
+		"A..../.1.../..2../....B 1 3 12 zz 1;",    // unknown opcode
+		"A..../.1.../..2../....B 1 3 12 id take;", // unquoted
+		"A..../.1.../..2../....B 1 3 12 bm 1,2; id \"x\";\n" + // duplicate id
+			"A..../.1.../..2../....B 1 3 12 bm 1,2; id \"x\";",
+	} {
+		if _, err := ParseSuite(strings.NewReader(bad)); err == nil {
+			t.Errorf("parsed %q", bad)
+		}
+	}
+}
+
+// TestCheckedInSuites parses every suite file: each position valid, each bm
+// and am legal, every case tagged with a motif. Solving them is cmd/suite's
+// job and the opt-in gates'.
+func TestCheckedInSuites(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("suites", "*.epd"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no suites: %v", err)
+	}
+	for _, path := range paths {
+		cases, err := ReadSuite(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range cases {
+			if c.Motif == "" || strings.HasPrefix(c.ID, "line-") {
+				t.Errorf("%s line %d: every checked-in case needs an id and a motif", path, c.Line)
+			}
+		}
+	}
+}
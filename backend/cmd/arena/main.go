@@ -7,9 +7,12 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"virusgame/arena"
+	"virusgame/engine"
 	"virusgame/search"
 )
 
@@ -18,7 +21,12 @@ func main() {
 	depth := flag.Int("depth", 3, "deterministic action depth")
 	production := flag.Bool("production", false, "use the deployed anytime search path and budget")
 	nodeBudget := flag.Uint64("node-budget", 0, "deterministic equal-node budget without a wall deadline")
-	opponent := flag.String("opponent", "all", "opponent to run: all, incumbent, random, legacy, greedy, base, or mobility")
+	selective := flag.String("selective", "", "node-budget contender selective search: comma list of aspiration, lmr, futility, nullmove, or all")
+	paramsPath := flag.String("params", "", "node-budget contender eval weights: EvalParams JSON (spsatune bestTheta, texeltune -out)")
+	policyPath := flag.String("policy", "", "node-budget contender move-ordering policy weights (nnue-train -policy)")
+	policyKeep := flag.Int("policy-keep", 0, "with -policy: quiet actions kept per node, 0 keeps all")
+	opponent := flag.String("opponent", "all", "opponents to run: all (incumbent, random, legacy, greedy, base, mobility) or a comma list of engine specs")
+	contenderSpec := flag.String("contender", "", "contender engine spec, e.g. search:v2?params=tuned.json; unbudgeted specs play the mode budget")
 	matrix := flag.String("matrix", "ci", "board matrix: ci or full (manual variable-size/time gate)")
 	corpusPath := flag.String("corpus", "", "frozen strength corpus JSON; replaces repeated empty-board openings")
 	corpusSplit := flag.String("corpus-split", "train", "frozen corpus split: train (default) or explicitly requested heldout")
@@ -27,7 +35,28 @@ func main() {
 	parallel := flag.Int("parallel", defaultParallelism(runtime.GOMAXPROCS(0)), "maximum concurrent board shards")
 	jsonOutput := flag.Bool("json", false, "emit machine-readable corpus report")
 	enforceGate := flag.Bool("enforce-corpus-gate", true, "hard-fail incumbent train superiority thresholds")
+	sprtMode := flag.Bool("sprt", false, "run an SPRT over balanced opening pairs instead of the tournament")
+	elo0 := flag.Float64("elo0", 0, "-sprt: Elo difference under H0")
+	elo1 := flag.Float64("elo1", 10, "-sprt: Elo difference under H1")
+	alpha := flag.Float64("alpha", 0.05, "-sprt: false-positive rate")
+	beta := flag.Float64("beta", 0.05, "-sprt: false-negative rate")
+	sprtOpenings := flag.Int("sprt-openings", 2000, "-sprt: most opening pairs before giving up undecided")
+	sprtBoard := flag.String("sprt-board", "12x12", "-sprt: board of the seeded openings")
+	openingSuite := flag.String("opening-suite", "", "opening suite file (openinggen) whose openings for -sprt-board replace the seeded random ones; needs -sprt")
+	journalPath := flag.String("journal", "", "corpus mode: append finished games to this run journal and skip those already in it")
+	mergeJournals := flag.String("merge-journals", "", "comma list of run journals to merge into one corpus report instead of playing")
 	flag.Parse()
+	var suite *arena.OpeningSuite
+	if *openingSuite != "" {
+		if !*sprtMode {
+			log.Fatal("-opening-suite needs -sprt")
+		}
+		loaded, err := arena.ReadOpeningSuite(*openingSuite)
+		if err != nil {
+			log.Fatal(err)
+		}
+		suite = &loaded
+	}
 	boards := []arena.Board{{Rows: 5, Cols: 5}, {Rows: 6, Cols: 6}, {Rows: 8, Cols: 8}}
 	if *matrix == "full" {
 		boards = []arena.Board{
@@ -41,67 +70,130 @@ func main() {
 	contender := arena.Tournament(*depth)
 	telemetryContender := arena.TelemetryTournament(*depth)
 	mode := fmt.Sprintf("fixed-depth=%d", *depth)
+	// newContender is the contender of one corpus game. A -contender spec
+	// builds a fresh agent per game, seeded 1 like the opponents, so no game
+	// inherits another's RNG, tree or book stream; a Serial spec also plays
+	// its games one at a time.
+	newContender := func() arena.TelemetryAgent { return telemetryContender }
+	contenderSerial := false
 	if *production {
 		contender = arena.Production()
 		telemetryContender = arena.TelemetryProduction()
 		mode = "production-budget"
 	}
-	if *nodeBudget > 0 {
+	sel, err := search.ParseSelectivity(*selective)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if sel.Any() && *nodeBudget == 0 {
+		log.Fatal("-selective requires -node-budget")
+	}
+	if *paramsPath != "" && *nodeBudget == 0 {
+		log.Fatal("-params requires -node-budget")
+	}
+	if *policyPath != "" && *nodeBudget == 0 {
+		log.Fatal("-policy requires -node-budget")
+	}
+	if *policyKeep != 0 && *policyPath == "" {
+		log.Fatal("-policy-keep requires -policy")
+	}
+	if *contenderSpec != "" {
+		if sel.Any() || *paramsPath != "" || *policyPath != "" {
+			log.Fatal("-contender takes its selectivity, params and policy in the spec")
+		}
+		spec, err := engine.ParseSpec(*contenderSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		built, err := arena.BuildEngineSpec(modeBudget(spec, *nodeBudget, *depth, *production))
+		if err != nil {
+			log.Fatal(err)
+		}
+		telemetryContender = built.New(1)
+		contender = arena.Strip(built.New(1))
+		newContender = func() arena.TelemetryAgent { return built.New(1) }
+		contenderSerial = built.Serial
+		mode = "contender=" + built.Spec
+	} else if *nodeBudget > 0 {
 		telemetryContender = arena.TelemetryNodeBudget(*nodeBudget, false)
 		mode = fmt.Sprintf("node-budget=%d", *nodeBudget)
+		if sel.Any() {
+			telemetryContender = arena.TelemetryNodeBudgetSelective(*nodeBudget, sel)
+			mode += " selective=" + sel.String()
+		}
+		opts := search.Options{Selectivity: sel}
+		if *paramsPath != "" {
+			params, err := search.LoadEvalParams(*paramsPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.Params = &params
+			mode += " params=" + *paramsPath
+		}
+		if *policyPath != "" {
+			if opts.Policy, err = search.ReadPolicyWeights(*policyPath); err != nil {
+				log.Fatal(err)
+			}
+			opts.PolicyKeep = *policyKeep
+			mode += fmt.Sprintf(" policy=%s keep=%d", *policyPath, *policyKeep)
+		}
+		if opts.Params != nil || opts.Policy != nil {
+			telemetryContender = arena.TelemetryNodeBudgetOptions(*nodeBudget, opts)
+		}
 	}
-	if *opponent != "all" && *opponent != "incumbent" && *opponent != "random" && *opponent != "legacy" && *opponent != "greedy" && *opponent != "base" && *opponent != "mobility" {
-		log.Fatalf("unknown opponent %q", *opponent)
+	benchmarks, err := opponentEngines(*opponent, *nodeBudget, *depth, *production)
+	if err != nil {
+		log.Fatal(err)
 	}
 	legacyPassed, greedyPassed, complete := false, false, true
-	benchmarks := []struct {
-		name    string
-		factory arena.TelemetryOpponentFactory
-	}{
-		{name: "incumbent", factory: func(uint64) arena.TelemetryAgent {
-			if *nodeBudget > 0 {
-				return arena.TelemetryNodeBudget(*nodeBudget, true)
-			}
-			if *production {
-				return arena.TelemetryFrozenProduction()
-			}
-			return arena.TelemetryFrozenTournament(*depth)
-		}},
-		{name: "random", factory: func(seed uint64) arena.TelemetryAgent { return arena.Instrument(arena.Random(seed)) }},
-		{name: "legacy", factory: func(seed uint64) arena.TelemetryAgent { return arena.Instrument(arena.Legacy(seed)) }},
-		{name: "greedy", factory: func(uint64) arena.TelemetryAgent { return arena.Instrument(arena.Greedy) }},
-		{name: "base", factory: func(uint64) arena.TelemetryAgent { return arena.Instrument(arena.BaseAttacker) }},
-		{name: "mobility", factory: func(uint64) arena.TelemetryAgent { return arena.Instrument(arena.MobilityAttacker) }},
+	if *sprtMode {
+		runSPRT(arena.SPRT{Elo0: *elo0, Elo1: *elo1, Alpha: *alpha, Beta: *beta}, suite, *sprtBoard, *sprtOpenings, *parallel, mode,
+			telemetryContender, contenderSerial, benchmarks)
+		return
 	}
-	if *corpusPath != "" {
-		fixture, err := os.Open(*corpusPath)
-		if err != nil {
-			log.Fatal(err)
+	if *mergeJournals != "" {
+		if len(benchmarks) != 1 || *corpusPath == "" {
+			log.Fatal("-merge-journals needs the -corpus and -opponent the journals played")
 		}
-		corpus, err := arena.DecodeCorpus(fixture)
-		fixture.Close()
+		corpus := readCorpus(*corpusPath)
+		rows, cols := parseCorpusBoard(*corpusBoard)
+		filter := arena.CorpusFilter{Track: *corpusTrack, Rows: rows, Cols: cols}
+		report, err := arena.MergeJournals(corpus, *corpusSplit, filter, strings.Split(*mergeJournals, ",")...)
 		if err != nil {
 			log.Fatal(err)
 		}
+		printCorpusReport(report, "merged", *opponent, *corpusSplit, *jsonOutput, *enforceGate)
+		return
+	}
+	if *journalPath != "" && (*corpusPath == "" || len(benchmarks) != 1) {
+		log.Fatal("-journal needs -corpus and a single -opponent")
+	}
+	if *corpusPath != "" {
+		corpus := readCorpus(*corpusPath)
 		for _, benchmark := range benchmarks {
-			if *opponent != "all" && *opponent != benchmark.name {
-				continue
+			rows, cols := parseCorpusBoard(*corpusBoard)
+			progress := func(update arena.CorpusProgress) {
+				fmt.Fprintf(os.Stderr, "progress board=%s games=%d\n", update.Board, update.Games)
 			}
-			rows, cols := 0, 0
-			if *corpusBoard != "" {
-				if _, err := fmt.Sscanf(*corpusBoard, "%dx%d", &rows, &cols); err != nil || rows < 2 || cols < 2 {
-					log.Fatalf("invalid corpus board %q", *corpusBoard)
+			var journal *arena.Journal
+			if *journalPath != "" {
+				run := fmt.Sprintf("corpus=%s split=%s track=%s mode=%s opponent=%s",
+					corpus.GroupHashes[*corpusSplit], *corpusSplit, *corpusTrack, mode, benchmark.name)
+				if journal, err = arena.OpenJournal(*journalPath, run); err != nil {
+					log.Fatal(err)
 				}
+				defer journal.Close()
+				fmt.Fprintf(os.Stderr, "journal %s: resuming after %d games\n", *journalPath, journal.Len())
 			}
-			progress := func(update arena.CorpusProgress) {
-				fmt.Fprintf(os.Stderr, "progress board=%s games=%d\n", update.Board, update.Games)
+			workers := *parallel
+			if contenderSerial || benchmark.serial {
+				workers = 1
 			}
 			var report arena.CorpusReport
-			var err error
 			if rows > 0 {
-				report, err = arena.CompareCorpusFiltered(corpus, *corpusSplit, arena.CorpusFilter{Track: *corpusTrack, Rows: rows, Cols: cols}, progress,
-					func() arena.TelemetryAgent { return telemetryContender }, func() arena.TelemetryAgent { return benchmark.factory(1) })
-			} else if *parallel > 1 {
+				report, err = arena.CompareCorpusFiltered(corpus, *corpusSplit, arena.CorpusFilter{Track: *corpusTrack, Rows: rows, Cols: cols, Journal: journal}, progress,
+					newContender, func() arena.TelemetryAgent { return benchmark.factory(1) })
+			} else if workers > 1 {
 				var shardBoards []arena.Board
 				seen := map[arena.Board]bool{}
 				for _, testCase := range corpus.Cases {
@@ -113,44 +205,22 @@ func main() {
 						}
 					}
 				}
-				report, err = arena.CompareCorpusBoards(corpus, *corpusSplit, *corpusTrack, shardBoards, *parallel, progress,
-					func() arena.TelemetryAgent { return telemetryContender }, func() arena.TelemetryAgent { return benchmark.factory(1) })
+				report, err = arena.CompareCorpusBoards(corpus, *corpusSplit, arena.CorpusFilter{Track: *corpusTrack, Journal: journal}, shardBoards, workers, progress,
+					newContender, func() arena.TelemetryAgent { return benchmark.factory(1) })
 			} else {
-				report, err = arena.CompareCorpusFiltered(corpus, *corpusSplit, arena.CorpusFilter{Track: *corpusTrack}, progress,
-					func() arena.TelemetryAgent { return telemetryContender },
+				report, err = arena.CompareCorpusFiltered(corpus, *corpusSplit, arena.CorpusFilter{Track: *corpusTrack, Journal: journal}, progress,
+					newContender,
 					func() arena.TelemetryAgent { return benchmark.factory(1) },
 				)
 			}
 			if err != nil {
 				log.Fatal(err)
 			}
-			if *jsonOutput {
-				if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
-					log.Fatal(err)
-				}
-			} else {
-				fmt.Printf("corpus mode=%s opponent=%s %s\n", mode, benchmark.name, report)
-			}
-			if *enforceGate && benchmark.name == "incumbent" && *corpusSplit == "train" {
-				if err := report.ValidateSuperiority(); err != nil {
-					log.Fatal(err)
-				}
-			}
-			if *jsonOutput {
-				continue
-			}
-			for _, key := range report.SortedBuckets() {
-				bucket := report.Buckets[key]
-				interval := arena.Wilson95(bucket.Wins, bucket.Games)
-				fmt.Printf("  bucket %s %s wilson95=[%.1f%%,%.1f%%]\n", key, bucket, interval.Low, interval.High)
-			}
+			printCorpusReport(report, mode, benchmark.name, *corpusSplit, *jsonOutput, *enforceGate)
 		}
 		return
 	}
 	for _, benchmark := range benchmarks {
-		if *opponent != "all" && *opponent != benchmark.name {
-			continue
-		}
 		report, err := arena.CompareTelemetry(boards, *seeds, telemetryContender, benchmark.factory)
 		if err != nil {
 			log.Fatal(err)
@@ -198,19 +268,95 @@ func main() {
 		}
 	}
 	passed := complete
-	switch *opponent {
-	case "all":
-		passed = passed && legacyPassed && greedyPassed
-	case "legacy":
-		passed = passed && legacyPassed
-	case "greedy":
-		passed = passed && greedyPassed
+	for _, benchmark := range benchmarks {
+		switch benchmark.name {
+		case "legacy":
+			passed = passed && legacyPassed
+		case "greedy":
+			passed = passed && greedyPassed
+		}
 	}
 	if !passed {
 		log.Fatalf("strength gate failed: complete=%v legacy=%v greedy=%v", complete, legacyPassed, greedyPassed)
 	}
 }
 
+// runSPRT plays each selected opponent until the SPRT accepts a hypothesis
+// or the opening cap, printing the running LLR after every pair to stderr.
+// The summary line names the opening source so runs over different openings
+// are never read as one.
+func runSPRT(sprt arena.SPRT, suite *arena.OpeningSuite, board string, openings, parallel int, mode string, contender arena.TelemetryAgent, contenderSerial bool, benchmarks []benchmark) {
+	if err := sprt.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	rows, cols := 0, 0
+	if _, err := fmt.Sscanf(board, "%dx%d", &rows, &cols); err != nil || rows < 2 || cols < 2 {
+		log.Fatalf("invalid -sprt-board %q", board)
+	}
+	lower, upper := sprt.Bounds()
+	for _, benchmark := range benchmarks {
+		workers := parallel
+		if contenderSerial || benchmark.serial {
+			workers = 1 // RNG-carrying and wall-clock agents are neither goroutine-safe nor order-independent
+		}
+		progress := func(r arena.SPRTResult) {
+			fmt.Fprintf(os.Stderr, "sprt opponent=%s pairs=%d games=%d llr=%.2f [%.2f,%.2f] %s\n",
+				benchmark.name, r.Pentanomial.Pairs(), r.Games, r.LLR, lower, upper, r.Elo)
+		}
+		result, err := arena.PlaySPRTOpenings(suite, rows, cols, openings, sprt, contender, benchmark.factory(1), workers, progress)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("sprt mode=%s board=%s openings=%s opponent=%s elo0=%g elo1=%g alpha=%g beta=%g %s\n",
+			mode, board, suite.Source(), benchmark.name, sprt.Elo0, sprt.Elo1, sprt.Alpha, sprt.Beta, result)
+	}
+}
+
+// benchmark is one opponent, named by the spec it was given as so the gates
+// and journals keep reading "incumbent", "legacy" and "greedy".
+type benchmark struct {
+	name    string
+	factory arena.TelemetryOpponentFactory
+	serial  bool
+}
+
+// defaultOpponents are the opponents of -opponent all.
+var defaultOpponents = []string{"incumbent", "random", "legacy", "greedy", "base", "mobility"}
+
+// opponentEngines builds the opponents of -opponent: all or a comma list of
+// engine specs, each without a budget of its own given the mode's.
+func opponentEngines(list string, nodes uint64, depth int, production bool) ([]benchmark, error) {
+	specs := defaultOpponents
+	if list != "all" {
+		specs = strings.Split(list, ",")
+	}
+	benchmarks := make([]benchmark, 0, len(specs))
+	for _, text := range specs {
+		spec, err := engine.ParseSpec(text)
+		if err != nil {
+			return nil, fmt.Errorf("-opponent: %w", err)
+		}
+		built, err := arena.BuildEngineSpec(modeBudget(spec, nodes, depth, production))
+		if err != nil {
+			return nil, fmt.Errorf("-opponent %s: %w", text, err)
+		}
+		benchmarks = append(benchmarks, benchmark{name: text, factory: built.New, serial: built.Serial})
+	}
+	return benchmarks, nil
+}
+
+// modeBudget gives an unbudgeted spec the run's budget: -node-budget, else
+// -depth unless -production keeps the wall clock.
+func modeBudget(spec engine.Spec, nodes uint64, depth int, production bool) engine.Spec {
+	if nodes > 0 {
+		return spec.WithNodes(nodes)
+	}
+	if !production && !spec.Budgeted() && spec.Takes("depth") {
+		return spec.With("depth", strconv.Itoa(depth))
+	}
+	return spec
+}
+
 func defaultParallelism(cpus int) int {
 	if cpus <= 1 {
 		return 1
@@ -230,3 +376,53 @@ func searchBudget(production bool) time.Duration {
 	}
 	return 10 * time.Second
 }
+
+// readCorpus decodes the corpus fixture at path.
+func readCorpus(path string) arena.Corpus {
+	fixture, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fixture.Close()
+	corpus, err := arena.DecodeCorpus(fixture)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return corpus
+}
+
+// parseCorpusBoard parses -corpus-board; an empty flag is every board (0, 0).
+func parseCorpusBoard(board string) (rows, cols int) {
+	if board == "" {
+		return 0, 0
+	}
+	if _, err := fmt.Sscanf(board, "%dx%d", &rows, &cols); err != nil || rows < 2 || cols < 2 {
+		log.Fatalf("invalid corpus board %q", board)
+	}
+	return rows, cols
+}
+
+// printCorpusReport prints a corpus report, as JSON or as text with its
+// buckets, and enforces the incumbent train gate when asked to.
+func printCorpusReport(report arena.CorpusReport, mode, opponent, split string, jsonOutput, enforceGate bool) {
+	if jsonOutput {
+		if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		fmt.Printf("corpus mode=%s opponent=%s %s\n", mode, opponent, report)
+	}
+	if enforceGate && opponent == "incumbent" && split == "train" {
+		if err := report.ValidateSuperiority(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if jsonOutput {
+		return
+	}
+	for _, key := range report.SortedBuckets() {
+		bucket := report.Buckets[key]
+		interval := arena.Wilson95(bucket.Wins, bucket.Games)
+		fmt.Printf("  bucket %s %s wilson95=[%.1f%%,%.1f%%]\n", key, bucket, interval.Low, interval.High)
+	}
+}
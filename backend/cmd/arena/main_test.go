@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"testing"
+
+	"virusgame/engine"
+)
 
 func TestDefaultParallelismLeavesHeadroomAndCapsAtThree(t *testing.T) {
 	tests := map[int]int{0: 1, 1: 1, 2: 1, 3: 2, 4: 3, 8: 3}
@@ -10,3 +14,45 @@ func TestDefaultParallelismLeavesHeadroomAndCapsAtThree(t *testing.T) {
 		}
 	}
 }
+
+func TestOpponentEnginesKeepNamesAndTakeTheModeBudget(t *testing.T) {
+	benchmarks, err := opponentEngines("all", 0, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(benchmarks) != len(defaultOpponents) {
+		t.Fatalf("all built %d opponents, want %d", len(benchmarks), len(defaultOpponents))
+	}
+	for i, benchmark := range benchmarks {
+		if benchmark.name != defaultOpponents[i] {
+			t.Fatalf("opponent %d named %q, want %q", i, benchmark.name, defaultOpponents[i])
+		}
+		if serial := benchmark.name == "random" || benchmark.name == "legacy"; benchmark.serial != serial {
+			t.Fatalf("%s serial=%v", benchmark.name, benchmark.serial)
+		}
+	}
+	if _, err := opponentEngines("greedy,alphazero", 0, 2, false); err == nil {
+		t.Fatal("unknown opponent accepted")
+	}
+
+	for _, tt := range []struct {
+		spec       string
+		nodes      uint64
+		production bool
+		want       string
+	}{
+		{"incumbent", 500, false, "incumbent?nodes=500"},
+		{"incumbent", 0, false, "incumbent?depth=3"},
+		{"incumbent", 0, true, "incumbent"},
+		{"search?nodes=200", 500, false, "search:v2?nodes=200"},
+		{"greedy", 500, false, "greedy"},
+	} {
+		spec, err := engine.ParseSpec(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := modeBudget(spec, tt.nodes, 3, tt.production).String(); got != tt.want {
+			t.Errorf("modeBudget(%s, %d, production=%v) = %s, want %s", tt.spec, tt.nodes, tt.production, got, tt.want)
+		}
+	}
+}
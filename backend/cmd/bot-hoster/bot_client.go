@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"virusgame/engine"
 	"virusgame/game"
 	gamesearch "virusgame/search"
 )
@@ -77,6 +78,12 @@ type Bot struct {
 	searchVersion   uint64
 	searchCancel    context.CancelFunc
 	choose          func(context.Context, game.State) (gamesearch.Result, bool)
+	// chooseClock replaces choose when the server reports the mover's clock.
+	chooseClock func(context.Context, game.State, gamesearch.Clock) (gamesearch.Result, bool)
+	// clock is the bot's own clock as of clockAt. clockAt is zero while
+	// another seat moves: the bot's clock only runs on its own turns.
+	clock   gamesearch.Clock
+	clockAt time.Time
 }
 
 type outboundMessage struct {
@@ -116,6 +123,10 @@ type Message struct {
 	PlayerSymbol     string         `json:"playerSymbol,omitempty"`
 	IsMultiplayer    bool           `json:"isMultiplayer,omitempty"`
 	Snapshot         *game.Snapshot `json:"snapshot,omitempty"`
+	// Clock (optional): the mover's remaining time and per-action increment.
+	// Without it the bot searches for the flat ProductionBudget.
+	ClockMs     *int64 `json:"clockMs,omitempty"`
+	IncrementMs int64  `json:"incrementMs,omitempty"`
 
 	// Diagnostics
 	Score            *float64          `json:"score,omitempty"`
@@ -180,15 +191,38 @@ type CellPos struct {
 
 // NewBot creates a new bot instance
 func NewBot(backendURL string, manager *BotManager) *Bot {
-	return &Bot{
+	bot := &Bot{
 		ID:         fmt.Sprintf("bot-%d", time.Now().UnixNano()),
 		Manager:    manager,
 		BackendURL: backendURL,
 		State:      BotDisconnected,
 		send:       make(chan outboundMessage, 256),
 		done:       make(chan bool),
-		choose:     gamesearch.Choose,
 	}
+	bot.useSearchOptions(gamesearch.Options{})
+	return bot
+}
+
+// useSearchOptions makes the bot search with opts, with or without a clock.
+func (b *Bot) useSearchOptions(opts gamesearch.Options) {
+	b.choose = func(ctx context.Context, state game.State) (gamesearch.Result, bool) {
+		return gamesearch.ChooseOptions(ctx, state, opts)
+	}
+	b.chooseClock = func(ctx context.Context, state game.State, clock gamesearch.Clock) (gamesearch.Result, bool) {
+		opts := opts
+		opts.Clock = clock
+		return gamesearch.ChooseOptions(ctx, state, opts)
+	}
+}
+
+// useChooser makes the bot play an engine's chooser (engine.Engine), which
+// takes the server's clock when it reports one. chooser may keep state
+// between decisions (an MCTS tree), so each bot needs its own.
+func (b *Bot) useChooser(chooser engine.Chooser) {
+	b.choose = func(ctx context.Context, state game.State) (gamesearch.Result, bool) {
+		return chooser(ctx, state, gamesearch.Clock{})
+	}
+	b.chooseClock = chooser
 }
 
 // Connect establishes WebSocket connection to backend
@@ -488,7 +522,7 @@ func (b *Bot) handleGameStart1v1(msg *Message) {
 		log.Printf("[Bot %s] Rejected game start snapshot: %v", b.Username, err)
 		return
 	}
-	b.startGame(msg.GameID, msg.YourPlayer, position, []GamePlayerInfo{
+	b.startGame(msg, position, []GamePlayerInfo{
 		{PlayerIndex: 1, Username: "Player 1", IsActive: true},
 		{PlayerIndex: 2, Username: "Player 2", IsActive: true},
 	})
@@ -528,20 +562,26 @@ func (b *Bot) handleGameStart(msg *Message) {
 		log.Printf("[Bot %s] Rejected game start snapshot: %v", b.Username, err)
 		return
 	}
-	b.startGame(msg.GameID, msg.YourPlayer, position, msg.GamePlayers)
+	b.startGame(msg, position, msg.GamePlayers)
 	log.Printf("[Bot %s] Game started as player %d in game %s", b.Username, msg.YourPlayer, msg.GameID)
 }
 
-func (b *Bot) startGame(gameID string, player int, position game.State, players []GamePlayerInfo) {
+// startGame enters the game msg starts from position, with the clock it
+// reports, if any.
+func (b *Bot) startGame(msg *Message, position game.State, players []GamePlayerInfo) {
 	b.mu.Lock()
 	b.cancelSearchLocked()
 	b.State = BotInGame
-	b.CurrentGame = gameID
-	b.YourPlayer = player
+	b.CurrentGame = msg.GameID
+	b.YourPlayer = msg.YourPlayer
 	b.Position = position
 	b.GamePlayers = append([]GamePlayerInfo(nil), players...)
 	b.positionVersion++
 	b.searchVersion = 0
+	now := time.Now()
+	b.clock, b.clockAt = gamesearch.Clock{}, time.Time{}
+	b.setClockLocked(msg, now)
+	b.runClockLocked(now)
 	b.mu.Unlock()
 	b.startSearch()
 }
@@ -575,9 +615,48 @@ func (b *Bot) handleGameState(msg *Message) {
 		log.Printf("[Bot %s] Rejected game snapshot: %v", b.Username, err)
 		return
 	}
+	b.recordClock(msg)
 	b.startSearch()
 }
 
+// recordClock takes the clock a snapshot reports.
+func (b *Bot) recordClock(msg *Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setClockLocked(msg, time.Now())
+}
+
+// setClockLocked takes a reported clock as the bot's own when it is the bot's
+// turn; the server reports the mover's clock, so on another seat's turn it is
+// not ours. A snapshot without a clock leaves the running clock alone.
+func (b *Bot) setClockLocked(msg *Message, now time.Time) {
+	if msg.ClockMs == nil || *msg.ClockMs <= 0 || !b.toMoveLocked() {
+		return
+	}
+	b.clock = gamesearch.Clock{
+		Remaining: time.Duration(*msg.ClockMs) * time.Millisecond,
+		Increment: time.Duration(msg.IncrementMs) * time.Millisecond,
+	}
+	b.clockAt = now
+}
+
+// runClockLocked starts the bot's clock when the turn passes to it and stops
+// it, charging the time used, when the turn passes on.
+func (b *Bot) runClockLocked(now time.Time) {
+	switch mine := b.toMoveLocked(); {
+	case mine && b.clockAt.IsZero():
+		b.clockAt = now
+	case !mine && !b.clockAt.IsZero():
+		b.clock.Remaining -= now.Sub(b.clockAt)
+		b.clockAt = time.Time{}
+	}
+}
+
+// toMoveLocked reports whether the bot is to move in its current game.
+func (b *Bot) toMoveLocked() bool {
+	return b.State == BotInGame && int(b.Position.CurrentPlayer()) == b.YourPlayer && !b.Position.GameOver()
+}
+
 func decodeSnapshot(msg *Message) (game.State, error) {
 	if msg.Snapshot == nil {
 		return game.State{}, fmt.Errorf("missing snapshot")
@@ -621,6 +700,7 @@ func (b *Bot) updatePosition(msg *Message) error {
 	b.cancelSearchLocked()
 	b.Position = position
 	b.positionVersion++
+	b.runClockLocked(time.Now())
 	for index := range b.GamePlayers {
 		player := game.Player(b.GamePlayers[index].PlayerIndex)
 		b.GamePlayers[index].IsActive = position.Active(player)
@@ -636,12 +716,24 @@ func (b *Bot) startSearch() {
 	}
 	b.cancelSearchLocked()
 	ctx, cancel := context.WithTimeout(context.Background(), gamesearch.ProductionBudget)
+	choose := b.choose
+	if b.clock.Enabled() {
+		// The time manager sizes its own deadline; keep only cancellation.
+		cancel()
+		ctx, cancel = context.WithCancel(context.Background())
+		clock, chooseClock := b.clock, b.chooseClock
+		if !b.clockAt.IsZero() {
+			clock.Remaining -= time.Since(b.clockAt)
+		}
+		choose = func(ctx context.Context, state game.State) (gamesearch.Result, bool) {
+			return chooseClock(ctx, state, clock)
+		}
+	}
 	b.searchCancel = cancel
 	b.searchVersion = b.positionVersion
 	version := b.positionVersion
 	gameID := b.CurrentGame
 	position := b.Position
-	choose := b.choose
 	b.mu.Unlock()
 	go b.calculateAndQueueAction(ctx, choose, position, gameID, version)
 }
@@ -67,7 +67,7 @@ func TestGameEndAndGameChangeInvalidateSearch(t *testing.T) {
 		func(bot *Bot) { bot.handleGameEnd(&Message{GameID: "g", Winner: 2}) },
 		func(bot *Bot) {
 			position, _ := game.New(5, 5, 2)
-			bot.startGame("new", 2, position, nil)
+			bot.startGame(&Message{GameID: "new", YourPlayer: 2}, position, nil)
 		},
 	} {
 		bot := testBot(t, 1)
@@ -89,7 +89,7 @@ func TestGameEndAndGameChangeInvalidateSearch(t *testing.T) {
 func TestOldGameEndCannotCancelNewGame(t *testing.T) {
 	bot := testBot(t, 1)
 	position, _ := game.New(5, 5, 2)
-	bot.startGame("new", 2, position, nil)
+	bot.startGame(&Message{GameID: "new", YourPlayer: 2}, position, nil)
 	bot.handleGameEnd(&Message{GameID: "g", Winner: 2})
 	if bot.State != BotInGame || bot.CurrentGame != "new" {
 		t.Fatalf("stale game end changed current game: state=%v game=%q", bot.State, bot.CurrentGame)
@@ -103,6 +103,88 @@ func TestProductionSearchQueuesDeadlineResult(t *testing.T) {
 	assertStandardMessage(t, message)
 }
 
+func TestServerClockDrivesTimeManagedSearch(t *testing.T) {
+	bot := testBot(t, 1)
+	bot.choose = func(context.Context, game.State) (gamesearch.Result, bool) {
+		t.Error("flat-budget search used despite a server clock")
+		return gamesearch.Result{}, false
+	}
+	clocks := make(chan gamesearch.Clock, 1)
+	bot.chooseClock = func(ctx context.Context, state game.State, clock gamesearch.Clock) (gamesearch.Result, bool) {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("clocked search got a fixed deadline")
+		}
+		clocks <- clock
+		return gamesearch.Result{Action: state.LegalActions()[0]}, true
+	}
+	clockMs := int64(30_000)
+	start := bot.Position.Snapshot()
+	bot.handleGameState(&Message{Type: "game_state", GameID: "g", Snapshot: &start, ClockMs: &clockMs, IncrementMs: 500})
+	receiveAction(t, bot)
+	clock := <-clocks
+	if clock.Remaining > 30*time.Second || clock.Remaining < 29*time.Second || clock.Increment != 500*time.Millisecond {
+		t.Fatalf("clock = %+v, want the reported 30s + 500ms", clock)
+	}
+}
+
+// TestClockRunsOnlyOnTheBotsTurns: game_start hands over the bot's clock, and
+// a slow opponent's thinking time is not charged to the bot.
+func TestClockRunsOnlyOnTheBotsTurns(t *testing.T) {
+	bot := testBot(t, 1)
+	clocks := make(chan gamesearch.Clock, 4)
+	bot.chooseClock = func(_ context.Context, state game.State, clock gamesearch.Clock) (gamesearch.Result, bool) {
+		clocks <- clock
+		return gamesearch.Result{Action: state.LegalActions()[0]}, true
+	}
+	clockMs := int64(30_000)
+	start := bot.Position
+	startSnapshot := start.Snapshot()
+	bot.handleGameStart1v1(&Message{Type: "game_start", GameID: "g2", YourPlayer: 1, Snapshot: &startSnapshot, ClockMs: &clockMs})
+	receiveAction(t, bot)
+	if clock := receiveClock(t, clocks); clock.Remaining > 30*time.Second || clock.Remaining < 29*time.Second {
+		t.Fatalf("game_start clock = %+v, want the reported 30s", clock)
+	}
+
+	// The bot's turn ends; the opponent's report is the opponent's clock.
+	state := start
+	for state.CurrentPlayer() == 1 {
+		state = mustApplyFirst(t, state)
+	}
+	theirs := state.Snapshot()
+	opponentMs := int64(5_000)
+	bot.handleGameState(&Message{Type: "game_state", GameID: "g2", Snapshot: &theirs, ClockMs: &opponentMs})
+	time.Sleep(300 * time.Millisecond) // the opponent thinks
+	for state.CurrentPlayer() == 2 {
+		state = mustApplyFirst(t, state)
+	}
+	mine := state.Snapshot()
+	bot.handleGameState(&Message{Type: "game_state", GameID: "g2", Snapshot: &mine})
+	receiveAction(t, bot)
+	if clock := receiveClock(t, clocks); clock.Remaining < 29*time.Second || clock.Remaining > 30*time.Second {
+		t.Fatalf("clock after a slow opponent = %+v, want about the bot's own 30s", clock)
+	}
+}
+
+func receiveClock(t *testing.T, clocks <-chan gamesearch.Clock) gamesearch.Clock {
+	t.Helper()
+	select {
+	case clock := <-clocks:
+		return clock
+	case <-time.After(time.Second):
+		t.Fatal("the search was not given the server clock")
+		return gamesearch.Clock{}
+	}
+}
+
+func mustApplyFirst(t *testing.T, state game.State) game.State {
+	t.Helper()
+	next, err := state.Apply(state.LegalActions()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return next
+}
+
 func TestActionMessageConversion(t *testing.T) {
 	standard := actionMessage("g", gamesearch.Result{Action: game.Action{Kind: game.Move, Target: game.Pos{Row: 2, Col: 3}}}, 0)
 	assertStandardMessage(t, standard)
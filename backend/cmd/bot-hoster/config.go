@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -19,6 +20,18 @@ type Config struct {
 	// instead of the search's best, per turn. Injects diversity into self-play
 	// data (deterministic search otherwise replays identical games). 0 = off.
 	ExploreEpsilon float64
+	// Engine is the BOT_ENGINE engine spec (engine.ParseSpec): "search"
+	// (default, alpha-beta), "mcts" (Monte Carlo tree search with per-bot
+	// tree reuse), or another engine package engine, e.g.
+	// "search:v2?nodes=200000" or "incumbent". The arena's scripted sparring
+	// agents are not linked in. Without a budget of its own it plays the
+	// server's clock.
+	Engine string
+	// EvalParams lists EvalParams JSON files (BOT_EVAL_PARAMS, comma
+	// separated), set as the spec's params. Bot i plays with file i mod len,
+	// so one pool can host differently weighted personalities. Empty keeps
+	// the spec's weights.
+	EvalParams []string
 }
 
 func LoadConfig() *Config {
@@ -32,6 +45,8 @@ func LoadConfig() *Config {
 		NamePrefix:     getEnv("BOT_NAME_PREFIX", ""),
 		Challenger:     getEnv("BOT_CHALLENGER", "") == "true",
 		ExploreEpsilon: epsilon,
+		Engine:         getEnv("BOT_ENGINE", "search"),
+		EvalParams:     splitList(getEnv("BOT_EVAL_PARAMS", "")),
 	}
 }
 
@@ -41,3 +56,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -6,6 +6,8 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+
+	"virusgame/engine"
 )
 
 func main() {
@@ -15,11 +17,34 @@ func main() {
 	log.Printf("Configuration:")
 	log.Printf("  Backend URL: %s", config.BackendURL)
 	log.Printf("  Pool Size: %d", config.PoolSize)
+	spec, err := engine.ParseSpec(config.Engine)
+	if err != nil {
+		log.Fatalf("BOT_ENGINE: %v", err)
+	}
+	log.Printf("  Engine: %s", spec)
 	if config.NamePrefix != "" {
 		log.Printf("  Bot Name Prefix: %q", config.NamePrefix)
 	}
 
 	manager := NewBotManager(config)
+	personalities := []engine.Spec{spec}
+	if len(config.EvalParams) > 0 {
+		if !spec.Takes("params") {
+			log.Fatalf("BOT_EVAL_PARAMS needs a BOT_ENGINE that takes params, not %s", spec)
+		}
+		personalities = nil
+		for _, path := range config.EvalParams {
+			personalities = append(personalities, spec.With("params", path))
+			log.Printf("  Eval params: %s", path)
+		}
+	}
+	for _, personality := range personalities {
+		built, err := personality.Build()
+		if err != nil {
+			log.Fatalf("BOT_ENGINE %s: %v", personality, err)
+		}
+		manager.engines = append(manager.engines, built)
+	}
 
 	// Start bot pool
 	if err := manager.Start(); err != nil {
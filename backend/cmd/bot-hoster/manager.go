@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"log"
 	"sync"
+
+	"virusgame/engine"
 )
 
 type BotManager struct {
 	config *Config
 	bots   []*Bot
 	mu     sync.RWMutex
+	// engines are the built BOT_ENGINE, one per Config.EvalParams
+	// personality, assigned round-robin. Empty keeps NewBot's search.
+	engines []engine.Engine
 }
 
 func NewBotManager(config *Config) *BotManager {
@@ -25,6 +30,9 @@ func (m *BotManager) Start() error {
 
 	for i := 0; i < m.config.PoolSize; i++ {
 		bot := NewBot(m.config.BackendURL, m)
+		if len(m.engines) > 0 {
+			bot.useChooser(m.engines[i%len(m.engines)].Chooser())
+		}
 
 		// Challenger mode: split the pool so even-indexed bots initiate games
 		// against odd-indexed (acceptor) peers → up to PoolSize/2 concurrent games.
@@ -0,0 +1,208 @@
+// Command evalexplain takes the hand-tuned eval of one position apart: every
+// EvalParams term per seat (metric, weight, contribution), the predatory cuts,
+// the NNUE scores when VS_NNUE is on, and an ASCII overlay of the cells the
+// eval reasons about.
+//
+//	go run ./cmd/evalexplain -snapshot position.json
+//	go run ./cmd/evalexplain -position 'A1..../.11.../....../....../....../.....B' -to-move 2
+//
+// -snapshot reads a game.Snapshot (the server's wire snapshot, or any
+// State.Snapshot() marshalled as JSON). -position is a compact board
+// (arena.ParseBoard, the board field of a suite line): rows separated by '/',
+// one glyph per cell:
+//
+//	.  empty          #  neutral
+//	1-4  Normal cell of that seat
+//	A-D  base of seat 1-4 (bases sit in their corners)
+//	a-d  Fortified cell of seat 1-4
+//
+// The seat count is the highest seat with a base on the board; a seat whose
+// base is gone is out of play. -to-move, -moves-left and -neutral-used fill in
+// the rest of the state.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"virusgame/arena"
+	"virusgame/game"
+	"virusgame/search"
+)
+
+func main() {
+	snapshotPath := flag.String("snapshot", "", "game.Snapshot JSON file")
+	position := flag.String("position", "", "compact board string (see the package doc)")
+	toMove := flag.Int("to-move", 1, "-position: seat to move")
+	movesLeft := flag.Int("moves-left", 3, "-position: actions left in the mover's turn")
+	neutralUsed := flag.String("neutral-used", "", "-position: seats that have placed their neutrals, e.g. 12")
+	player := flag.Int("player", 0, "seat whose eval is headlined (0 = the seat to move)")
+	paramsPath := flag.String("params", "", "EvalParams JSON to explain instead of the process-wide weights")
+	jsonOutput := flag.Bool("json", false, "emit the explanation as JSON")
+	flag.Parse()
+
+	var state game.State
+	var err error
+	switch {
+	case (*snapshotPath == "") == (*position == ""):
+		log.Fatal("pass exactly one of -snapshot or -position")
+	case *snapshotPath != "":
+		state, err = readSnapshot(*snapshotPath)
+	default:
+		state, err = arena.ParseBoard(*position, game.Player(*toMove), *movesLeft, *neutralUsed)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	seat := game.Player(*player)
+	if seat == 0 {
+		seat = state.CurrentPlayer()
+	}
+	if seat < 1 || int(seat) > state.Players() {
+		log.Fatalf("-player %d: the position has %d seats", seat, state.Players())
+	}
+	explained := search.ExplainEval(state, seat)
+	if *paramsPath != "" {
+		params, err := search.LoadEvalParams(*paramsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		explained = search.ExplainEvalParams(state, seat, params)
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(explained); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	writeTable(os.Stdout, state, explained)
+	fmt.Println()
+	writeOverlay(os.Stdout, state, explained)
+}
+
+// readSnapshot loads and validates a game.Snapshot JSON file.
+func readSnapshot(path string) (game.State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return game.State{}, err
+	}
+	var snapshot game.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return game.State{}, fmt.Errorf("%s: %w", path, err)
+	}
+	state, err := game.FromSnapshot(snapshot)
+	if err != nil {
+		return game.State{}, fmt.Errorf("%s: invalid snapshot: %w", path, err)
+	}
+	return state, nil
+}
+
+// writeTable prints every term of every seat, then the totals, cuts and NNUE
+// scores.
+func writeTable(w io.Writer, state game.State, e search.EvalExplanation) {
+	fmt.Fprintf(w, "%dx%d, seat %d to move with %d left; eval for seat %d: %+d\n\n",
+		state.Rows(), state.Cols(), state.CurrentPlayer(), state.MovesLeft(), e.Player, e.Score)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := "term\tweight\t"
+	for _, s := range e.Seats {
+		header += fmt.Sprintf("seat %d metric\tvalue\t", s.Player)
+	}
+	fmt.Fprintln(table, header)
+	var terms []search.EvalTerm
+	for _, s := range e.Seats {
+		if len(s.Terms) > 0 {
+			terms = s.Terms
+			break
+		}
+	}
+	for i, term := range terms {
+		line := fmt.Sprintf("%s\t%d\t", term.Name, term.Weight)
+		for _, s := range e.Seats {
+			if !s.Active {
+				line += "-\t-\t"
+				continue
+			}
+			line += fmt.Sprintf("%d\t%+d\t", s.Terms[i].Metric, s.Terms[i].Value)
+		}
+		fmt.Fprintln(table, line)
+	}
+	for _, row := range []struct {
+		name string
+		get  func(search.SeatEval) string
+	}{
+		{"raw", func(s search.SeatEval) string { return fmt.Sprintf("\t%+d", s.Raw) }},
+		{"score", func(s search.SeatEval) string { return fmt.Sprintf("\t%+d", s.Score) }},
+		{"space race cells", func(s search.SeatEval) string { return fmt.Sprintf("%d\t", s.SpaceRace) }},
+		{"threat tempo", func(s search.SeatEval) string { return fmt.Sprintf("%d\t", s.ThreatTempo) }},
+	} {
+		line := row.name + "\t\t"
+		for _, s := range e.Seats {
+			line += row.get(s) + "\t"
+		}
+		fmt.Fprintln(table, line)
+	}
+	table.Flush()
+	for _, s := range e.Seats {
+		for _, cut := range s.Cuts {
+			fmt.Fprintf(w, "predatory cut: seat %d threatens seat %d at (%d,%d), %d cells, %+d\n",
+				s.Player, cut.Victim, cut.Cell.Row, cut.Cell.Col, cut.Loss, cut.Value)
+		}
+	}
+	if e.NNUE != nil {
+		fmt.Fprint(w, "nnue:")
+		for _, s := range e.Seats {
+			fmt.Fprintf(w, " seat %d %+d", s.Player, e.NNUE[s.Player-1])
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeOverlay prints the board beside the eval's view of it.
+func writeOverlay(w io.Writer, state game.State, e search.EvalExplanation) {
+	fmt.Fprintf(w, "%-*s   overlay\n", state.Cols(), "board")
+	for row := 0; row < state.Rows(); row++ {
+		var board, overlay strings.Builder
+		for col := 0; col < state.Cols(); col++ {
+			cell, _ := state.At(game.Pos{Row: row, Col: col})
+			board.WriteByte(arena.CellGlyph(cell))
+			overlay.WriteByte(overlayGlyph(cell, e, row*state.Cols()+col))
+		}
+		fmt.Fprintf(w, "%s   %s\n", board.String(), overlay.String())
+	}
+	fmt.Fprintln(w, "\noverlay: X articulation and threatened, * articulation, ! threatened, o other owned,")
+	fmt.Fprintln(w, "         1-4 empty cell the seat reaches first, + contested, . unreached, # neutral, @ base")
+}
+
+func overlayGlyph(cell game.Cell, e search.EvalExplanation, index int) byte {
+	switch cell.Kind {
+	case game.Empty:
+		switch owner := e.Voronoi[index]; {
+		case owner > 0:
+			return '0' + byte(owner)
+		case owner < 0:
+			return '+'
+		}
+		return '.'
+	case game.Neutral:
+		return '#'
+	case game.Base:
+		return '@'
+	}
+	switch cut, threatened := e.Articulation[index], e.Threatened[index]; {
+	case cut && threatened:
+		return 'X'
+	case cut:
+		return '*'
+	case threatened:
+		return '!'
+	}
+	return 'o'
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"virusgame/arena"
+	"virusgame/search"
+)
+
+// TestSnapshotFileMatchesPosition: a snapshot file of a -position state
+// reads back to the same eval.
+func TestSnapshotFileMatchesPosition(t *testing.T) {
+	state, err := arena.ParseBoard("A1#.C/.1a../..3.3/.22../D...B", 2, 1, "13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(state.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if search.StaticEval(read, 2) != search.StaticEval(state, 2) {
+		t.Fatal("snapshot file evaluates differently")
+	}
+}
+
+// TestReportShowsTermsAndOverlay: the table lists every weight with the
+// headline score, and the overlay marks the chain's cut cell and the regions.
+func TestReportShowsTermsAndOverlay(t *testing.T) {
+	state, err := arena.ParseBoard("A...../.1..../..1.../....../....../.....B", 2, 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explained := search.ExplainEval(state, 2)
+	var out bytes.Buffer
+	writeTable(&out, state, explained)
+	for _, want := range []string{"SpaceRace", "PredatoryCutLossDiv", "space race cells", "eval for seat 2: "} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("table lacks %q:\n%s", want, out.String())
+		}
+	}
+	out.Reset()
+	writeOverlay(&out, state, explained)
+	lines := strings.Split(out.String(), "\n")
+	// Row 1: the (1,1) cell holds (2,2) to the base.
+	if overlay := strings.Fields(lines[2])[1]; overlay[1] != '*' {
+		t.Fatalf("row 1 overlay %q, want the cut cell at col 1", overlay)
+	}
+	if overlay := strings.Fields(lines[6])[1]; overlay[5] != '@' || !strings.Contains(overlay, "2") {
+		t.Fatalf("row 5 overlay %q, want seat 2's base and region", overlay)
+	}
+}
@@ -0,0 +1,174 @@
+// Command suite solves tactical suite files (arena.ParseSuite's EPD-style
+// lines) with one engine and reports pass/fail per case and per motif.
+//
+//	go run ./cmd/suite arena/suites/*.epd
+//	go run ./cmd/suite -engine incumbent -nodes 30000 arena/suites/exchange.epd
+//	go run ./cmd/suite -engine search -movetime 1s -params tuned.json arena/suites/*.epd
+//
+// -engine is an engine spec (engine.ParseSpec), e.g. search, incumbent,
+// nnue?net=candidate.nnue, search:v2?params=tuned.json or mcts, whose
+// iterations stand in for nodes. -params, -policy and -nnue-weights set the
+// spec's params, policy and net. Each case runs at its own nodes opcode, else
+// -nodes, unless the spec names its own budget; -movetime switches to a
+// wall-clock budget and ignores both. Reference cases (no bm or am) are
+// skipped. The exit status is 1 when -strict is set and a case fails.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"virusgame/arena"
+	"virusgame/engine"
+)
+
+func main() {
+	engineName := flag.String("engine", "search", "engine spec: search, nnue, incumbent, mcts, ... (see engine.Usage)")
+	nodes := flag.Uint64("nodes", 30000, "node budget of a case without a nodes opcode (mcts: iterations)")
+	movetime := flag.Duration("movetime", 0, "wall-clock budget per case instead of nodes")
+	paramsPath := flag.String("params", "", "search/nnue: EvalParams JSON")
+	policyPath := flag.String("policy", "", "search/nnue: move-ordering policy weights")
+	nnueWeights := flag.String("nnue-weights", "", "nnue: aggregate net weights instead of the process-wide net")
+	motif := flag.String("motif", "", "solve only cases with this motif")
+	verbose := flag.Bool("v", false, "print every case, not only failures")
+	jsonOutput := flag.Bool("json", false, "emit the report as JSON")
+	strict := flag.Bool("strict", false, "exit 1 when any case fails")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("usage: suite [flags] file.epd...")
+	}
+
+	var cases []arena.SuiteCase
+	for _, path := range flag.Args() {
+		read, err := arena.ReadSuite(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, c := range read {
+			if *motif == "" || c.Motif == *motif {
+				cases = append(cases, c)
+			}
+		}
+	}
+	engine, err := buildEngine(*engineName, *movetime, *paramsPath, *policyPath, *nnueWeights)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report, err := arena.RunSuite(cases, *nodes, engine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(jsonReport(report)); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		writeReport(os.Stdout, report, *verbose)
+	}
+	if passed, scored := report.Passed(); *strict && passed < scored {
+		os.Exit(1)
+	}
+}
+
+// buildEngine returns the agent factory RunSuite calls with each case's node
+// budget. The flags fill in the spec's params, policy, net and movetime.
+func buildEngine(name string, movetime time.Duration, paramsPath, policyPath, nnueWeights string) (func(uint64) arena.TelemetryAgent, error) {
+	spec, err := engine.ParseSpec(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range []struct{ flag, key, value string }{
+		{"params", "params", paramsPath}, {"policy", "policy", policyPath}, {"nnue-weights", "net", nnueWeights},
+	} {
+		if option.value == "" {
+			continue
+		}
+		if !spec.Takes(option.key) {
+			return nil, fmt.Errorf("-%s does not apply to %s", option.flag, name)
+		}
+		spec = spec.With(option.key, option.value)
+	}
+	if movetime > 0 {
+		if !spec.Takes("movetime") {
+			return nil, fmt.Errorf("-movetime does not apply to %s", name)
+		}
+		spec = spec.With("movetime", movetime.String())
+	}
+	if _, err := arena.BuildEngineSpec(spec); err != nil {
+		return nil, err
+	}
+	return func(nodes uint64) arena.TelemetryAgent {
+		built, err := arena.BuildEngineSpec(spec.WithNodes(nodes))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return built.New(1)
+	}, nil
+}
+
+func writeReport(w io.Writer, report arena.SuiteReport, verbose bool) {
+	for _, result := range report.Results {
+		if result.Passed && !verbose {
+			continue
+		}
+		verdict := "FAIL"
+		if result.Passed {
+			verdict = "pass"
+		}
+		fmt.Fprintf(w, "%s %-32s %-12s played %-10s score %+d depth %d nodes %d\n", verdict, result.Case.ID, result.Case.Motif,
+			arena.FormatAction(result.Action), result.Telemetry.Score, result.Telemetry.Depth, result.Telemetry.Nodes)
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "motif\tpassed\tcases\t")
+	for _, m := range report.Motifs {
+		fmt.Fprintf(table, "%s\t%d\t%d\t\n", m.Motif, m.Passed, m.Scored)
+	}
+	passed, scored := report.Passed()
+	fmt.Fprintf(table, "total\t%d\t%d\t\n", passed, scored)
+	table.Flush()
+	if report.Skipped > 0 {
+		fmt.Fprintf(w, "%d reference positions skipped\n", report.Skipped)
+	}
+}
+
+type caseJSON struct {
+	ID     string `json:"id"`
+	Motif  string `json:"motif"`
+	Played string `json:"played"`
+	Passed bool   `json:"passed"`
+	Score  int    `json:"score"`
+	Depth  int    `json:"depth"`
+	Nodes  uint64 `json:"nodes"`
+}
+
+type motifJSON struct {
+	Motif  string `json:"motif"`
+	Passed int    `json:"passed"`
+	Cases  int    `json:"cases"`
+}
+
+func jsonReport(report arena.SuiteReport) any {
+	out := struct {
+		Cases   []caseJSON  `json:"cases"`
+		Motifs  []motifJSON `json:"motifs"`
+		Skipped int         `json:"skipped"`
+	}{Skipped: report.Skipped}
+	for _, r := range report.Results {
+		out.Cases = append(out.Cases, caseJSON{
+			ID: r.Case.ID, Motif: r.Case.Motif, Played: arena.FormatAction(r.Action), Passed: r.Passed,
+			Score: r.Telemetry.Score, Depth: r.Telemetry.Depth, Nodes: r.Telemetry.Nodes,
+		})
+	}
+	for _, m := range report.Motifs {
+		out.Motifs = append(out.Motifs, motifJSON{Motif: m.Motif, Passed: m.Passed, Cases: m.Scored})
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"virusgame/arena"
+)
+
+// TestSolvesAndReportsPerMotif: a case whose bm is every legal action passes,
+// one whose am is every legal action fails, and the table totals both.
+func TestSolvesAndReportsPerMotif(t *testing.T) {
+	const board = "A..../.11../..2../....B"
+	state, err := arena.ParseBoard(board, 1, 1, "12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	free := arena.SuiteCaseFor(state, "free", "free")
+	free.Best = state.LegalActions()
+	trap := arena.SuiteCaseFor(state, "impossible", "trap")
+	trap.Avoid = state.LegalActions()
+	cases, err := arena.ParseSuite(strings.NewReader(free.String() + "\n" + trap.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := buildEngine("search", 0, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := arena.RunSuite(cases, 2000, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	writeReport(&out, report, false)
+	text := out.String()
+	if !strings.Contains(text, "FAIL impossible") || strings.Contains(text, "pass free") || !strings.Contains(text, "total  1       2") {
+		t.Fatalf("report:\n%s", text)
+	}
+
+	for _, bad := range [][5]string{{"alphazero"}, {"incumbent", "", "tuned.json"}, {"search", "", "", "", "net.nnue"}} {
+		if _, err := buildEngine(bad[0], 0, bad[2], bad[3], bad[4]); err == nil {
+			t.Errorf("buildEngine%v accepted", bad)
+		}
+	}
+}
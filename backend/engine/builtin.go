@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"virusgame/game"
+	"virusgame/mcts"
+	"virusgame/search"
+	"virusgame/search/incumbent"
+)
+
+// SearchKeys are the options of the live search engine.
+var SearchKeys = []string{"nodes", "depth", "movetime", "params", "policy", "keep", "selective", "endgame", "nobook"}
+
+// IncumbentKeys are the options of the frozen engine.
+var IncumbentKeys = []string{"nodes", "depth", "movetime"}
+
+// MCTSKeys are the options of the Monte Carlo engine.
+var MCTSKeys = []string{"nodes", "movetime", "reuse", "seed"}
+
+func init() {
+	Register("search", "alpha-beta engine; v2 live, v1 the post-PR58 freeze", []string{"v2", "v1"}, SearchKeys, buildSearch)
+	Register("incumbent", "the frozen engine, search:v1", nil, IncumbentKeys, buildIncumbent)
+	Register("production", "the deployed engine at its wall-clock budget", nil, nil, buildSearch)
+	Register("nnue", "live engine on the NNUE eval; net picks the weights", nil, append(append([]string(nil), SearchKeys...), "net"), buildSearch)
+	Register("mcts", "Monte Carlo tree search; nodes counts iterations", nil, MCTSKeys, buildMCTS)
+}
+
+// buildSearch builds the search engine: v1 is the frozen incumbent.
+func buildSearch(spec Spec) (Engine, error) {
+	if spec.Version == "v1" {
+		return buildIncumbent(spec)
+	}
+	budget, err := spec.Budget()
+	if err != nil {
+		return Engine{}, err
+	}
+	opts, err := spec.SearchOptions()
+	if err != nil {
+		return Engine{}, err
+	}
+	engine := Engine{Options: &opts, Serial: budget.Nodes == 0 && budget.Depth == 0}
+	engine.Chooser = func() Chooser {
+		return func(ctx context.Context, state game.State, clock search.Clock) (search.Result, bool) {
+			switch {
+			case budget.Nodes > 0:
+				return search.ChooseNodeBudgetOptions(state, budget.Nodes, opts)
+			case budget.Depth > 0:
+				return search.ChooseDepthOptions(ctx, state, budget.Depth, opts)
+			case budget.Movetime > 0:
+				ctx, cancel := context.WithTimeout(ctx, budget.Movetime)
+				defer cancel()
+				return search.ChooseOptions(ctx, state, opts)
+			}
+			opts := opts
+			opts.Clock = clock
+			return search.ChooseOptions(ctx, state, opts)
+		}
+	}
+	return engine, nil
+}
+
+// buildIncumbent builds the frozen v1 engine, which has no options beyond
+// its budget.
+func buildIncumbent(spec Spec) (Engine, error) {
+	for key := range spec.options {
+		if !containsString(IncumbentKeys, key) {
+			return Engine{}, fmt.Errorf("the frozen engine takes only nodes, depth or movetime, not %s", key)
+		}
+	}
+	budget, err := spec.Budget()
+	if err != nil {
+		return Engine{}, err
+	}
+	choose := func(ctx context.Context, state game.State, clock search.Clock) (incumbent.Result, bool) {
+		switch {
+		case budget.Nodes > 0:
+			return incumbent.ChooseNodeBudget(state, budget.Nodes)
+		case budget.Depth > 0:
+			return incumbent.ChooseDepth(ctx, state, budget.Depth)
+		case budget.Movetime > 0:
+			ctx, cancel := context.WithTimeout(ctx, budget.Movetime)
+			defer cancel()
+			return incumbent.Choose(ctx, state)
+		case clock.Enabled():
+			ctx, cancel := context.WithTimeout(ctx, search.Allocate(clock, state).Soft)
+			defer cancel()
+			return incumbent.Choose(ctx, state)
+		}
+		return incumbent.Choose(ctx, state)
+	}
+	return Engine{
+		Chooser: func() Chooser {
+			return func(ctx context.Context, state game.State, clock search.Clock) (search.Result, bool) {
+				result, ok := choose(ctx, state, clock)
+				return search.Result{Action: result.Action, Score: result.Score, Depth: result.Depth, Nodes: result.Nodes, Evaluations: result.Evaluations}, ok
+			}
+		},
+		Serial: budget.Nodes == 0 && budget.Depth == 0,
+	}, nil
+}
+
+// MCTSConfig reads the Monte Carlo engine's reuse and seed options.
+func (s Spec) MCTSConfig() (mcts.Config, error) {
+	cfg := mcts.DefaultConfig()
+	var err error
+	if s.options["reuse"] != "" {
+		if cfg.ReuseTree, err = s.Flag("reuse"); err != nil {
+			return cfg, err
+		}
+	}
+	if text := s.options["seed"]; text != "" {
+		if cfg.Seed, err = strconv.ParseInt(text, 10, 64); err != nil {
+			return cfg, fmt.Errorf("seed=%s: %w", text, err)
+		}
+	}
+	return cfg, nil
+}
+
+// buildMCTS builds the Monte Carlo engine; nodes counts iterations.
+func buildMCTS(spec Spec) (Engine, error) {
+	budget, err := spec.Budget()
+	if err != nil {
+		return Engine{}, err
+	}
+	cfg, err := spec.MCTSConfig()
+	if err != nil {
+		return Engine{}, err
+	}
+	chooser := func() Chooser {
+		engine := mcts.New(cfg)
+		return func(ctx context.Context, state game.State, clock search.Clock) (search.Result, bool) {
+			switch {
+			case budget.Nodes > 0:
+				return engine.ChooseIterations(state, budget.Nodes)
+			case budget.Movetime > 0:
+				ctx, cancel := context.WithTimeout(ctx, budget.Movetime)
+				defer cancel()
+				return engine.Choose(ctx, state)
+			case clock.Enabled():
+				ctx, cancel := context.WithTimeout(ctx, search.Allocate(clock, state).Soft)
+				defer cancel()
+				return engine.Choose(ctx, state)
+			}
+			return engine.Choose(ctx, state)
+		}
+	}
+	return Engine{Chooser: chooser, Serial: cfg.ReuseTree || budget.Nodes == 0}, nil
+}
@@ -0,0 +1,389 @@
+// Package engine is the engine registry: every engine version and agent
+// registers here under a stable name, and every tool, the bot-hoster
+// included, builds its players from the same spec string:
+//
+//	name[:version][?key=value&key=value...]
+//	search:v2?nodes=200000&params=tuned.json
+//	incumbent?depth=3
+//	nnue?nodes=20000&net=candidate.nnue
+//	mcts?nodes=5000&reuse=0
+//
+// The search engine is versioned: v1 is the post-PR58 freeze in
+// search/incumbent (also registered as incumbent), v2 the live package
+// search. A later freeze becomes v3 rather than overwriting an existing
+// version, so a spec keeps naming the engine it was rated with. Budgets are
+// nodes (deterministic), depth (fixed depth) or movetime (wall clock); a spec
+// without one plays the production wall-clock budget.
+//
+// This package registers the search engines and MCTS and builds their live
+// choosers. Package arena registers its scripted and seeded agents here too
+// and adds the per-game telemetry agents its matches play.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"virusgame/game"
+	"virusgame/search"
+	"virusgame/search/nnueweights"
+)
+
+// Spec is a parsed engine spec. The zero Spec is invalid.
+type Spec struct {
+	Name    string
+	Version string
+	options map[string]string
+}
+
+// Chooser picks an action for live play: until ctx ends or, with an enabled
+// clock, within the engine's own time allocation. Node-budget and fixed-depth
+// engines ignore the clock.
+type Chooser func(ctx context.Context, state game.State, clock search.Clock) (search.Result, bool)
+
+// Engine is a built engine spec.
+type Engine struct {
+	// Spec is the canonical spec the engine was built from.
+	Spec string
+	// Chooser returns a fresh chooser for live play (bot-hoster).
+	Chooser func() Chooser
+	// Serial engines carry state between decisions (an RNG, a reused tree) or
+	// play a wall-clock budget; run their games one at a time.
+	Serial bool
+	// Options are the per-search options of the live package search
+	// engines, nil for every other engine.
+	Options *search.Options
+}
+
+type entry struct {
+	summary  string
+	versions []string
+	keys     []string
+	build    func(Spec) (Engine, error)
+}
+
+var (
+	enginesMu sync.RWMutex
+	engines   = map[string]entry{}
+)
+
+// Register adds an engine under name. versions, when given, are the versions
+// the engine answers to, the first being the default; keys are the options
+// its specs may set. Registering a name twice panics.
+func Register(name, summary string, versions, keys []string, build func(Spec) (Engine, error)) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	if _, ok := engines[name]; ok || name == "" || strings.ContainsAny(name, ":?&=@,") {
+		panic(fmt.Sprintf("engine: %q registered twice or badly named", name))
+	}
+	engines[name] = entry{summary: summary, versions: versions, keys: keys, build: build}
+}
+
+func lookup(name string) (entry, bool) {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	found, ok := engines[name]
+	return found, ok
+}
+
+// Usage lists the registered engines, their versions and options, one per
+// line, for flag help.
+func Usage() string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		registered := engines[name]
+		line := name
+		if len(registered.versions) > 0 {
+			line += ":" + strings.Join(registered.versions, "|")
+		}
+		if len(registered.keys) > 0 {
+			line += "?" + strings.Join(registered.keys, ",")
+		}
+		lines = append(lines, fmt.Sprintf("  %-44s %s", line, registered.summary))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseSpec parses name[:version][?key=value&...] against the registry.
+// Option values are checked when the spec is built.
+func ParseSpec(text string) (Spec, error) {
+	text = strings.TrimSpace(text)
+	head, query, _ := strings.Cut(text, "?")
+	name, version, versioned := strings.Cut(head, ":")
+	registered, ok := lookup(name)
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown engine %q", text)
+	}
+	spec := Spec{Name: name, options: map[string]string{}}
+	switch {
+	case len(registered.versions) == 0 && versioned:
+		return Spec{}, fmt.Errorf("engine %q: %s has no versions", text, name)
+	case len(registered.versions) > 0 && !versioned:
+		spec.Version = registered.versions[0]
+	case len(registered.versions) > 0:
+		if !containsString(registered.versions, version) {
+			return Spec{}, fmt.Errorf("engine %q: %s has versions %s", text, name, strings.Join(registered.versions, ", "))
+		}
+		spec.Version = version
+	}
+	if query != "" {
+		for _, pair := range strings.Split(query, "&") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || value == "" {
+				return Spec{}, fmt.Errorf("engine %q: option %q wants key=value", text, pair)
+			}
+			if _, dup := spec.options[key]; dup {
+				return Spec{}, fmt.Errorf("engine %q: option %s given twice", text, key)
+			}
+			spec.options[key] = value
+		}
+	}
+	if err := spec.checkKeys(registered); err != nil {
+		return Spec{}, fmt.Errorf("engine %q: %w", text, err)
+	}
+	return spec, nil
+}
+
+func (s Spec) checkKeys(registered entry) error {
+	for key := range s.options {
+		if !containsString(registered.keys, key) {
+			if len(registered.keys) == 0 {
+				return fmt.Errorf("%s takes no options", s.Name)
+			}
+			return fmt.Errorf("%s takes %s, not %s", s.Name, strings.Join(registered.keys, ", "), key)
+		}
+	}
+	budgets := 0
+	for _, key := range []string{"nodes", "depth", "movetime"} {
+		if s.options[key] != "" {
+			budgets++
+		}
+	}
+	if budgets > 1 {
+		return fmt.Errorf("give one of nodes, depth or movetime")
+	}
+	return nil
+}
+
+// Build parses and builds text.
+func Build(text string) (Engine, error) {
+	spec, err := ParseSpec(text)
+	if err != nil {
+		return Engine{}, err
+	}
+	return spec.Build()
+}
+
+// Build builds the engine the spec names.
+func (s Spec) Build() (Engine, error) {
+	registered, ok := lookup(s.Name)
+	if !ok {
+		return Engine{}, fmt.Errorf("unknown engine %q", s.Name)
+	}
+	if err := s.checkKeys(registered); err != nil {
+		return Engine{}, fmt.Errorf("engine %s: %w", s, err)
+	}
+	built, err := registered.build(s)
+	if err != nil {
+		return Engine{}, fmt.Errorf("engine %s: %w", s, err)
+	}
+	built.Spec = s.String()
+	return built, nil
+}
+
+// String is the canonical spec: the version spelled out and the options in
+// key order.
+func (s Spec) String() string {
+	text := s.Name
+	if s.Version != "" {
+		text += ":" + s.Version
+	}
+	keys := make([]string, 0, len(s.options))
+	for key := range s.options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if i == 0 {
+			text += "?"
+		} else {
+			text += "&"
+		}
+		text += key + "=" + s.options[key]
+	}
+	return text
+}
+
+// Get returns the value of option key, or "".
+func (s Spec) Get(key string) string { return s.options[key] }
+
+// With returns a copy of s with option key set to value.
+func (s Spec) With(key, value string) Spec {
+	options := make(map[string]string, len(s.options)+1)
+	for k, v := range s.options {
+		options[k] = v
+	}
+	options[key] = value
+	s.options = options
+	return s
+}
+
+// Takes reports whether s's engine accepts option key.
+func (s Spec) Takes(key string) bool {
+	registered, ok := lookup(s.Name)
+	return ok && containsString(registered.keys, key)
+}
+
+// Budgeted reports whether s sets nodes, depth or movetime.
+func (s Spec) Budgeted() bool {
+	return s.options["nodes"] != "" || s.options["depth"] != "" || s.options["movetime"] != ""
+}
+
+// WithNodes gives an unbudgeted spec whose engine takes a node budget nodes;
+// any other spec is returned as it is.
+func (s Spec) WithNodes(nodes uint64) Spec {
+	if nodes == 0 || s.Budgeted() || !s.Takes("nodes") {
+		return s
+	}
+	return s.With("nodes", strconv.FormatUint(nodes, 10))
+}
+
+// Budget is a spec's budget; all zero is the production wall clock.
+type Budget struct {
+	Nodes    uint64
+	Depth    int
+	Movetime time.Duration
+}
+
+// Budget reads the spec's nodes, depth and movetime.
+func (s Spec) Budget() (Budget, error) {
+	var budget Budget
+	var err error
+	if text := s.options["nodes"]; text != "" {
+		if budget.Nodes, err = strconv.ParseUint(text, 10, 64); err != nil || budget.Nodes == 0 {
+			return budget, fmt.Errorf("nodes=%s: want a positive count", text)
+		}
+	}
+	if text := s.options["depth"]; text != "" {
+		if budget.Depth, err = strconv.Atoi(text); err != nil || budget.Depth < 1 {
+			return budget, fmt.Errorf("depth=%s: want a positive depth", text)
+		}
+	}
+	if text := s.options["movetime"]; text != "" {
+		if budget.Movetime, err = time.ParseDuration(text); err != nil || budget.Movetime <= 0 {
+			return budget, fmt.Errorf("movetime=%s: want a positive duration such as 500ms", text)
+		}
+	}
+	return budget, nil
+}
+
+// Flag reads boolean option key; unset is false.
+func (s Spec) Flag(key string) (bool, error) {
+	text := s.options[key]
+	if text == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(text)
+	if err != nil {
+		return false, fmt.Errorf("%s=%s: want 0 or 1", key, text)
+	}
+	return value, nil
+}
+
+// engineFiles caches the weight files specs name, so building a spec per
+// game or per suite case reads each file once.
+var engineFiles = struct {
+	sync.Mutex
+	loaded map[string]any
+}{loaded: map[string]any{}}
+
+// CachedFile returns read(path), reading each kind of file at path once per
+// process.
+func CachedFile(kind, path string, read func(string) (any, error)) (any, error) {
+	engineFiles.Lock()
+	defer engineFiles.Unlock()
+	key := kind + "\x00" + path
+	if value, ok := engineFiles.loaded[key]; ok {
+		return value, nil
+	}
+	value, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	engineFiles.loaded[key] = value
+	return value, nil
+}
+
+// SearchOptions reads the live engine's options from s.
+func (s Spec) SearchOptions() (search.Options, error) {
+	var opts search.Options
+	if path := s.options["params"]; path != "" {
+		params, err := CachedFile("params", path, func(path string) (any, error) { return search.LoadEvalParams(path) })
+		if err != nil {
+			return opts, err
+		}
+		copied := params.(search.EvalParams)
+		opts.Params = &copied
+	}
+	if path := s.options["policy"]; path != "" {
+		policy, err := CachedFile("policy", path, func(path string) (any, error) { return search.ReadPolicyWeights(path) })
+		if err != nil {
+			return opts, err
+		}
+		opts.Policy = policy.(*nnueweights.PolicyNet)
+	}
+	if text := s.options["keep"]; text != "" {
+		keep, err := strconv.Atoi(text)
+		if err != nil || keep < 1 || opts.Policy == nil {
+			return opts, fmt.Errorf("keep=%s: want a positive count and a policy", text)
+		}
+		opts.PolicyKeep = keep
+	}
+	if text := s.options["selective"]; text != "" {
+		// '+' separates techniques: a comma would end the spec in a list.
+		sel, err := search.ParseSelectivity(strings.ReplaceAll(text, "+", ","))
+		if err != nil {
+			return opts, err
+		}
+		opts.Selectivity = sel
+	}
+	var err error
+	if opts.Endgame, err = s.Flag("endgame"); err != nil {
+		return opts, err
+	}
+	if opts.NoBook, err = s.Flag("nobook"); err != nil {
+		return opts, err
+	}
+	if s.Name == "nnue" {
+		opts.NNUE = search.NNUEOn
+		if path := s.options["net"]; path != "" {
+			net, err := CachedFile("net", path, func(path string) (any, error) { return search.ReadNNUEWeights(path) })
+			if err != nil {
+				return opts, err
+			}
+			opts.Net = net.(*nnueweights.Net)
+		}
+	}
+	return opts, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+func TestParseSpecCanonicalForms(t *testing.T) {
+	for text, want := range map[string]string{
+		"search":                                "search:v2",
+		"search:v1?nodes=500":                   "search:v1?nodes=500",
+		"search?params=a.json&nodes=200000":     "search:v2?nodes=200000&params=a.json",
+		"incumbent?nodes=500":                   "incumbent?nodes=500",
+		"nnue?nodes=800&net=candidate.nnue":     "nnue?net=candidate.nnue&nodes=800",
+		"mcts?reuse=0&nodes=50":                 "mcts?nodes=50&reuse=0",
+		" production ":                          "production",
+		"search?selective=lmr+futility&depth=3": "search:v2?depth=3&selective=lmr+futility",
+	} {
+		spec, err := ParseSpec(text)
+		if err != nil {
+			t.Fatalf("ParseSpec(%q): %v", text, err)
+		}
+		if got := spec.String(); got != want {
+			t.Errorf("ParseSpec(%q) = %s, want %s", text, got, want)
+		}
+		again, err := ParseSpec(spec.String())
+		if err != nil || again.String() != want {
+			t.Errorf("canonical %s does not round-trip: %s %v", want, again, err)
+		}
+	}
+}
+
+func TestParseSpecRejects(t *testing.T) {
+	for _, text := range []string{
+		"", "alphazero", "search:v9", "mcts:v1", "production?nodes=5", "search?nodes", "search?nodes=1&nodes=2",
+		"search?nodes=1&depth=2", "mcts?params=a.json", "search@2000", "search@2000:tuned.json", "incumbent@500",
+		"nnue@800:candidate.nnue",
+	} {
+		if spec, err := ParseSpec(text); err == nil {
+			t.Errorf("ParseSpec(%q) accepted as %s", text, spec)
+		}
+	}
+}
+
+func TestBuildRejectsBadOptions(t *testing.T) {
+	for _, text := range []string{
+		"search?nodes=0", "search?depth=-1", "search?movetime=soon", "search:v1?nodes=100&params=a.json",
+		"search?keep=4", "search?endgame=maybe", "mcts?reuse=2", "mcts?nodes=5&seed=x",
+		"search?params=" + filepath.Join(t.TempDir(), "missing.json"),
+	} {
+		if _, err := Build(text); err == nil {
+			t.Errorf("Build(%q) built", text)
+		}
+	}
+}
+
+func TestChoosersPlayLegalMoves(t *testing.T) {
+	state, err := game.New(8, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"search?nodes=200", "search:v1?nodes=200", "search?depth=1", "incumbent?depth=1", "nnue?nodes=200", "mcts?nodes=50"} {
+		built, err := Build(text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		result, ok := built.Chooser()(context.Background(), state, search.Clock{})
+		if !ok {
+			t.Fatalf("%s: no action", text)
+		}
+		if _, err := state.Apply(result.Action); err != nil {
+			t.Fatalf("%s played %+v: %v", text, result.Action, err)
+		}
+	}
+}
+
+func TestSerialAndBudgetDefaults(t *testing.T) {
+	for text, serial := range map[string]bool{
+		"search?nodes=100": false, "search?depth=2": false, "search": true, "search?movetime=50ms": true,
+		"incumbent?nodes=100": false, "incumbent": true, "mcts?nodes=10&reuse=0": false, "mcts?nodes=10": true,
+	} {
+		built, err := Build(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if built.Serial != serial {
+			t.Errorf("%s Serial = %v, want %v", text, built.Serial, serial)
+		}
+	}
+	for text, want := range map[string]string{
+		"search":             "search:v2?nodes=700",
+		"search?depth=2":     "search:v2?depth=2",
+		"incumbent":          "incumbent?nodes=700",
+		"mcts?reuse=0":       "mcts?nodes=700&reuse=0",
+		"production":         "production",
+		"search?movetime=1s": "search:v2?movetime=1s",
+	} {
+		spec, err := ParseSpec(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := spec.WithNodes(700).String(); got != want {
+			t.Errorf("%s.WithNodes(700) = %s, want %s", text, got, want)
+		}
+	}
+	if usage := Usage(); !strings.Contains(usage, "search:v2|v1?nodes") || !strings.Contains(usage, "mcts?nodes") {
+		t.Fatalf("usage lacks engines:\n%s", usage)
+	}
+}
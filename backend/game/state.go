@@ -80,6 +80,7 @@ func New(rows, cols, players int) (State, error) {
 
 func (s *State) Rows() int             { return s.rows }
 func (s *State) Cols() int             { return s.cols }
+func (s *State) Players() int          { return s.players }
 func (s *State) CurrentPlayer() Player { return s.current }
 func (s *State) MovesLeft() int        { return s.movesLeft }
 func (s *State) GameOver() bool        { return s.over }
@@ -168,6 +169,33 @@ func (s *State) Apply(action Action) (State, error) {
 	return next, nil
 }
 
+// PassTurn returns the state after the current player forfeits the rest of its
+// turn. It is NOT a legal game action and never reaches Apply; search uses it
+// only to probe null-move bounds. The board is shared, not copied: nothing is
+// placed, and every real transition copies before mutating.
+func (s *State) PassTurn() State {
+	next := *s
+	if next.over || !next.Active(next.current) {
+		return next
+	}
+	next.advance(next.current)
+	return next
+}
+
+// HandTurnTo returns the state with player starting a fresh turn, skipping
+// everyone seated in between. Like PassTurn it is search-only (Best-Reply
+// Search lets exactly one opponent answer) and shares the board. An inactive or
+// unseated player, or a finished game, leaves the state unchanged.
+func (s *State) HandTurnTo(player Player) State {
+	next := *s
+	if next.over || !next.validPlayer(player) || !next.Active(player) {
+		return next
+	}
+	next.current = player
+	next.movesLeft = actionsPerTurn
+	return next
+}
+
 func (s *State) eliminateStuckPlayersGenerated() {
 	seen := make([]bool, len(s.cells))
 	queue := make([]int32, len(s.cells))
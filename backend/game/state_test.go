@@ -261,3 +261,38 @@ func assertMoveLegal(t *testing.T, s State, pos Pos, want bool) {
 		t.Fatalf("legalMove(%v) = %v, want %v", pos, got, want)
 	}
 }
+
+func TestPassTurnAdvancesWithoutTouchingTheBoard(t *testing.T) {
+	s := testState(6, 6, 3)
+	s, err := s.Apply(Action{Kind: Move, Target: Pos{0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := append([]Cell(nil), s.cells...)
+	passed := s.PassTurn()
+	if passed.CurrentPlayer() != 2 || passed.MovesLeft() != 3 || passed.GameOver() {
+		t.Fatalf("pass: player=%d moves=%d over=%v", passed.CurrentPlayer(), passed.MovesLeft(), passed.GameOver())
+	}
+	if !reflect.DeepEqual(passed.cells, before) || s.CurrentPlayer() != 1 || s.MovesLeft() != 2 {
+		t.Fatal("PassTurn mutated the board or its receiver")
+	}
+}
+
+func TestHandTurnToSkipsSeatsWithoutTouchingTheBoard(t *testing.T) {
+	s := testState(6, 6, 4)
+	s, err := s.Apply(Action{Kind: Move, Target: Pos{0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := append([]Cell(nil), s.cells...)
+	handed := s.HandTurnTo(3)
+	if handed.CurrentPlayer() != 3 || handed.MovesLeft() != 3 {
+		t.Fatalf("hand: player=%d moves=%d", handed.CurrentPlayer(), handed.MovesLeft())
+	}
+	if !reflect.DeepEqual(handed.cells, before) || s.CurrentPlayer() != 1 || s.MovesLeft() != 2 {
+		t.Fatal("HandTurnTo mutated the board or its receiver")
+	}
+	if same := s.HandTurnTo(5); same.CurrentPlayer() != 1 || same.MovesLeft() != 2 {
+		t.Fatalf("unseated player changed the turn: player=%d moves=%d", same.CurrentPlayer(), same.MovesLeft())
+	}
+}
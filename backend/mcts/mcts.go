@@ -0,0 +1,296 @@
+// Package mcts chooses Virus actions with Monte Carlo Tree Search, an
+// alternative to the alpha-beta engine in package search.
+//
+// Minimax has to enumerate every sibling before it can trust a score, and a
+// Virus action has dozens of placements plus the strategic neutral pairs at
+// each of the three actions of a turn. MCTS instead grows the tree where the
+// visit statistics say it matters: PUCT selection (AlphaZero style) balances a
+// cheap heuristic prior against the running per-seat value, leaves are scored
+// by the static eval, the NNUE net, or a short capture-first playout, and the
+// subtree under the chosen action is kept for the next action of the turn.
+//
+// Values are per-seat vectors in [0,1] (1 = certain win for that seat), so the
+// same backup serves 1v1 and 3-4 players: each node's mover picks the child
+// that is best for its own seat. Results come back as a search.Result, so the
+// bot-hoster and arena drive it exactly like the minimax engine.
+package mcts
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+// Value selects how a newly reached leaf is scored.
+type Value uint8
+
+const (
+	// ValueStatic scores leaves with search.StaticEvalAll.
+	ValueStatic Value = iota
+	// ValuePlayout plays PlayoutActions capture-first random actions, then
+	// scores the position reached with the static eval.
+	ValuePlayout
+	// ValueNNUE scores leaves with the active NNUE net (see search.LoadNNUEWeights).
+	ValueNNUE
+)
+
+func (v Value) String() string {
+	switch v {
+	case ValueStatic:
+		return "static"
+	case ValuePlayout:
+		return "playout"
+	case ValueNNUE:
+		return "nnue"
+	}
+	return "unknown"
+}
+
+// Config tunes an Engine. The zero value is usable; DefaultConfig documents
+// the defaults that zero fields fall back to.
+type Config struct {
+	// Exploration is the PUCT constant c.
+	Exploration float64
+	Value       Value
+	// PlayoutActions is the playout horizon for ValuePlayout, in actions.
+	PlayoutActions int
+	// EvalScale is how many eval units make one logistic unit when an eval
+	// is squashed into a [0,1] value.
+	EvalScale float64
+	// Seed drives playout randomness; equal seeds replay equal searches.
+	Seed int64
+	// ReuseTree keeps the chosen subtree and the playout RNG between calls.
+	// Off makes every call a pure function of (state, budget, Seed), which
+	// equal-node ladders need.
+	ReuseTree bool
+	// Prior, when set, replaces the heuristic move prior. It returns one
+	// non-negative weight per action; the engine normalizes them.
+	Prior func(state game.State, actions []game.Action) []float64
+}
+
+// DefaultConfig is the configuration the bot-hoster uses.
+func DefaultConfig() Config {
+	return Config{Exploration: 1.5, Value: ValueStatic, PlayoutActions: 12, EvalScale: 4000, ReuseTree: true}
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.Exploration <= 0 {
+		c.Exploration = d.Exploration
+	}
+	if c.PlayoutActions <= 0 {
+		c.PlayoutActions = d.PlayoutActions
+	}
+	if c.EvalScale <= 0 {
+		c.EvalScale = d.EvalScale
+	}
+	return c
+}
+
+// reuseDepth bounds how far below the retained node the next root is looked
+// for: one full turn for each of up to three opponents plus our own.
+const reuseDepth = 12
+
+// Engine is an MCTS searcher. It is safe for concurrent use, but calls are
+// serialized; with ReuseTree on, results depend on the previous call.
+type Engine struct {
+	mu   sync.Mutex
+	cfg  Config
+	rng  *rand.Rand
+	kept *node
+}
+
+// New returns an Engine for cfg.
+func New(cfg Config) *Engine {
+	cfg = cfg.withDefaults()
+	return &Engine{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// Reset drops the retained tree.
+func (e *Engine) Reset() {
+	e.mu.Lock()
+	e.kept = nil
+	e.mu.Unlock()
+}
+
+// Choose searches until ctx is done. Without a deadline it applies
+// search.ProductionBudget, like search.Choose.
+func (e *Engine) Choose(ctx context.Context, state game.State) (search.Result, bool) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, search.ProductionBudget)
+		defer cancel()
+	}
+	return e.run(ctx, state, 0)
+}
+
+// ChooseIterations runs exactly iterations simulations with no deadline, for
+// reproducible benchmarks and arena ladders.
+func (e *Engine) ChooseIterations(state game.State, iterations uint64) (search.Result, bool) {
+	if iterations == 0 {
+		return search.Result{}, false
+	}
+	return e.run(context.Background(), state, iterations)
+}
+
+func (e *Engine) run(ctx context.Context, state game.State, limit uint64) (search.Result, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if state.GameOver() || !state.Active(state.CurrentPlayer()) {
+		return search.Result{}, false
+	}
+	root := e.rootFor(state)
+	e.expandRoot(root)
+	if len(root.children) == 0 {
+		return search.Result{}, false
+	}
+	rng := e.rng
+	if !e.cfg.ReuseTree {
+		// A fresh stream per call keeps playouts independent of call history.
+		rng = rand.New(rand.NewSource(e.cfg.Seed))
+	}
+	t := tree{cfg: e.cfg, rng: rng}
+	for limit == 0 || t.iterations < limit {
+		if ctx.Err() != nil {
+			break
+		}
+		t.simulate(root)
+	}
+	best := root.bestChild()
+	result := t.result(root, best)
+	result.BudgetExhausted = limit > 0 && t.iterations >= limit
+	if e.cfg.ReuseTree {
+		e.kept = best
+	}
+	return result, true
+}
+
+// rootFor returns the retained node for state if the previous search already
+// grew it, else a fresh root.
+func (e *Engine) rootFor(state game.State) *node {
+	if !e.cfg.ReuseTree || e.kept == nil {
+		return newNode(state, game.Action{}, 1)
+	}
+	want := fingerprint(state)
+	level := []*node{e.kept}
+	for depth := 0; depth <= reuseDepth && len(level) > 0; depth++ {
+		var next []*node
+		for _, n := range level {
+			if n.visited() && fingerprint(n.state) == want {
+				n.parent = nil // release the rest of the old tree
+				return n
+			}
+			for _, c := range n.children {
+				if c.visited() {
+					next = append(next, c)
+				}
+			}
+		}
+		level = next
+	}
+	return newNode(state, game.Action{}, 1)
+}
+
+// expandRoot expands the root, keeps only immediate wins when there are any,
+// and otherwise drops self-eliminating actions whenever a preserving one
+// exists, mirroring search's preservingChildren.
+func (e *Engine) expandRoot(root *node) {
+	root.expand(e.cfg)
+	actor := root.state.CurrentPlayer()
+	var wins, preserving []*node
+	for _, c := range root.children {
+		next := c.materialize()
+		if next.GameOver() && next.Winner() == actor {
+			wins = append(wins, c)
+		}
+		if next.Active(actor) {
+			preserving = append(preserving, c)
+		}
+	}
+	switch {
+	case len(wins) > 0:
+		root.children = wins
+	case len(preserving) > 0:
+		root.children = preserving
+	}
+}
+
+// tree is the per-call simulation state.
+type tree struct {
+	cfg                     Config
+	rng                     *rand.Rand
+	iterations, evaluations uint64
+	maxDepth                int
+}
+
+// simulate runs one select-expand-evaluate-backup pass from root.
+func (t *tree) simulate(root *node) {
+	path := []*node{root}
+	n := root
+	for n.expanded && !n.terminal {
+		n = n.selectChild(t.cfg.Exploration)
+		n.materialize()
+		path = append(path, n)
+	}
+	var value [4]float64
+	switch {
+	case n.terminal:
+		value = n.leaf
+	case n.state.GameOver():
+		n.terminal, n.leaf = true, terminalValue(n.state)
+		value = n.leaf
+	default:
+		n.expand(t.cfg)
+		value = t.evaluate(n.state)
+		if len(n.children) == 0 {
+			// Stuck but not yet eliminated: the eval is all there is to know.
+			n.terminal, n.leaf = true, value
+		}
+	}
+	for _, visited := range path {
+		visited.visits++
+		for seat := range value {
+			visited.value[seat] += value[seat]
+		}
+	}
+	t.iterations++
+	t.maxDepth = max(t.maxDepth, len(path)-1)
+}
+
+func (t *tree) result(root, best *node) search.Result {
+	actor := root.state.CurrentPlayer()
+	result := search.Result{
+		Action:      best.action,
+		Score:       t.score(best.mean(actor)),
+		Depth:       t.maxDepth,
+		Nodes:       t.iterations,
+		Evaluations: t.evaluations,
+	}
+	ranked := make([]*node, 0, len(root.children))
+	for _, c := range root.children {
+		if c != best && c.visited() {
+			ranked = append(ranked, c)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].visits > ranked[j].visits })
+	for _, c := range ranked[:min(len(ranked), 4)] {
+		result.Alternatives = append(result.Alternatives, search.RootMove{Action: c.action, Score: t.score(c.mean(actor))})
+	}
+	return result
+}
+
+// score maps a [0,1] value back to eval units so Result.Score reads like a
+// minimax score (0 = even, positive = good for the mover).
+func (t *tree) score(value float64) int {
+	const clamp = 1e-6
+	value = math.Min(math.Max(value, clamp), 1-clamp)
+	return int(math.Round(t.cfg.EvalScale * math.Log(value/(1-value))))
+}
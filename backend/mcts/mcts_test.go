@@ -0,0 +1,189 @@
+package mcts
+
+import (
+	"context"
+	"testing"
+
+	"virusgame/game"
+)
+
+func mustState(t *testing.T, rows, cols, players int) game.State {
+	t.Helper()
+	state, err := game.New(rows, cols, players)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func play(t *testing.T, state game.State, targets ...game.Pos) game.State {
+	t.Helper()
+	for _, target := range targets {
+		next, err := state.Apply(game.Action{Kind: game.Move, Target: target})
+		if err != nil {
+			t.Fatalf("move %+v: %v", target, err)
+		}
+		state = next
+	}
+	return state
+}
+
+func midgame(t *testing.T) game.State {
+	return play(t, mustState(t, 8, 8, 2),
+		game.Pos{Row: 1, Col: 1}, game.Pos{Row: 2, Col: 2}, game.Pos{Row: 3, Col: 3},
+		game.Pos{Row: 6, Col: 6}, game.Pos{Row: 5, Col: 5}, game.Pos{Row: 4, Col: 4},
+	)
+}
+
+func TestChooseIterationsIsLegalAndDeterministic(t *testing.T) {
+	state := midgame(t)
+	for _, value := range []Value{ValueStatic, ValuePlayout, ValueNNUE} {
+		cfg := Config{Value: value, Seed: 7}
+		first, ok := New(cfg).ChooseIterations(state, 400)
+		if !ok {
+			t.Fatalf("%s: no result", value)
+		}
+		if _, err := state.Apply(first.Action); err != nil {
+			t.Fatalf("%s: illegal action %+v: %v", value, first.Action, err)
+		}
+		second, _ := New(cfg).ChooseIterations(state, 400)
+		if first.Action != second.Action || first.Score != second.Score || first.Nodes != second.Nodes {
+			t.Fatalf("%s: non-deterministic %+v then %+v", value, first, second)
+		}
+		if first.Nodes != 400 || !first.BudgetExhausted || first.Depth < 2 {
+			t.Fatalf("%s: result %+v, want 400 iterations reaching depth >= 2", value, first)
+		}
+		for _, alt := range first.Alternatives {
+			if alt.Action == first.Action {
+				t.Fatalf("%s: alternative repeats the chosen action", value)
+			}
+		}
+	}
+}
+
+// TestNoReuseIgnoresCallHistory: with ReuseTree off, a search of one position
+// does not depend on what the engine searched before it, playouts included.
+func TestNoReuseIgnoresCallHistory(t *testing.T) {
+	state := midgame(t)
+	cfg := Config{Value: ValuePlayout, Seed: 3}
+	want, _ := New(cfg).ChooseIterations(state, 300)
+	engine := New(cfg)
+	if _, ok := engine.ChooseIterations(play(t, state, game.Pos{Row: 4, Col: 3}), 300); !ok {
+		t.Fatal("no result")
+	}
+	got, _ := engine.ChooseIterations(state, 300)
+	if got.Action != want.Action || got.Score != want.Score {
+		t.Fatalf("after another search %+v, fresh engine %+v", got, want)
+	}
+}
+
+func TestTakesImmediateWin(t *testing.T) {
+	state, win := findWinningPosition(t)
+	result, ok := New(Config{}).ChooseIterations(state, 300)
+	if !ok {
+		t.Fatal("no result")
+	}
+	next, err := state.Apply(result.Action)
+	if err != nil || !next.GameOver() || next.Winner() != state.CurrentPlayer() {
+		t.Fatalf("played %+v, a win such as %+v was available", result.Action, win)
+	}
+}
+
+// findWinningPosition walks 4x4 1v1 games breadth-first until the mover has a
+// winning placement.
+func findWinningPosition(t *testing.T) (game.State, game.Action) {
+	t.Helper()
+	frontier := []game.State{mustState(t, 4, 4, 2)}
+	seen := map[uint64]bool{}
+	for ply := 0; ply < 12 && len(frontier) > 0; ply++ {
+		var next []game.State
+		for _, state := range frontier {
+			for _, action := range state.LegalActions() {
+				if action.Kind != game.Move {
+					continue
+				}
+				child, err := state.Apply(action)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if child.GameOver() && child.Winner() == state.CurrentPlayer() {
+					return state, action
+				}
+				if key := fingerprint(child); !seen[key] && len(next) < 20_000 {
+					seen[key] = true
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+	t.Fatal("no winning fixture found")
+	return game.State{}, game.Action{}
+}
+
+func TestTreeIsReusedAcrossTheActionsOfATurn(t *testing.T) {
+	state := midgame(t)
+	engine := New(Config{ReuseTree: true})
+	first, ok := engine.ChooseIterations(state, 500)
+	if !ok {
+		t.Fatal("no result")
+	}
+	next, err := state.Apply(first.Action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.CurrentPlayer() != state.CurrentPlayer() {
+		t.Skip("chosen action ended the turn")
+	}
+	root := engine.rootFor(next)
+	if root != engine.kept || root.visits == 0 {
+		t.Fatalf("next action of the turn did not reuse the chosen subtree (visits=%d)", root.visits)
+	}
+	if _, ok := engine.ChooseIterations(next, 100); !ok {
+		t.Fatal("no result on reused tree")
+	}
+	if fresh := New(Config{ReuseTree: true}).rootFor(next); fresh.visits != 0 {
+		t.Fatal("a fresh engine reused a tree it never grew")
+	}
+}
+
+func TestMultiplayerValuesArePerSeat(t *testing.T) {
+	for _, players := range []int{3, 4} {
+		state := mustState(t, 8, 8, players)
+		state = play(t, state, game.Pos{Row: 1, Col: 1}, game.Pos{Row: 2, Col: 2}, game.Pos{Row: 1, Col: 2})
+		engine := New(Config{ReuseTree: true})
+		result, ok := engine.ChooseIterations(state, 300)
+		if !ok {
+			t.Fatalf("%dp: no result", players)
+		}
+		if _, err := state.Apply(result.Action); err != nil {
+			t.Fatalf("%dp: illegal action %+v", players, result.Action)
+		}
+		chosen := engine.kept
+		for seat := 0; seat < 4; seat++ {
+			if seated := seat < players; seated != (chosen.value[seat] > 0) {
+				t.Fatalf("%dp: seat %d value sum %v after %d visits", players, seat+1, chosen.value[seat], chosen.visits)
+			}
+		}
+	}
+}
+
+func TestCanceledChooseReturnsLegalPreservingAction(t *testing.T) {
+	state := midgame(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, ok := New(Config{}).Choose(ctx, state)
+	if !ok {
+		t.Fatal("no fallback result")
+	}
+	next, err := state.Apply(result.Action)
+	if err != nil || !next.Active(state.CurrentPlayer()) {
+		t.Fatalf("fallback %+v illegal or self-eliminating: %v", result.Action, err)
+	}
+}
+
+func TestChooseIterationsRejectsZeroBudget(t *testing.T) {
+	if _, ok := New(Config{}).ChooseIterations(midgame(t), 0); ok {
+		t.Fatal("zero iterations accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package mcts
+
+import (
+	"math"
+	"sort"
+
+	"virusgame/game"
+)
+
+// node is one tree position. Child states are built lazily the first time a
+// child is selected, so an expanded node costs only its action list.
+type node struct {
+	parent   *node
+	state    game.State
+	ready    bool
+	action   game.Action
+	prior    float64
+	visits   uint32
+	value    [4]float64 // per-seat value sums, index player-1
+	children []*node
+	expanded bool
+	terminal bool
+	leaf     [4]float64 // fixed value of a terminal node
+}
+
+func newNode(state game.State, action game.Action, prior float64) *node {
+	return &node{state: state, ready: true, action: action, prior: prior}
+}
+
+func (n *node) visited() bool { return n.visits > 0 }
+
+// mean is the average value for player, or 0.5 when unvisited.
+func (n *node) mean(player game.Player) float64 {
+	if n.visits == 0 {
+		return 0.5
+	}
+	return n.value[player-1] / float64(n.visits)
+}
+
+// materialize builds the child's state from its parent on first use.
+func (n *node) materialize() game.State {
+	if !n.ready {
+		n.state = game.NewPosition(n.parent.state).ApplySearch(n.action).State()
+		n.ready = true
+	}
+	return n.state
+}
+
+// expand lists the node's search actions with normalized priors, best first.
+func (n *node) expand(cfg Config) {
+	if n.expanded {
+		return
+	}
+	n.expanded = true
+	var actions []game.Action
+	game.NewPosition(n.state).ForEachSearchAction(func(action game.Action) bool {
+		actions = append(actions, action)
+		return true
+	})
+	if len(actions) == 0 {
+		return
+	}
+	var weights []float64
+	if cfg.Prior != nil {
+		weights = cfg.Prior(n.state, actions)
+	}
+	if len(weights) != len(actions) {
+		weights = heuristicPrior(n.state, actions)
+	}
+	total := 0.0
+	for _, w := range weights {
+		total += math.Max(w, 0)
+	}
+	n.children = make([]*node, len(actions))
+	for i, action := range actions {
+		prior := 1 / float64(len(actions))
+		if total > 0 {
+			prior = math.Max(weights[i], 0) / total
+		}
+		n.children[i] = &node{parent: n, action: action, prior: prior}
+	}
+	sort.SliceStable(n.children, func(i, j int) bool { return n.children[i].prior > n.children[j].prior })
+}
+
+// selectChild applies PUCT for the node's mover. Unvisited children inherit
+// the parent's mean (first-play urgency), so a strong prior decides which
+// sibling is tried first; ties keep the prior order.
+func (n *node) selectChild(exploration float64) *node {
+	mover := n.state.CurrentPlayer()
+	fpu := n.mean(mover)
+	scale := exploration * math.Sqrt(float64(n.visits))
+	var best *node
+	bestScore := math.Inf(-1)
+	for _, c := range n.children {
+		q := fpu
+		if c.visits > 0 {
+			q = c.mean(mover)
+		}
+		score := q + scale*c.prior/float64(1+c.visits)
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// bestChild is the most-visited root child; ties go to the higher mean.
+func (n *node) bestChild() *node {
+	mover := n.state.CurrentPlayer()
+	best := n.children[0]
+	for _, c := range n.children[1:] {
+		if c.visits > best.visits || c.visits == best.visits && c.mean(mover) > best.mean(mover) {
+			best = c
+		}
+	}
+	return best
+}
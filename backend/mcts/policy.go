@@ -0,0 +1,150 @@
+package mcts
+
+import (
+	"math"
+
+	"virusgame/game"
+	"virusgame/search"
+)
+
+// heuristicPrior weights captures over plain placements and shares a single
+// placement's worth of mass across all neutral pairs, so the pair list (often
+// longer than the placement list) cannot drown the prior.
+func heuristicPrior(state game.State, actions []game.Action) []float64 {
+	actor := state.CurrentPlayer()
+	pairs := 0
+	for _, action := range actions {
+		if action.Kind == game.PlaceNeutrals {
+			pairs++
+		}
+	}
+	weights := make([]float64, len(actions))
+	for i, action := range actions {
+		if action.Kind == game.PlaceNeutrals {
+			weights[i] = 1 / float64(pairs)
+			continue
+		}
+		weights[i] = 1
+		if target, _ := state.At(action.Target); target.Kind == game.Normal && target.Owner != actor {
+			weights[i] = 4
+		}
+	}
+	return weights
+}
+
+// evaluate scores a non-terminal leaf as a per-seat value vector.
+func (t *tree) evaluate(state game.State) [4]float64 {
+	t.evaluations++
+	switch t.cfg.Value {
+	case ValuePlayout:
+		state = t.playout(state)
+		if state.GameOver() {
+			return terminalValue(state)
+		}
+	case ValueNNUE:
+		return nnueValue(state, t.cfg.EvalScale)
+	}
+	return staticValue(state, t.cfg.EvalScale)
+}
+
+// playout plays capture-first random actions (the arena.Greedy instinct
+// without its per-action lookahead) for the configured horizon. Neutral pairs
+// are skipped: they are a strategic whole-turn choice, not playout material.
+func (t *tree) playout(state game.State) game.State {
+	for ply := 0; ply < t.cfg.PlayoutActions && !state.GameOver(); ply++ {
+		pos := game.NewPosition(state)
+		actor := state.CurrentPlayer()
+		var captures, placements []game.Action
+		pos.ForEachSearchAction(func(action game.Action) bool {
+			if action.Kind != game.Move {
+				return true
+			}
+			if target, _ := state.At(action.Target); target.Kind == game.Normal && target.Owner != actor {
+				captures = append(captures, action)
+			} else {
+				placements = append(placements, action)
+			}
+			return true
+		})
+		pool := captures
+		if len(pool) == 0 {
+			pool = placements
+		}
+		if len(pool) == 0 {
+			break
+		}
+		state = pos.ApplySearch(pool[t.rng.Intn(len(pool))]).State()
+	}
+	return state
+}
+
+func staticValue(state game.State, scale float64) [4]float64 {
+	evals := search.StaticEvalAll(state)
+	var value [4]float64
+	for player := game.Player(1); player <= 4; player++ {
+		if state.Active(player) {
+			value[player-1] = squash(float64(evals[player-1]), scale)
+		}
+	}
+	return value
+}
+
+// nnueValue uses the net's mover-perspective score; every other active seat
+// gets its negation, the same zero-sum reading search's NNUE path uses.
+func nnueValue(state game.State, scale float64) [4]float64 {
+	pred := search.NNUEPredict(state)
+	mover := state.CurrentPlayer()
+	var value [4]float64
+	for player := game.Player(1); player <= 4; player++ {
+		switch {
+		case !state.Active(player):
+		case player == mover:
+			value[player-1] = squash(pred, scale)
+		default:
+			value[player-1] = squash(-pred, scale)
+		}
+	}
+	return value
+}
+
+func terminalValue(state game.State) [4]float64 {
+	var value [4]float64
+	if winner := state.Winner(); winner >= 1 && winner <= 4 {
+		value[winner-1] = 1
+	}
+	return value
+}
+
+func squash(eval, scale float64) float64 {
+	return 1 / (1 + math.Exp(-eval/scale))
+}
+
+// fingerprint identifies a position for tree reuse (FNV-1a over everything
+// that affects play, like search's transposition key).
+func fingerprint(state game.State) uint64 {
+	const prime = uint64(1099511628211)
+	hash := uint64(1469598103934665603)
+	add := func(value byte) {
+		hash ^= uint64(value)
+		hash *= prime
+	}
+	add(byte(state.Rows()))
+	add(byte(state.Cols()))
+	add(byte(state.CurrentPlayer()))
+	add(byte(state.MovesLeft()))
+	for player := game.Player(1); player <= 4; player++ {
+		if state.Active(player) {
+			add(byte(player) | 0x10)
+		}
+		if state.NeutralUsed(player) {
+			add(byte(player) | 0x20)
+		}
+	}
+	for row := 0; row < state.Rows(); row++ {
+		for col := 0; col < state.Cols(); col++ {
+			cell, _ := state.At(game.Pos{Row: row, Col: col})
+			add(byte(cell.Owner)<<3 | byte(cell.Kind))
+		}
+	}
+	return hash
+}
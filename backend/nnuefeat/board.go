@@ -0,0 +1,90 @@
+package nnuefeat
+
+import "virusgame/game"
+
+// vs-ai2.65 board planes: the sparse NNUE input. Every occupied cell switches on
+// exactly one feature per seat perspective — (cell, owner relative to that seat,
+// kind) — so one action changes at most a handful of features and the first
+// layer can be kept as a running sum instead of being recomputed per leaf (the
+// aggregate Features() need whole-board flood fills every time).
+//
+// Each seat sees the board oriented so its own base is the top-left corner and
+// owners renumbered so it is always owner 0, which lets one set of weights
+// serve every seat of every game size up to BoardSide.
+
+// BoardSide is the largest board edge the planes cover (the server's limit).
+const BoardSide = 50
+
+// boardKinds are the owned cell kinds with a plane each: Normal, Base, Fortified.
+const boardKinds = 3
+
+// BoardPlanes is Seats×(Normal, Base, Fortified) owner planes plus one plane
+// for neutrals.
+const BoardPlanes = Seats*boardKinds + 1
+
+// BoardFeatures is the width of the sparse board-plane input.
+const BoardFeatures = BoardPlanes * BoardSide * BoardSide
+
+// BoardSchemaVersion identifies the meaning of BoardFeature indices; bump it
+// whenever the plane layout or orientation changes.
+const BoardSchemaVersion = 1
+
+// FitsBoard reports whether a rows×cols board is covered by the planes.
+func FitsBoard(rows, cols int) bool {
+	return rows <= BoardSide && cols <= BoardSide
+}
+
+// BoardView is one seat's view of a board: the geometry and turn order its
+// feature indices are relative to.
+type BoardView struct {
+	Seat                game.Player
+	Players, Rows, Cols int
+}
+
+// ViewOf is seat's view of state.
+func ViewOf(state game.State, seat game.Player) BoardView {
+	return BoardView{Seat: seat, Players: state.Players(), Rows: state.Rows(), Cols: state.Cols()}
+}
+
+// Feature is the index cell at pos switches on in this view, or -1 for an empty
+// cell. Owners are numbered by turn distance from the seat (0 = the seat, 1 =
+// whoever moves after it), matching the order BoardNet reads accumulators in.
+// The board must satisfy FitsBoard.
+func (v BoardView) Feature(pos game.Pos, cell game.Cell) int {
+	var plane int
+	switch cell.Kind {
+	case game.Empty:
+		return -1
+	case game.Neutral:
+		plane = Seats * boardKinds
+	default:
+		relative := (int(cell.Owner) - int(v.Seat) + v.Players) % v.Players
+		plane = relative*boardKinds + int(cell.Kind-game.Normal)
+	}
+	// Bases sit top-left, bottom-right, top-right, bottom-left (game.New).
+	row, col := pos.Row, pos.Col
+	if v.Seat == 2 || v.Seat == 4 {
+		row = v.Rows - 1 - row
+	}
+	if v.Seat == 2 || v.Seat == 3 {
+		col = v.Cols - 1 - col
+	}
+	return (plane*BoardSide+row)*BoardSide + col
+}
+
+// AppendBoardFeatures appends every active board feature of seat's view of
+// state to dst, in row-major cell order. This is the full-refresh path; search
+// keeps the sums current incrementally instead.
+func AppendBoardFeatures(dst []int, state game.State, seat game.Player) []int {
+	view := ViewOf(state, seat)
+	for row := 0; row < view.Rows; row++ {
+		for col := 0; col < view.Cols; col++ {
+			pos := game.Pos{Row: row, Col: col}
+			cell, _ := state.At(pos)
+			if feature := view.Feature(pos, cell); feature >= 0 {
+				dst = append(dst, feature)
+			}
+		}
+	}
+	return dst
+}
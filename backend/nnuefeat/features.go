@@ -69,6 +69,12 @@ type PlayerFeatures struct {
 // FeatureCount is the length of the Features() flat vector.
 const FeatureCount = 26
 
+// FeatureSchemaVersion identifies the meaning of the Features() vector. Bump it
+// whenever a feature is added, removed, reordered or redefined (1 was the
+// 19-wide vector, 2 the vs-ai2.56 owner-profile growth to 26); weights files
+// record it and search refuses a file trained on another schema.
+const FeatureSchemaVersion = 2
+
 // Seats is the fixed number of seats the Input() vector spans.
 const Seats = 4
 
@@ -117,6 +123,32 @@ func Input(state game.State) []float64 {
 	return vec
 }
 
+// SeatInputs is the seat-relative input layout (vs-ai2.66), one vector per
+// active seat from a single extraction: slot k holds the features of the seat k
+// places after it in turn order, so slot 0 is always the seat being scored and a
+// net trained this way is run once per seat. Inactive seats get nil; their
+// slots in other seats' vectors stay zero. The trainer's Record.seatInput
+// mirrors it.
+func SeatInputs(state game.State) [Seats][]float64 {
+	feats := NNUEFeatures(state)
+	players := state.Players()
+	var out [Seats][]float64
+	for seat := 0; seat < players; seat++ {
+		if !state.Active(game.Player(seat + 1)) {
+			continue
+		}
+		vec := make([]float64, InputDim)
+		for k := 0; k < players; k++ {
+			other := (seat + k) % players
+			if state.Active(game.Player(other + 1)) {
+				copy(vec[k*FeatureCount:], feats[other].Features())
+			}
+		}
+		out[seat] = vec
+	}
+	return out
+}
+
 // NNUEFeatures computes the per-player feature vectors for a position, indexed
 // by seat-1. Inactive seats keep the zero value.
 func NNUEFeatures(state game.State) [4]PlayerFeatures {
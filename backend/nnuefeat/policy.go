@@ -0,0 +1,79 @@
+package nnuefeat
+
+import "virusgame/game"
+
+// vs-ai2.69 policy patches: the input of the move-ordering policy net. The net
+// scores one cell at a time from the 5×5 window around it, seen by the mover
+// with the same orientation as the board planes (its own base towards the top
+// left), so one set of weights serves every cell of every board size. Each
+// window cell switches on exactly one channel; the mover's movesLeft switches
+// on one of three more features.
+
+// PolicyRadius is how far the window reaches from the scored cell.
+const PolicyRadius = 2
+
+// PolicyWindow is the window's edge.
+const PolicyWindow = 2*PolicyRadius + 1
+
+// Window cell channels: off-board, empty, neutral, then the mover's and the
+// opponents' Normal, Base and Fortified cells.
+const (
+	policyOffBoard = iota
+	policyEmpty
+	policyNeutral
+	policyOwn
+	policyOpponent = policyOwn + boardKinds
+	policyChannels = policyOpponent + boardKinds
+)
+
+// PolicyInputs is the width of the sparse policy input: every window cell's
+// channel plus the movesLeft one-hot.
+const PolicyInputs = PolicyWindow*PolicyWindow*policyChannels + 3
+
+// PolicyActive is how many policy features are on for every cell.
+const PolicyActive = PolicyWindow*PolicyWindow + 1
+
+// PolicySchemaVersion identifies the meaning of policy feature indices; bump
+// it whenever the window, channels or orientation change.
+const PolicySchemaVersion = 1
+
+// AppendPolicyFeatures appends the PolicyActive features of pos, seen by the
+// player to move in state, to dst.
+func AppendPolicyFeatures(dst []int, state game.State, pos game.Pos) []int {
+	mover := state.CurrentPlayer()
+	// Mirror the board-plane orientation: window offsets run away from the
+	// mover's base corner.
+	dr, dc := 1, 1
+	if mover == 2 || mover == 4 {
+		dr = -1
+	}
+	if mover == 2 || mover == 3 {
+		dc = -1
+	}
+	for r := -PolicyRadius; r <= PolicyRadius; r++ {
+		for c := -PolicyRadius; c <= PolicyRadius; c++ {
+			channel := policyOffBoard
+			if cell, ok := state.At(game.Pos{Row: pos.Row + r*dr, Col: pos.Col + c*dc}); ok {
+				channel = policyChannel(cell, mover)
+			}
+			slot := (r+PolicyRadius)*PolicyWindow + c + PolicyRadius
+			dst = append(dst, slot*policyChannels+channel)
+		}
+	}
+	movesLeft := min(max(state.MovesLeft(), 1), 3)
+	return append(dst, PolicyWindow*PolicyWindow*policyChannels+movesLeft-1)
+}
+
+func policyChannel(cell game.Cell, mover game.Player) int {
+	switch cell.Kind {
+	case game.Empty:
+		return policyEmpty
+	case game.Neutral:
+		return policyNeutral
+	}
+	kind := int(cell.Kind - game.Normal)
+	if cell.Owner == mover {
+		return policyOwn + kind
+	}
+	return policyOpponent + kind
+}
@@ -0,0 +1,227 @@
+package search
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"virusgame/game"
+	"virusgame/search/openingbook"
+)
+
+// vs-ai2.68: data-driven opening book. cmd/bookgen grows a tree of early-game
+// positions from engine self-play and stored human games and writes them with
+// per-move statistics (search/openingbook); search plays from it for the first
+// Turns of every seat on any board size. The hand-coded wedge
+// (openingBookMove) stays as the fallback entry for positions the book does not
+// hold.
+//
+// CANARY-FIRST: with no book loaded (VS_OPENING_BOOK unset, no Options.Book)
+// openingBookResult is the wedge alone, byte-identical to before.
+
+// BookChoice picks among a book position's moves.
+type BookChoice uint8
+
+const (
+	// BookBest plays the move with the best mean result, ties to the most
+	// played.
+	BookBest BookChoice = iota
+	// BookWeighted draws a move with probability proportional to how often it
+	// was played. The draws come from the book's RNG (ForGame, or the
+	// process-wide book's own), so games vary; a Book with no RNG draws from
+	// its Seed and the position alone and always replays the same line.
+	BookWeighted
+)
+
+// DefaultBookTurns is how many of each seat's own turns a book covers unless
+// told otherwise.
+const DefaultBookTurns = 4
+
+// Book is a loaded opening book and how to play from it.
+type Book struct {
+	Positions *openingbook.Book
+	// Turns limits the book to each seat's first Turns own turns.
+	Turns  int
+	Choice BookChoice
+	// MinGames drops moves played fewer times than this; 0 keeps every move.
+	MinGames uint32
+	// Seed varies BookWeighted draws. Without an RNG it alone picks each
+	// position's draw, the deterministic mode tests rely on.
+	Seed uint64
+
+	rng *bookRNG
+}
+
+// bookRNG is a SplitMix64 stream shared by every search that plays from one
+// book, so concurrent draws are serialized.
+type bookRNG struct {
+	mu    sync.Mutex
+	state uint64
+}
+
+func (r *bookRNG) next() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state += 0x9e3779b97f4a7c15
+	return splitmix64(r.state)
+}
+
+// ForGame returns a copy of the book whose BookWeighted draws come from an RNG
+// of its own seeded by seed (a game or agent seed) and Seed, so games with
+// different seeds play different lines and a seed replays its game. A nil book
+// stays nil.
+func (b *Book) ForGame(seed uint64) *Book {
+	if b == nil {
+		return nil
+	}
+	forGame := *b
+	forGame.rng = &bookRNG{state: splitmix64(seed) ^ b.Seed}
+	return &forGame
+}
+
+// GameBook is the process-wide book (VS_OPENING_BOOK, LoadOpeningBook) for one
+// game seeded by seed, for Options.Book; nil when no book is loaded.
+func GameBook(seed uint64) *Book {
+	return activeBook.ForGame(seed)
+}
+
+// BookKey is the position key book files are indexed by. It is stateHash, so
+// changing stateHash invalidates every book on disk.
+func BookKey(state game.State) uint64 { return stateHash(state) }
+
+// ReadOpeningBook decodes a book file.
+func ReadOpeningBook(path string) (*openingbook.Book, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	book, err := openingbook.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// LoadOpeningBook installs the book file at path for every later search that
+// carries no Options.Book. Call it at startup, before any search runs; it is
+// not synchronized.
+func LoadOpeningBook(path string, turns int, choice BookChoice, seed uint64) error {
+	positions, err := ReadOpeningBook(path)
+	if err != nil {
+		return err
+	}
+	activeBook = &Book{Positions: positions, Turns: turns, Choice: choice, Seed: seed, rng: &bookRNG{state: seed}}
+	return nil
+}
+
+// activeBook is the process-wide book: VS_OPENING_BOOK names the file,
+// VS_OPENING_BOOK_TURNS its depth (default DefaultBookTurns) and
+// VS_OPENING_BOOK_CHOICE "best" (default) or "weighted". Weighted draws come
+// from one stream for the whole process, seeded from VS_OPENING_BOOK_SEED or
+// the clock when that is unset; GameBook gives a game a stream of its own. A
+// bad file is logged and ignored, leaving the wedge.
+var activeBook = func() *Book {
+	path := os.Getenv("VS_OPENING_BOOK")
+	if path == "" {
+		return nil
+	}
+	positions, err := ReadOpeningBook(path)
+	if err != nil {
+		log.Printf("search: VS_OPENING_BOOK ignored: %v", err)
+		return nil
+	}
+	book := &Book{Positions: positions, Turns: DefaultBookTurns}
+	if v := os.Getenv("VS_OPENING_BOOK_TURNS"); v != "" {
+		if turns, err := strconv.Atoi(v); err == nil && turns >= 0 {
+			book.Turns = turns
+		} else {
+			log.Printf("search: VS_OPENING_BOOK_TURNS %q ignored", v)
+		}
+	}
+	switch v := os.Getenv("VS_OPENING_BOOK_CHOICE"); v {
+	case "", "best":
+	case "weighted":
+		book.Choice = BookWeighted
+		book.Seed = uint64(time.Now().UnixNano())
+		if s := os.Getenv("VS_OPENING_BOOK_SEED"); s != "" {
+			if seed, err := strconv.ParseUint(s, 10, 64); err == nil {
+				book.Seed = seed
+			} else {
+				log.Printf("search: VS_OPENING_BOOK_SEED %q ignored", s)
+			}
+		}
+		book.rng = &bookRNG{state: book.Seed}
+	default:
+		log.Printf("search: VS_OPENING_BOOK_CHOICE %q ignored, playing best", v)
+	}
+	return book
+}()
+
+// book is the search's opening book: Options.Book, else the process-wide one.
+func (opts Options) book() *Book {
+	if opts.Book != nil {
+		return opts.Book
+	}
+	return activeBook
+}
+
+// move returns the book's move for state, or false when the position is not in
+// the book, lies past Turns, or has no legal move left after MinGames.
+func (b *Book) move(state game.State) (game.Action, bool) {
+	if b == nil || b.Positions == nil || state.GameOver() {
+		return game.Action{}, false
+	}
+	key := BookKey(state)
+	entry, ok := b.Positions.Entries[key]
+	if !ok || int(entry.Turn) >= b.Turns {
+		return game.Action{}, false
+	}
+	moves := make([]openingbook.Move, 0, len(entry.Moves))
+	for _, m := range entry.Moves {
+		if m.Games < max(b.MinGames, 1) {
+			continue
+		}
+		if _, err := state.Apply(m.Action); err != nil {
+			continue // a stale or hash-colliding entry; never trust the file
+		}
+		moves = append(moves, m)
+	}
+	if len(moves) == 0 {
+		return game.Action{}, false
+	}
+	if b.Choice == BookWeighted {
+		var total uint64
+		for _, m := range moves {
+			total += uint64(m.Games)
+		}
+		draw := splitmix64(key ^ b.Seed)
+		if b.rng != nil {
+			draw = b.rng.next()
+		}
+		pick := draw % total
+		for _, m := range moves {
+			if pick < uint64(m.Games) {
+				return m.Action, true
+			}
+			pick -= uint64(m.Games)
+		}
+	}
+	best := moves[0]
+	for _, m := range moves[1:] {
+		if m.Score > best.Score || (m.Score == best.Score && m.Games > best.Games) {
+			best = m
+		}
+	}
+	return best.Action, true
+}
+
+// splitmix64 is the SplitMix64 finalizer, spreading a key into a uniform draw.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
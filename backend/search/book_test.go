@@ -0,0 +1,131 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/search/openingbook"
+)
+
+// bookFixture holds the fresh 10x10 start with two continuations: (0,1) more
+// played, (1,0) better scoring. Neither is the wedge's (1,1).
+func bookFixture(t *testing.T) (game.State, *openingbook.Book) {
+	t.Helper()
+	state := mustState(t, 10, 10, 2)
+	return state, &openingbook.Book{Entries: map[uint64]openingbook.Entry{
+		BookKey(state): {Moves: []openingbook.Move{
+			{Action: move(0, 1), Games: 5, Score: 0.4},
+			{Action: move(1, 0), Games: 3, Score: 0.8},
+		}},
+	}}
+}
+
+func bookAction(t *testing.T, state game.State, book *Book) game.Action {
+	t.Helper()
+	result, ok := ChooseNodeBudgetOptions(state, 1000, Options{Book: book})
+	if !ok {
+		t.Fatal("no result")
+	}
+	return result.Action
+}
+
+func TestBookOverridesTheWedge(t *testing.T) {
+	state, positions := bookFixture(t)
+	wedge, ok := openingBookMove(state)
+	if !ok || wedge != move(1, 1) {
+		t.Fatalf("wedge %+v %t", wedge, ok)
+	}
+	if got := bookAction(t, state, nil); got != wedge {
+		t.Fatalf("no book played %+v, want the wedge", got)
+	}
+	book := &Book{Positions: positions, Turns: 1}
+	if got := bookAction(t, state, book); got != move(1, 0) {
+		t.Fatalf("best choice %+v, want the higher score", got)
+	}
+	result, _ := ChooseNodeBudgetOptions(state, 1000, Options{Book: book})
+	if result.Depth != 0 || result.Nodes != 0 || !result.SearchComplete {
+		t.Fatalf("book result %+v", result)
+	}
+	book.MinGames = 4
+	if got := bookAction(t, state, book); got != move(0, 1) {
+		t.Fatalf("MinGames 4 played %+v", got)
+	}
+	book.MinGames = 10
+	if got := bookAction(t, state, book); got != wedge {
+		t.Fatalf("emptied entry played %+v, want the wedge", got)
+	}
+}
+
+func TestBookRespectsTurnsAndLegality(t *testing.T) {
+	state, positions := bookFixture(t)
+	entry := positions.Entries[BookKey(state)]
+	entry.Turn = 2
+	positions.Entries[BookKey(state)] = entry
+	if got := bookAction(t, state, &Book{Positions: positions, Turns: 2}); got != move(1, 1) {
+		t.Fatalf("entry past Turns played %+v", got)
+	}
+	if got := bookAction(t, state, &Book{Positions: positions, Turns: 3}); got != move(1, 0) {
+		t.Fatalf("entry within Turns played %+v", got)
+	}
+
+	illegal := &openingbook.Book{Entries: map[uint64]openingbook.Entry{
+		BookKey(state): {Moves: []openingbook.Move{{Action: move(5, 5), Games: 9, Score: 1}}},
+	}}
+	if got := bookAction(t, state, &Book{Positions: illegal, Turns: 1}); got != move(1, 1) {
+		t.Fatalf("illegal book move not skipped: %+v", got)
+	}
+}
+
+func TestBookWeightedIsSeeded(t *testing.T) {
+	state, positions := bookFixture(t)
+	seen := map[game.Action]int{}
+	for seed := uint64(0); seed < 200; seed++ {
+		book := &Book{Positions: positions, Turns: 1, Choice: BookWeighted, Seed: seed}
+		first := bookAction(t, state, book)
+		if again := bookAction(t, state, book); again != first {
+			t.Fatalf("seed %d drew %+v then %+v", seed, first, again)
+		}
+		seen[first]++
+	}
+	if len(seen) != 2 || seen[move(0, 1)] <= seen[move(1, 0)] {
+		t.Fatalf("weighted draws %v, want both moves, (0,1) more often", seen)
+	}
+}
+
+func TestBookForGameVariesAndReplays(t *testing.T) {
+	state, positions := bookFixture(t)
+	book := &Book{Positions: positions, Turns: 1, Choice: BookWeighted, Seed: 7}
+	line := func(book *Book) []game.Action {
+		var actions []game.Action
+		for i := 0; i < 40; i++ {
+			actions = append(actions, bookAction(t, state, book))
+		}
+		return actions
+	}
+	first := line(book.ForGame(1))
+	seen := map[game.Action]bool{}
+	for _, action := range first {
+		seen[action] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("one RNG drew %v over 40 games, want both moves", seen)
+	}
+	if again := line(book.ForGame(1)); !reflect.DeepEqual(again, first) {
+		t.Fatal("the same seed drew a different line")
+	}
+	if other := line(book.ForGame(2)); reflect.DeepEqual(other, first) {
+		t.Fatal("another seed drew the same line")
+	}
+	if book.rng != nil || GameBook(1) != nil {
+		t.Fatal("ForGame touched the book, or GameBook made one from nothing")
+	}
+}
+
+func TestNoBookSearchesTheOpening(t *testing.T) {
+	state, positions := bookFixture(t)
+	result, ok := ChooseNodeBudgetOptions(state, 1000, Options{Book: &Book{Positions: positions, Turns: 1}, NoBook: true})
+	if !ok || result.Depth == 0 || result.Nodes == 0 {
+		t.Fatalf("NoBook result %+v", result)
+	}
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"virusgame/game"
+)
+
+// Endgame solver (vs-ai2.63). Late in a 1v1 game the cells anyone can still
+// change — empty cells reachable by an active player plus enemy Normals a
+// player can capture — dwindle, and the hand-tuned eval keeps misjudging races
+// that are small enough to solve. Below endgameThreshold such cells the search
+// first runs an exact alpha-beta whose leaves know only win and loss: terminals
+// score ±mateScore (ply-adjusted, so faster wins and slower losses rank first)
+// and every other leaf (depth-limited, or a node with no children to search)
+// scores 0, "unknown". Because 0 sits strictly
+// inside the mate bounds, a root score at a mate bound is a proof, not an
+// estimate, and the result is marked SearchComplete. An unproven solve hands
+// the remaining budget to the ordinary search.
+//
+// Opt-in through Options.Endgame (canary-first). Multiplayer positions are
+// never solved: maxN scores are not zero-sum proofs.
+
+const (
+	// endgameThreshold is the largest contested region the solver attempts:
+	// the largest measured at which every position was proved.
+	// TestEndgameThresholdMeasurement (24 seeded random 7x7 endgames, 1M-node
+	// budget) proved 24/24 at 16 cells (median 139 nodes, worst 204k), 23/24
+	// at 18 and 22/24 at 20.
+	endgameThreshold = 16
+	// endgameSolveShare is the fraction (1/n) of a node or time budget the
+	// solver may spend before the ordinary search takes over.
+	endgameSolveShare = 2
+)
+
+// EndgameCandidate reports whether Options.Endgame would try to solve state.
+func EndgameCandidate(state game.State) bool {
+	if state.GameOver() || activeCount(state) != 2 {
+		return false
+	}
+	return ContestedCells(state) <= endgameThreshold
+}
+
+// ContestedCells counts the cells whose ownership can still change: empty
+// cells in any empty region touching an active player's connected territory,
+// plus Normal cells an opponent's connected territory is adjacent to.
+func ContestedCells(state game.State) int {
+	cells := snapshotCells(state)
+	connected := allConnected(state, cells)
+	reach := make([]bool, len(cells))
+	queue := make([]int, 0, len(cells))
+	capturable := 0
+	var nearby [8]game.Pos
+	for index, cell := range cells {
+		pos := game.Pos{Row: index / state.Cols(), Col: index % state.Cols()}
+		count := neighbors(state, pos, &nearby)
+		for _, neighbor := range nearby[:count] {
+			at := indexFor(state, neighbor)
+			if cell.Kind == game.Empty && touchesActive(connected, at) && !reach[index] {
+				reach[index] = true
+				queue = append(queue, index)
+				break
+			}
+			if cell.Kind == game.Normal && touchesEnemy(connected, at, cell.Owner) {
+				capturable++
+				break
+			}
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		index := queue[head]
+		pos := game.Pos{Row: index / state.Cols(), Col: index % state.Cols()}
+		count := neighbors(state, pos, &nearby)
+		for _, neighbor := range nearby[:count] {
+			at := indexFor(state, neighbor)
+			if cells[at].Kind == game.Empty && !reach[at] {
+				reach[at] = true
+				queue = append(queue, at)
+			}
+		}
+	}
+	return len(queue) + capturable
+}
+
+func touchesActive(connected [4][]bool, index int) bool {
+	for _, mask := range connected {
+		if mask != nil && mask[index] {
+			return true
+		}
+	}
+	return false
+}
+
+func touchesEnemy(connected [4][]bool, index int, owner game.Player) bool {
+	for player, mask := range connected {
+		if game.Player(player+1) != owner && mask != nil && mask[index] {
+			return true
+		}
+	}
+	return false
+}
+
+// solveEndgame runs win/loss-only iterative deepening within limit nodes (0 =
+// none) and ctx. It reports the result only when the root score is proven,
+// along with the nodes spent either way.
+func solveEndgame(ctx context.Context, state game.State, limit uint64) (Result, uint64, bool) {
+	s := newSearcher(ctx, state)
+	if s.multi {
+		return Result{}, 0, false
+	}
+	s.solving = true
+	s.nodeLimit = limit
+	for depth := 1; depth <= maxDepth; depth++ {
+		result, complete := s.atDepth(state, depth)
+		if !complete {
+			break
+		}
+		if provenScore(result.Score) {
+			result.Depth, result.Nodes = depth, s.nodes
+			result.SearchComplete = true
+			return result, s.nodes, true
+		}
+	}
+	return Result{}, s.nodes, false
+}
+
+// provenScore reports whether score is a ply-adjusted terminal score.
+func provenScore(score int) bool {
+	return abs(score) >= mateScore-maxDepth-1
+}
+
+// endgameNodeBudget tries the solver with a share of a node budget. It returns
+// the proven result, or the nodes the failed attempt consumed.
+func endgameNodeBudget(state game.State, limit uint64, opts Options) (Result, uint64, bool) {
+	share := limit / endgameSolveShare
+	if !opts.Endgame || share == 0 || !EndgameCandidate(state) {
+		return Result{}, 0, false
+	}
+	return solveEndgame(context.Background(), state, share)
+}
+
+// endgameDeadline tries the solver with a share of ctx's remaining time.
+func endgameDeadline(ctx context.Context, state game.State, opts Options) (Result, bool) {
+	if !opts.Endgame || !EndgameCandidate(state) {
+		return Result{}, false
+	}
+	deadline, _ := ctx.Deadline()
+	solveCtx, cancel := context.WithTimeout(ctx, time.Until(deadline)/endgameSolveShare)
+	defer cancel()
+	result, _, ok := solveEndgame(solveCtx, state, 0)
+	return result, ok
+}
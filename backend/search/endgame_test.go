@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+
+	"virusgame/game"
+)
+
+// sparseEndgames plays seeded random 1v1 games on a rows x cols board until
+// the position becomes an endgame candidate, skipping games that end first.
+func sparseEndgames(t *testing.T, rows, cols, count int) []game.State {
+	t.Helper()
+	var positions []game.State
+	for seed := int64(1); len(positions) < count && seed < 10*int64(count); seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		state := mustState(t, rows, cols, 2)
+		for !state.GameOver() && !EndgameCandidate(state) {
+			actions := state.LegalActions()
+			next, err := state.Apply(actions[rng.Intn(len(actions))])
+			if err != nil {
+				t.Fatal(err)
+			}
+			state = next
+		}
+		if !state.GameOver() {
+			positions = append(positions, state)
+		}
+	}
+	if len(positions) < count {
+		t.Fatalf("found %d sparse endgames, want %d", len(positions), count)
+	}
+	return positions
+}
+
+// TestEndgameSolverAgreesWithFullSearch cross-checks every proof against the
+// ordinary search at the proof depth: a forced result within that horizon is
+// a mate score for the eval-leaf search too, and it must be the same one.
+func TestEndgameSolverAgreesWithFullSearch(t *testing.T) {
+	checked := 0
+	for i, state := range sparseEndgames(t, 6, 6, 12) {
+		solved, _, proven := solveEndgame(context.Background(), state, 500_000)
+		if !proven {
+			t.Fatalf("position %d (contested %d) not proven", i, ContestedCells(state))
+		}
+		if !solved.SearchComplete {
+			t.Fatalf("position %d: proven result not marked SearchComplete", i)
+		}
+		if solved.Depth > 5 {
+			continue // the eval-leaf search is too slow to cross-check deep proofs here
+		}
+		checked++
+		full, ok := ChooseDepth(context.Background(), state, solved.Depth)
+		if !ok || full.Score != solved.Score {
+			t.Fatalf("position %d: solver score %d at depth %d, full search %d", i, solved.Score, solved.Depth, full.Score)
+		}
+	}
+	if checked < 6 {
+		t.Fatalf("only %d shallow proofs cross-checked", checked)
+	}
+}
+
+// TestEndgameProofsHoldUnderPlay plays each proven position out with the
+// solver on both sides: the side the proof favours must win. The solver is
+// called directly because a capture can grow the contested region back past
+// the candidate threshold mid-proof.
+func TestEndgameProofsHoldUnderPlay(t *testing.T) {
+	for i, state := range sparseEndgames(t, 6, 6, 8) {
+		first, _, proven := solveEndgame(context.Background(), state, 500_000)
+		if !proven {
+			t.Fatalf("position %d not proven", i)
+		}
+		want := state.CurrentPlayer()
+		if first.Score < 0 {
+			want = 3 - want
+		}
+		for ply := 0; !state.GameOver(); ply++ {
+			result, _, ok := solveEndgame(context.Background(), state, 1_000_000)
+			if !ok || !result.SearchComplete {
+				t.Fatalf("position %d ply %d: proof lost (%+v)", i, ply, result)
+			}
+			next, err := state.Apply(result.Action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			state = next
+		}
+		if state.Winner() != want {
+			t.Fatalf("position %d: proof favoured %d, winner %d", i, want, state.Winner())
+		}
+	}
+}
+
+func TestEndgameOptionIsOptIn(t *testing.T) {
+	state := sparseEndgames(t, 6, 6, 1)[0]
+	_, cost, _ := solveEndgame(context.Background(), state, 0)
+	budget := 2*cost + 2
+	plain, _ := ChooseNodeBudget(state, budget)
+	off, _ := ChooseNodeBudgetOptions(state, budget, Options{})
+	if !sameCore(plain, off) || plain.SearchComplete {
+		t.Fatalf("endgame off: %+v, plain %+v", off, plain)
+	}
+	on, ok := ChooseNodeBudgetOptions(state, budget, Options{Endgame: true})
+	if !ok || !on.SearchComplete || on.Nodes != cost {
+		t.Fatalf("endgame on: %+v, want the %d-node proof", on, cost)
+	}
+	starved, ok := ChooseNodeBudgetOptions(state, cost, Options{Endgame: true})
+	if !ok || starved.SearchComplete || starved.Nodes > cost {
+		t.Fatalf("endgame with half the proof budget: %+v, want the ordinary search within %d nodes", starved, cost)
+	}
+}
+
+func TestEndgameSkipsOpenAndMultiplayerPositions(t *testing.T) {
+	if EndgameCandidate(mustState(t, 12, 12, 2)) {
+		t.Fatal("empty 12x12 board treated as an endgame")
+	}
+	if ContestedCells(mustState(t, 5, 5, 2)) != 23 {
+		t.Fatalf("empty 5x5 contested = %d, want 23 (all but the bases)", ContestedCells(mustState(t, 5, 5, 2)))
+	}
+	three := mustState(t, 4, 4, 3)
+	if EndgameCandidate(three) {
+		t.Fatal("3-player position treated as an endgame")
+	}
+	if _, _, ok := solveEndgame(context.Background(), three, 1000); ok {
+		t.Fatal("solver claimed a multiplayer proof")
+	}
+}
+
+// TestEndgameThresholdMeasurement is the measurement behind endgameThreshold:
+// for each candidate threshold it plays 24 seeded random 7x7 games to the
+// first position at or under it and solves that with a 1M-node budget.
+//
+//	VS_ENDGAME_THRESHOLD=1 go test ./search -run TestEndgameThresholdMeasurement -v
+func TestEndgameThresholdMeasurement(t *testing.T) {
+	if os.Getenv("VS_ENDGAME_THRESHOLD") != "1" {
+		t.Skip("set VS_ENDGAME_THRESHOLD=1 to measure solver cost by contested-cell threshold")
+	}
+	for _, threshold := range []int{16, 18, 20} {
+		proved, total := 0, 0
+		var nodes []int
+		for seed := int64(1); total < 24 && seed < 400; seed++ {
+			rng := rand.New(rand.NewSource(seed))
+			state := mustState(t, 7, 7, 2)
+			for !state.GameOver() && (activeCount(state) != 2 || ContestedCells(state) > threshold) {
+				actions := state.LegalActions()
+				next, err := state.Apply(actions[rng.Intn(len(actions))])
+				if err != nil {
+					t.Fatal(err)
+				}
+				state = next
+			}
+			if state.GameOver() {
+				continue
+			}
+			total++
+			if _, used, ok := solveEndgame(context.Background(), state, 1_000_000); ok {
+				proved++
+				nodes = append(nodes, int(used))
+			}
+		}
+		sort.Ints(nodes)
+		median, worst := 0, 0
+		if len(nodes) > 0 {
+			median, worst = nodes[len(nodes)/2], nodes[len(nodes)-1]
+		}
+		t.Logf("threshold %d: proved %d/%d within 1M nodes, median %d, worst %d", threshold, proved, total, median, worst)
+	}
+}
@@ -1,6 +1,9 @@
 package search
 
-import "virusgame/game"
+import (
+	"virusgame/game"
+	"virusgame/search/nnueweights"
+)
 
 const mateScore = 1_000_000_000
 
@@ -45,8 +48,9 @@ const spaceRaceWeight = 32
 // EvalParams is the flat vector of hand-set evaluation weights. Every field
 // defaults (via defaultEvalParams) to the constant it replaced, so the
 // production path is byte-equivalent to the old literals — proven by the
-// oracle/golden tests. The tuner (cmd/spsatune) is the ONLY injection point,
-// through SetEvalParams; production never calls it.
+// oracle/golden tests. The tuners (cmd/spsatune, cmd/texeltune) and bot-hoster
+// personalities inject weights through Options.Params or SetEvalParams; the
+// default production path never does.
 type EvalParams struct {
 	Connected           int
 	Normal              int
@@ -79,12 +83,13 @@ func defaultEvalParams() EvalParams {
 	}
 }
 
-// activeEvalParams holds the weights used by evaluateAllWithWorkspace. It is a
-// process global: only SetEvalParams mutates it, and only the tuner calls that.
+// activeEvalParams holds the weights evaluateAllWithWorkspace falls back to when
+// the search carries no Options.Params. Only SetEvalParams mutates it.
 var activeEvalParams = defaultEvalParams()
 
-// SetEvalParams overrides the active evaluation weights. It is the tuner-only
-// injection point (cmd/spsatune) and is NOT called on the production path.
+// SetEvalParams overrides the process-wide evaluation weights. It is NOT called
+// on the production path, and it races with running searches; engines that
+// need their own weights set Options.Params instead.
 func SetEvalParams(p EvalParams) { activeEvalParams = p }
 
 // DefaultEvalParams returns the hand-tuned baseline weights.
@@ -124,6 +129,20 @@ type evalWorkspace struct {
 	scratch      analysisScratch
 	spaceDist    []int16
 	spaceOwner   []int8
+	// params, nnue and net are the searcher's per-search eval overrides; nil
+	// and NNUEDefault read the process-wide settings.
+	params *EvalParams
+	nnue   NNUEMode
+	net    *nnueweights.Net
+	// board is the board-plane NNUE accumulator stack along the search path.
+	board boardAccumulator
+}
+
+func (w *evalWorkspace) evalParams() *EvalParams {
+	if w.params != nil {
+		return w.params
+	}
+	return &activeEvalParams
 }
 
 func (w *evalWorkspace) ensure(size int) {
@@ -230,6 +249,19 @@ func StaticEval(state game.State, player game.Player) int {
 	return evaluate(state, player)
 }
 
+// StaticEvalAll is StaticEval for every seat at once (one shared analysis),
+// indexed by player-1. Unseated and eliminated seats carry their fixed floor.
+func StaticEvalAll(state game.State) [4]int {
+	return evaluateAll(state)
+}
+
+// StaticEvalParams is StaticEval under params rather than the process-wide
+// weights, so a tuner can score candidate weights concurrently. It is always
+// the hand-tuned eval: under VS_NNUE the net would ignore params.
+func StaticEvalParams(state game.State, player game.Player, params EvalParams) int {
+	return evaluateAllWithWorkspace(state, &evalWorkspace{params: &params, nnue: NNUEOff})[player-1]
+}
+
 func evaluateAll(state game.State) [4]int {
 	return evaluateAllWithWorkspace(state, &evalWorkspace{})
 }
@@ -239,13 +271,16 @@ func evaluateWithWorkspace(state game.State, player game.Player, workspace *eval
 	// the production eval below is byte-identical to origin-main. GameOver is
 	// handled by the caller (minimax) before any leaf eval, so nnueEvaluate only
 	// ever sees non-terminal positions.
-	if nnueEnabled {
-		return nnueEvaluate(state, player)
+	if workspace.nnue.enabled() {
+		return nnueEvaluate(state, player, workspace)
 	}
 	return evaluateAllWithWorkspace(state, workspace)[player-1]
 }
 
 func evaluateAllWithWorkspace(state game.State, workspace *evalWorkspace) [4]int {
+	if workspace.nnue.enabled() && !state.GameOver() {
+		return nnueEvaluateAll(state, workspace)
+	}
 	var utility [4]int
 	if state.GameOver() {
 		for player := game.Player(1); player <= 4; player++ {
@@ -264,6 +299,7 @@ func evaluateAllWithWorkspace(state game.State, workspace *evalWorkspace) [4]int
 	cells := snapshotCellsInto(state, workspace.cells)
 	connected := allConnectedInto(state, cells, workspace)
 	space := spaceRace(state, cells, connected, workspace)
+	p := workspace.evalParams()
 	var raw [4]int
 	active := 0
 	for player := game.Player(1); player <= 4; player++ {
@@ -278,7 +314,6 @@ func evaluateAllWithWorkspace(state game.State, workspace *evalWorkspace) [4]int
 		m := metrics[player-1]
 		area := state.Rows() * state.Cols()
 		owned := m.normal + m.fortified + 1 // include the base
-		p := activeEvalParams
 		raw[player-1] = normalized(m.connected, area, p.Connected) +
 			normalized(m.normal, area, p.Normal) + normalized(m.fortified, area, p.Fortified) +
 			normalized(m.mobility, area, p.Mobility) + normalized(m.captures, area, p.Captures) -
@@ -311,7 +346,7 @@ func evaluateAllWithWorkspace(state game.State, workspace *evalWorkspace) [4]int
 			for index, cut := range metrics[opponent-1].articulation {
 				if cut && adjacentConnected(state, index, own.connectedCells) {
 					loss := int(metrics[opponent-1].cutLoss[index])
-					raw[player-1] += activeEvalParams.PredatoryCutBase + ratio(loss, max(1, metrics[opponent-1].connected))/activeEvalParams.PredatoryCutLossDiv
+					raw[player-1] += p.PredatoryCutBase + ratio(loss, max(1, metrics[opponent-1].connected))/p.PredatoryCutLossDiv
 				}
 			}
 		}
@@ -0,0 +1,236 @@
+package search
+
+import "virusgame/game"
+
+// vs-ai2.70: eval explanation. The hand-tuned eval is one long sum in
+// evaluateAllWithWorkspace; when a search picks a bad move the question is
+// which addend pulled it there. ExplainEval recomputes that sum term by term
+// from the same analysis (the same metrics, the same integer arithmetic), so
+// every seat's Score equals StaticEvalAll's — TestExplainEvalMatchesStaticEval
+// holds the two together. It is a diagnostic path; search never calls it.
+
+// EvalTerm is one EvalParams weight's part of a seat's raw score.
+type EvalTerm struct {
+	// Name is the EvalParams field.
+	Name string `json:"name"`
+	// Metric is the raw count the weight applies to: cells, exits, a 0/1
+	// flag, or for ThreatenedLossMult, ThreatenedMult and PredatoryCutLossDiv
+	// a per-mille share of the connected territory.
+	Metric int `json:"metric"`
+	// Weight is the field's value; PredatoryCutLossDiv divides.
+	Weight int `json:"weight"`
+	// Value is the signed contribution after normalization and tempo.
+	Value int `json:"value"`
+}
+
+// PredatoryCut is one opponent articulation cell a seat's connected territory
+// touches, which the eval rewards as a cut threat.
+type PredatoryCut struct {
+	Victim game.Player `json:"victim"`
+	Cell   game.Pos    `json:"cell"`
+	// Loss is how many of the victim's cells the cut disconnects, itself
+	// included.
+	Loss  int `json:"loss"`
+	Value int `json:"value"`
+}
+
+// SeatEval is one seat's side of the eval.
+type SeatEval struct {
+	Player game.Player `json:"player"`
+	Active bool        `json:"active"`
+	Terms  []EvalTerm  `json:"terms,omitempty"`
+	// ThreatTempo multiplies the base-threat and threatened terms.
+	ThreatTempo int `json:"threatTempo"`
+	// SpaceRace is the empty cells the seat reaches strictly first.
+	SpaceRace int            `json:"spaceRace"`
+	Cuts      []PredatoryCut `json:"cuts,omitempty"`
+	// Raw is the sum of Terms; Score is Raw less the mean opponent Raw, the
+	// seat's StaticEval.
+	Raw   int `json:"raw"`
+	Score int `json:"score"`
+}
+
+// EvalExplanation is the hand-tuned eval of a position taken apart.
+type EvalExplanation struct {
+	// Player is the seat Score is for.
+	Player game.Player `json:"player"`
+	Score  int         `json:"score"`
+	Seats  []SeatEval  `json:"seats"`
+	Params EvalParams  `json:"params"`
+	// Per-cell overlays, row-major. Voronoi is the seat that reaches an empty
+	// cell first (0 for occupied or unreached cells, -1 for contested ones).
+	// Articulation marks Normal cells whose capture disconnects their owner's
+	// territory, CutLoss how many cells that would cost. Threatened marks
+	// connected Normal cells next to an opponent's connected territory.
+	Rows         int     `json:"rows"`
+	Cols         int     `json:"cols"`
+	Voronoi      []int8  `json:"voronoi"`
+	Articulation []bool  `json:"articulation"`
+	CutLoss      []int   `json:"cutLoss"`
+	Threatened   []bool  `json:"threatened"`
+	NNUE         *[4]int `json:"nnue,omitempty"`
+}
+
+// ExplainEval takes the process-wide eval of state apart for player. NNUE
+// holds the net's per-seat scores when VS_NNUE routes the eval through it.
+func ExplainEval(state game.State, player game.Player) EvalExplanation {
+	return explainEval(state, player, activeEvalParams, nnueEnabled)
+}
+
+// ExplainEvalParams is ExplainEval under params rather than the process-wide
+// weights.
+func ExplainEvalParams(state game.State, player game.Player, params EvalParams) EvalExplanation {
+	return explainEval(state, player, params, nnueEnabled)
+}
+
+func explainEval(state game.State, player game.Player, p EvalParams, nnue bool) EvalExplanation {
+	size := state.Rows() * state.Cols()
+	out := EvalExplanation{
+		Player: player, Params: p, Rows: state.Rows(), Cols: state.Cols(),
+		Voronoi: make([]int8, size), Articulation: make([]bool, size),
+		CutLoss: make([]int, size), Threatened: make([]bool, size),
+	}
+	if nnue && !state.GameOver() {
+		scores := nnueEvaluateAll(state, &evalWorkspace{})
+		out.NNUE = &scores
+	}
+	if state.GameOver() {
+		utility := evaluateAll(state)
+		for seat := game.Player(1); int(seat) <= state.Players(); seat++ {
+			out.Seats = append(out.Seats, SeatEval{Player: seat, Active: state.Active(seat), Raw: utility[seat-1], Score: utility[seat-1]})
+		}
+		out.Score = utility[player-1]
+		return out
+	}
+
+	workspace := &evalWorkspace{params: &p}
+	workspace.ensure(size)
+	cells := snapshotCellsInto(state, workspace.cells)
+	connected := allConnectedInto(state, cells, workspace)
+	space := spaceRace(state, cells, connected, workspace)
+	for i, owner := range workspace.spaceOwner[:size] {
+		if cells[i].Kind != game.Empty {
+			continue
+		}
+		switch {
+		case owner >= 0:
+			out.Voronoi[i] = owner + 1
+		case owner == -2:
+			out.Voronoi[i] = -1
+		}
+	}
+
+	var metrics [4]playerMetrics
+	var seats [4]SeatEval
+	area := size
+	active := 0
+	for seat := game.Player(1); seat <= 4; seat++ {
+		s := &seats[seat-1]
+		s.Player = seat
+		if !state.Active(seat) {
+			s.Raw = -mateScore / 2
+			continue
+		}
+		active++
+		s.Active = true
+		index := seat - 1
+		metrics[index] = analyzeWithConnectivity(state, seat, cells, connected, &workspace.scratch,
+			workspace.articulation[index], workspace.cutLoss[index])
+		m := metrics[index]
+		for i, cut := range m.articulation {
+			if cut {
+				out.Articulation[i] = true
+				out.CutLoss[i] = int(m.cutLoss[i])
+			}
+			if m.connectedCells[i] && cells[i].Kind == game.Normal && threatenedByConnected(state, i, seat, connected) {
+				out.Threatened[i] = true
+			}
+		}
+
+		owned := m.normal + m.fortified + 1 // include the base
+		sealed, unused, tempo := 0, 0, 0
+		if m.baseExits+m.baseOpenings == 0 {
+			sealed = 1
+		}
+		if !state.NeutralUsed(seat) {
+			unused = 1
+		}
+		if state.CurrentPlayer() == seat {
+			tempo = state.MovesLeft()
+		}
+		s.ThreatTempo, s.SpaceRace = m.threatTempo, space[index]
+		s.Terms = []EvalTerm{
+			{"Connected", m.connected, p.Connected, normalized(m.connected, area, p.Connected)},
+			{"Normal", m.normal, p.Normal, normalized(m.normal, area, p.Normal)},
+			{"Fortified", m.fortified, p.Fortified, normalized(m.fortified, area, p.Fortified)},
+			{"Mobility", m.mobility, p.Mobility, normalized(m.mobility, area, p.Mobility)},
+			{"Captures", m.captures, p.Captures, normalized(m.captures, area, p.Captures)},
+			{"Disconnected", m.disconnected, p.Disconnected, -normalized(m.disconnected, owned, p.Disconnected)},
+			{"BaseExits", m.baseExits, p.BaseExits, p.BaseExits * m.baseExits},
+			{"BaseOpenings", m.baseOpenings, p.BaseOpenings, p.BaseOpenings * m.baseOpenings},
+			{"BaseAnchors", m.baseAnchors, p.BaseAnchors, p.BaseAnchors * m.baseAnchors},
+			{"BaseThreat", m.baseThreat, p.BaseThreat, -p.BaseThreat * m.baseThreat * m.threatTempo},
+			{"ThreatenedLossMult", ratio(m.threatenedLoss, max(1, m.connected)), p.ThreatenedLossMult,
+				-m.threatTempo * p.ThreatenedLossMult * ratio(m.threatenedLoss, max(1, m.connected))},
+			{"ThreatenedMult", ratio(m.threatened, max(1, m.connected)), p.ThreatenedMult,
+				-m.threatTempo * p.ThreatenedMult * ratio(m.threatened, max(1, m.connected))},
+			{"SpaceRace", space[index], p.SpaceRace, normalized(space[index], area, p.SpaceRace)},
+			{"SealedBasePenalty", sealed, p.SealedBasePenalty, -p.SealedBasePenalty * sealed},
+			{"NeutralUnusedBonus", unused, p.NeutralUnusedBonus, p.NeutralUnusedBonus * unused},
+			{"MovesLeftTempo", tempo, p.MovesLeftTempo, p.MovesLeftTempo * tempo},
+		}
+	}
+
+	for seat := game.Player(1); seat <= 4; seat++ {
+		if !state.Active(seat) {
+			continue
+		}
+		s := &seats[seat-1]
+		own := &metrics[seat-1]
+		base := EvalTerm{Name: "PredatoryCutBase", Weight: p.PredatoryCutBase}
+		loss := EvalTerm{Name: "PredatoryCutLossDiv", Weight: p.PredatoryCutLossDiv}
+		for victim := game.Player(1); victim <= 4; victim++ {
+			if victim == seat || !state.Active(victim) {
+				continue
+			}
+			for index, cut := range metrics[victim-1].articulation {
+				if !cut || !adjacentConnected(state, index, own.connectedCells) {
+					continue
+				}
+				cells := int(metrics[victim-1].cutLoss[index])
+				share := ratio(cells, max(1, metrics[victim-1].connected))
+				value := p.PredatoryCutBase + share/p.PredatoryCutLossDiv
+				s.Cuts = append(s.Cuts, PredatoryCut{
+					Victim: victim, Cell: game.Pos{Row: index / state.Cols(), Col: index % state.Cols()},
+					Loss: cells, Value: value,
+				})
+				base.Metric++
+				base.Value += p.PredatoryCutBase
+				loss.Metric += share
+				loss.Value += share / p.PredatoryCutLossDiv
+			}
+		}
+		s.Terms = append(s.Terms, base, loss)
+		for _, term := range s.Terms {
+			s.Raw += term.Value
+		}
+	}
+
+	for seat := game.Player(1); seat <= 4; seat++ {
+		s := &seats[seat-1]
+		s.Score = s.Raw
+		if !s.Active || active <= 1 {
+			continue
+		}
+		opponents := 0
+		for other := game.Player(1); other <= 4; other++ {
+			if other != seat && state.Active(other) {
+				opponents += seats[other-1].Raw
+			}
+		}
+		s.Score = s.Raw - opponents/(active-1)
+	}
+	out.Seats = seats[:state.Players()]
+	out.Score = seats[player-1].Score
+	return out
+}
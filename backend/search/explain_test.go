@@ -0,0 +1,101 @@
+package search
+
+import (
+	"testing"
+
+	"virusgame/game"
+)
+
+// explainPositions walks a few seeded random games of every seat count and
+// returns their positions along the way.
+func explainPositions(t *testing.T) []game.State {
+	t.Helper()
+	var out []game.State
+	rng := uint64(0x5eed)
+	for _, setup := range []struct{ rows, cols, players int }{{6, 6, 2}, {8, 8, 2}, {7, 9, 3}, {8, 8, 4}} {
+		state := mustState(t, setup.rows, setup.cols, setup.players)
+		for ply := 0; ply < 60 && !state.GameOver(); ply++ {
+			legal := state.LegalActions()
+			rng ^= rng << 13
+			rng ^= rng >> 7
+			rng ^= rng << 17
+			var err error
+			if state, err = state.Apply(legal[rng%uint64(len(legal))]); err != nil {
+				t.Fatal(err)
+			}
+			if ply%4 == 0 || state.GameOver() {
+				out = append(out, state)
+			}
+		}
+	}
+	return out
+}
+
+// TestExplainEvalMatchesStaticEval: the explained terms add up to the eval
+// search runs, seat by seat, under default and altered weights.
+func TestExplainEvalMatchesStaticEval(t *testing.T) {
+	params := DefaultEvalParams()
+	params.SpaceRace, params.PredatoryCutBase, params.ThreatenedMult, params.PredatoryCutLossDiv = 50, 400, 3, 5
+	cuts, articulation, threatened := 0, 0, 0
+	for i, state := range explainPositions(t) {
+		want := StaticEvalAll(state)
+		for seat := game.Player(1); int(seat) <= state.Players(); seat++ {
+			got := ExplainEval(state, seat)
+			if got.Score != want[seat-1] {
+				t.Fatalf("position %d seat %d: explained %d, StaticEval %d", i, seat, got.Score, want[seat-1])
+			}
+			if tuned := ExplainEvalParams(state, seat, params); tuned.Score != StaticEvalParams(state, seat, params) {
+				t.Fatalf("position %d seat %d: explained %d under params, StaticEvalParams %d", i, seat, tuned.Score, StaticEvalParams(state, seat, params))
+			}
+		}
+		explained := ExplainEval(state, state.CurrentPlayer())
+		for _, s := range explained.Seats {
+			sum := 0
+			for _, term := range s.Terms {
+				sum += term.Value
+			}
+			if s.Active && !state.GameOver() && sum != s.Raw {
+				t.Fatalf("position %d seat %d: terms sum to %d, raw %d", i, s.Player, sum, s.Raw)
+			}
+			cuts += len(s.Cuts)
+		}
+		for cell := range explained.Articulation {
+			if explained.Articulation[cell] {
+				articulation++
+			}
+			if explained.Threatened[cell] {
+				threatened++
+			}
+		}
+	}
+	if cuts == 0 || articulation == 0 || threatened == 0 {
+		t.Fatalf("fixture too quiet: %d cuts, %d articulation cells, %d threatened cells", cuts, articulation, threatened)
+	}
+}
+
+// TestExplainEvalOverlays: the opening position splits the board into the
+// seats' Voronoi regions, and NNUE is reported only when it is on.
+func TestExplainEvalOverlays(t *testing.T) {
+	state := play(t, mustState(t, 6, 6, 2), move(1, 1), move(2, 2), move(3, 3))
+	explained := explainEval(state, 1, DefaultEvalParams(), false)
+	if explained.NNUE != nil {
+		t.Fatal("NNUE reported with the net off")
+	}
+	regions := map[int8]int{}
+	for _, owner := range explained.Voronoi {
+		regions[owner]++
+	}
+	if regions[1] <= regions[2] || regions[2] == 0 {
+		t.Fatalf("regions %v: the seat three cells out should claim more", regions)
+	}
+	if s := explained.Seats[0]; s.SpaceRace != regions[1] {
+		t.Fatalf("seat 1 space race %d, overlay holds %d", s.SpaceRace, regions[1])
+	}
+	// Seat 1's chain hangs off (1,1): losing it loses the rest.
+	if !explained.Articulation[1*6+1] || explained.CutLoss[1*6+1] != 3 {
+		t.Fatalf("chain root articulation %t, loss %d", explained.Articulation[7], explained.CutLoss[7])
+	}
+	if on := explainEval(state, 1, DefaultEvalParams(), true); on.NNUE == nil {
+		t.Fatal("NNUE scores missing with the net on")
+	}
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"virusgame/game"
+)
+
+// Multiplayer search algorithms (vs-ai2.62). maxN is the production 3-4 player
+// search, but it only prunes on immediate wins, so at a fixed node budget it
+// stays shallow and never assumes the opponents might cooperate against it.
+//
+// Paranoid treats every opponent as minimizing the root's eval, which turns
+// the game back into 1v1 minimax with full alpha-beta (and its deeper search)
+// at the price of pessimism. Best-Reply Search (Schadd & Winands 2011) goes
+// further: after each root turn only the single strongest opponent replies —
+// every opponent's turn is merged into one min layer — and the root moves
+// again. BRS reaches the root's next turn in two layers instead of N, so its
+// horizon is longer still, but the positions it searches are not always
+// reachable in real play.
+//
+// Both reuse minimax: the root maximizes evaluate(root), everyone else
+// minimizes it. They are opt-in per search; 1v1 is always plain minimax.
+
+// MultiplayerAlgorithm selects the 3-4 player search. The zero value is MaxN.
+type MultiplayerAlgorithm uint8
+
+const (
+	MaxN MultiplayerAlgorithm = iota
+	Paranoid
+	BestReply
+)
+
+func (a MultiplayerAlgorithm) String() string {
+	switch a {
+	case MaxN:
+		return "maxn"
+	case Paranoid:
+		return "paranoid"
+	case BestReply:
+		return "brs"
+	}
+	return fmt.Sprintf("MultiplayerAlgorithm(%d)", uint8(a))
+}
+
+// ParseMultiplayerAlgorithm parses maxn, paranoid or brs (also "best-reply").
+// The empty string is MaxN.
+func ParseMultiplayerAlgorithm(name string) (MultiplayerAlgorithm, error) {
+	switch strings.TrimSpace(strings.ToLower(name)) {
+	case "", "maxn":
+		return MaxN, nil
+	case "paranoid":
+		return Paranoid, nil
+	case "brs", "best-reply", "bestreply":
+		return BestReply, nil
+	}
+	return MaxN, fmt.Errorf("unknown multiplayer algorithm %q", name)
+}
+
+// maxNSearch reports whether this search runs maxN rather than minimax.
+func (s *searcher) maxNSearch() bool {
+	return s.multi && s.opts.Multiplayer == MaxN
+}
+
+// minimaxChildren expands a minimax node. Outside Best-Reply it is plain
+// orderedChildren.
+func (s *searcher) minimaxChildren(state game.State, ttMove game.Action, hasTT bool) ([]child, bool) {
+	if !s.multi || s.opts.Multiplayer != BestReply {
+		return s.orderedChildren(state, ttMove, hasTT)
+	}
+	return s.bestReplyChildren(state, ttMove, hasTT)
+}
+
+// bestReplyChildren expands a Best-Reply node. The root's own turn expands
+// normally and ends at the opponent layer: the start of an opponent's turn,
+// where every active opponent's first action competes as one merged, re-ordered
+// sibling list. Whichever opponent is chosen plays its whole turn, then the
+// turn is handed straight back to the root, skipping the others.
+func (s *searcher) bestReplyChildren(state game.State, ttMove game.Action, hasTT bool) ([]child, bool) {
+	actor := state.CurrentPlayer()
+	if actor == s.root {
+		return s.orderedChildren(state, ttMove, hasTT)
+	}
+	if state.MovesLeft() < 3 {
+		children, ok := s.orderedChildren(state, ttMove, hasTT)
+		return s.handBackToRoot(children, actor), ok
+	}
+	var merged []child
+	for opponent := game.Player(1); opponent <= 4; opponent++ {
+		if opponent == s.root || !state.Active(opponent) {
+			continue
+		}
+		children, ok := s.orderedChildren(state.HandTurnTo(opponent), ttMove, hasTT)
+		if !ok {
+			return nil, false
+		}
+		merged = append(merged, s.handBackToRoot(children, opponent)...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].order > merged[j].order })
+	return merged, true
+}
+
+// handBackToRoot rewrites children whose turn passed from replier to a third
+// player so the root moves next instead.
+func (s *searcher) handBackToRoot(children []child, replier game.Player) []child {
+	for i := range children {
+		next := children[i].state
+		if next.GameOver() || next.CurrentPlayer() == replier || next.CurrentPlayer() == s.root {
+			continue
+		}
+		children[i].state = next.HandTurnTo(s.root)
+	}
+	return children
+}
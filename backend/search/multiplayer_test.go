@@ -0,0 +1,155 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"virusgame/game"
+)
+
+func threePlayerFixture(t *testing.T) game.State {
+	t.Helper()
+	return play(t, mustState(t, 5, 5, 3),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(3, 3), move(2, 3), move(1, 2),
+		move(1, 3), move(2, 2), move(3, 1),
+	)
+}
+
+func fourPlayerFixture(t *testing.T) game.State {
+	t.Helper()
+	return play(t, mustState(t, 8, 8, 4),
+		move(1, 1), move(2, 2), move(1, 2),
+		move(6, 6), move(5, 5), move(6, 5),
+		move(1, 6), move(2, 5), move(1, 5),
+		move(6, 1), move(5, 2), move(6, 2),
+	)
+}
+
+func TestMultiplayerAlgorithmsAreLegalAndDeterministic(t *testing.T) {
+	for name, state := range map[string]game.State{"3p": threePlayerFixture(t), "4p": fourPlayerFixture(t)} {
+		for _, alg := range []MultiplayerAlgorithm{MaxN, Paranoid, BestReply} {
+			opts := Options{Multiplayer: alg}
+			first, ok := ChooseNodeBudgetOptions(state, 3000, opts)
+			if !ok {
+				t.Fatalf("%s %s: search failed", name, alg)
+			}
+			next, err := state.Apply(first.Action)
+			if err != nil {
+				t.Fatalf("%s %s: illegal action %+v: %v", name, alg, first.Action, err)
+			}
+			if !next.Active(state.CurrentPlayer()) && hasPreservingSuccessor(state) {
+				t.Fatalf("%s %s: self-eliminating %+v", name, alg, first.Action)
+			}
+			second, _ := ChooseNodeBudgetOptions(state, 3000, opts)
+			if !sameCore(first, second) {
+				t.Fatalf("%s %s: non-deterministic %+v then %+v", name, alg, first, second)
+			}
+		}
+	}
+}
+
+// TestMaxNOptionMatchesDefault pins that the zero Options is the production
+// maxN path (the vs-ai2.34 maxn goldens still apply).
+func TestMaxNOptionMatchesDefault(t *testing.T) {
+	state := threePlayerFixture(t)
+	plain, _ := ChooseNodeBudget(state, 1000)
+	explicit, _ := ChooseNodeBudgetOptions(state, 1000, Options{Multiplayer: MaxN})
+	if !sameCore(plain, explicit) {
+		t.Fatalf("explicit MaxN %+v != default %+v", explicit, plain)
+	}
+}
+
+// TestTwoPlayerIgnoresMultiplayerAlgorithm: 1v1 is always minimax.
+func TestTwoPlayerIgnoresMultiplayerAlgorithm(t *testing.T) {
+	state := play(t, mustState(t, 5, 5, 2),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(3, 4), move(2, 3), move(1, 2),
+	)
+	plain, _ := ChooseNodeBudget(state, 1000)
+	for _, alg := range []MultiplayerAlgorithm{Paranoid, BestReply} {
+		got, _ := ChooseNodeBudgetOptions(state, 1000, Options{Multiplayer: alg})
+		if !sameCore(plain, got) {
+			t.Fatalf("%s changed a 1v1 search: %+v, plain %+v", alg, got, plain)
+		}
+	}
+}
+
+// TestParanoidAndBestReplyPruneDeeper is the point of both algorithms: at the
+// same fixed depth, alpha-beta over a paranoid or best-reply tree visits fewer
+// nodes than shallow-pruned maxN, and best-reply skips whole opponent turns.
+func TestParanoidAndBestReplyPruneDeeper(t *testing.T) {
+	state := fourPlayerFixture(t)
+	nodes := map[MultiplayerAlgorithm]uint64{}
+	for _, alg := range []MultiplayerAlgorithm{MaxN, Paranoid, BestReply} {
+		result, ok := ChooseDepthOptions(context.Background(), state, 5, Options{Multiplayer: alg})
+		if !ok {
+			t.Fatalf("%s: depth-5 search did not complete", alg)
+		}
+		nodes[alg] = result.Nodes
+	}
+	if nodes[Paranoid] >= nodes[MaxN] {
+		t.Fatalf("paranoid nodes %d >= maxN %d at depth 5", nodes[Paranoid], nodes[MaxN])
+	}
+	if nodes[BestReply] >= nodes[MaxN] {
+		t.Fatalf("best-reply nodes %d >= maxN %d at depth 5", nodes[BestReply], nodes[MaxN])
+	}
+}
+
+// TestBestReplyHandsTurnBackToRoot walks the Best-Reply tree: after the root's
+// turn the opponent layer offers every opponent's replies, and a finished
+// reply returns the move to the root rather than the next seat.
+func TestBestReplyHandsTurnBackToRoot(t *testing.T) {
+	state := fourPlayerFixture(t)
+	s := newSearcher(context.Background(), state)
+	s.opts.Multiplayer = BestReply
+	layer := state.PassTurn() // the opponent layer: player 2 to start a turn
+	children, ok := s.minimaxChildren(layer, game.Action{}, false)
+	if !ok || len(children) == 0 {
+		t.Fatal("empty opponent layer")
+	}
+	movers := map[game.Player]bool{}
+	for _, c := range children {
+		if c.action.Kind != game.Move {
+			continue // a neutral pair is a whole turn
+		}
+		if c.state.CurrentPlayer() == s.root {
+			t.Fatalf("first reply action %+v already handed back", c.action)
+		}
+		movers[c.state.CurrentPlayer()] = true
+	}
+	if len(movers) != 3 {
+		t.Fatalf("opponent layer movers = %v, want all three opponents", movers)
+	}
+	finishing := layer.HandTurnTo(3)
+	for finishing.MovesLeft() > 1 {
+		finishing = play(t, finishing, firstMove(finishing))
+	}
+	children, _ = s.minimaxChildren(finishing, game.Action{}, false)
+	for _, c := range children {
+		if !c.state.GameOver() && c.action.Kind == game.Move && c.state.CurrentPlayer() != s.root {
+			t.Fatalf("reply %+v passed the turn to %d, want root %d", c.action, c.state.CurrentPlayer(), s.root)
+		}
+	}
+}
+
+func TestParseMultiplayerAlgorithm(t *testing.T) {
+	for _, alg := range []MultiplayerAlgorithm{MaxN, Paranoid, BestReply} {
+		got, err := ParseMultiplayerAlgorithm(alg.String())
+		if err != nil || got != alg {
+			t.Fatalf("round trip %s = %s, %v", alg, got, err)
+		}
+	}
+	if _, err := ParseMultiplayerAlgorithm("expectimax"); err == nil {
+		t.Fatal("unknown algorithm accepted")
+	}
+}
+
+func firstMove(state game.State) game.Action {
+	for _, action := range state.LegalActions() {
+		if action.Kind == game.Move {
+			return action
+		}
+	}
+	return game.Action{}
+}
@@ -1,6 +1,8 @@
 package search
 
 import (
+	"fmt"
+	"log"
 	"os"
 
 	"virusgame/game"
@@ -29,17 +31,164 @@ var nnueEnabled = func() bool {
 	return v != "" && v != "0"
 }()
 
-// nnueEvaluate returns the net's score for player at a non-terminal leaf. The
-// net predicts the mover's (CurrentPlayer's) deep-search score; for the
-// 2-player zero-sum game the score for any other seat is its negation.
-//
-// ponytail: 2-player sign flip only. A real 4-player perspective mapping is a
-// concern for when the net actually ships — the smoke net's strength is
-// meaningless, so exact multi-seat perspective is not worth building now.
-func nnueEvaluate(state game.State, player game.Player) int {
-	pred := int(nnueweights.Predict(nnuefeat.Input(state)))
-	if player == state.CurrentPlayer() {
-		return pred
-	}
-	return -pred
+// activeNet is the net nnueEvaluate runs: the compiled-in weights, unless
+// VS_NNUE_WEIGHTS names a weights file (nnueweights binary format) that loads
+// and matches this build's feature schema. A bad file is logged and ignored, so
+// a typo can never take the engine down — it just runs the compiled net.
+var activeNet = func() *nnueweights.Net {
+	path := os.Getenv("VS_NNUE_WEIGHTS")
+	if path == "" {
+		return nnueweights.Compiled()
+	}
+	net, err := ReadNNUEWeights(path)
+	if err != nil {
+		log.Printf("search: VS_NNUE_WEIGHTS ignored, using compiled weights: %v", err)
+		return nnueweights.Compiled()
+	}
+	return net
+}()
+
+// ReadNNUEWeights decodes a weights file and checks that it was trained on
+// this build's feature vector.
+func ReadNNUEWeights(path string) (*nnueweights.Net, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	net, err := nnueweights.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if net.Schema != nnuefeat.FeatureSchemaVersion || net.FeatureCount != nnuefeat.FeatureCount || net.Seats != nnuefeat.Seats {
+		return nil, fmt.Errorf("%s: trained on feature schema v%d (%d×%d), this build extracts v%d (%d×%d)", path,
+			net.Schema, net.Seats, net.FeatureCount, nnuefeat.FeatureSchemaVersion, nnuefeat.Seats, nnuefeat.FeatureCount)
+	}
+	return net, nil
+}
+
+// LoadNNUEWeights installs the weights file at path for every later NNUE eval.
+// Call it at startup, before any search runs; it is not synchronized.
+func LoadNNUEWeights(path string) error {
+	net, err := ReadNNUEWeights(path)
+	if err != nil {
+		return err
+	}
+	activeNet = net
+	return nil
+}
+
+// NNUEPredict is the active net's prediction for the mover of state, in eval
+// units, for engines outside search (MCTS leaf values).
+func NNUEPredict(state game.State) float64 {
+	return nnuePredict(state, state.CurrentPlayer(), nil)
+}
+
+// nnuePredict is the net's score for an active seat. It runs the board-plane
+// net when one is loaded and covers the board, moving workspace's accumulator
+// (a fresh one when workspace is nil), and the aggregate net otherwise.
+func nnuePredict(state game.State, seat game.Player, workspace *evalWorkspace) float64 {
+	if net := workspace.boardNet(state); net != nil {
+		if workspace == nil {
+			workspace = &evalWorkspace{}
+		}
+		return workspace.board.predict(net, state, seat)
+	}
+	net := workspace.aggregateNet()
+	if net.SeatRelative {
+		return net.Predict(nnuefeat.SeatInputs(state)[seat-1])
+	}
+	pred := net.Predict(nnuefeat.Input(state))
+	if seat != state.CurrentPlayer() {
+		return -pred
+	}
+	return pred
+}
+
+// aggregateNet is the search's Options.Net, else the process-wide net. A nil
+// workspace reads the process-wide net.
+func (w *evalWorkspace) aggregateNet() *nnueweights.Net {
+	if w != nil && w.net != nil {
+		return w.net
+	}
+	return activeNet
+}
+
+// boardNet is the board-plane net to run on state, or nil when the aggregate
+// net applies: none is loaded, it does not cover the board, or the search
+// named its own aggregate net.
+func (w *evalWorkspace) boardNet(state game.State) *nnueweights.BoardNet {
+	if w != nil && w.net != nil {
+		return nil
+	}
+	if activeBoardNet == nil || !nnuefeat.FitsBoard(state.Rows(), state.Cols()) {
+		return nil
+	}
+	return activeBoardNet
+}
+
+// NNUEMode overrides the VS_NNUE switch for one search.
+type NNUEMode uint8
+
+const (
+	// NNUEDefault follows VS_NNUE.
+	NNUEDefault NNUEMode = iota
+	// NNUEOff always uses the hand-tuned eval.
+	NNUEOff
+	// NNUEOn always uses the net.
+	NNUEOn
+)
+
+func (m NNUEMode) enabled() bool {
+	switch m {
+	case NNUEOff:
+		return false
+	case NNUEOn:
+		return true
+	}
+	return nnueEnabled
+}
+
+// nnueEvaluate returns the net's score for player at a non-terminal leaf.
+// Seat-relative nets (vs-ai2.66: LayoutSeat files and the board-plane net) score
+// the seat directly. A mover-layout net predicts the mover's deep-search score
+// and every other seat gets its negation — exact for the 2-player zero-sum
+// game, a stand-in beyond it.
+func nnueEvaluate(state game.State, player game.Player, workspace *evalWorkspace) int {
+	if !state.Active(player) {
+		return -mateScore / 2
+	}
+	return int(nnuePredict(state, player, workspace))
+}
+
+// nnueEvaluateAll is nnueEvaluate for every seat, the [4]int maxN backs up.
+// Seats out of play carry the classic eval's floor, and one feature extraction
+// serves every seat.
+func nnueEvaluateAll(state game.State, workspace *evalWorkspace) [4]int {
+	var out [4]int
+	var inputs [nnuefeat.Seats][]float64
+	board := workspace.boardNet(state)
+	net := workspace.aggregateNet()
+	if board == nil && net.SeatRelative {
+		inputs = nnuefeat.SeatInputs(state)
+	}
+	mover := 0.0
+	if board == nil && !net.SeatRelative {
+		mover = net.Predict(nnuefeat.Input(state))
+	}
+	for seat := game.Player(1); seat <= 4; seat++ {
+		switch {
+		case !state.Active(seat):
+			out[seat-1] = -mateScore / 2
+		case board != nil:
+			out[seat-1] = int(workspace.board.predict(board, state, seat))
+		case net.SeatRelative:
+			out[seat-1] = int(net.Predict(inputs[seat-1]))
+		case seat == state.CurrentPlayer():
+			out[seat-1] = int(mover)
+		default:
+			out[seat-1] = int(-mover)
+		}
+	}
+	return out
 }
@@ -51,13 +51,53 @@ func BenchmarkClassicEval(b *testing.B) {
 func BenchmarkNNUEEval(b *testing.B) {
 	state := benchMidgame(b)
 	root := state.CurrentPlayer()
+	ws := evalWorkspace{}
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = nnueEvaluate(state, root)
+		_ = nnueEvaluate(state, root, &ws)
 	}
 }
 
+// BenchmarkNNUEBoardEval is the board-plane net as search drives it: the
+// mid-game leaf's children in generation order, each pushed onto the rooted
+// accumulator stack (the one or two cells its action changes), scored and
+// popped. BenchmarkNNUEBoardRefresh rebuilds the accumulators from scratch
+// every call. Both use a 32-wide synthetic net; only the cost matters here.
+func BenchmarkNNUEBoardEval(b *testing.B) {
+	net, root, actions, leaves := boardBench(b)
+	ws := evalWorkspace{}
+	ws.board.root(net, root)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := i % len(leaves)
+		ws.board.push(root, leaves[k], actions[k])
+		_ = ws.board.predict(net, leaves[k], 1)
+		ws.board.pop()
+	}
+}
+
+func BenchmarkNNUEBoardRefresh(b *testing.B) {
+	net, _, _, leaves := boardBench(b)
+	ws := evalWorkspace{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ws.board.predict(net, leaves[i%len(leaves)], 1)
+	}
+}
+
+func boardBench(b *testing.B) (*nnueweights.BoardNet, game.State, []game.Action, []game.State) {
+	root := benchMidgame(b)
+	var actions []game.Action
+	var leaves []game.State
+	forEachChild(root, func(action game.Action, child game.State) {
+		actions, leaves = append(actions, action), append(leaves, child)
+	})
+	return syntheticBoardNet(32, false), root, actions, leaves
+}
+
 // BenchmarkNNUEForwardOnly isolates the int8 forward pass from feature
 // extraction, so the cost breakdown (extract vs matmul) is visible.
 func BenchmarkNNUEForwardOnly(b *testing.B) {
@@ -0,0 +1,190 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"virusgame/game"
+	"virusgame/nnuefeat"
+	"virusgame/search/nnueweights"
+)
+
+// vs-ai2.65: incrementally updated NNUE. The aggregate net (nnue.go) re-extracts
+// connectivity, articulation and space-race for every seat at every leaf; the
+// board-plane net (nnuefeat board planes, nnueweights.BoardNet) only needs the
+// cells an action changes. Search is copy-make, so the accumulator is a stack
+// beside the recursion: the root refreshes it, each child searched pushes the
+// parent's sums moved by the one or two cells its action changed (one column
+// subtract/add per seat perspective), and returning pops it. Null moves and
+// Best-Reply hand-backs leave the board alone and need no entry.
+//
+// CANARY-FIRST like the aggregate net: nothing changes unless a board net is
+// loaded (VS_NNUE_BOARD_WEIGHTS or LoadNNUEBoardWeights) AND the NNUE path is
+// on (VS_NNUE or Options.NNUE).
+
+// activeBoardNet, when set, replaces the aggregate net on the NNUE path.
+var activeBoardNet = func() *nnueweights.BoardNet {
+	path := os.Getenv("VS_NNUE_BOARD_WEIGHTS")
+	if path == "" {
+		return nil
+	}
+	net, err := ReadNNUEBoardWeights(path)
+	if err != nil {
+		log.Printf("search: VS_NNUE_BOARD_WEIGHTS ignored: %v", err)
+		return nil
+	}
+	return net
+}()
+
+// ReadNNUEBoardWeights decodes a board-plane weights file and checks it was
+// trained on this build's plane layout.
+func ReadNNUEBoardWeights(path string) (*nnueweights.BoardNet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	net, err := nnueweights.DecodeBoard(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if net.Schema != nnuefeat.BoardSchemaVersion {
+		return nil, fmt.Errorf("%s: trained on board schema v%d, this build extracts v%d", path, net.Schema, nnuefeat.BoardSchemaVersion)
+	}
+	return net, nil
+}
+
+// LoadNNUEBoardWeights installs the board-plane net at path for every later
+// NNUE eval. Call it at startup, before any search runs; it is not synchronized.
+func LoadNNUEBoardWeights(path string) error {
+	net, err := ReadNNUEBoardWeights(path)
+	if err != nil {
+		return err
+	}
+	activeBoardNet = net
+	return nil
+}
+
+// boardAccumulator is one BoardNet first layer per seat perspective for each
+// state on the search path. Outside a search (rooted false) every predict
+// rebuilds it from scratch.
+type boardAccumulator struct {
+	net     *nnueweights.BoardNet
+	views   [nnuefeat.Seats]nnuefeat.BoardView
+	tracked [nnuefeat.Seats]bool
+	// stack[top] is the node being searched, stack[0] the root.
+	stack    [][nnuefeat.Seats][]int32
+	top      int
+	rooted   bool
+	features []int
+}
+
+// root starts a search at state.
+func (a *boardAccumulator) root(net *nnueweights.BoardNet, state game.State) {
+	a.refresh(net, state)
+	a.rooted = true
+}
+
+// push moves the accumulator from parent to next, the child action leads to.
+func (a *boardAccumulator) push(parent, next game.State, action game.Action) {
+	if !a.rooted {
+		return
+	}
+	a.top++
+	if a.top == len(a.stack) {
+		a.stack = append(a.stack, [nnuefeat.Seats][]int32{})
+	}
+	for seat, tracked := range a.tracked {
+		if tracked {
+			a.stack[a.top][seat] = append(a.stack[a.top][seat][:0], a.stack[a.top-1][seat]...)
+		}
+	}
+	if action.Kind == game.PlaceNeutrals {
+		a.change(parent, next, action.Neutrals[0])
+		a.change(parent, next, action.Neutrals[1])
+		return
+	}
+	a.change(parent, next, action.Target)
+}
+
+// pop returns to the parent of the node push entered.
+func (a *boardAccumulator) pop() {
+	if a.rooted {
+		a.top--
+	}
+}
+
+// change swaps pos's feature in every tracked perspective at the top of the
+// stack from its cell in parent to its cell in next.
+func (a *boardAccumulator) change(parent, next game.State, pos game.Pos) {
+	old, _ := parent.At(pos)
+	cell, _ := next.At(pos)
+	if cell == old {
+		return
+	}
+	for seat, tracked := range a.tracked {
+		if !tracked {
+			continue
+		}
+		if f := a.views[seat].Feature(pos, old); f >= 0 {
+			a.net.Sub(a.stack[a.top][seat], f)
+		}
+		if f := a.views[seat].Feature(pos, cell); f >= 0 {
+			a.net.Add(a.stack[a.top][seat], f)
+		}
+	}
+}
+
+// predict is the net's score for seat. Inside a search state must be the node
+// at the top of the stack; outside one the accumulator is rebuilt for it.
+func (a *boardAccumulator) predict(net *nnueweights.BoardNet, state game.State, seat game.Player) float64 {
+	if !a.rooted || a.net != net {
+		a.refresh(net, state)
+	}
+	sums := &a.stack[a.top]
+	players := state.Players()
+	var accs [nnuefeat.Seats][]int32
+	var inPlay [nnuefeat.Seats]bool
+	for k := 0; k < players; k++ {
+		other := game.Player((int(seat)-1+k)%players + 1)
+		accs[k], inPlay[k] = sums[other-1], state.Active(other)
+	}
+	var dense []float64
+	if net.DenseDim > 0 {
+		dense = nnuefeat.SeatInputs(state)[seat-1]
+	}
+	return net.Output(&accs, inPlay, dense)
+}
+
+// refresh rebuilds every in-play perspective of state from scratch as the only
+// entry on the stack.
+func (a *boardAccumulator) refresh(net *nnueweights.BoardNet, state game.State) {
+	a.net, a.top, a.rooted = net, 0, false
+	if len(a.stack) == 0 {
+		a.stack = make([][nnuefeat.Seats][]int32, 1)
+	}
+	for seat := range a.tracked {
+		player := game.Player(seat + 1)
+		a.views[seat] = nnuefeat.ViewOf(state, player)
+		a.tracked[seat] = state.Active(player)
+		if !a.tracked[seat] {
+			continue
+		}
+		a.stack[0][seat] = resize(a.stack[0][seat], net.HiddenDim)
+		a.features = nnuefeat.AppendBoardFeatures(a.features[:0], state, player)
+		net.Refresh(a.stack[0][seat], a.features)
+	}
+}
+
+// enterRoot points the board accumulator at a search root when the board net
+// runs there, and otherwise leaves it rebuilding per predict.
+func (w *evalWorkspace) enterRoot(state game.State) {
+	w.board.rooted = false
+	if !w.nnue.enabled() {
+		return
+	}
+	if net := w.boardNet(state); net != nil {
+		w.board.root(net, state)
+	}
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/nnuefeat"
+	"virusgame/search/nnueweights"
+)
+
+// syntheticBoardNet is a seeded random board-plane net: strength is irrelevant,
+// the tests only need every column and seat block to matter.
+func syntheticBoardNet(hidden int, dense bool) *nnueweights.BoardNet {
+	rng := rand.New(rand.NewSource(65))
+	int8s := func(n int) []int8 {
+		out := make([]int8, n)
+		for i := range out {
+			out[i] = int8(rng.Intn(129) - 64)
+		}
+		return out
+	}
+	net := &nnueweights.BoardNet{
+		Schema: nnuefeat.BoardSchemaVersion, HiddenDim: hidden, Std: 1000,
+		B1: make([]int32, hidden), W1: int8s(nnuefeat.BoardFeatures * hidden), W1Scale: 1.0 / 400,
+		W2: int8s(nnuefeat.Seats * hidden), W2Scale: 1.0 / 64,
+	}
+	for h := range net.B1 {
+		net.B1[h] = int32(rng.Intn(401) - 200)
+	}
+	if dense {
+		net.DenseDim, net.DenseScale = nnuefeat.InputDim, 1.0/4000
+		for h := 0; h < hidden; h++ {
+			net.Dense = append(net.Dense, int8s(nnuefeat.InputDim))
+		}
+	}
+	return net
+}
+
+func useBoardNet(t testing.TB, net *nnueweights.BoardNet) {
+	prev := activeBoardNet
+	activeBoardNet = net
+	t.Cleanup(func() { activeBoardNet = prev })
+}
+
+// TestBoardFeaturesAreSeatRelative: every seat sees its own base top-left and
+// itself as owner 0, so a symmetric 1v1 looks identical from both seats.
+func TestBoardFeaturesAreSeatRelative(t *testing.T) {
+	state := play(t, mustState(t, 7, 9, 2), move(1, 1), move(2, 2), move(2, 3), move(5, 7), move(4, 6), move(4, 5))
+	one := nnuefeat.AppendBoardFeatures(nil, state, 1)
+	two := nnuefeat.AppendBoardFeatures(nil, state, 2)
+	slices.Sort(one)
+	slices.Sort(two)
+	if len(one) != 8 || !slices.Equal(one, two) {
+		t.Fatalf("seat 1 sees %v, seat 2 sees %v", one, two)
+	}
+	ownBase := nnuefeat.BoardSide * nnuefeat.BoardSide // plane 1 (own Base), cell (0, 0)
+	for seat := game.Player(1); seat <= 4; seat++ {
+		corners := mustState(t, 7, 9, 4)
+		if !slices.Contains(nnuefeat.AppendBoardFeatures(nil, corners, seat), ownBase) {
+			t.Fatalf("seat %d does not see its base top-left", seat)
+		}
+	}
+	if cell, _ := state.At(game.Pos{Row: 3, Col: 3}); nnuefeat.ViewOf(state, 1).Feature(game.Pos{Row: 3, Col: 3}, cell) != -1 {
+		t.Fatal("empty cell switched a feature on")
+	}
+}
+
+// TestBoardAccumulatorMatchesRefresh walks one accumulator through a depth-2
+// tree in search order, pushing and popping each child; every leaf must score
+// exactly as a from-scratch accumulator does.
+func TestBoardAccumulatorMatchesRefresh(t *testing.T) {
+	for _, dense := range []bool{false, true} {
+		net := syntheticBoardNet(8, dense)
+		for _, root := range []game.State{
+			optionsFixture(t),
+			play(t, mustState(t, 7, 7, 4), move(1, 1), move(1, 2), move(2, 2)),
+		} {
+			var walker boardAccumulator
+			walker.root(net, root)
+			leaves := 0
+			forEachChild(root, func(action game.Action, child game.State) {
+				walker.push(root, child, action)
+				forEachChild(child, func(action game.Action, leaf game.State) {
+					walker.push(child, leaf, action)
+					for seat := game.Player(1); int(seat) <= leaf.Players(); seat++ {
+						if !leaf.Active(seat) {
+							continue
+						}
+						var fresh boardAccumulator
+						if got, want := walker.predict(net, leaf, seat), fresh.predict(net, leaf, seat); got != want {
+							t.Fatalf("leaf %d seat %d: incremental %v, refresh %v", leaves, seat, got, want)
+						}
+					}
+					walker.pop()
+					leaves++
+				})
+				walker.pop()
+			})
+			if leaves < 10 || walker.top != 0 {
+				t.Fatalf("walked %d leaves, ended at stack depth %d", leaves, walker.top)
+			}
+		}
+	}
+}
+
+// TestBoardSearchMatchesRefresh searches with the stack rooted and again with
+// every leaf rebuilt from scratch (minimax and maxN entered below the root
+// never root it): a missed push or pop anywhere in the recursion, null moves
+// and Best-Reply hand-backs included, changes a score or the node count.
+func TestBoardSearchMatchesRefresh(t *testing.T) {
+	useBoardNet(t, syntheticBoardNet(8, false))
+	everything := Selectivity{LateMoveReductions: true, Futility: true, NullMove: true}
+	four := play(t, mustState(t, 7, 7, 4), move(1, 1), move(1, 2), move(2, 2))
+	for _, tc := range []struct {
+		name  string
+		state game.State
+		opts  Options
+	}{
+		{"1v1", optionsFixture(t), Options{NNUE: NNUEOn, Selectivity: everything}},
+		{"paranoid", four, Options{NNUE: NNUEOn, Multiplayer: Paranoid}},
+		{"best-reply", four, Options{NNUE: NNUEOn, Multiplayer: BestReply}},
+		{"maxn", four, Options{NNUE: NNUEOn, Multiplayer: MaxN}},
+	} {
+		search := func(rooted bool) ([4]int, uint64) {
+			s := newSearcher(context.Background(), tc.state)
+			s.configure(tc.opts)
+			if rooted {
+				s.eval.enterRoot(tc.state)
+				if !s.eval.board.rooted {
+					t.Fatalf("%s: board net not rooted", tc.name)
+				}
+			}
+			if s.maxNSearch() {
+				values, _ := s.maxN(tc.state, 3, 0)
+				return values, s.nodes
+			}
+			score, _ := s.minimax(tc.state, 3, -infScore, infScore, 0)
+			return [4]int{score}, s.nodes
+		}
+		incremental, nodes := search(true)
+		fresh, want := search(false)
+		if incremental != fresh || nodes != want {
+			t.Fatalf("%s: incremental %v in %d nodes, refresh %v in %d", tc.name, incremental, nodes, fresh, want)
+		}
+	}
+}
+
+func forEachChild(state game.State, visit func(game.Action, game.State)) {
+	if state.GameOver() {
+		return
+	}
+	pos := game.NewPosition(state)
+	pos.ForEachSearchAction(func(action game.Action) bool {
+		visit(action, pos.ApplySearch(action).State())
+		return true
+	})
+}
+
+func TestBoardNetDrivesTheNNUEPath(t *testing.T) {
+	state := optionsFixture(t)
+	aggregate := nnueEvaluate(state, state.CurrentPlayer(), &evalWorkspace{})
+	net := syntheticBoardNet(8, false)
+	var buf bytes.Buffer
+	if err := net.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "board.nnue")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useBoardNet(t, nil)
+	if err := LoadNNUEBoardWeights(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var fresh boardAccumulator
+	want := int(fresh.predict(activeBoardNet, state, state.CurrentPlayer()))
+	ws := &evalWorkspace{nnue: NNUEOn}
+	if got := evaluateWithWorkspace(state, state.CurrentPlayer(), ws); got != want || got == aggregate {
+		t.Fatalf("NNUE leaf = %d, want board net %d (aggregate net %d)", got, want, aggregate)
+	}
+	if got := int(NNUEPredict(state)); got != want {
+		t.Fatalf("NNUEPredict = %d, want %d", got, want)
+	}
+	first, _ := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOn})
+	again, _ := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOn})
+	if !sameCore(first, again) {
+		t.Fatalf("board-net search is not deterministic: %+v vs %+v", first, again)
+	}
+
+	// Boards past the planes fall back to the aggregate net.
+	wide := mustState(t, nnuefeat.BoardSide+1, 6, 2)
+	if got, want := nnueEvaluate(wide, 1, ws), int(activeNet.Predict(nnuefeat.Input(wide))); got != want {
+		t.Fatalf("oversized board scored %d, aggregate net %d", got, want)
+	}
+
+	stale := syntheticBoardNet(4, false)
+	stale.Schema = nnuefeat.BoardSchemaVersion + 1
+	buf.Reset()
+	if err := stale.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadNNUEBoardWeights(path); err == nil {
+		t.Fatal("board net from another schema accepted")
+	}
+}
@@ -1,10 +1,15 @@
 package search
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"virusgame/game"
 	"virusgame/nnuefeat"
+	"virusgame/search/nnueweights"
 )
 
 // TestNNUEInputLayout guards the one invariant that makes inference valid: the
@@ -47,7 +52,7 @@ func TestNNUEEvaluateRouting(t *testing.T) {
 	}
 
 	nnueEnabled = true
-	if got, want := evaluateWithWorkspace(state, root, &evalWorkspace{}), nnueEvaluate(state, root); got != want {
+	if got, want := evaluateWithWorkspace(state, root, &evalWorkspace{}), nnueEvaluate(state, root, &evalWorkspace{}); got != want {
 		t.Fatalf("flag-on eval = %d, want net %d", got, want)
 	}
 	// Perspective: the net predicts the mover's score; the other seat negates it.
@@ -55,7 +60,135 @@ func TestNNUEEvaluateRouting(t *testing.T) {
 	if root == 1 {
 		other = 2
 	}
-	if got := nnueEvaluate(state, other); got != -nnueEvaluate(state, root) {
-		t.Fatalf("opponent perspective = %d, want %d", got, -nnueEvaluate(state, root))
+	if got := nnueEvaluate(state, other, &evalWorkspace{}); got != -nnueEvaluate(state, root, &evalWorkspace{}) {
+		t.Fatalf("opponent perspective = %d, want %d", got, -nnueEvaluate(state, root, &evalWorkspace{}))
+	}
+}
+
+// TestLoadNNUEWeightsSwapsTheNet installs a file-loaded net and checks the leaf
+// eval follows it; a file trained on another feature schema is refused.
+func TestLoadNNUEWeightsSwapsTheNet(t *testing.T) {
+	state := mustState(t, 6, 6, 2)
+	defer func(prev *nnueweights.Net) { activeNet = prev }(activeNet)
+	dir := t.TempDir()
+	write := func(name string, net *nnueweights.Net) string {
+		var buf bytes.Buffer
+		if err := net.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	shifted := nnueweights.Compiled()
+	shifted.Mean += 1000
+	compiled := nnueEvaluate(state, state.CurrentPlayer(), &evalWorkspace{})
+	if err := LoadNNUEWeights(write("shifted.nnue", shifted)); err != nil {
+		t.Fatal(err)
+	}
+	if got := nnueEvaluate(state, state.CurrentPlayer(), &evalWorkspace{}); got != compiled+1000 {
+		t.Fatalf("loaded net scores %d, want compiled %d + 1000", got, compiled)
+	}
+
+	stale := nnueweights.Compiled()
+	stale.Schema = 1
+	if err := LoadNNUEWeights(write("stale.nnue", stale)); err == nil || !strings.Contains(err.Error(), "schema v1") {
+		t.Fatalf("stale schema error = %v", err)
+	}
+	if activeNet.Mean != shifted.Mean {
+		t.Fatal("a rejected file replaced the active net")
+	}
+}
+
+// TestSeatRelativeNetScoresEverySeat: a LayoutSeat net is run once per seat on
+// that seat's rotated input, and maxN backs those scores up in multiplayer.
+func TestSeatRelativeNetScoresEverySeat(t *testing.T) {
+	state := play(t, mustState(t, 7, 7, 4),
+		move(1, 1), move(1, 2), move(2, 2), move(5, 5), move(5, 4), move(4, 4),
+		move(1, 5), move(1, 4), move(2, 4), move(5, 1), move(5, 2), move(4, 2))
+	inputs := nnuefeat.SeatInputs(state)
+	feats := nnuefeat.NNUEFeatures(state)
+	for seat := 0; seat < 4; seat++ {
+		next := (seat + 1) % 4
+		got := inputs[seat][nnuefeat.FeatureCount : 2*nnuefeat.FeatureCount]
+		for i, want := range feats[next].Features() {
+			if got[i] != want {
+				t.Fatalf("seat %d slot 1 = %v, want seat %d's features", seat+1, got, next+1)
+			}
+		}
+	}
+
+	defer func(prev *nnueweights.Net) { activeNet = prev }(activeNet)
+	seatNet := nnueweights.Compiled()
+	seatNet.SeatRelative = true
+	var buf bytes.Buffer
+	if err := seatNet.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "seat.nnue")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadNNUEWeights(path); err != nil {
+		t.Fatal(err)
+	}
+	if !activeNet.SeatRelative {
+		t.Fatal("layout field did not survive the file")
+	}
+	all := nnueEvaluateAll(state, &evalWorkspace{})
+	for seat := game.Player(1); seat <= 4; seat++ {
+		want := int(activeNet.Predict(inputs[seat-1]))
+		if all[seat-1] != want || nnueEvaluate(state, seat, &evalWorkspace{}) != want {
+			t.Fatalf("seat %d scored %d / %d, want %d", seat, all[seat-1], nnueEvaluate(state, seat, &evalWorkspace{}), want)
+		}
+	}
+
+	result, ok := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOn})
+	if !ok {
+		t.Fatal("no move")
+	}
+	if result.SeatScores[state.CurrentPlayer()-1] != result.Score {
+		t.Fatalf("seat scores %v do not carry the root score %d", result.SeatScores, result.Score)
+	}
+	distinct := map[int]bool{}
+	for _, score := range result.SeatScores {
+		distinct[score] = true
+	}
+	if len(distinct) < 2 {
+		t.Fatalf("maxN backed up one value for every seat: %v", result.SeatScores)
+	}
+}
+
+// TestOptionsNetOverridesTheActiveNet: Options.Net scores one search's leaves
+// without touching the process-wide nets, board-plane included.
+func TestOptionsNetOverridesTheActiveNet(t *testing.T) {
+	state := play(t, mustState(t, 7, 9, 2), move(1, 1), move(2, 2), move(2, 3), move(5, 7), move(4, 6), move(4, 5))
+	shifted := nnueweights.Compiled()
+	shifted.Mean += 1000
+	mover := state.CurrentPlayer()
+	base := nnueEvaluate(state, mover, &evalWorkspace{})
+	if got := nnueEvaluate(state, mover, &evalWorkspace{net: shifted}); got != base+1000 {
+		t.Fatalf("override scores %d, want %d", got, base+1000)
+	}
+	useBoardNet(t, syntheticBoardNet(8, false))
+	if got := nnueEvaluateAll(state, &evalWorkspace{net: shifted})[mover-1]; got != base+1000 {
+		t.Fatalf("board-plane net outranked the override: %d, want %d", got, base+1000)
+	}
+
+	compiled := nnueweights.Compiled()
+	plain, _ := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOn, Net: compiled})
+	again, _ := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOn, Net: compiled})
+	moved, _ := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOn, Net: shifted})
+	if plain.Action != again.Action || plain.Score != again.Score {
+		t.Fatalf("same net searched differently: %+v vs %+v", plain, again)
+	}
+	if moved.Score == plain.Score {
+		t.Fatalf("shifted net left the root score at %d", plain.Score)
+	}
+	if activeNet.Mean == shifted.Mean {
+		t.Fatal("Options.Net leaked into the process-wide net")
 	}
 }
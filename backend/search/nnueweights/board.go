@@ -0,0 +1,244 @@
+package nnueweights
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"virusgame/nnuefeat"
+)
+
+// Board-plane weights file (format version 1). Same conventions as the
+// aggregate net's file — little-endian, CRC-32 trailer — under its own magic:
+//
+//	magic          [4]byte  "VNNB"
+//	format         uint16   BoardFormatVersion
+//	schema         uint16   nnuefeat.BoardSchemaVersion
+//	side, planes   uint16   nnuefeat.BoardSide, nnuefeat.BoardPlanes
+//	hiddenDim      uint32   accumulator width per seat perspective
+//	denseDim       uint32   0, or nnuefeat.InputDim with the aggregate input
+//	mean, std      float64  rawScore = out*std + mean
+//	w1Scale        float64  accumulator units → pre-activation
+//	w2Scale        float64
+//	b2             float64
+//	denseScale     float64
+//	b1             [hiddenDim]int32
+//	w1             [features][hiddenDim]int8
+//	w2             [seats][hiddenDim]int8
+//	dense          [hiddenDim][denseDim]int8
+//	checksum       uint32   CRC-32 (IEEE) of every preceding byte
+
+// BoardMagic opens every board-plane weights file.
+const BoardMagic = "VNNB"
+
+// BoardFormatVersion is the board-plane layout version this package reads and
+// writes.
+const BoardFormatVersion = 1
+
+// BoardNet is a true NNUE over nnuefeat's board planes. Each seat perspective
+// has an integer accumulator, B1 plus the W1 column of every active feature,
+// which search moves along with the board rather than recomputing. The output
+// scores one seat, reading the accumulators in turn order from it through a
+// clipped ReLU.
+type BoardNet struct {
+	Schema, HiddenDim int
+	Mean, Std         float64
+	// B1 and W1 are in accumulator units; W1 is feature-major, so feature f's
+	// column is W1[f*HiddenDim : (f+1)*HiddenDim].
+	B1      []int32
+	W1      []int8
+	W1Scale float64
+	// W2 holds one HiddenDim block per relative seat (0 = the scored seat).
+	W2      []int8
+	W2Scale float64
+	B2      float64
+	// Dense optionally feeds the seat's aggregate nnuefeat.SeatInputs vector
+	// into its own pre-activation; DenseDim 0 keeps the net purely incremental.
+	DenseDim   int
+	Dense      [][]int8
+	DenseScale float64
+}
+
+// Column is the accumulator delta of board feature f.
+func (n *BoardNet) Column(f int) []int8 {
+	return n.W1[f*n.HiddenDim : (f+1)*n.HiddenDim]
+}
+
+// Refresh sets acc to the accumulator of the given active features.
+func (n *BoardNet) Refresh(acc []int32, features []int) {
+	copy(acc, n.B1)
+	for _, f := range features {
+		for h, w := range n.Column(f) {
+			acc[h] += int32(w)
+		}
+	}
+}
+
+// Add and Sub move acc by one feature switching on or off.
+func (n *BoardNet) Add(acc []int32, f int) {
+	for h, w := range n.Column(f) {
+		acc[h] += int32(w)
+	}
+}
+
+func (n *BoardNet) Sub(acc []int32, f int) {
+	for h, w := range n.Column(f) {
+		acc[h] -= int32(w)
+	}
+}
+
+// Output scores a position for one seat from its accumulators, indexed by turn
+// distance from that seat; seats not in play are skipped. dense is the seat's
+// nnuefeat.SeatInputs vector when DenseDim > 0 and ignored otherwise.
+func (n *BoardNet) Output(accs *[nnuefeat.Seats][]int32, inPlay [nnuefeat.Seats]bool, dense []float64) float64 {
+	out := n.B2
+	for k, acc := range accs {
+		if !inPlay[k] {
+			continue
+		}
+		w2 := n.W2[k*n.HiddenDim : (k+1)*n.HiddenDim]
+		for h, sum := range acc {
+			z := float64(sum) * n.W1Scale
+			if k == 0 && n.DenseDim > 0 {
+				row := n.Dense[h]
+				for i, x := range dense {
+					z += float64(row[i]) * n.DenseScale * x
+				}
+			}
+			out += float64(w2[h]) * n.W2Scale * min(max(z, 0), 1)
+		}
+	}
+	return out*n.Std + n.Mean
+}
+
+type boardHeader struct {
+	Magic                   [4]byte
+	Format, Schema          uint16
+	Side, Planes            uint16
+	HiddenDim, DenseDim     uint32
+	Mean, Std, W1Scale      float64
+	W2Scale, B2, DenseScale float64
+}
+
+// Encode writes n in the board-plane binary format.
+func (n *BoardNet) Encode(w io.Writer) error {
+	if err := n.validate(); err != nil {
+		return err
+	}
+	h := boardHeader{
+		Format: BoardFormatVersion, Schema: uint16(n.Schema),
+		Side: nnuefeat.BoardSide, Planes: nnuefeat.BoardPlanes,
+		HiddenDim: uint32(n.HiddenDim), DenseDim: uint32(n.DenseDim),
+		Mean: n.Mean, Std: n.Std, W1Scale: n.W1Scale,
+		W2Scale: n.W2Scale, B2: n.B2, DenseScale: n.DenseScale,
+	}
+	copy(h.Magic[:], BoardMagic)
+	var buf bytes.Buffer
+	for _, part := range n.parts(&h) {
+		if err := binary.Write(&buf, binary.LittleEndian, part); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes())); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (n *BoardNet) parts(h *boardHeader) []any {
+	parts := []any{h, n.B1, n.W1, n.W2}
+	for _, row := range n.Dense {
+		parts = append(parts, row)
+	}
+	return parts
+}
+
+// DecodeBoard reads a board-plane weights file, verifying its magic, format
+// version, checksum, plane geometry and internal consistency. Matching Schema
+// against nnuefeat.BoardSchemaVersion is the caller's job.
+func DecodeBoard(r io.Reader) (*BoardNet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || string(data[:4]) != BoardMagic {
+		return nil, errors.New("nnueweights: not a board weights file")
+	}
+	if len(data) < binary.Size(boardHeader{})+4 {
+		return nil, errors.New("nnueweights: truncated header")
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, errors.New("nnueweights: checksum mismatch")
+	}
+	var h boardHeader
+	if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if h.Format != BoardFormatVersion {
+		return nil, fmt.Errorf("nnueweights: format version %d, want %d", h.Format, BoardFormatVersion)
+	}
+	if h.Side != nnuefeat.BoardSide || h.Planes != nnuefeat.BoardPlanes {
+		return nil, fmt.Errorf("nnueweights: %d planes of side %d, this build has %d of %d",
+			h.Planes, h.Side, nnuefeat.BoardPlanes, nnuefeat.BoardSide)
+	}
+	if h.HiddenDim == 0 || h.HiddenDim > maxHiddenDim || h.DenseDim > math.MaxUint16 {
+		return nil, fmt.Errorf("nnueweights: implausible dims hidden %d dense %d", h.HiddenDim, h.DenseDim)
+	}
+	hidden, dense := int(h.HiddenDim), int(h.DenseDim)
+	want := binary.Size(h) + 4*hidden + nnuefeat.BoardFeatures*hidden + nnuefeat.Seats*hidden + hidden*dense
+	if len(body) != want {
+		return nil, fmt.Errorf("nnueweights: %d payload bytes, want %d for hidden %d dense %d", len(body), want, hidden, dense)
+	}
+	n := &BoardNet{
+		Schema: int(h.Schema), HiddenDim: hidden, Mean: h.Mean, Std: h.Std,
+		B1: make([]int32, hidden), W1: make([]int8, nnuefeat.BoardFeatures*hidden), W1Scale: h.W1Scale,
+		W2: make([]int8, nnuefeat.Seats*hidden), W2Scale: h.W2Scale, B2: h.B2,
+		DenseDim: dense, DenseScale: h.DenseScale,
+	}
+	if dense > 0 {
+		n.Dense = make([][]int8, hidden)
+		for i := range n.Dense {
+			n.Dense[i] = make([]int8, dense)
+		}
+	}
+	reader := bytes.NewReader(body)
+	for _, part := range n.parts(&h) {
+		if err := binary.Read(reader, binary.LittleEndian, part); err != nil {
+			return nil, err
+		}
+	}
+	if err := n.validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// validate checks shapes and that every float is finite.
+func (n *BoardNet) validate() error {
+	if len(n.B1) != n.HiddenDim || len(n.W1) != nnuefeat.BoardFeatures*n.HiddenDim || len(n.W2) != nnuefeat.Seats*n.HiddenDim {
+		return fmt.Errorf("nnueweights: board layer widths disagree with hidden dim %d", n.HiddenDim)
+	}
+	if n.DenseDim != 0 && n.DenseDim != nnuefeat.InputDim {
+		return fmt.Errorf("nnueweights: dense dim %d, want 0 or %d", n.DenseDim, nnuefeat.InputDim)
+	}
+	if len(n.Dense) != 0 && len(n.Dense) != n.HiddenDim || n.DenseDim > 0 && len(n.Dense) == 0 {
+		return fmt.Errorf("nnueweights: dense rows %d, want %d", len(n.Dense), n.HiddenDim)
+	}
+	for _, row := range n.Dense {
+		if len(row) != n.DenseDim {
+			return fmt.Errorf("nnueweights: dense row width %d, want %d", len(row), n.DenseDim)
+		}
+	}
+	for _, f := range []float64{n.Mean, n.Std, n.W1Scale, n.W2Scale, n.B2, n.DenseScale} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New("nnueweights: non-finite weight or scale")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package nnueweights
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"virusgame/nnuefeat"
+)
+
+func boardNet(dense int) *BoardNet {
+	n := &BoardNet{
+		Schema: nnuefeat.BoardSchemaVersion, HiddenDim: 2, Mean: 5, Std: 10,
+		B1: []int32{-300, 700}, W1: make([]int8, nnuefeat.BoardFeatures*2), W1Scale: 1.0 / 1000,
+		W2: make([]int8, nnuefeat.Seats*2), W2Scale: 0.5, B2: 0.25, DenseDim: dense, DenseScale: 0.001,
+	}
+	for i := range n.W1 {
+		n.W1[i] = int8(i%255 - 127)
+	}
+	for i := range n.W2 {
+		n.W2[i] = int8(i - 3)
+	}
+	for h := 0; h < n.HiddenDim && dense > 0; h++ {
+		n.Dense = append(n.Dense, make([]int8, dense))
+		n.Dense[h][h] = 50
+	}
+	return n
+}
+
+func TestBoardNetAccumulatesAndRoundTrips(t *testing.T) {
+	for _, dense := range []int{0, nnuefeat.InputDim} {
+		net := boardNet(dense)
+		features := []int{3, 4, nnuefeat.BoardFeatures - 1}
+		acc := make([]int32, 2)
+		net.Refresh(acc, features[:2])
+		net.Add(acc, features[2])
+		net.Sub(acc, features[0])
+		net.Add(acc, features[0])
+		want := make([]int32, 2)
+		net.Refresh(want, features)
+		if acc[0] != want[0] || acc[1] != want[1] {
+			t.Fatalf("incremental %v, refresh %v", acc, want)
+		}
+		accs := [nnuefeat.Seats][]int32{acc, want}
+		x := make([]float64, nnuefeat.InputDim)
+		x[1] = 300
+		score := net.Output(&accs, [nnuefeat.Seats]bool{true, true}, x)
+
+		var buf bytes.Buffer
+		if err := net.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeBoard(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decoded.Output(&accs, [nnuefeat.Seats]bool{true, true}, x); got != score {
+			t.Fatalf("dense %d: decoded net scores %v, original %v", dense, got, score)
+		}
+		data := buf.Bytes()
+		data[len(data)/2] ^= 1
+		if _, err := DecodeBoard(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "checksum") {
+			t.Fatalf("damaged board file: %v", err)
+		}
+	}
+	if _, err := DecodeBoard(bytes.NewReader(encoded(t, Compiled()))); err == nil {
+		t.Fatal("aggregate weights file decoded as a board net")
+	}
+}
+
+// TestTrainerBoardExportDecodes reads the board net tools/nnue-train wrote for
+// its TestExportBoardGolden and scores the trainer's probe, pinning that the
+// two modules agree on the VNNB format and the forward pass.
+func TestTrainerBoardExportDecodes(t *testing.T) {
+	data, err := os.ReadFile("testdata/trainer-golden-board.nnue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	net, err := DecodeBoard(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net.Schema != nnuefeat.BoardSchemaVersion || net.HiddenDim != 2 || net.DenseDim != 0 {
+		t.Fatalf("golden header %+v", net)
+	}
+	sidecar, err := os.ReadFile("testdata/trainer-golden-board.predict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prediction, err := strconv.ParseFloat(strings.TrimSpace(string(sidecar)), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accs [nnuefeat.Seats][]int32
+	for k, features := range [][]int{{7, 2600, 12345}, {99, 5100, 30000, 32499}} {
+		accs[k] = make([]int32, net.HiddenDim)
+		net.Refresh(accs[k], features)
+	}
+	if got := net.Output(&accs, [nnuefeat.Seats]bool{true, true}, nil); math.Abs(got-prediction) > 1e-9 {
+		t.Fatalf("golden board net scores %v, trainer's int8 pass gave %v", got, prediction)
+	}
+}
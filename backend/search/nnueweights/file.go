@@ -0,0 +1,376 @@
+package nnueweights
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"virusgame/nnuefeat"
+)
+
+// Binary weights file (format version 1), written by tools/nnue-train
+// -export-bin so a new net can be tried without a rebuild. All integers and
+// floats are little-endian:
+//
+//	magic          [4]byte  "VNNU"
+//	format         uint16   FormatVersion
+//	schema         uint16   feature schema version (nnuefeat.FeatureSchemaVersion)
+//	featureCount   uint16   features per seat (nnuefeat.FeatureCount)
+//	seats          uint16
+//	inputDim       uint32   seats × featureCount
+//	hiddenDim      uint32
+//	mean, std      float64  rawScore = out*std + mean
+//	b1Scale        float64
+//	w2Scale        float64
+//	b2             float64
+//	layout         uint16   LayoutMover or LayoutSeat
+//	activation     uint16   ActTanh or ActClippedReLU
+//	depth          uint16   hidden layers after the first
+//	widths         [depth]uint32
+//	w1Scale        [hiddenDim]float64
+//	w1             [hiddenDim][inputDim]int8
+//	b1             [hiddenDim]int8
+//	per deep layer, each over the previous layer's width:
+//	  wScale       [width]float64
+//	  w            [width][previous]int8
+//	  b            [width]int8
+//	  bScale       float64
+//	w2             [last width]int8
+//	checksum       uint32   CRC-32 (IEEE) of every preceding byte
+//
+// A one-layer tanh net mirrors the compiled package vars one for one, so
+// Compiled and a decoded file run the same forward pass.
+
+// Magic opens every weights file.
+const Magic = "VNNU"
+
+// FormatVersion is the binary layout version this package reads and writes.
+const FormatVersion = 1
+
+// Input layouts a net can be trained on.
+const (
+	// LayoutMover is nnuefeat.Input: seats in absolute order, the net scores
+	// the side to move, and other seats are read off it (negated in 1v1).
+	LayoutMover = 0
+	// LayoutSeat is nnuefeat.SeatInputs: the input is rotated to the seat
+	// being scored and the net runs once per seat.
+	LayoutSeat = 1
+)
+
+// Hidden-layer activations.
+const (
+	ActTanh        = 0
+	ActClippedReLU = 1
+)
+
+// maxDepth bounds the deep layers Decode will accept.
+const maxDepth = 8
+
+// maxHiddenDim bounds what Decode will allocate for a corrupt header.
+const maxHiddenDim = 1 << 12
+
+// Net is one int8 network with the header it was exported under.
+type Net struct {
+	// Schema, FeatureCount and Seats describe the input the net was trained
+	// on.
+	Schema, FeatureCount, Seats int
+	InputDim, HiddenDim         int
+	Mean, Std                   float64
+	W1                          [][]int8
+	W1Scale                     []float64
+	B1                          []int8
+	B1Scale                     float64
+	W2                          []int8
+	W2Scale                     float64
+	B2                          float64
+	// SeatRelative marks a LayoutSeat net.
+	SeatRelative bool
+	// Activation is ActTanh or ActClippedReLU for every hidden layer, and
+	// Deep the hidden layers after W1; W2 reads the last one.
+	Activation int
+	Deep       []Layer
+}
+
+// Layer is a hidden layer after the first, quantized like W1 and B1.
+type Layer struct {
+	W      [][]int8
+	WScale []float64
+	B      []int8
+	BScale float64
+}
+
+// Compiled returns the weights built into this package.
+func Compiled() *Net {
+	n := &Net{
+		Schema: nnuefeat.FeatureSchemaVersion, FeatureCount: nnuefeat.FeatureCount, Seats: nnuefeat.Seats,
+		InputDim: InputDim, HiddenDim: HiddenDim, Mean: Mean, Std: Std,
+		W1: make([][]int8, HiddenDim), W1Scale: W1Scale[:], B1: B1[:], B1Scale: B1Scale,
+		W2: W2[:], W2Scale: W2Scale, B2: B2,
+	}
+	for h := range n.W1 {
+		n.W1[h] = W1[h][:]
+	}
+	return n
+}
+
+// Predict is the package Predict over n's weights, operation for operation,
+// generalized to other activations and deep layers.
+func (n *Net) Predict(x []float64) float64 {
+	if len(x) != n.InputDim {
+		panic("nnueweights: input width mismatch")
+	}
+	if len(n.Deep) == 0 {
+		out := n.B2
+		for h := 0; h < n.HiddenDim; h++ {
+			out += float64(n.W2[h]) * n.W2Scale * n.activate(n.unit(h, x))
+		}
+		return out*n.Std + n.Mean
+	}
+	hid := make([]float64, n.HiddenDim)
+	for h := range hid {
+		hid[h] = n.activate(n.unit(h, x))
+	}
+	for _, layer := range n.Deep {
+		next := make([]float64, len(layer.W))
+		for j, row := range layer.W {
+			z := float64(layer.B[j]) * layer.BScale
+			for i, a := range hid {
+				z += float64(row[i]) * layer.WScale[j] * a
+			}
+			next[j] = n.activate(z)
+		}
+		hid = next
+	}
+	out := n.B2
+	for h, a := range hid {
+		out += float64(n.W2[h]) * n.W2Scale * a
+	}
+	return out*n.Std + n.Mean
+}
+
+// unit is first-layer unit h's pre-activation.
+func (n *Net) unit(h int, x []float64) float64 {
+	z := float64(n.B1[h]) * n.B1Scale
+	s := n.W1Scale[h]
+	row := n.W1[h]
+	for i := 0; i < n.InputDim; i++ {
+		z += float64(row[i]) * s * x[i]
+	}
+	return z
+}
+
+func (n *Net) activate(z float64) float64 {
+	if n.Activation == ActClippedReLU {
+		return min(max(z, 0), 1)
+	}
+	return mathTanh(z)
+}
+
+type header struct {
+	Magic                     [4]byte
+	Format, Schema            uint16
+	FeatureCount, Seats       uint16
+	InputDim, HiddenDim       uint32
+	Mean, Std                 float64
+	B1Scale, W2Scale, B2      float64
+	Layout, Activation, Depth uint16
+}
+
+// Encode writes n in the binary format.
+func (n *Net) Encode(w io.Writer) error {
+	if err := n.validate(); err != nil {
+		return err
+	}
+	h := header{
+		Format: FormatVersion, Schema: uint16(n.Schema),
+		FeatureCount: uint16(n.FeatureCount), Seats: uint16(n.Seats),
+		InputDim: uint32(n.InputDim), HiddenDim: uint32(n.HiddenDim),
+		Mean: n.Mean, Std: n.Std, B1Scale: n.B1Scale, W2Scale: n.W2Scale, B2: n.B2,
+		Layout: LayoutMover, Activation: uint16(n.Activation), Depth: uint16(len(n.Deep)),
+	}
+	copy(h.Magic[:], Magic)
+	if n.SeatRelative {
+		h.Layout = LayoutSeat
+	}
+	var buf bytes.Buffer
+	for _, part := range append([]any{&h, n.widths()}, n.body()...) {
+		if err := binary.Write(&buf, binary.LittleEndian, part); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes())); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// body lists the weights after the header and widths, in order, as
+// binary.Read/Write targets.
+func (n *Net) body() []any {
+	parts := append([]any{n.W1Scale}, rows(n.W1)...)
+	parts = append(parts, n.B1)
+	for l := range n.Deep {
+		layer := &n.Deep[l]
+		parts = append(parts, layer.WScale)
+		parts = append(parts, rows(layer.W)...)
+		parts = append(parts, layer.B, &layer.BScale)
+	}
+	return append(parts, n.W2)
+}
+
+func rows(w [][]int8) []any {
+	out := make([]any, len(w))
+	for i, row := range w {
+		out[i] = row
+	}
+	return out
+}
+
+// widths lists the deep layers' widths.
+func (n *Net) widths() []uint32 {
+	out := make([]uint32, len(n.Deep))
+	for l, layer := range n.Deep {
+		out[l] = uint32(len(layer.W))
+	}
+	return out
+}
+
+// Decode reads a binary weights file, verifying its magic, format version,
+// checksum and internal consistency. Matching the schema against the running
+// feature extractor is the caller's job.
+func Decode(r io.Reader) (*Net, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || string(data[:4]) != Magic {
+		return nil, errors.New("nnueweights: not a weights file")
+	}
+	if len(data) < binary.Size(header{})+4 {
+		return nil, errors.New("nnueweights: truncated header")
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, errors.New("nnueweights: checksum mismatch")
+	}
+	var h header
+	reader := bytes.NewReader(body)
+	if err := binary.Read(reader, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if h.Format != FormatVersion {
+		return nil, fmt.Errorf("nnueweights: format version %d, want %d", h.Format, FormatVersion)
+	}
+	if h.HiddenDim == 0 || h.HiddenDim > maxHiddenDim || h.InputDim == 0 || h.InputDim > math.MaxUint16 {
+		return nil, fmt.Errorf("nnueweights: implausible dims %dx%d", h.InputDim, h.HiddenDim)
+	}
+	if h.Depth > maxDepth {
+		return nil, fmt.Errorf("nnueweights: implausible depth %d", h.Depth)
+	}
+	widths := make([]uint32, h.Depth)
+	if err := binary.Read(reader, binary.LittleEndian, widths); err != nil {
+		return nil, fmt.Errorf("nnueweights: truncated header: %w", err)
+	}
+	in, hidden := int(h.InputDim), int(h.HiddenDim)
+	want := len(body) - reader.Len() + hidden*8 + hidden*in + hidden
+	prev := hidden
+	for _, width := range widths {
+		if width == 0 || width > maxHiddenDim {
+			return nil, fmt.Errorf("nnueweights: implausible layer width %d", width)
+		}
+		want += int(width)*8 + int(width)*prev + int(width) + 8
+		prev = int(width)
+	}
+	want += prev
+	if len(body) != want {
+		return nil, fmt.Errorf("nnueweights: %d payload bytes, want %d for %dx%d", len(body), want, in, hidden)
+	}
+	n := &Net{
+		Schema: int(h.Schema), FeatureCount: int(h.FeatureCount), Seats: int(h.Seats),
+		InputDim: in, HiddenDim: hidden, Mean: h.Mean, Std: h.Std,
+		B1Scale: h.B1Scale, W2Scale: h.W2Scale, B2: h.B2,
+		W1Scale: make([]float64, hidden), W1: matrix(hidden, in),
+		B1: make([]int8, hidden), W2: make([]int8, prev),
+		Activation: int(h.Activation),
+	}
+	prev = hidden
+	for _, width := range widths {
+		n.Deep = append(n.Deep, Layer{W: matrix(int(width), prev), WScale: make([]float64, width), B: make([]int8, width)})
+		prev = int(width)
+	}
+	for _, part := range n.body() {
+		if err := binary.Read(reader, binary.LittleEndian, part); err != nil {
+			return nil, err
+		}
+	}
+	switch h.Layout {
+	case LayoutMover:
+	case LayoutSeat:
+		n.SeatRelative = true
+	default:
+		return nil, fmt.Errorf("nnueweights: unknown input layout %d", h.Layout)
+	}
+	if err := n.validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func matrix(rows, cols int) [][]int8 {
+	m := make([][]int8, rows)
+	for i := range m {
+		m[i] = make([]int8, cols)
+	}
+	return m
+}
+
+// validate checks shapes and that every float is finite.
+func (n *Net) validate() error {
+	if n.Seats*n.FeatureCount != n.InputDim {
+		return fmt.Errorf("nnueweights: %d seats × %d features != input dim %d", n.Seats, n.FeatureCount, n.InputDim)
+	}
+	if len(n.W1) != n.HiddenDim || len(n.W1Scale) != n.HiddenDim || len(n.B1) != n.HiddenDim {
+		return fmt.Errorf("nnueweights: layer widths disagree with hidden dim %d", n.HiddenDim)
+	}
+	if n.Activation != ActTanh && n.Activation != ActClippedReLU {
+		return fmt.Errorf("nnueweights: unknown activation %d", n.Activation)
+	}
+	if err := checkRows(n.W1, n.InputDim); err != nil {
+		return err
+	}
+	floats := append([]float64{n.Mean, n.Std, n.B1Scale, n.W2Scale, n.B2}, n.W1Scale...)
+	prev := n.HiddenDim
+	for _, layer := range n.Deep {
+		if len(layer.W) == 0 || len(layer.WScale) != len(layer.W) || len(layer.B) != len(layer.W) {
+			return errors.New("nnueweights: deep layer widths disagree")
+		}
+		if err := checkRows(layer.W, prev); err != nil {
+			return err
+		}
+		floats = append(append(floats, layer.BScale), layer.WScale...)
+		prev = len(layer.W)
+	}
+	if len(n.W2) != prev {
+		return fmt.Errorf("nnueweights: W2 width %d, want %d", len(n.W2), prev)
+	}
+	for _, f := range floats {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New("nnueweights: non-finite weight or scale")
+		}
+	}
+	return nil
+}
+
+func checkRows(w [][]int8, width int) error {
+	for _, row := range w {
+		if len(row) != width {
+			return fmt.Errorf("nnueweights: row width %d, want %d", len(row), width)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package nnueweights
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func encoded(t *testing.T, n *Net) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := n.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func probe(width int) []float64 {
+	x := make([]float64, width)
+	for i := range x {
+		x[i] = float64(i%7) - 3
+	}
+	return x
+}
+
+func TestCompiledNetMatchesPackagePredict(t *testing.T) {
+	x := probe(InputDim)
+	if got, want := Compiled().Predict(x), Predict(x); got != want {
+		t.Fatalf("Compiled().Predict = %v, package Predict %v", got, want)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	net := Compiled()
+	decoded, err := Decode(bytes.NewReader(encoded(t, net)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Schema != net.Schema || decoded.FeatureCount != net.FeatureCount || decoded.HiddenDim != HiddenDim {
+		t.Fatalf("header %+v did not survive the round trip", decoded)
+	}
+	x := probe(InputDim)
+	if got, want := decoded.Predict(x), Predict(x); got != want {
+		t.Fatalf("decoded net predicts %v, compiled %v", got, want)
+	}
+}
+
+func TestDecodeRejectsDamagedFiles(t *testing.T) {
+	good := encoded(t, Compiled())
+	resum := func(data []byte) []byte {
+		binary.LittleEndian.PutUint32(data[len(data)-4:], crc32.ChecksumIEEE(data[:len(data)-4]))
+		return data
+	}
+	mutate := func(edit func([]byte) []byte) []byte {
+		return edit(append([]byte(nil), good...))
+	}
+	for name, tc := range map[string]struct {
+		data []byte
+		want string
+	}{
+		"magic":     {mutate(func(d []byte) []byte { d[0] = 'X'; return d }), "not a weights file"},
+		"flipped":   {mutate(func(d []byte) []byte { d[len(d)/2] ^= 1; return d }), "checksum"},
+		"truncated": {good[:len(good)-9], "checksum"},
+		"version":   {mutate(func(d []byte) []byte { d[4] = 9; return resum(d) }), "format version"},
+		"dims":      {mutate(func(d []byte) []byte { d[16]++; return resum(d) }), "payload bytes"},
+		"short":     {good[:10], "truncated header"},
+	} {
+		if _, err := Decode(bytes.NewReader(tc.data)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
+// TestSeatLayoutRoundTrip: the input layout survives encoding.
+func TestSeatLayoutRoundTrip(t *testing.T) {
+	seat := Compiled()
+	seat.SeatRelative = true
+	if decoded, err := Decode(bytes.NewReader(encoded(t, seat))); err != nil || !decoded.SeatRelative {
+		t.Fatalf("seat layout round trip: %v", err)
+	}
+}
+
+// TestDeepNetRoundTrip encodes a two-layer ClippedReLU net and checks the
+// decoded copy predicts the same and matches a hand-rolled forward pass.
+func TestDeepNetRoundTrip(t *testing.T) {
+	net := Compiled()
+	net.Activation = ActClippedReLU
+	layer := Layer{W: make([][]int8, 3), WScale: []float64{0.01, 0.02, 0.03}, B: []int8{5, -5, 0}, BScale: 0.1}
+	for j := range layer.W {
+		layer.W[j] = make([]int8, HiddenDim)
+		for i := range layer.W[j] {
+			layer.W[j][i] = int8((j*13+i*5)%31 - 15)
+		}
+	}
+	net.Deep = []Layer{layer}
+	net.W2 = []int8{40, -30, 20}
+	decoded, err := Decode(bytes.NewReader(encoded(t, net)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := probe(InputDim)
+	crelu := func(z float64) float64 { return min(max(z, 0), 1) }
+	hid := make([]float64, HiddenDim)
+	for h := range hid {
+		z := float64(net.B1[h]) * net.B1Scale
+		for i, w := range net.W1[h] {
+			z += float64(w) * net.W1Scale[h] * x[i]
+		}
+		hid[h] = crelu(z)
+	}
+	want := net.B2
+	for j, row := range layer.W {
+		z := float64(layer.B[j]) * layer.BScale
+		for i, w := range row {
+			z += float64(w) * layer.WScale[j] * hid[i]
+		}
+		want += float64(net.W2[j]) * net.W2Scale * crelu(z)
+	}
+	want = want*net.Std + net.Mean
+	if got := decoded.Predict(x); got != net.Predict(x) || math.Abs(got-want) > 1e-9 {
+		t.Fatalf("decoded deep net predicts %v, encoded %v, by hand %v", got, net.Predict(x), want)
+	}
+	net.W2 = net.W2[:2]
+	if err := net.Encode(&bytes.Buffer{}); err == nil {
+		t.Fatal("W2 narrower than the last layer encoded")
+	}
+}
+
+// TestTrainerExportDecodes reads the files tools/nnue-train wrote for its own
+// golden test, pinning that the two modules agree on the format.
+func TestTrainerExportDecodes(t *testing.T) {
+	for name, want := range map[string]struct{ depth, activation int }{
+		"trainer-golden":      {0, ActTanh},
+		"trainer-golden-deep": {1, ActClippedReLU},
+	} {
+		data, err := os.ReadFile("testdata/" + name + ".nnue")
+		if err != nil {
+			t.Fatal(err)
+		}
+		net, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if net.InputDim != InputDim || net.HiddenDim != 4 || net.Seats != 4 || len(net.Deep) != want.depth || net.Activation != want.activation {
+			t.Fatalf("%s: golden header %+v", name, net)
+		}
+		sidecar, err := os.ReadFile("testdata/" + name + ".predict")
+		if err != nil {
+			t.Fatal(err)
+		}
+		prediction, err := strconv.ParseFloat(strings.TrimSpace(string(sidecar)), 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := net.Predict(probe(net.InputDim)); math.Abs(got-prediction) > 1e-9 {
+			t.Fatalf("%s: golden net predicts %v, trainer's int8 pass gave %v", name, got, prediction)
+		}
+	}
+}
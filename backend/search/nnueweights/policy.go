@@ -0,0 +1,169 @@
+package nnueweights
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"virusgame/nnuefeat"
+)
+
+// Policy weights file (format version 1). Same conventions as the other
+// weights files — little-endian, CRC-32 trailer — under its own magic:
+//
+//	magic          [4]byte  "VNNP"
+//	format         uint16   PolicyFormatVersion
+//	schema         uint16   nnuefeat.PolicySchemaVersion
+//	inputs         uint32   nnuefeat.PolicyInputs
+//	hiddenDim      uint32
+//	w1Scale        float64  accumulator units → pre-activation
+//	w2Scale        float64
+//	b2             [2]float64  move, neutral head biases
+//	b1             [hiddenDim]int32
+//	w1             [inputs][hiddenDim]int8
+//	w2             [2][hiddenDim]int8   move head, then neutral head
+//	checksum       uint32   CRC-32 (IEEE) of every preceding byte
+
+// PolicyMagic opens every policy weights file.
+const PolicyMagic = "VNNP"
+
+// PolicyFormatVersion is the policy layout version this package reads and
+// writes.
+const PolicyFormatVersion = 1
+
+// PolicyNet scores cells for move ordering from nnuefeat's policy patches: one
+// hidden layer over the sparse patch features, clipped ReLU, and two heads. The
+// move head is the cell's logit as a placement or capture target; the neutral
+// head is its logit as half of a neutral pair, whose score is the sum of both
+// halves.
+type PolicyNet struct {
+	Schema, HiddenDim int
+	// B1 and W1 are in accumulator units; W1 is feature-major like BoardNet's.
+	B1      []int32
+	W1      []int8
+	W1Scale float64
+	// W2 is the move head's HiddenDim block, then the neutral head's.
+	W2      []int8
+	W2Scale float64
+	B2      [2]float64
+}
+
+// Logits returns the move and neutral logits of the cell whose active policy
+// features are given. acc is HiddenDim scratch.
+func (n *PolicyNet) Logits(features []int, acc []int32) (move, neutral float64) {
+	copy(acc, n.B1)
+	for _, f := range features {
+		for h, w := range n.W1[f*n.HiddenDim : (f+1)*n.HiddenDim] {
+			acc[h] += int32(w)
+		}
+	}
+	move, neutral = n.B2[0], n.B2[1]
+	for h, sum := range acc {
+		x := min(max(float64(sum)*n.W1Scale, 0), 1) * n.W2Scale
+		move += float64(n.W2[h]) * x
+		neutral += float64(n.W2[n.HiddenDim+h]) * x
+	}
+	return move, neutral
+}
+
+type policyHeader struct {
+	Magic             [4]byte
+	Format, Schema    uint16
+	Inputs, HiddenDim uint32
+	W1Scale, W2Scale  float64
+	B2                [2]float64
+}
+
+// Encode writes n in the policy binary format.
+func (n *PolicyNet) Encode(w io.Writer) error {
+	if err := n.validate(); err != nil {
+		return err
+	}
+	h := policyHeader{
+		Format: PolicyFormatVersion, Schema: uint16(n.Schema),
+		Inputs: nnuefeat.PolicyInputs, HiddenDim: uint32(n.HiddenDim),
+		W1Scale: n.W1Scale, W2Scale: n.W2Scale, B2: n.B2,
+	}
+	copy(h.Magic[:], PolicyMagic)
+	var buf bytes.Buffer
+	for _, part := range []any{h, n.B1, n.W1, n.W2} {
+		if err := binary.Write(&buf, binary.LittleEndian, part); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes())); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DecodePolicy reads a policy weights file, verifying its magic, format
+// version, checksum, input width and internal consistency. Matching Schema
+// against nnuefeat.PolicySchemaVersion is the caller's job.
+func DecodePolicy(r io.Reader) (*PolicyNet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || string(data[:4]) != PolicyMagic {
+		return nil, errors.New("nnueweights: not a policy weights file")
+	}
+	if len(data) < binary.Size(policyHeader{})+4 {
+		return nil, errors.New("nnueweights: truncated header")
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, errors.New("nnueweights: checksum mismatch")
+	}
+	reader := bytes.NewReader(body)
+	var h policyHeader
+	if err := binary.Read(reader, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if h.Format != PolicyFormatVersion {
+		return nil, fmt.Errorf("nnueweights: format version %d, want %d", h.Format, PolicyFormatVersion)
+	}
+	if h.Inputs != nnuefeat.PolicyInputs {
+		return nil, fmt.Errorf("nnueweights: %d policy inputs, this build has %d", h.Inputs, nnuefeat.PolicyInputs)
+	}
+	if h.HiddenDim == 0 || h.HiddenDim > maxHiddenDim {
+		return nil, fmt.Errorf("nnueweights: implausible hidden dim %d", h.HiddenDim)
+	}
+	hidden := int(h.HiddenDim)
+	want := binary.Size(h) + 4*hidden + nnuefeat.PolicyInputs*hidden + 2*hidden
+	if len(body) != want {
+		return nil, fmt.Errorf("nnueweights: %d payload bytes, want %d for hidden %d", len(body), want, hidden)
+	}
+	n := &PolicyNet{
+		Schema: int(h.Schema), HiddenDim: hidden,
+		B1: make([]int32, hidden), W1: make([]int8, nnuefeat.PolicyInputs*hidden), W1Scale: h.W1Scale,
+		W2: make([]int8, 2*hidden), W2Scale: h.W2Scale, B2: h.B2,
+	}
+	for _, part := range []any{n.B1, n.W1, n.W2} {
+		if err := binary.Read(reader, binary.LittleEndian, part); err != nil {
+			return nil, err
+		}
+	}
+	if err := n.validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// validate checks shapes and that every float is finite.
+func (n *PolicyNet) validate() error {
+	if len(n.B1) != n.HiddenDim || len(n.W1) != nnuefeat.PolicyInputs*n.HiddenDim || len(n.W2) != 2*n.HiddenDim {
+		return fmt.Errorf("nnueweights: policy layer widths disagree with hidden dim %d", n.HiddenDim)
+	}
+	for _, f := range []float64{n.W1Scale, n.W2Scale, n.B2[0], n.B2[1]} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New("nnueweights: non-finite weight or scale")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package nnueweights
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"virusgame/nnuefeat"
+)
+
+func TestPolicyNetScoresAndRoundTrips(t *testing.T) {
+	net := &PolicyNet{
+		Schema: nnuefeat.PolicySchemaVersion, HiddenDim: 2,
+		B1: []int32{-100, 400}, W1: make([]int8, nnuefeat.PolicyInputs*2), W1Scale: 1.0 / 1000,
+		W2: []int8{10, -20, 30, 40}, W2Scale: 0.1, B2: [2]float64{0.5, -0.5},
+	}
+	for i := range net.W1 {
+		net.W1[i] = int8(i%255 - 127)
+	}
+	features := []int{0, 7, nnuefeat.PolicyInputs - 1}
+	acc := make([]int32, 2)
+	move, neutral := net.Logits(features, acc)
+	// By hand: acc = B1 + the three W1 columns, clipped to [0, 1] after scaling.
+	var want [2]float64
+	sums := [2]int32{-100, 400}
+	for _, f := range features {
+		sums[0] += int32(net.W1[2*f])
+		sums[1] += int32(net.W1[2*f+1])
+	}
+	for h, sum := range sums {
+		x := min(max(float64(sum)/1000, 0), 1) * 0.1
+		want[0] += float64(net.W2[h]) * x
+		want[1] += float64(net.W2[2+h]) * x
+	}
+	if math.Abs(move-want[0]-0.5) > 1e-12 || math.Abs(neutral-want[1]+0.5) > 1e-12 {
+		t.Fatalf("logits %v %v, want %v %v", move, neutral, want[0]+0.5, want[1]-0.5)
+	}
+
+	var buf bytes.Buffer
+	if err := net.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodePolicy(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, n := decoded.Logits(features, acc); m != move || n != neutral {
+		t.Fatalf("decoded net scores %v %v, original %v %v", m, n, move, neutral)
+	}
+	data := buf.Bytes()
+	data[len(data)/2] ^= 1
+	if _, err := DecodePolicy(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("damaged policy file: %v", err)
+	}
+	if _, err := DecodePolicy(bytes.NewReader(encoded(t, Compiled()))); err == nil {
+		t.Fatal("aggregate weights file decoded as a policy net")
+	}
+}
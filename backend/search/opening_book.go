@@ -5,11 +5,19 @@ import (
 	"virusgame/game"
 )
 
-// openingBookResult wraps openingBookMove as a completed search Result so every
+// openingBookResult wraps the opening book as a completed search Result so every
 // entry point (Choose, ChooseNodeBudget, ChooseDepth) can short-circuit its
-// iterative deepening on the opening turn with a single guard.
-func openingBookResult(state game.State) (Result, bool) {
-	action, ok := openingBookMove(state)
+// iterative deepening on the opening turns with a single guard. A loaded book
+// (opts.Book or VS_OPENING_BOOK) answers first; openingBookMove's wedge is the
+// fallback entry for positions it does not hold.
+func openingBookResult(state game.State, opts Options) (Result, bool) {
+	if opts.NoBook {
+		return Result{}, false
+	}
+	action, ok := opts.book().move(state)
+	if !ok {
+		action, ok = openingBookMove(state)
+	}
 	if !ok {
 		return Result{}, false
 	}
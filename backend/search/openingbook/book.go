@@ -0,0 +1,222 @@
+// Package openingbook is the opening book file: early-game positions, keyed by
+// search.BookKey, with the moves played from them and how those games went.
+// cmd/bookgen writes it; search reads it (VS_OPENING_BOOK, Options.Book).
+package openingbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"virusgame/game"
+)
+
+// Book file (format version 1). Little-endian throughout:
+//
+//	magic        [4]byte  "VBOK"
+//	format       uint16   FormatVersion
+//	entries      uint32
+//	per entry, in ascending key order:
+//	  key        uint64   search.BookKey of the position
+//	  turn       uint8    the mover's own turn number, 0 = its first turn
+//	  moves      uint8
+//	  per move:
+//	    kind     uint8    game.ActionKind
+//	    target   [2]uint8 row, col
+//	    neutrals [4]uint8 row, col, row, col
+//	    games    uint32   times the move was played from the position
+//	    score    float32  the mover's mean result after it: 1 win, 0 loss
+//	checksum     uint32   CRC-32 (IEEE) of every preceding byte
+//
+// Coordinates fit a byte because boards are at most 50×50.
+
+// Magic opens every book file.
+const Magic = "VBOK"
+
+// FormatVersion is the layout version this package writes and reads.
+const FormatVersion = 1
+
+// maxEntries bounds what Decode will allocate for a corrupt header.
+const maxEntries = 1 << 24
+
+// Move is one continuation from a book position.
+type Move struct {
+	Action game.Action
+	Games  uint32
+	Score  float32
+}
+
+// Entry is one book position.
+type Entry struct {
+	Turn  uint8
+	Moves []Move
+}
+
+// Book maps position keys to entries.
+type Book struct {
+	Entries map[uint64]Entry
+}
+
+type fileHeader struct {
+	Magic   [4]byte
+	Format  uint16
+	Entries uint32
+}
+
+type entryHeader struct {
+	Key         uint64
+	Turn, Moves uint8
+}
+
+type fileMove struct {
+	Kind     uint8
+	Target   [2]uint8
+	Neutrals [4]uint8
+	Games    uint32
+	Score    float32
+}
+
+// Encode writes b in the binary format. Entries with more than 255 moves keep
+// their 255 most played.
+func (b *Book) Encode(w io.Writer) error {
+	keys := make([]uint64, 0, len(b.Entries))
+	for key := range b.Entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	h := fileHeader{Format: FormatVersion, Entries: uint32(len(keys))}
+	copy(h.Magic[:], Magic)
+	var buf bytes.Buffer
+	put := func(v any) {
+		// Writes to a bytes.Buffer cannot fail for these fixed-size values.
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	put(h)
+	for _, key := range keys {
+		entry := b.Entries[key]
+		moves := mostPlayed(entry.Moves, 255)
+		put(entryHeader{Key: key, Turn: entry.Turn, Moves: uint8(len(moves))})
+		for _, move := range moves {
+			m, err := encodeMove(move)
+			if err != nil {
+				return fmt.Errorf("openingbook: entry %016x: %w", key, err)
+			}
+			put(m)
+		}
+	}
+	put(crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// mostPlayed is moves ordered by games (then action, for a stable file),
+// capped at limit.
+func mostPlayed(moves []Move, limit int) []Move {
+	out := append([]Move(nil), moves...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Games != out[j].Games {
+			return out[i].Games > out[j].Games
+		}
+		return less(out[i].Action, out[j].Action)
+	})
+	return out[:min(len(out), limit)]
+}
+
+func less(a, b game.Action) bool {
+	ka, kb := actionBytes(a), actionBytes(b)
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return ka[i] < kb[i]
+		}
+	}
+	return false
+}
+
+func actionBytes(a game.Action) [7]int {
+	return [7]int{int(a.Kind), a.Target.Row, a.Target.Col,
+		a.Neutrals[0].Row, a.Neutrals[0].Col, a.Neutrals[1].Row, a.Neutrals[1].Col}
+}
+
+func encodeMove(move Move) (fileMove, error) {
+	m := fileMove{Kind: uint8(move.Action.Kind), Games: move.Games, Score: move.Score}
+	coords := actionBytes(move.Action)
+	for i, c := range coords[1:] {
+		if c < 0 || c > 255 {
+			return fileMove{}, fmt.Errorf("coordinate %d out of range in %+v", c, move.Action)
+		}
+		if i < 2 {
+			m.Target[i] = uint8(c)
+		} else {
+			m.Neutrals[i-2] = uint8(c)
+		}
+	}
+	return m, nil
+}
+
+func (m fileMove) action() game.Action {
+	return game.Action{
+		Kind:   game.ActionKind(m.Kind),
+		Target: game.Pos{Row: int(m.Target[0]), Col: int(m.Target[1])},
+		Neutrals: [2]game.Pos{
+			{Row: int(m.Neutrals[0]), Col: int(m.Neutrals[1])},
+			{Row: int(m.Neutrals[2]), Col: int(m.Neutrals[3])},
+		},
+	}
+}
+
+// Decode reads a book file, verifying its magic, format version, checksum and
+// size. Whether a move is legal where it is stored is the caller's check.
+func Decode(r io.Reader) (*Book, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || string(data[:4]) != Magic {
+		return nil, errors.New("openingbook: not a book file")
+	}
+	if len(data) < binary.Size(fileHeader{})+4 {
+		return nil, errors.New("openingbook: truncated header")
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, errors.New("openingbook: checksum mismatch")
+	}
+	reader := bytes.NewReader(body)
+	var h fileHeader
+	if err := binary.Read(reader, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if h.Format != FormatVersion {
+		return nil, fmt.Errorf("openingbook: format version %d, want %d", h.Format, FormatVersion)
+	}
+	if h.Entries > maxEntries {
+		return nil, fmt.Errorf("openingbook: implausible entry count %d", h.Entries)
+	}
+	book := &Book{Entries: make(map[uint64]Entry, h.Entries)}
+	for i := uint32(0); i < h.Entries; i++ {
+		var eh entryHeader
+		if err := binary.Read(reader, binary.LittleEndian, &eh); err != nil {
+			return nil, fmt.Errorf("openingbook: truncated entry %d: %w", i, err)
+		}
+		moves := make([]fileMove, eh.Moves)
+		if err := binary.Read(reader, binary.LittleEndian, moves); err != nil {
+			return nil, fmt.Errorf("openingbook: truncated entry %d: %w", i, err)
+		}
+		if _, dup := book.Entries[eh.Key]; dup {
+			return nil, fmt.Errorf("openingbook: duplicate key %016x", eh.Key)
+		}
+		entry := Entry{Turn: eh.Turn, Moves: make([]Move, len(moves))}
+		for j, m := range moves {
+			entry.Moves[j] = Move{Action: m.action(), Games: m.Games, Score: m.Score}
+		}
+		book.Entries[eh.Key] = entry
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("openingbook: %d trailing bytes", reader.Len())
+	}
+	return book, nil
+}
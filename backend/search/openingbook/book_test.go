@@ -0,0 +1,71 @@
+package openingbook
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"virusgame/game"
+)
+
+func sample() *Book {
+	return &Book{Entries: map[uint64]Entry{
+		7: {Turn: 0, Moves: []Move{
+			{Action: game.Action{Kind: game.Move, Target: game.Pos{Row: 1, Col: 1}}, Games: 3, Score: 0.5},
+			{Action: game.Action{Kind: game.Move, Target: game.Pos{Row: 0, Col: 2}}, Games: 9, Score: 0.75},
+		}},
+		1 << 40: {Turn: 3, Moves: []Move{
+			{Action: game.Action{Kind: game.PlaceNeutrals, Neutrals: [2]game.Pos{{Row: 4, Col: 5}, {Row: 49, Col: 48}}}, Games: 1, Score: 1},
+		}},
+	}}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sample().Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sample()
+	// Encode stores moves most played first.
+	want.Entries[7].Moves[0], want.Entries[7].Moves[1] = want.Entries[7].Moves[1], want.Entries[7].Moves[0]
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("decoded %+v, want %+v", got, want)
+	}
+	var again bytes.Buffer
+	if err := got.Encode(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Fatal("re-encoding changed the file")
+	}
+}
+
+func TestDecodeRejectsDamage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sample().Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	flipped := append([]byte(nil), good...)
+	flipped[12] ^= 1
+	for name, tc := range map[string]struct {
+		data []byte
+		want string
+	}{
+		"magic":     {append([]byte("XBOK"), good[4:]...), "not a book file"},
+		"checksum":  {flipped, "checksum"},
+		"truncated": {good[:len(good)-7], "checksum"},
+	} {
+		if _, err := Decode(bytes.NewReader(tc.data)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: error %v, want %q", name, err, tc.want)
+		}
+	}
+	if err := (&Book{Entries: map[uint64]Entry{1: {Moves: []Move{{Action: game.Action{Target: game.Pos{Row: 300}}}}}}}).Encode(&bytes.Buffer{}); err == nil {
+		t.Fatal("encoded a coordinate past 255")
+	}
+}
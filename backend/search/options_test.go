@@ -0,0 +1,143 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"virusgame/game"
+)
+
+func optionsFixture(t *testing.T) game.State {
+	t.Helper()
+	return play(t, mustState(t, 8, 8, 2),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(6, 6), move(5, 5), move(4, 4),
+	)
+}
+
+// TestDefaultParamsOptionIsByteIdentical pins the oracle path: carrying the
+// default weights explicitly must not move a single node.
+func TestDefaultParamsOptionIsByteIdentical(t *testing.T) {
+	state := optionsFixture(t)
+	defaults := DefaultEvalParams()
+	plain, _ := ChooseNodeBudget(state, 3000)
+	carried, _ := ChooseNodeBudgetOptions(state, 3000, Options{Params: &defaults})
+	if !sameCore(plain, carried) {
+		t.Fatalf("explicit default params %+v, plain %+v", carried, plain)
+	}
+	off, _ := ChooseNodeBudgetOptions(state, 3000, Options{NNUE: NNUEOff})
+	if !sameCore(plain, off) {
+		t.Fatalf("NNUEOff %+v, plain %+v", off, plain)
+	}
+}
+
+// TestPerSearchParamsRunConcurrently runs differently weighted engines side by
+// side; each must match its own serial result, and the process-wide weights
+// must stay untouched.
+func TestPerSearchParamsRunConcurrently(t *testing.T) {
+	state := optionsFixture(t)
+	defaults := DefaultEvalParams()
+	aggressive := defaults
+	aggressive.Captures *= 4
+	aggressive.Mobility = 0
+	engines := []Options{{Params: &defaults}, {Params: &aggressive}}
+	serial := make([]Result, len(engines))
+	for i, opts := range engines {
+		serial[i], _ = ChooseNodeBudgetOptions(state, 3000, opts)
+	}
+	if serial[0].Score == serial[1].Score {
+		t.Fatalf("both weightings scored %d; the fixture does not tell them apart", serial[0].Score)
+	}
+	var wg sync.WaitGroup
+	results := make([]Result, 4*len(engines))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = ChooseNodeBudgetOptions(state, 3000, engines[i%len(engines)])
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		if want := serial[i%len(engines)]; !sameCore(result, want) {
+			t.Fatalf("concurrent run %d = %+v, serial %+v", i, result, want)
+		}
+	}
+	if CurrentEvalParams() != defaults {
+		t.Fatal("per-search params leaked into the process-wide weights")
+	}
+}
+
+func TestNNUEOptionOverridesTheEnvSwitch(t *testing.T) {
+	state := optionsFixture(t)
+	defer func(prev bool) { nnueEnabled = prev }(nnueEnabled)
+
+	nnueEnabled = true
+	envOn, _ := ChooseNodeBudget(state, 2000)
+	forcedOff, _ := ChooseNodeBudgetOptions(state, 2000, Options{NNUE: NNUEOff})
+	nnueEnabled = false
+	envOff, _ := ChooseNodeBudget(state, 2000)
+	forcedOn, _ := ChooseNodeBudgetOptions(state, 2000, Options{NNUE: NNUEOn})
+	if !sameCore(envOn, forcedOn) || !sameCore(envOff, forcedOff) {
+		t.Fatalf("NNUEOn %+v vs env %+v; NNUEOff %+v vs env %+v", forcedOn, envOn, forcedOff, envOff)
+	}
+}
+
+// TestStaticEvalParams: the default weights reproduce StaticEval, and other
+// weights move the score without touching the process-wide ones.
+func TestStaticEvalParams(t *testing.T) {
+	state := optionsFixture(t)
+	mover := state.CurrentPlayer()
+	if got, want := StaticEvalParams(state, mover, DefaultEvalParams()), StaticEval(state, mover); got != want {
+		t.Fatalf("default params scored %d, StaticEval %d", got, want)
+	}
+	params := DefaultEvalParams()
+	params.MovesLeftTempo += 100
+	if got, want := StaticEvalParams(state, mover, params), StaticEval(state, mover)+100*state.MovesLeft(); got != want {
+		t.Fatalf("tempo +100 scored %d, want %d", got, want)
+	}
+	if CurrentEvalParams() != DefaultEvalParams() {
+		t.Fatal("StaticEvalParams changed the process-wide weights")
+	}
+}
+
+// TestStaticEvalParamsIgnoresVSNNUE: with VS_NNUE on, params still decide
+// the score, so texeltune never fits against the net.
+func TestStaticEvalParamsIgnoresVSNNUE(t *testing.T) {
+	state := optionsFixture(t)
+	mover := state.CurrentPlayer()
+	defer func(prev bool) { nnueEnabled = prev }(nnueEnabled)
+	nnueEnabled = true
+	params := DefaultEvalParams()
+	params.MovesLeftTempo += 100
+	if got, want := StaticEvalParams(state, mover, params), StaticEvalParams(state, mover, DefaultEvalParams())+100*state.MovesLeft(); got != want {
+		t.Fatalf("under VS_NNUE tempo +100 scored %d, want %d", got, want)
+	}
+}
+
+func TestLoadEvalParams(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	want := DefaultEvalParams()
+	want.Captures = 99
+	if got, err := LoadEvalParams(write("partial.json", `{"Captures": 99}`)); err != nil || got != want {
+		t.Fatalf("partial params = %+v, %v; want defaults with Captures 99", got, err)
+	}
+	for name, body := range map[string]string{
+		"unknown.json": `{"Captures": 1, "Capture": 2}`,
+		"divide.json":  `{"PredatoryCutLossDiv": 0}`,
+		"broken.json":  `{"Captures":`,
+	} {
+		if _, err := LoadEvalParams(write(name, body)); err == nil {
+			t.Fatalf("%s accepted", name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadEvalParams reads an EvalParams JSON object (the shape spsatune's
+// bestTheta and Options.Params use). Missing fields keep their default weight;
+// unknown fields are rejected so a renamed term cannot silently drop out.
+func LoadEvalParams(path string) (EvalParams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return EvalParams{}, err
+	}
+	params := DefaultEvalParams()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&params); err != nil {
+		return EvalParams{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if params.PredatoryCutLossDiv == 0 {
+		return EvalParams{}, fmt.Errorf("%s: PredatoryCutLossDiv must be non-zero", path)
+	}
+	return params, nil
+}
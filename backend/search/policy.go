@@ -0,0 +1,226 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"virusgame/game"
+	"virusgame/nnuefeat"
+	"virusgame/search/nnueweights"
+)
+
+// vs-ai2.69: learned policy for move ordering. orderedChildren ranks siblings
+// by hand-set tactical classes (TT move, wins, eliminations, captures) and then
+// leaves quiet moves in generation order, and the neutral-pair candidates are
+// whatever game.Position's strategicNeutralPairs keeps. A small policy net
+// (nnuefeat policy patches, nnueweights.PolicyNet), trained by nnue-train
+// -policy on nnuegen's search best moves, fills both gaps:
+//
+//   - it orders siblings inside each tactical class by prior, so the class
+//     structure (and with it every tactical guarantee) is unchanged;
+//   - at a node where a neutral pair may be placed it adds the pairs of its
+//     policyNeutralCells best-rated cells to the candidates;
+//   - with PolicyKeep > 0 it prunes quiet actions to the PolicyKeep it rates
+//     highest. Tactical children are never pruned.
+//
+// CANARY-FIRST: nothing changes unless a policy net is loaded
+// (VS_POLICY_WEIGHTS, LoadPolicyWeights or Options.Policy). Its node-budget
+// value is measured by the arena's equal-node ladder (cmd/arena -policy).
+
+// policyOrderScale is the order bonus of the best-rated sibling. It stays
+// under the capture bonus, so priors rank within a tactical class only.
+const policyOrderScale = 9_000
+
+// tacticalOrder is the lowest order orderedChildren gives a tactical child.
+const tacticalOrder = 10_000
+
+// policyNeutralCells is how many top-rated cells the policy pairs up as extra
+// neutral candidates.
+const policyNeutralCells = 4
+
+// activePolicy is the process-wide policy net, from VS_POLICY_WEIGHTS. A bad
+// file is logged and ignored.
+var activePolicy = func() *nnueweights.PolicyNet {
+	path := os.Getenv("VS_POLICY_WEIGHTS")
+	if path == "" {
+		return nil
+	}
+	net, err := ReadPolicyWeights(path)
+	if err != nil {
+		log.Printf("search: VS_POLICY_WEIGHTS ignored: %v", err)
+		return nil
+	}
+	return net
+}()
+
+// activePolicyKeep is the process-wide PolicyKeep, from VS_POLICY_KEEP.
+var activePolicyKeep = func() int {
+	v := os.Getenv("VS_POLICY_KEEP")
+	if v == "" {
+		return 0
+	}
+	keep, err := strconv.Atoi(v)
+	if err != nil || keep < 0 {
+		log.Printf("search: VS_POLICY_KEEP %q ignored", v)
+		return 0
+	}
+	return keep
+}()
+
+// ReadPolicyWeights decodes a policy weights file and checks it was trained
+// on this build's patch layout.
+func ReadPolicyWeights(path string) (*nnueweights.PolicyNet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	net, err := nnueweights.DecodePolicy(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if net.Schema != nnuefeat.PolicySchemaVersion {
+		return nil, fmt.Errorf("%s: trained on policy schema v%d, this build extracts v%d", path, net.Schema, nnuefeat.PolicySchemaVersion)
+	}
+	return net, nil
+}
+
+// LoadPolicyWeights installs the policy net at path for every later search
+// without Options.Policy. Call it at startup, before any search runs; it is
+// not synchronized.
+func LoadPolicyWeights(path string) error {
+	net, err := ReadPolicyWeights(path)
+	if err != nil {
+		return err
+	}
+	activePolicy = net
+	return nil
+}
+
+// policyScorer evaluates one searcher's policy net. Like evalWorkspace it
+// owns scratch buffers and is not shared between searchers.
+type policyScorer struct {
+	net      *nnueweights.PolicyNet
+	acc      []int32
+	features []int
+}
+
+func newPolicyScorer(net *nnueweights.PolicyNet) *policyScorer {
+	if net == nil {
+		return nil
+	}
+	return &policyScorer{net: net, acc: make([]int32, net.HiddenDim), features: make([]int, 0, nnuefeat.PolicyActive)}
+}
+
+// logits rates pos for the player to move in state.
+func (p *policyScorer) logits(state game.State, pos game.Pos) (move, neutral float64) {
+	p.features = nnuefeat.AppendPolicyFeatures(p.features[:0], state, pos)
+	return p.net.Logits(p.features, p.acc)
+}
+
+// PolicyLogits returns the move and neutral logits the policy net rates pos
+// with for the player to move, for tools outside search (evaluation, MCTS).
+func PolicyLogits(net *nnueweights.PolicyNet, state game.State, pos game.Pos) (move, neutral float64) {
+	return newPolicyScorer(net).logits(state, pos)
+}
+
+// order adds the policy's priors to children of state, appends the extra
+// neutral-pair candidates, and prunes quiet children past keep. The caller
+// sorts.
+func (p *policyScorer) order(state game.State, children []child, keep int, expand func(game.Action) child) []child {
+	neutral := map[game.Pos]float64{}
+	neutralLogit := func(pos game.Pos) float64 {
+		if logit, ok := neutral[pos]; ok {
+			return logit
+		}
+		_, logit := p.logits(state, pos)
+		neutral[pos] = logit
+		return logit
+	}
+	if state.MovesLeft() == 3 && !state.NeutralUsed(state.CurrentPlayer()) {
+		children = p.addNeutralPairs(state, children, neutralLogit, expand)
+	}
+	logits := make([]float64, len(children))
+	best := [2]float64{math.Inf(-1), math.Inf(-1)}
+	for i, c := range children {
+		kind := 0
+		if c.action.Kind == game.Move {
+			logits[i], _ = p.logits(state, c.action.Target)
+		} else {
+			kind = 1
+			logits[i] = neutralLogit(c.action.Neutrals[0]) + neutralLogit(c.action.Neutrals[1])
+		}
+		best[kind] = max(best[kind], logits[i])
+	}
+	quiet := make([]int, 0, len(children))
+	for i := range children {
+		kind := 0
+		if children[i].action.Kind != game.Move {
+			kind = 1
+		}
+		if children[i].order < tacticalOrder {
+			quiet = append(quiet, i)
+		}
+		children[i].order += int(policyOrderScale * math.Exp(logits[i]-best[kind]))
+	}
+	if keep <= 0 || len(quiet) <= keep {
+		return children
+	}
+	sort.SliceStable(quiet, func(a, b int) bool { return children[quiet[a]].order > children[quiet[b]].order })
+	drop := make([]bool, len(children))
+	for _, i := range quiet[keep:] {
+		drop[i] = true
+	}
+	kept := children[:0]
+	for i, c := range children {
+		if !drop[i] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// addNeutralPairs appends every pair of the policy's policyNeutralCells
+// best-rated own Normal cells that children does not already hold.
+func (p *policyScorer) addNeutralPairs(state game.State, children []child, logit func(game.Pos) float64, expand func(game.Action) child) []child {
+	actor := state.CurrentPlayer()
+	var owned []game.Pos
+	for row := 0; row < state.Rows(); row++ {
+		for col := 0; col < state.Cols(); col++ {
+			pos := game.Pos{Row: row, Col: col}
+			if cell, _ := state.At(pos); cell.Owner == actor && cell.Kind == game.Normal {
+				owned = append(owned, pos)
+			}
+		}
+	}
+	if len(owned) < 2 {
+		return children
+	}
+	sort.SliceStable(owned, func(i, j int) bool { return logit(owned[i]) > logit(owned[j]) })
+	top := owned[:min(len(owned), policyNeutralCells)]
+	have := map[[2]game.Pos]bool{}
+	for _, c := range children {
+		if c.action.Kind == game.PlaceNeutrals {
+			have[c.action.Neutrals] = true
+		}
+	}
+	index := func(pos game.Pos) int { return pos.Row*state.Cols() + pos.Col }
+	for i := range top {
+		for j := i + 1; j < len(top); j++ {
+			a, b := top[i], top[j]
+			if index(a) > index(b) {
+				a, b = b, a
+			}
+			pair := [2]game.Pos{a, b}
+			if have[pair] {
+				continue
+			}
+			children = append(children, expand(game.Action{Kind: game.PlaceNeutrals, Neutrals: pair}))
+		}
+	}
+	return children
+}
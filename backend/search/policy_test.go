@@ -0,0 +1,135 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"virusgame/game"
+	"virusgame/nnuefeat"
+	"virusgame/search/nnueweights"
+)
+
+// edgePolicy is a one-unit policy net that rates a cell highly, as a move and
+// as a neutral, when the cell just left of it in the mover's orientation is
+// off the board: seat 1's first column.
+// policyChannels is the per-slot channel count of the policy window.
+const policyChannels = (nnuefeat.PolicyInputs - 3) / (nnuefeat.PolicyWindow * nnuefeat.PolicyWindow)
+
+func edgePolicy() *nnueweights.PolicyNet {
+	net := &nnueweights.PolicyNet{
+		Schema: nnuefeat.PolicySchemaVersion, HiddenDim: 1,
+		B1: []int32{0}, W1: make([]int8, nnuefeat.PolicyInputs), W1Scale: 0.01,
+		W2: []int8{127, 127}, W2Scale: 0.1,
+	}
+	// Window slot (r=0, c=-1), channel 0 (off-board).
+	slot := nnuefeat.PolicyRadius*nnuefeat.PolicyWindow + nnuefeat.PolicyRadius - 1
+	net.W1[slot*policyChannels] = 100
+	return net
+}
+
+func policyChildren(t *testing.T, state game.State, opts Options) []child {
+	t.Helper()
+	s := newSearcher(context.Background(), state)
+	s.configure(opts)
+	children, ok := s.orderedChildren(state, game.Action{}, false)
+	if !ok {
+		t.Fatal("ordering canceled")
+	}
+	return children
+}
+
+func TestPolicyOrdersWithinTacticalClassesAndPrunes(t *testing.T) {
+	state := mustState(t, 8, 8, 2)
+	plain := policyChildren(t, state, Options{})
+	ordered := policyChildren(t, state, Options{Policy: edgePolicy()})
+	if len(ordered) != len(plain) {
+		t.Fatalf("policy changed the candidate count %d -> %d", len(plain), len(ordered))
+	}
+	if ordered[0].action.Target.Col != 0 {
+		t.Fatalf("policy led with %+v, want a first-column placement", ordered[0].action)
+	}
+	if plain[0].action.Target.Col == 0 {
+		t.Fatal("fixture does not distinguish policy order from generation order")
+	}
+	pruned := policyChildren(t, state, Options{Policy: edgePolicy(), PolicyKeep: 1})
+	if len(pruned) != 1 || pruned[0].action != ordered[0].action {
+		t.Fatalf("PolicyKeep 1 kept %d children", len(pruned))
+	}
+
+	// A capture outranks every quiet placement however the policy rates them.
+	capture := play(t, mustState(t, 6, 6, 2),
+		move(0, 1), move(1, 1), move(2, 2),
+		move(4, 4), move(3, 3), move(4, 3),
+	)
+	children := policyChildren(t, capture, Options{Policy: edgePolicy(), PolicyKeep: 1})
+	captures := 0
+	for _, c := range children {
+		if c.order >= tacticalOrder+100 {
+			captures++
+		}
+	}
+	if captures == 0 || children[0].action.Target != (game.Pos{Row: 3, Col: 3}) && children[0].action.Target != (game.Pos{Row: 4, Col: 3}) {
+		t.Fatalf("capture not first: %+v", children[0].action)
+	}
+	if len(children) != captures+1 {
+		t.Fatalf("PolicyKeep 1 kept %d children with %d captures", len(children), captures)
+	}
+}
+
+// TestPolicyAddsLegalNeutralPairs: with nine own Normals the neutral
+// candidates come from the strategic selection, and the policy's extra pairs
+// must be legal and not duplicated.
+func TestPolicyAddsLegalNeutralPairs(t *testing.T) {
+	state := play(t, mustState(t, 14, 14, 2),
+		move(0, 1), move(1, 0), move(1, 1), move(13, 12), move(12, 13), move(12, 12),
+		move(0, 2), move(2, 0), move(2, 2), move(13, 11), move(11, 13), move(11, 11),
+		move(0, 3), move(3, 0), move(3, 3), move(13, 10), move(10, 13), move(10, 10),
+	)
+	// Rate cells on the first column as neutral cells only.
+	tip := edgePolicy()
+	tip.W2[0] = 0
+	plain := policyChildren(t, state, Options{})
+	children := policyChildren(t, state, Options{Policy: tip})
+	seen := map[game.Action]bool{}
+	for _, c := range children {
+		if seen[c.action] {
+			t.Fatalf("duplicate candidate %+v", c.action)
+		}
+		seen[c.action] = true
+		if _, err := state.Apply(c.action); err != nil {
+			t.Fatalf("illegal candidate %+v: %v", c.action, err)
+		}
+	}
+	if len(children) <= len(plain) {
+		t.Fatalf("policy added no neutral pairs: %d -> %d candidates", len(plain), len(children))
+	}
+	if result, ok := ChooseNodeBudgetOptions(state, 2000, Options{Policy: tip}); !ok {
+		t.Fatal("no result with a policy")
+	} else if _, err := state.Apply(result.Action); err != nil {
+		t.Fatalf("policy search played %+v: %v", result.Action, err)
+	}
+}
+
+func TestPolicyWeightsFileLoads(t *testing.T) {
+	var buf bytes.Buffer
+	if err := edgePolicy().Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "policy.nnue")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	net, err := ReadPolicyWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := mustState(t, 8, 8, 2)
+	edge, _ := PolicyLogits(net, state, game.Pos{Row: 1, Col: 0})
+	inner, _ := PolicyLogits(net, state, game.Pos{Row: 1, Col: 1})
+	if edge <= inner {
+		t.Fatalf("first-column logit %v not above %v", edge, inner)
+	}
+}
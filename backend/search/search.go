@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"virusgame/game"
+	"virusgame/search/nnueweights"
 )
 
 const (
@@ -37,6 +38,11 @@ type Result struct {
 	// diagnostics metadata (vs-ai2.60): populating it never changes the chosen
 	// Action/Score/Nodes/Depth or any deterministic node-budget behaviour.
 	Alternatives []RootMove
+	// SeatScores is the chosen line's backed-up score for every seat (index
+	// seat-1), output-only like Alternatives (vs-ai2.66, per-seat NNUE labels).
+	// maxN fills the whole vector; 1v1 fills the root and, zero-sum, the
+	// opponent; paranoid/best-reply search knows only the root's entry.
+	SeatScores [4]int
 }
 
 // RootMove is a root candidate action with its search score. Non-chosen scores
@@ -91,34 +97,93 @@ type searcher struct {
 	nodes, evaluations uint64
 	nodeLimit          uint64
 	eval               evalWorkspace
+	policy             *policyScorer
+	policyKeep         int
+	opts               Options
+	inNull             bool
+	solving            bool
+}
+
+// Options selects per-search algorithm variants. The zero value is the
+// production search.
+type Options struct {
+	Selectivity Selectivity
+	// Multiplayer picks the 3-4 player algorithm; 1v1 always runs minimax.
+	Multiplayer MultiplayerAlgorithm
+	// Endgame tries the exact win/loss solver first when a 1v1 position is
+	// sparse enough (see EndgameCandidate). ChooseDepth never solves.
+	Endgame bool
+	// Clock, when enabled, lets Choose size and adapt its own time budget
+	// (see Allocate). Node-budget and fixed-depth searches ignore it.
+	Clock Clock
+	// Params, when set, replaces the process-wide eval weights (SetEvalParams)
+	// for this search only, so differently weighted engines can share a
+	// process. The search keeps its own copy.
+	Params *EvalParams
+	// NNUE overrides the VS_NNUE leaf-eval switch for this search.
+	NNUE NNUEMode
+	// Net, when set, replaces the process-wide aggregate net (VS_NNUE_WEIGHTS,
+	// LoadNNUEWeights) and any board-plane net on the NNUE path for this
+	// search, so a candidate net can be gated against its incumbent in one
+	// process. It does not turn the NNUE path on; see NNUE.
+	Net *nnueweights.Net
+	// Book, when set, replaces the process-wide opening book
+	// (VS_OPENING_BOOK, LoadOpeningBook) for this search. ChooseDepth never
+	// consults a book.
+	Book *Book
+	// NoBook skips every opening book, the wedge included, so a book builder
+	// can search the positions it is filling in.
+	NoBook bool
+	// Policy, when set, replaces the process-wide policy net
+	// (VS_POLICY_WEIGHTS, LoadPolicyWeights) for this search; with either,
+	// siblings are ordered by its priors (see policy.go).
+	Policy *nnueweights.PolicyNet
+	// PolicyKeep, when positive, prunes each node's quiet actions to the
+	// PolicyKeep the policy net rates highest; 0 reads VS_POLICY_KEEP. It
+	// does nothing without a policy net.
+	PolicyKeep int
 }
 
 // ChooseNodeBudget performs deterministic iterative deepening without an
 // implicit wall-clock deadline.
 func ChooseNodeBudget(state game.State, limit uint64) (Result, bool) {
-	return chooseNodeBudget(state, limit)
+	return ChooseNodeBudgetOptions(state, limit, Options{})
 }
 
-func chooseNodeBudget(state game.State, limit uint64) (Result, bool) {
-	if result, ok := openingBookResult(state); ok {
+// ChooseNodeBudgetSelective is ChooseNodeBudget with the given selective-search
+// techniques switched on. The zero Selectivity is exactly ChooseNodeBudget.
+func ChooseNodeBudgetSelective(state game.State, limit uint64, sel Selectivity) (Result, bool) {
+	return ChooseNodeBudgetOptions(state, limit, Options{Selectivity: sel})
+}
+
+// ChooseNodeBudgetOptions is ChooseNodeBudget with per-search options. The zero
+// Options is exactly ChooseNodeBudget.
+func ChooseNodeBudgetOptions(state game.State, limit uint64, opts Options) (Result, bool) {
+	if result, ok := openingBookResult(state, opts); ok {
 		return result, true
 	}
 	fallback, ok := preservingFallback(state)
 	if !ok || limit == 0 {
 		return Result{}, false
 	}
+	solved, solverNodes, proven := endgameNodeBudget(state, limit, opts)
+	if proven {
+		return solved, true
+	}
+	limit -= solverNodes
 	best := Result{Action: fallback}
 	s := newSearcher(context.Background(), state)
 	s.nodeLimit = limit
+	s.configure(opts)
 	for depth := 1; depth <= maxDepth && s.nodes < limit; depth++ {
-		result, complete := s.atDepth(state, depth)
+		result, complete := s.iterate(state, depth, best)
 		if !complete {
 			break
 		}
 		best = result
 		best.Depth = depth
 	}
-	best.Nodes, best.Evaluations = s.nodes, s.evaluations
+	best.Nodes, best.Evaluations = s.nodes+solverNodes, s.evaluations
 	best.BudgetExhausted = s.nodes >= limit
 	best.SearchComplete = best.Depth == maxDepth
 	return best, true
@@ -127,6 +192,12 @@ func chooseNodeBudget(state game.State, limit uint64) (Result, bool) {
 // ChooseDepth performs one deterministic, fully completed action-depth search.
 // It is intended for reproducible benchmarks; production callers should use Choose.
 func ChooseDepth(ctx context.Context, state game.State, depth int) (Result, bool) {
+	return ChooseDepthOptions(ctx, state, depth, Options{})
+}
+
+// ChooseDepthOptions is ChooseDepth with per-search options. The zero Options
+// is exactly ChooseDepth.
+func ChooseDepthOptions(ctx context.Context, state game.State, depth int, opts Options) (Result, bool) {
 	if depth < 1 || depth > maxDepth {
 		return Result{}, false
 	}
@@ -138,6 +209,7 @@ func ChooseDepth(ctx context.Context, state game.State, depth int) (Result, bool
 		ctx = context.Background()
 	}
 	s := newSearcher(ctx, state)
+	s.configure(opts)
 	result, complete := s.atDepth(state, depth)
 	if !complete {
 		return Result{Action: fallback}, false
@@ -151,7 +223,19 @@ func ChooseDepth(ctx context.Context, state game.State, depth int) (Result, bool
 // Choose returns the best action from the last fully completed iteration. If
 // ctx has no deadline, a production-safe default deadline is applied.
 func Choose(ctx context.Context, state game.State) (Result, bool) {
-	if result, ok := openingBookResult(state); ok {
+	return ChooseOptions(ctx, state, Options{})
+}
+
+// ChooseSelective is Choose with the given selective-search techniques
+// switched on. The zero Selectivity is exactly Choose.
+func ChooseSelective(ctx context.Context, state game.State, sel Selectivity) (Result, bool) {
+	return ChooseOptions(ctx, state, Options{Selectivity: sel})
+}
+
+// ChooseOptions is Choose with per-search options. The zero Options is exactly
+// Choose.
+func ChooseOptions(ctx context.Context, state game.State, opts Options) (Result, bool) {
+	if result, ok := openingBookResult(state, opts); ok {
 		return result, true
 	}
 	fallback, ok := preservingFallback(state)
@@ -161,16 +245,30 @@ func Choose(ctx context.Context, state game.State) (Result, bool) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	var clock *timeManager
+	if opts.Clock.Enabled() {
+		if only, forced := forcedAction(state); forced {
+			return Result{Action: only}, true
+		}
+		clock = newTimeManager(opts.Clock, state)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, clock.budget.Hard)
+		defer cancel()
+	}
 	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, ProductionBudget)
 		defer cancel()
 	}
+	if solved, proven := endgameDeadline(ctx, state, opts); proven {
+		return solved, true
+	}
 
 	best := Result{Action: fallback}
 	s := newSearcher(ctx, state)
+	s.configure(opts)
 	for depth := 1; depth <= maxDepth; depth++ {
-		result, complete := s.atDepth(state, depth)
+		result, complete := s.iterate(state, depth, best)
 		if !complete {
 			break
 		}
@@ -178,6 +276,9 @@ func Choose(ctx context.Context, state game.State) (Result, bool) {
 		best.Depth = depth
 		best.Nodes = s.nodes
 		best.Evaluations = s.evaluations
+		if clock != nil && clock.done(best) {
+			break
+		}
 	}
 	return best, true
 }
@@ -195,7 +296,34 @@ func newSearcher(ctx context.Context, state game.State) *searcher {
 	}
 }
 
+// configure applies per-search options, including the eval overrides.
+func (s *searcher) configure(opts Options) {
+	s.opts = opts
+	if opts.Params != nil {
+		params := *opts.Params
+		s.eval.params = &params
+	}
+	s.eval.nnue = opts.NNUE
+	s.eval.net = opts.Net
+	policy := opts.Policy
+	if policy == nil {
+		policy = activePolicy
+	}
+	s.policy = newPolicyScorer(policy)
+	s.policyKeep = opts.PolicyKeep
+	if s.policyKeep == 0 {
+		s.policyKeep = activePolicyKeep
+	}
+}
+
 func (s *searcher) atDepth(state game.State, depth int) (Result, bool) {
+	return s.atDepthWindow(state, depth, -infScore, infScore)
+}
+
+// atDepthWindow searches the root inside (alpha, beta). Outside 1v1 the window
+// is ignored. A 1v1 score at or beyond either bound is only a bound, which the
+// aspiration loop in iterate detects and re-searches.
+func (s *searcher) atDepthWindow(state game.State, depth, alpha, beta int) (Result, bool) {
 	key := stateHash(state)
 	rootEntry, hasRoot := s.table[key]
 	children, ok := s.orderedChildren(state, rootEntry.bestAction, hasRoot)
@@ -203,13 +331,15 @@ func (s *searcher) atDepth(state game.State, depth int) (Result, bool) {
 		return Result{}, ok
 	}
 	children = preservingChildren(children, s.root)
+	s.eval.enterRoot(state)
 	best := Result{Action: children[0].action, Score: -infScore}
+	alphaOrig := alpha
 	roots := make([]RootMove, 0, len(children))
-	alpha, beta := -infScore, infScore
 	for i, child := range children {
 		var values [4]int
 		var complete bool
-		if s.multi {
+		s.eval.board.push(state, child.state, child.action)
+		if s.maxNSearch() {
 			values, complete = s.maxN(child.state, depth-1, 1)
 		} else if i == 0 {
 			values[0], complete = s.minimax(child.state, depth-1, alpha, beta, 1)
@@ -220,26 +350,56 @@ func (s *searcher) atDepth(state game.State, depth int) (Result, bool) {
 				values[0], complete = s.minimax(child.state, depth-1, alpha, beta, 1)
 			}
 		}
+		s.eval.board.pop()
 		if !complete {
 			return Result{}, false
 		}
 		score := values[0]
-		if s.multi {
+		if s.maxNSearch() {
 			score = values[s.root-1]
 		}
 		roots = append(roots, RootMove{Action: child.action, Score: score})
 		if score > best.Score {
 			best.Action, best.Score = child.action, score
+			best.SeatScores = values
+			if !s.maxNSearch() {
+				best.SeatScores = s.rootOnlyScores(state, score)
+			}
 		}
-		if !s.multi && score > alpha {
+		if !s.maxNSearch() && score > alpha {
 			alpha = score
 		}
+		if !s.maxNSearch() && score >= beta {
+			break
+		}
 	}
-	s.table[key] = tableEntry{depth: depth, ply: 0, flag: flagExact, bestAction: best.Action, values: [4]int{best.Score}}
+	// An aspiration fail stores only the bound it proved, as minimax does, so
+	// the re-search does not trust it as exact.
+	flag := flagExact
+	if !s.maxNSearch() && best.Score <= alphaOrig {
+		flag = flagUpper
+	} else if !s.maxNSearch() && best.Score >= beta {
+		flag = flagLower
+	}
+	s.table[key] = tableEntry{depth: depth, ply: 0, flag: flag, bestAction: best.Action, values: [4]int{best.Score}}
 	best.Alternatives = topAlternatives(roots, best.Action)
 	return best, true
 }
 
+// rootOnlyScores is SeatScores for the root-perspective searches.
+func (s *searcher) rootOnlyScores(state game.State, score int) [4]int {
+	var scores [4]int
+	scores[s.root-1] = score
+	if !s.multi {
+		for seat := game.Player(1); seat <= 4; seat++ {
+			if seat != s.root && state.Active(seat) {
+				scores[seat-1] = -score
+			}
+		}
+	}
+	return scores
+}
+
 func (s *searcher) minimax(state game.State, depth, alpha, beta, ply int) (int, bool) {
 	if !s.running() {
 		return 0, false
@@ -248,7 +408,14 @@ func (s *searcher) minimax(state game.State, depth, alpha, beta, ply int) (int,
 	if state.GameOver() {
 		return terminalScore(state, s.root, ply), true
 	}
+	if s.multi && !state.Active(s.root) {
+		// Paranoid/Best-Reply: the root is out even though the others play on.
+		return -mateScore + ply, true
+	}
 	if depth == 0 {
+		if s.solving {
+			return 0, true // unknown: strictly inside the mate bounds
+		}
 		s.evaluations++
 		return evaluateWithWorkspace(state, s.root, &s.eval), true
 	}
@@ -273,17 +440,32 @@ func (s *searcher) minimax(state game.State, depth, alpha, beta, ply int) (int,
 			return entry.values[0], true
 		}
 	}
-	children, complete := s.orderedChildren(state, entry.bestAction, hit)
+	maximizing := state.CurrentPlayer() == s.root
+	if s.opts.Selectivity.NullMove {
+		if score, cut, ok := s.nullMove(state, depth, alpha, beta, ply, maximizing); !ok {
+			return 0, false
+		} else if cut {
+			return score, true
+		}
+	}
+	// Null move needs depth > nullMoveReduction, so the two never share an eval.
+	static, hasStatic := 0, false
+	if !s.multi && s.opts.Selectivity.Futility && depth == 1 {
+		static, hasStatic = s.staticEval(state), true
+	}
+	children, complete := s.minimaxChildren(state, entry.bestAction, hit)
 	if !complete {
 		return 0, false
 	}
 	if len(children) == 0 {
+		if s.solving {
+			return 0, true // unknown: a childless node is not a proven terminal
+		}
 		s.evaluations++
 		return evaluateWithWorkspace(state, s.root, &s.eval), true
 	}
 
 	alphaOrig, betaOrig := alpha, beta
-	maximizing := state.CurrentPlayer() == s.root
 	best := infScore
 	if maximizing {
 		best = -infScore
@@ -292,20 +474,44 @@ func (s *searcher) minimax(state game.State, depth, alpha, beta, ply int) (int,
 	for i, child := range children {
 		var score int
 		var ok bool
+		if i > 0 && hasStatic && s.opts.Selectivity.Futility && depth == 1 && quietChild(child) {
+			// Frontier futility: this quiet placement cannot swing the static eval
+			// past the window, so bound it instead of evaluating it.
+			if maximizing && static+futilityMargin <= alpha {
+				best = max(best, static+futilityMargin)
+				continue
+			}
+			if !maximizing && static-futilityMargin >= beta {
+				best = min(best, static-futilityMargin)
+				continue
+			}
+		}
+		s.eval.board.push(state, child.state, child.action)
 		if i == 0 {
 			score, ok = s.minimax(child.state, depth-1, alpha, beta, ply+1)
 		} else if maximizing {
-			// Null-window scout: probe whether this sibling beats alpha.
-			score, ok = s.minimax(child.state, depth-1, alpha, alpha+1, ply+1)
+			// Null-window scout: probe whether this sibling beats alpha. A late
+			// quiet sibling is probed shallower first and re-probed at full depth
+			// only when the reduced probe says it might.
+			reduced := depth - 1 - s.reduction(child, i, depth)
+			score, ok = s.minimax(child.state, reduced, alpha, alpha+1, ply+1)
+			if ok && reduced < depth-1 && score > alpha {
+				score, ok = s.minimax(child.state, depth-1, alpha, alpha+1, ply+1)
+			}
 			if ok && score > alpha && score < beta {
 				score, ok = s.minimax(child.state, depth-1, alpha, beta, ply+1)
 			}
 		} else {
-			score, ok = s.minimax(child.state, depth-1, beta-1, beta, ply+1)
+			reduced := depth - 1 - s.reduction(child, i, depth)
+			score, ok = s.minimax(child.state, reduced, beta-1, beta, ply+1)
+			if ok && reduced < depth-1 && score < beta {
+				score, ok = s.minimax(child.state, depth-1, beta-1, beta, ply+1)
+			}
 			if ok && score < beta && score > alpha {
 				score, ok = s.minimax(child.state, depth-1, alpha, beta, ply+1)
 			}
 		}
+		s.eval.board.pop()
 		if !ok {
 			return 0, false
 		}
@@ -374,7 +580,9 @@ func (s *searcher) maxN(state game.State, depth, ply int) ([4]int, bool) {
 	best[player-1] = -infScore
 	var bestAction game.Action
 	for _, child := range children {
+		s.eval.board.push(state, child.state, child.action)
 		values, ok := s.maxN(child.state, depth-1, ply+1)
+		s.eval.board.pop()
 		if !ok {
 			return [4]int{}, false
 		}
@@ -447,13 +655,7 @@ func (s *searcher) orderedChildren(state game.State, ttMove game.Action, hasTT b
 	pos := game.NewPosition(state)
 	actor := state.CurrentPlayer()
 	beforeActive := activeCount(state)
-	var children []child
-	stopped := false
-	pos.ForEachSearchAction(func(action game.Action) bool {
-		if !s.running() {
-			stopped = true
-			return false
-		}
+	expand := func(action game.Action) child {
 		target, _ := state.At(action.Target)
 		next := pos.ApplySearch(action).State()
 		order := 0
@@ -470,12 +672,24 @@ func (s *searcher) orderedChildren(state game.State, ttMove game.Action, hasTT b
 		if next.CurrentPlayer() == actor {
 			order += 100
 		}
-		children = append(children, child{action: action, state: next, order: order})
+		return child{action: action, state: next, order: order}
+	}
+	var children []child
+	stopped := false
+	pos.ForEachSearchAction(func(action game.Action) bool {
+		if !s.running() {
+			stopped = true
+			return false
+		}
+		children = append(children, expand(action))
 		return true
 	})
 	if stopped {
 		return nil, false
 	}
+	if s.policy != nil {
+		children = s.policy.order(state, children, s.policyKeep, expand)
+	}
 	sort.SliceStable(children, func(i, j int) bool { return children[i].order > children[j].order })
 	return children, true
 }
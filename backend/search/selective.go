@@ -0,0 +1,189 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"virusgame/game"
+)
+
+// Selective search (vs-ai2.61): aspiration windows, late-move reductions,
+// frontier futility and null-move pruning, each behind its own switch.
+//
+// CANARY-FIRST: the zero Selectivity is the production search. Every technique
+// here is unsound in some position (a reduced or pruned line can hide the only
+// saving reply), so none of them may change a default result until an
+// equal-node arena ladder says it pays for itself — see
+// arena/selective_ladder_test.go. The techniques only act in 1v1 minimax; 3-4
+// player searches ignore them (maxN has no window to aspire to or prune
+// against, and Paranoid/Best-Reply margins were never measured).
+
+// Selectivity switches individual selective-search techniques on. The zero
+// value disables all of them and is byte-identical to the plain search.
+type Selectivity struct {
+	// Aspiration searches each iteration inside a window around the previous
+	// iteration's score, widening and re-searching on a fail.
+	Aspiration bool
+	// LateMoveReductions probes late quiet placements one ply shallower and
+	// re-searches at full depth only when the probe beats the bound.
+	LateMoveReductions bool
+	// Futility skips quiet placements at the frontier whose static eval plus a
+	// margin cannot reach the window.
+	Futility bool
+	// NullMove lets the side to move forfeit the rest of its turn at a reduced
+	// depth; if that still fails high the node is cut.
+	NullMove bool
+}
+
+// AllSelectivity returns a Selectivity with every technique on.
+func AllSelectivity() Selectivity {
+	return Selectivity{Aspiration: true, LateMoveReductions: true, Futility: true, NullMove: true}
+}
+
+// Any reports whether at least one technique is on.
+func (sel Selectivity) Any() bool {
+	return sel.Aspiration || sel.LateMoveReductions || sel.Futility || sel.NullMove
+}
+
+// String lists the enabled techniques in ParseSelectivity form ("none" when off).
+func (sel Selectivity) String() string {
+	var names []string
+	if sel.Aspiration {
+		names = append(names, "aspiration")
+	}
+	if sel.LateMoveReductions {
+		names = append(names, "lmr")
+	}
+	if sel.Futility {
+		names = append(names, "futility")
+	}
+	if sel.NullMove {
+		names = append(names, "nullmove")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseSelectivity parses a comma-separated technique list: any of
+// aspiration, lmr, futility, nullmove, or the shorthands "all" and "none".
+func ParseSelectivity(spec string) (Selectivity, error) {
+	var sel Selectivity
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(strings.ToLower(name)) {
+		case "", "none":
+		case "all":
+			sel = AllSelectivity()
+		case "aspiration", "asp":
+			sel.Aspiration = true
+		case "lmr":
+			sel.LateMoveReductions = true
+		case "futility":
+			sel.Futility = true
+		case "nullmove", "null":
+			sel.NullMove = true
+		default:
+			return Selectivity{}, fmt.Errorf("unknown selective-search technique %q", name)
+		}
+	}
+	return sel, nil
+}
+
+const (
+	// aspirationWindow is the initial half-width around the previous score. A
+	// single cell of territory is worth a few hundred eval units, so 1000 holds
+	// a quiet iteration-to-iteration swing and fails on a real tactical shift.
+	aspirationWindow = 1000
+	// aspirationMaxWindow is where widening gives up and searches the full window.
+	aspirationMaxWindow = 64_000
+	// futilityMargin bounds how far one quiet placement can move the static eval.
+	futilityMargin = 2000
+	// nullMoveReduction is the extra depth taken off a null-move probe (R).
+	nullMoveReduction = 2
+	// lmrMinDepth and lmrMinIndex keep reductions away from shallow nodes and
+	// from the first, best-ordered siblings.
+	lmrMinDepth = 3
+	lmrMinIndex = 3
+)
+
+// iterate runs one iterative-deepening iteration. With Aspiration on in 1v1 and
+// a non-mate previous score it searches a narrow window first, widening on a
+// fail until the score lands inside or the window covers everything.
+func (s *searcher) iterate(state game.State, depth int, prev Result) (Result, bool) {
+	if !s.opts.Selectivity.Aspiration || s.multi || prev.Depth == 0 || abs(prev.Score) >= mateScore/2 {
+		return s.atDepth(state, depth)
+	}
+	for window := aspirationWindow; window <= aspirationMaxWindow; window *= 4 {
+		alpha, beta := prev.Score-window, prev.Score+window
+		result, complete := s.atDepthWindow(state, depth, alpha, beta)
+		if !complete {
+			return result, false
+		}
+		if result.Score > alpha && result.Score < beta {
+			return result, true
+		}
+	}
+	return s.atDepth(state, depth)
+}
+
+// staticEval is the leaf evaluation used for pruning decisions at inner nodes.
+func (s *searcher) staticEval(state game.State) int {
+	s.evaluations++
+	return evaluateWithWorkspace(state, s.root, &s.eval)
+}
+
+// nullMove probes the node with the mover passing the rest of its turn. It
+// reports cut when even that concedes nothing past the null window. Only
+// non-PV 1v1 nodes already standing past the bound qualify, and never inside
+// another null probe (two passes in a row would just search the same board).
+// The static eval is only taken once those cheap checks pass.
+func (s *searcher) nullMove(state game.State, depth, alpha, beta, ply int, maximizing bool) (int, bool, bool) {
+	if s.multi || s.inNull || ply == 0 || depth < nullMoveReduction+1 || beta-alpha != 1 {
+		return 0, false, true
+	}
+	static := s.staticEval(state)
+	if maximizing && static < beta || !maximizing && static > alpha {
+		return 0, false, true
+	}
+	passed := state.PassTurn()
+	if passed.CurrentPlayer() == state.CurrentPlayer() {
+		return 0, false, true
+	}
+	s.inNull = true
+	score, ok := s.minimax(passed, depth-1-nullMoveReduction, alpha, beta, ply+1)
+	s.inNull = false
+	if !ok {
+		return 0, false, false
+	}
+	// A mate found after a pass is not a mate the mover can claim; report the
+	// bound instead.
+	if maximizing && score >= beta {
+		return min(score, mateScore/2), true, true
+	}
+	if !maximizing && score <= alpha {
+		return max(score, -mateScore/2), true, true
+	}
+	return 0, false, true
+}
+
+// reduction is how many plies a late-move reduction takes off this sibling.
+func (s *searcher) reduction(c child, index, depth int) int {
+	if !s.opts.Selectivity.LateMoveReductions || s.multi || index < lmrMinIndex || depth < lmrMinDepth || !quietChild(c) {
+		return 0
+	}
+	return 1
+}
+
+// quietChild reports whether c is a plain placement on an empty cell: not the
+// TT move, not a capture, win or elimination, and not a neutral pair.
+func quietChild(c child) bool {
+	return c.action.Kind == game.Move && c.order < 10_000
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"virusgame/game"
+)
+
+// TestZeroSelectivityMatchesPlainSearch pins the canary-first contract: with
+// every technique off the selective entry point is the plain search, so the
+// vs-ai2.34 goldens in TestSearchMatchesOriginMainAtFixedDepthAndNodes still
+// describe production.
+func TestZeroSelectivityMatchesPlainSearch(t *testing.T) {
+	two := play(t, mustState(t, 5, 5, 2),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(3, 4), move(2, 3), move(1, 2),
+	)
+	dense := denseState12x12(t)
+	for name, state := range map[string]game.State{"minimax": two, "dense": dense} {
+		plain, ok := ChooseNodeBudget(state, 5000)
+		if !ok {
+			t.Fatalf("%s: plain search failed", name)
+		}
+		selective, ok := ChooseNodeBudgetSelective(state, 5000, Selectivity{})
+		if !ok || !sameCore(plain, selective) {
+			t.Fatalf("%s: zero Selectivity = %+v, plain = %+v", name, selective, plain)
+		}
+	}
+}
+
+func TestSelectiveTechniquesAreLegalAndDeterministic(t *testing.T) {
+	state := denseState12x12(t)
+	for _, sel := range []Selectivity{
+		{Aspiration: true},
+		{LateMoveReductions: true},
+		{Futility: true},
+		{NullMove: true},
+		AllSelectivity(),
+	} {
+		t.Run(sel.String(), func(t *testing.T) {
+			first, ok := ChooseNodeBudgetSelective(state, 20_000, sel)
+			if !ok {
+				t.Fatal("search failed")
+			}
+			if _, err := state.Apply(first.Action); err != nil {
+				t.Fatalf("illegal action %+v: %v", first.Action, err)
+			}
+			second, _ := ChooseNodeBudgetSelective(state, 20_000, sel)
+			if !sameCore(first, second) {
+				t.Fatalf("non-deterministic: %+v then %+v", first, second)
+			}
+			if first.Depth < 1 || first.Nodes > 20_000 {
+				t.Fatalf("result = %+v, want a completed depth within budget", first)
+			}
+		})
+	}
+}
+
+// TestSelectiveKeepsForcedWins checks that no technique prunes away a win the
+// plain search finds at the same depth.
+func TestSelectiveKeepsForcedWins(t *testing.T) {
+	state, want, ok := findWinningMove(t)
+	if !ok {
+		t.Skip("no winning fixture")
+	}
+	result, ok := ChooseNodeBudgetSelective(state, 50_000, AllSelectivity())
+	if !ok {
+		t.Fatal("search failed")
+	}
+	next, err := state.Apply(result.Action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.GameOver() || next.Winner() != state.CurrentPlayer() {
+		t.Fatalf("selective search played %+v, want a win such as %+v", result.Action, want)
+	}
+}
+
+func TestSelectiveIsIgnoredInMultiplayer(t *testing.T) {
+	three := play(t, mustState(t, 5, 5, 3),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(3, 3), move(2, 3), move(1, 2),
+		move(1, 3), move(2, 2), move(3, 1),
+	)
+	plain, _ := ChooseNodeBudget(three, 1000)
+	selective, _ := ChooseNodeBudgetSelective(three, 1000, AllSelectivity())
+	if !sameCore(plain, selective) {
+		t.Fatalf("maxN changed under selectivity: %+v, plain %+v", selective, plain)
+	}
+}
+
+func TestParseSelectivity(t *testing.T) {
+	for spec, want := range map[string]Selectivity{
+		"":                       {},
+		"none":                   {},
+		"all":                    AllSelectivity(),
+		"lmr,nullmove":           {LateMoveReductions: true, NullMove: true},
+		" Aspiration , futility": {Aspiration: true, Futility: true},
+	} {
+		got, err := ParseSelectivity(spec)
+		if err != nil || got != want {
+			t.Fatalf("ParseSelectivity(%q) = %+v, %v; want %+v", spec, got, err, want)
+		}
+		if round, err := ParseSelectivity(got.String()); err != nil || round != got {
+			t.Fatalf("String round trip of %+v = %+v, %v", got, round, err)
+		}
+	}
+	if _, err := ParseSelectivity("lmr,quiescence"); err == nil {
+		t.Fatal("unknown technique accepted")
+	}
+}
+
+// TestRootStoresAspirationFailsAsBounds: a root search that fails outside its
+// window stores the bound it proved, not an exact score.
+func TestRootStoresAspirationFailsAsBounds(t *testing.T) {
+	state := play(t, mustState(t, 5, 5, 2),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(3, 4), move(2, 3), move(1, 2),
+	)
+	full, ok := newSearcher(context.Background(), state).atDepth(state, 2)
+	if !ok {
+		t.Fatal("search failed")
+	}
+	for name, window := range map[string]struct {
+		alpha, beta int
+		flag        uint8
+	}{
+		"full":      {-infScore, infScore, flagExact},
+		"fail high": {full.Score - 10, full.Score - 1, flagLower},
+		"fail low":  {full.Score + 1, full.Score + 10, flagUpper},
+	} {
+		s := newSearcher(context.Background(), state)
+		if _, ok := s.atDepthWindow(state, 2, window.alpha, window.beta); !ok {
+			t.Fatalf("%s: search failed", name)
+		}
+		if entry := s.table[stateHash(state)]; entry.flag != window.flag {
+			t.Errorf("%s: root stored flag %d, want %d", name, entry.flag, window.flag)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package search
+
+import (
+	"time"
+
+	"virusgame/game"
+)
+
+// Time management (vs-ai2.64). A flat ProductionBudget spends as long on a
+// forced recapture as on the mid-game cut that decides the game. With a Clock
+// the search instead sizes each decision from the remaining time, the
+// increment and how much of the board is still to be played, weights the first
+// action of a turn (which fixes the shape of the other two) above the third,
+// and then adapts while it runs: a best action that holds for several
+// iterations stops early, a best action that flips or a score that drops
+// extends toward the hard limit.
+//
+// The zero Clock disables all of this and keeps the ProductionBudget path.
+
+// Clock is the time control a decision is made under.
+type Clock struct {
+	// Remaining is the time left on the mover's clock.
+	Remaining time.Duration
+	// Increment is added to the clock after each action.
+	Increment time.Duration
+	// ActionsToGo is the number of own actions Remaining must cover; 0
+	// estimates it from the empty cells left to play.
+	ActionsToGo int
+}
+
+// Enabled reports whether the clock carries any time to manage.
+func (c Clock) Enabled() bool { return c.Remaining > 0 }
+
+// TimeBudget is a decision's allocation: the search aims to finish by Soft
+// and is cut off at Hard.
+type TimeBudget struct {
+	Soft, Hard time.Duration
+}
+
+const (
+	// moveOverhead is held back from every allocation for the round trip to
+	// the server.
+	moveOverhead = 30 * time.Millisecond
+	minThink     = 10 * time.Millisecond
+	// minActionsToGo/maxActionsToGo bound the phase estimate: never bet the
+	// clock on the game ending within two turns, never starve the opening.
+	minActionsToGo = 6
+	maxActionsToGo = 60
+	// hardFactor caps extensions at this multiple of the soft target, and
+	// hardShare caps the hard limit at this fraction of the clock.
+	hardFactor = 4
+	hardShare  = 0.5
+	// stableIterations unchanged best actions in a row halve the target.
+	stableIterations = 3
+	// scoreDropMargin is the iteration-to-iteration score fall (eval units,
+	// twice the aspiration window) that counts as trouble.
+	scoreDropMargin = 2000
+	// extensionStep scales the target on each flip or drop, up to
+	// maxExtension.
+	extensionStep = 1.5
+	maxExtension  = 3
+)
+
+// actionWeights scales the per-action share by movesLeft (index 1..3): the
+// first action of a turn gets the most, the weights average to 1.
+var actionWeights = [4]float64{1, 0.6, 1, 1.4}
+
+// Allocate sizes the decision for state under clock.
+func Allocate(clock Clock, state game.State) TimeBudget {
+	usable := clock.Remaining - moveOverhead
+	if usable < minThink {
+		return TimeBudget{Soft: minThink, Hard: minThink}
+	}
+	togo := clock.ActionsToGo
+	if togo <= 0 {
+		togo = estimateActionsToGo(state)
+	}
+	weight := 1.0
+	if moves := state.MovesLeft(); moves >= 1 && moves <= 3 {
+		weight = actionWeights[moves]
+	}
+	soft := time.Duration(weight * float64(usable/time.Duration(togo)+clock.Increment))
+	hard := min(hardFactor*soft, time.Duration(hardShare*float64(usable)))
+	hard = max(hard, minThink)
+	soft = min(max(soft, minThink), hard)
+	return TimeBudget{Soft: soft, Hard: hard}
+}
+
+// estimateActionsToGo reads the game phase off the board: every action fills
+// at most one empty cell, and the active players share what is left.
+func estimateActionsToGo(state game.State) int {
+	empty := 0
+	for row := 0; row < state.Rows(); row++ {
+		for col := 0; col < state.Cols(); col++ {
+			if cell, _ := state.At(game.Pos{Row: row, Col: col}); cell.Kind == game.Empty {
+				empty++
+			}
+		}
+	}
+	return min(max(empty/max(activeCount(state), 1), minActionsToGo), maxActionsToGo)
+}
+
+// timeManager decides after each completed iteration whether to start the
+// next one.
+type timeManager struct {
+	start     time.Time
+	budget    TimeBudget
+	extension float64
+	stable    int
+	last      Result
+	seen      bool
+}
+
+func newTimeManager(clock Clock, state game.State) *timeManager {
+	return &timeManager{start: time.Now(), budget: Allocate(clock, state), extension: 1}
+}
+
+// done records a completed iteration and reports whether to stop.
+func (tm *timeManager) done(result Result) bool {
+	return tm.doneAfter(result, time.Since(tm.start))
+}
+
+func (tm *timeManager) doneAfter(result Result, elapsed time.Duration) bool {
+	if provenScore(result.Score) {
+		return true
+	}
+	if tm.seen {
+		if result.Action != tm.last.Action {
+			tm.stable = 0
+			tm.extend()
+		} else {
+			tm.stable++
+		}
+		if result.Score < tm.last.Score-scoreDropMargin {
+			tm.extend()
+		}
+	}
+	tm.last, tm.seen = result, true
+	return elapsed >= tm.target()/2 // the next iteration costs about as much as all before it
+}
+
+func (tm *timeManager) extend() {
+	tm.extension = min(tm.extension*extensionStep, maxExtension)
+}
+
+// target is the adjusted soft limit.
+func (tm *timeManager) target() time.Duration {
+	target := tm.extension * float64(tm.budget.Soft)
+	if tm.stable >= stableIterations {
+		target /= 2
+	}
+	return min(time.Duration(target), tm.budget.Hard)
+}
+
+// forcedAction returns the only action that keeps the mover in the game, if
+// there is exactly one.
+func forcedAction(state game.State) (game.Action, bool) {
+	actor := state.CurrentPlayer()
+	var only game.Action
+	count := 0
+	for _, action := range state.LegalActions() {
+		if next, err := state.Apply(action); err == nil && next.Active(actor) {
+			if count++; count > 1 {
+				return game.Action{}, false
+			}
+			only = action
+		}
+	}
+	return only, count == 1
+}
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAllocateFavoursTheFirstActionOfATurn(t *testing.T) {
+	state := mustState(t, 10, 10, 2)
+	clock := Clock{Remaining: time.Minute}
+	first := Allocate(clock, state)
+	second := Allocate(clock, play(t, state, move(1, 1)))
+	third := Allocate(clock, play(t, state, move(1, 1), move(2, 2)))
+	if !(first.Soft > second.Soft && second.Soft > third.Soft) {
+		t.Fatalf("soft targets %v, %v, %v, want decreasing across the turn", first.Soft, second.Soft, third.Soft)
+	}
+	for _, budget := range []TimeBudget{first, second, third} {
+		if budget.Soft > budget.Hard || budget.Hard > clock.Remaining/2 {
+			t.Fatalf("budget %+v out of bounds for %v", budget, clock.Remaining)
+		}
+	}
+}
+
+func TestAllocateFollowsClockIncrementAndPhase(t *testing.T) {
+	state := mustState(t, 10, 10, 2)
+	base := Allocate(Clock{Remaining: 30 * time.Second}, state)
+	if more := Allocate(Clock{Remaining: time.Minute}, state); more.Soft <= base.Soft {
+		t.Fatalf("doubling the clock gave %v, was %v", more.Soft, base.Soft)
+	}
+	if inc := Allocate(Clock{Remaining: 30 * time.Second, Increment: time.Second}, state); inc.Soft <= base.Soft {
+		t.Fatalf("an increment gave %v, was %v", inc.Soft, base.Soft)
+	}
+	if late := Allocate(Clock{Remaining: 30 * time.Second}, sparseEndgames(t, 6, 6, 1)[0]); late.Soft <= base.Soft {
+		t.Fatalf("a late position gave %v, the opening %v", late.Soft, base.Soft)
+	}
+	if short := Allocate(Clock{Remaining: 5 * time.Millisecond}, state); short.Hard != minThink {
+		t.Fatalf("an exhausted clock gave %+v, want the %v floor", short, minThink)
+	}
+}
+
+func TestTimeManagerStopsWhenStableAndExtendsOnTrouble(t *testing.T) {
+	budget := TimeBudget{Soft: 100 * time.Millisecond, Hard: 400 * time.Millisecond}
+	a := Result{Action: move(1, 1), Score: 500}
+	b := Result{Action: move(2, 2), Score: 500}
+
+	stable := &timeManager{budget: budget, extension: 1}
+	for i := 0; i < stableIterations; i++ {
+		if stable.doneAfter(a, 30*time.Millisecond) {
+			t.Fatalf("stopped after iteration %d at 30ms", i)
+		}
+	}
+	if !stable.doneAfter(a, 30*time.Millisecond) {
+		t.Fatal("a stable best action did not halve the target")
+	}
+
+	flipping := &timeManager{budget: budget, extension: 1}
+	flipping.doneAfter(a, 10*time.Millisecond)
+	if flipping.doneAfter(b, 60*time.Millisecond) {
+		t.Fatal("a best-action flip did not extend past the soft target")
+	}
+
+	dropping := &timeManager{budget: budget, extension: 1}
+	dropping.doneAfter(a, 10*time.Millisecond)
+	if dropping.doneAfter(Result{Action: a.Action, Score: a.Score - 2*scoreDropMargin}, 60*time.Millisecond) {
+		t.Fatal("a score drop did not extend past the soft target")
+	}
+	for i := 0; i < 10; i++ {
+		dropping.extend()
+	}
+	if want := maxExtension * budget.Soft; dropping.target() != want {
+		t.Fatalf("extensions reached %v, want the %v cap", dropping.target(), want)
+	}
+	if dropping.budget.Hard = 250 * time.Millisecond; dropping.target() != dropping.budget.Hard {
+		t.Fatalf("extensions reached %v past the %v hard limit", dropping.target(), dropping.budget.Hard)
+	}
+
+	proven := &timeManager{budget: budget, extension: 1}
+	if !proven.doneAfter(Result{Score: mateScore - 3}, 0) {
+		t.Fatal("a proven score did not stop the search")
+	}
+}
+
+func TestClockedChooseIsLegalAndWithinHardLimit(t *testing.T) {
+	state := play(t, mustState(t, 8, 8, 2),
+		move(1, 1), move(2, 2), move(3, 3),
+		move(6, 6), move(5, 5), move(4, 4),
+	)
+	clock := Clock{Remaining: 2 * time.Second}
+	budget := Allocate(clock, state)
+	start := time.Now()
+	result, ok := ChooseOptions(context.Background(), state, Options{Clock: clock})
+	elapsed := time.Since(start)
+	if !ok || result.Depth == 0 {
+		t.Fatalf("no searched result: %+v", result)
+	}
+	if _, err := state.Apply(result.Action); err != nil {
+		t.Fatalf("illegal action %+v: %v", result.Action, err)
+	}
+	if elapsed > budget.Hard+100*time.Millisecond {
+		t.Fatalf("took %v, hard limit %v", elapsed, budget.Hard)
+	}
+}
+
+func TestClockedChoosePlaysForcedActionsAtOnce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for attempt := 0; attempt < 200; attempt++ {
+		state := mustState(t, 5, 5, 2)
+		for !state.GameOver() {
+			if only, forced := forcedAction(state); forced {
+				result, ok := ChooseOptions(context.Background(), state, Options{Clock: Clock{Remaining: time.Minute}})
+				if !ok || result.Action != only || result.Depth != 0 {
+					t.Fatalf("forced %+v, got %+v", only, result)
+				}
+				return
+			}
+			actions := state.LegalActions()
+			next, err := state.Apply(actions[rng.Intn(len(actions))])
+			if err != nil {
+				t.Fatal(err)
+			}
+			state = next
+		}
+	}
+	t.Fatal("no forced position found")
+}
+
+func TestForcedActionNeedsASingleSurvivingChoice(t *testing.T) {
+	if _, forced := forcedAction(mustState(t, 6, 6, 2)); forced {
+		t.Fatal("the opening position reported a forced action")
+	}
+}
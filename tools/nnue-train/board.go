@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// vs-ai2.65 board-plane net (-board): the incrementally updated NNUE search
+// keeps as a running sum (backend/search/nnue_board.go). Its input is the raw
+// position, not the aggregate features: one sparse feature per occupied cell
+// per seat perspective (nnuefeat/board.go). Each seat's accumulator is B1 plus
+// the W1 column of every active feature; the output scores one seat from every
+// in-play accumulator, read in turn order from it through a clipped ReLU. It
+// trains on the same per-seat deep scores as -perspective seat, one sample per
+// scored seat, and exports the VNNB file backend/search/nnueweights/board.go
+// documents, loaded by search through VS_NNUE_BOARD_WEIGHTS. The trainer writes
+// purely incremental nets (no dense aggregate input).
+
+// Board plane layout, mirroring nnuefeat's board.go; bump boardSchema with
+// nnuefeat.BoardSchemaVersion.
+const (
+	boardSchema   = 1
+	boardSide     = 50
+	boardKinds    = 3 // Normal, Base, Fortified
+	boardPlanes   = seats*boardKinds + 1
+	boardFeatures = boardPlanes * boardSide * boardSide
+
+	boardMagic   = "VNNB"
+	boardVersion = 1
+)
+
+// BoardSample is one seat's deep score with the active features of every
+// seat's view, indexed by turn distance from the scored seat.
+type BoardSample struct {
+	Views  [seats][]int
+	InPlay [seats]bool
+	Score  float64
+	Hash   uint32
+}
+
+// boardFeatures is seat's (1-based) view of the record's board. Mirrors
+// nnuefeat.BoardView.Feature; keep the two in sync.
+func (r Record) boardFeatures(seat int) []int {
+	players := r.players()
+	var features []int
+	for cell := 0; cell < r.Rows*r.Cols; cell++ {
+		code := int(r.Position.Cells[cell] - 'A')
+		owner, kind := code/5, code%5
+		var plane int
+		switch kind {
+		case 0:
+			continue
+		case 4:
+			plane = seats * boardKinds
+		default:
+			plane = (owner-seat+players)%players*boardKinds + kind - 1
+		}
+		row, col := cell/r.Cols, cell%r.Cols
+		if seat == 2 || seat == 4 {
+			row = r.Rows - 1 - row
+		}
+		if seat == 2 || seat == 3 {
+			col = r.Cols - 1 - col
+		}
+		features = append(features, (plane*boardSide+row)*boardSide+col)
+	}
+	return features
+}
+
+// boardSamples turns a record into one sample per active seat with a known
+// score; boards past the planes give none.
+func (r Record) boardSamples() ([]BoardSample, error) {
+	if r.Rows > boardSide || r.Cols > boardSide {
+		return nil, nil
+	}
+	if len(r.Position.Cells) != r.Rows*r.Cols {
+		return nil, fmt.Errorf("%s: %d cells for a %dx%d board", r.Fingerprint, len(r.Position.Cells), r.Rows, r.Cols)
+	}
+	players := r.players()
+	var views [seats][]int
+	for seat := 0; seat < players; seat++ {
+		if len(r.Features[seat]) > 0 {
+			views[seat] = r.boardFeatures(seat + 1)
+		}
+	}
+	var out []BoardSample
+	for seat := 0; seat < players; seat++ {
+		score, ok := r.seatScore(seat)
+		if !ok {
+			continue
+		}
+		sample := BoardSample{Score: float64(score), Hash: hashString(r.Fingerprint)}
+		for k := 0; k < players; k++ {
+			other := (seat + k) % players
+			sample.Views[k], sample.InPlay[k] = views[other], len(r.Features[other]) > 0
+		}
+		out = append(out, sample)
+	}
+	return out, nil
+}
+
+// loadBoardShards reads every searched record under dir into board samples.
+func loadBoardShards(dir string) ([]BoardSample, error) {
+	shards, err := filepath.Glob(filepath.Join(dir, "shard-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no shard-*.jsonl files in %s", dir)
+	}
+	sort.Strings(shards)
+	var samples []BoardSample
+	for _, path := range shards {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 1<<20), 1<<24)
+		for scanner.Scan() {
+			var rec Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if rec.Budget == 0 {
+				continue // unsearched (nnuegen -human): a policy label only
+			}
+			rows, err := rec.boardSamples()
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			samples = append(samples, rows...)
+		}
+		if err := scanner.Err(); err != nil {
+			file.Close()
+			return nil, err
+		}
+		file.Close()
+	}
+	return samples, nil
+}
+
+// BoardModel is the float board-plane net: W1 feature-major (feature f's
+// column is W1[f*Hidden:(f+1)*Hidden]), W2 one Hidden block per relative seat.
+// It predicts the normalized score; Stats maps it back to eval units.
+type BoardModel struct {
+	Hidden int
+	W1, B1 []float64
+	W2     []float64
+	B2     float64
+	Stats  Stats
+}
+
+func newBoardModel(hidden int, rng *uint64) *BoardModel {
+	m := &BoardModel{
+		Hidden: hidden,
+		W1:     make([]float64, boardFeatures*hidden),
+		B1:     make([]float64, hidden),
+		W2:     make([]float64, seats*hidden),
+	}
+	// A mid-game board has a few dozen occupied cells; this keeps the
+	// accumulator inside the clipped ReLU's slope at the start.
+	for i := range m.W1 {
+		m.W1[i] = randNorm(rng) * 0.05
+	}
+	for i := range m.B1 {
+		m.B1[i] = 0.5
+	}
+	for i := range m.W2 {
+		m.W2[i] = randNorm(rng) / math.Sqrt(float64(seats*hidden))
+	}
+	return m
+}
+
+// forward returns the normalized prediction for s, leaving each relative
+// seat's pre-activations in accs.
+func (m *BoardModel) forward(s BoardSample, accs *[seats][]float64) float64 {
+	out := m.B2
+	for k, features := range s.Views {
+		if !s.InPlay[k] {
+			continue
+		}
+		acc := accs[k]
+		copy(acc, m.B1)
+		for _, f := range features {
+			for h, w := range m.W1[f*m.Hidden : (f+1)*m.Hidden] {
+				acc[h] += w
+			}
+		}
+		w2 := m.W2[k*m.Hidden : (k+1)*m.Hidden]
+		for h, a := range acc {
+			out += w2[h] * min(max(a, 0), 1)
+		}
+	}
+	return out
+}
+
+func (m *BoardModel) predict(s BoardSample) float64 {
+	var accs [seats][]float64
+	for k := range accs {
+		accs[k] = make([]float64, m.Hidden)
+	}
+	return m.forward(s, &accs)*m.Stats.Std + m.Stats.Mean
+}
+
+// boardAdam is lazy Adam: W1's moments move only on the rows a sample touches,
+// so a step costs the active features, not the whole plane stack.
+type boardAdam struct {
+	W1M, W1V, M, V  []float64
+	T               int
+	LR, B1, B2, Eps float64
+}
+
+func (a *boardAdam) update(p, g, m, v []float64, bc1, bc2 float64) {
+	for i := range p {
+		m[i] = a.B1*m[i] + (1-a.B1)*g[i]
+		v[i] = a.B2*v[i] + (1-a.B2)*g[i]*g[i]
+		p[i] -= a.LR * (m[i] / bc1) / (math.Sqrt(v[i]/bc2) + a.Eps)
+	}
+}
+
+// boardGrad is one sample's gradient: dense for the small tensors, W1 only on
+// the rows in touched.
+type boardGrad struct {
+	W1, B1, W2 []float64
+	B2         float64
+	touched    []int
+	seen       []bool
+}
+
+// trainStep takes one Adam step on s and returns its squared error.
+func (m *BoardModel) trainStep(s BoardSample, a *boardAdam, g *boardGrad, accs *[seats][]float64) float64 {
+	d := m.forward(s, accs) - m.Stats.norm(s.Score)
+	clear(g.B1)
+	clear(g.W2)
+	g.B2 = d
+	g.touched = g.touched[:0]
+	for k, features := range s.Views {
+		if !s.InPlay[k] {
+			continue
+		}
+		for _, f := range features {
+			if !g.seen[f] {
+				g.seen[f] = true
+				g.touched = append(g.touched, f)
+				clear(g.W1[f*m.Hidden : (f+1)*m.Hidden])
+			}
+		}
+	}
+	for k, features := range s.Views {
+		if !s.InPlay[k] {
+			continue
+		}
+		block := k * m.Hidden
+		for h, acc := range accs[k] {
+			g.W2[block+h] += d * min(max(acc, 0), 1)
+			if acc <= 0 || acc >= 1 {
+				continue
+			}
+			da := d * m.W2[block+h]
+			g.B1[h] += da
+			for _, f := range features {
+				g.W1[f*m.Hidden+h] += da
+			}
+		}
+	}
+	a.T++
+	bc1 := 1 - math.Pow(a.B1, float64(a.T))
+	bc2 := 1 - math.Pow(a.B2, float64(a.T))
+	for _, f := range g.touched {
+		row := f * m.Hidden
+		end := row + m.Hidden
+		a.update(m.W1[row:end], g.W1[row:end], a.W1M[row:end], a.W1V[row:end], bc1, bc2)
+		g.seen[f] = false
+	}
+	small := len(m.B1) + len(m.W2)
+	a.update(m.B1, g.B1, a.M[:len(m.B1)], a.V[:len(m.B1)], bc1, bc2)
+	a.update(m.W2, g.W2, a.M[len(m.B1):small], a.V[len(m.B1):small], bc1, bc2)
+	b2 := []float64{m.B2}
+	a.update(b2, []float64{g.B2}, a.M[small:], a.V[small:], bc1, bc2)
+	m.B2 = b2[0]
+	return d * d
+}
+
+// BoardConfig is a -board run's settings.
+type BoardConfig struct {
+	Hidden, Epochs int
+	LR             float64
+	Seed           uint64
+}
+
+// BoardEval is a model's normalized MSE and Spearman rank correlation with the
+// deep scores over a sample set.
+type BoardEval struct {
+	Loss, Spearman float64
+}
+
+func evalBoard(m *BoardModel, samples []BoardSample) BoardEval {
+	if len(samples) == 0 {
+		return BoardEval{}
+	}
+	var e BoardEval
+	preds := make([]float64, len(samples))
+	scores := make([]float64, len(samples))
+	for i, s := range samples {
+		preds[i], scores[i] = m.predict(s), s.Score
+		d := m.Stats.norm(preds[i]) - m.Stats.norm(s.Score)
+		e.Loss += d * d
+	}
+	e.Loss /= float64(len(samples))
+	if len(samples) > 1 {
+		e.Spearman = pearson(ranks(preds), ranks(scores))
+	}
+	return e
+}
+
+// TrainBoard fits a board-plane net to samples, one Adam step per sample,
+// logging per-epoch train loss and validation loss and Spearman to w. It
+// returns the model and its final validation score.
+func TrainBoard(samples []BoardSample, cfg BoardConfig, w io.Writer) (*BoardModel, BoardEval, error) {
+	if len(samples) == 0 {
+		return nil, BoardEval{}, fmt.Errorf("no samples with a board position")
+	}
+	if cfg.Hidden < 1 || cfg.Epochs < 1 || cfg.LR <= 0 {
+		return nil, BoardEval{}, fmt.Errorf("board: need hidden, epochs and lr > 0")
+	}
+	var train, val []BoardSample
+	for _, s := range samples {
+		if s.Hash%10 == 0 {
+			val = append(val, s)
+		} else {
+			train = append(train, s)
+		}
+	}
+	if len(train) == 0 {
+		train, val = samples, nil
+	}
+	rng := cfg.Seed | 1
+	m := newBoardModel(cfg.Hidden, &rng)
+	scores := make([]Sample, len(train))
+	for i, s := range train {
+		scores[i].Score = s.Score
+	}
+	m.Stats = normStats(scores)
+	small := 2*cfg.Hidden + seats*cfg.Hidden + 1
+	a := &boardAdam{
+		W1M: make([]float64, len(m.W1)), W1V: make([]float64, len(m.W1)),
+		M: make([]float64, small), V: make([]float64, small),
+		LR: cfg.LR, B1: 0.9, B2: 0.999, Eps: 1e-8,
+	}
+	g := &boardGrad{
+		W1: make([]float64, len(m.W1)), B1: make([]float64, cfg.Hidden), W2: make([]float64, len(m.W2)),
+		seen: make([]bool, boardFeatures),
+	}
+	var accs [seats][]float64
+	for k := range accs {
+		accs[k] = make([]float64, cfg.Hidden)
+	}
+	order := make([]int, len(train))
+	for i := range order {
+		order[i] = i
+	}
+	var score BoardEval
+	for e := 1; e <= cfg.Epochs; e++ {
+		for i := len(order) - 1; i > 0; i-- {
+			j := int(next(&rng) % uint64(i+1))
+			order[i], order[j] = order[j], order[i]
+		}
+		total := 0.0
+		for _, i := range order {
+			total += m.trainStep(train[i], a, g, &accs)
+		}
+		score = evalBoard(m, val)
+		fmt.Fprintf(w, "board epoch %3d  train_loss %.4f  val_loss %.4f  val_spearman %.4f\n",
+			e, total/float64(len(train)), score.Loss, score.Spearman)
+	}
+	return m, score, nil
+}
+
+// ExportBoard writes m in the int8 VNNB format: W1 and B1 share one scale so
+// B1 lands in accumulator units, W2 has its own, and B2 stays float.
+func ExportBoard(m *BoardModel, w io.Writer) error {
+	absMax := func(vs []float64) float64 {
+		peak := 0.0
+		for _, v := range vs {
+			peak = max(peak, math.Abs(v))
+		}
+		if peak == 0 {
+			return 1
+		}
+		return peak
+	}
+	w1Scale := absMax(m.W1) / 127
+	w2Scale := absMax(m.W2) / 127
+	quant := func(vs []float64, scale float64) []int8 {
+		q := make([]int8, len(vs))
+		for i, v := range vs {
+			q[i] = int8(min(max(math.Round(v/scale), -127), 127))
+		}
+		return q
+	}
+	b1 := make([]int32, m.Hidden)
+	for h, b := range m.B1 {
+		b1[h] = int32(math.Round(b / w1Scale))
+	}
+	var buf bytes.Buffer
+	put := func(v any) {
+		// Writes to a bytes.Buffer cannot fail for these fixed-size values.
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString(boardMagic)
+	put(uint16(boardVersion))
+	put(uint16(boardSchema))
+	put([]uint16{boardSide, boardPlanes})
+	put([]uint32{uint32(m.Hidden), 0})
+	put([]float64{m.Stats.Mean, m.Stats.Std, w1Scale, w2Scale, m.B2, 0})
+	put(b1)
+	put(quant(m.W1, w1Scale))
+	put(quant(m.W2, w2Scale))
+	put(crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeBoard(m *BoardModel, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := ExportBoard(m, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// checkpointVersion is bumped whenever checkpoint's shape changes.
+const checkpointVersion = 1
+
+// checkpoint is a training run's complete state after an epoch: weights,
+// optimizer moments, early-stopping and QAT bookkeeping, and the report so far.
+// Training draws no randomness after initialization and visits samples in a
+// fixed order, so a resumed run finishes bit-identical to an uninterrupted one.
+// encoding/json round-trips float64 exactly.
+type checkpoint struct {
+	Version int    `json:"version"`
+	Config  Config `json:"config"`
+	Stats   Stats  `json:"stats"`
+	Model   *MLP   `json:"model"`
+	Adam    *adam  `json:"adam"`
+	// Epoch counts completed float epochs; Best is the best of them by
+	// validation loss, Stale the epochs since it.
+	Epoch    int     `json:"epoch"`
+	Best     *MLP    `json:"best,omitempty"`
+	BestLoss float64 `json:"bestLoss"`
+	Stale    int     `json:"stale"`
+	// QATBest is the best int8 round trip seen so far, starting with the
+	// weights QAT began from.
+	QATDone int     `json:"qatDone"`
+	QATBest *MLP    `json:"qatBest,omitempty"`
+	QATLoss float64 `json:"qatLoss"`
+	Report  Report  `json:"report"`
+}
+
+// final is the model the run exports.
+func (c *checkpoint) final() *MLP {
+	switch {
+	case c.QATBest != nil:
+		return c.QATBest
+	case c.Config.Patience > 0 && c.Best != nil:
+		return c.Best
+	}
+	return c.Model
+}
+
+// matches refuses to resume c under a config or dataset that would make the
+// continuation a different run than the one checkpointed; fresh is the state a
+// new run on the current data starts from. Epoch counts, patience and QAT
+// settings may change.
+func (c *checkpoint) matches(cfg Config, fresh *checkpoint) error {
+	old := c.Config
+	switch {
+	case !slices.Equal(old.Hidden, cfg.Hidden) || old.Activation != cfg.Activation:
+		return fmt.Errorf("checkpoint is a %v %s net, run asks for %v %s", old.Hidden, old.Activation, cfg.Hidden, cfg.Activation)
+	case old.Objective != cfg.Objective || old.Seed != cfg.Seed:
+		return fmt.Errorf("checkpoint was trained with objective %+v seed %d", old.Objective, old.Seed)
+	case c.Stats != fresh.Stats || c.Report.Train != fresh.Report.Train || c.Report.Val != fresh.Report.Val:
+		return fmt.Errorf("checkpoint was trained on other data (%d/%d train/val samples, now %d/%d)",
+			c.Report.Train, c.Report.Val, fresh.Report.Train, fresh.Report.Val)
+	}
+	return nil
+}
+
+// save rewrites the checkpoint at path (no-op for ""), atomically so an
+// interrupted write leaves the previous epoch's checkpoint intact.
+func (c *checkpoint) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	c.Version = checkpointVersion
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("%s: checkpoint version %d, want %d", path, c.Version, checkpointVersion)
+	}
+	if c.Model == nil || c.Adam == nil {
+		return nil, fmt.Errorf("%s: checkpoint has no model", path)
+	}
+	return &c, nil
+}
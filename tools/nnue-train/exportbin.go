@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// featureSchema is nnuefeat.FeatureSchemaVersion for the featuresPerSeat-wide
+// vector this trainer builds; bump both together.
+const featureSchema = 2
+
+// Binary weights file, format version 1. The layout is owned by
+// backend/search/nnueweights (file.go documents it byte by byte); this is a
+// dependency-free writer for it. Little-endian throughout, CRC-32 (IEEE) of
+// everything before it as the trailer.
+const (
+	binMagic   = "VNNU"
+	binVersion = 1
+	// Input layouts, nnueweights.LayoutMover and LayoutSeat.
+	layoutMover = 0
+	layoutSeat  = 1
+)
+
+// ExportBinary writes t's int8 form as a weights file search can load at
+// startup (VS_NNUE_WEIGHTS) without a rebuild.
+func ExportBinary(t *Trained, w io.Writer) error {
+	qm := Quantize(t)
+	var buf bytes.Buffer
+	put := func(v any) {
+		// Writes to a bytes.Buffer cannot fail for these fixed-size values.
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString(binMagic)
+	put(uint16(binVersion))
+	put(uint16(featureSchema))
+	put(uint16(featuresPerSeat))
+	put(uint16(seats))
+	put(uint32(qm.In))
+	put(uint32(qm.Hidden))
+	put([]float64{qm.Mean, qm.Std, qm.B1.Scale, qm.W2.Scale, qm.B2})
+	layout := uint16(layoutMover)
+	if t.SeatRelative {
+		layout = layoutSeat
+	}
+	put(layout)
+	put(uint16(qm.Act))
+	put(uint16(len(qm.Deep)))
+	for _, layer := range qm.Deep {
+		put(uint32(len(layer.W)))
+	}
+	putRows := func(rows []QuantVec) {
+		for _, row := range rows {
+			put(row.Scale)
+		}
+		for _, row := range rows {
+			put(row.Q)
+		}
+	}
+	putRows(qm.W1)
+	put(qm.B1.Q)
+	for _, layer := range qm.Deep {
+		putRows(layer.W)
+		put(layer.B.Q)
+		put(layer.B.Scale)
+	}
+	put(qm.W2.Q)
+	put(crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -21,9 +21,17 @@ type QuantModel struct {
 	In, Hidden int
 	W1         []QuantVec // one per hidden unit (row of In weights)
 	B1         QuantVec
+	Deep       []QuantLayer
 	W2         QuantVec
 	B2         float64 // scalar bias kept as float (single value; nothing to quantize)
 	Mean, Std  float64
+	Act        Activation
+}
+
+// QuantLayer is the int8 form of a Dense layer, quantized like W1/B1.
+type QuantLayer struct {
+	W []QuantVec
+	B QuantVec
 }
 
 func quantVec(v []float64) QuantVec {
@@ -64,18 +72,12 @@ func checkFinite(t *Trained) error {
 		return nil
 	}
 	m := t.Model
-	for h := 0; h < m.Hidden; h++ {
-		for _, w := range m.W1[h] {
-			if err := bad("W1 weight", w); err != nil {
+	for _, v := range m.vectors() {
+		for _, w := range v {
+			if err := bad("weight", w); err != nil {
 				return err
 			}
 		}
-		if err := bad("B1 bias", m.B1[h]); err != nil {
-			return err
-		}
-		if err := bad("W2 weight", m.W2[h]); err != nil {
-			return err
-		}
 	}
 	if err := bad("B2 bias", m.B2); err != nil {
 		return err
@@ -89,35 +91,55 @@ func checkFinite(t *Trained) error {
 // Quantize converts a trained model to its int8 form.
 func Quantize(t *Trained) QuantModel {
 	m := t.Model
-	qm := QuantModel{In: m.In, Hidden: m.Hidden, B2: m.B2, Mean: t.Stats.Mean, Std: t.Stats.Std}
-	qm.W1 = make([]QuantVec, m.Hidden)
-	for h := 0; h < m.Hidden; h++ {
-		qm.W1[h] = quantVec(m.W1[h])
-	}
+	qm := QuantModel{In: m.In, Hidden: m.Hidden, B2: m.B2, Mean: t.Stats.Mean, Std: t.Stats.Std, Act: m.Act}
+	qm.W1 = quantRows(m.W1)
 	qm.B1 = quantVec(m.B1)
+	for _, layer := range m.Deep {
+		qm.Deep = append(qm.Deep, QuantLayer{W: quantRows(layer.W), B: quantVec(layer.B)})
+	}
 	qm.W2 = quantVec(m.W2)
 	return qm
 }
 
+func quantRows(w [][]float64) []QuantVec {
+	out := make([]QuantVec, len(w))
+	for j, row := range w {
+		out[j] = quantVec(row)
+	}
+	return out
+}
+
 // Predict runs the dequantized forward pass and returns a raw deep-score
 // prediction (de-normalized). This is the reference the emitted loader stub
 // mirrors and the test round-trips against.
 func (qm QuantModel) Predict(x []float64) float64 {
+	hid := quantForward(qm.W1, qm.B1, x, qm.Act)
+	for _, layer := range qm.Deep {
+		hid = quantForward(layer.W, layer.B, hid, qm.Act)
+	}
 	out := qm.B2
-	for h := 0; h < qm.Hidden; h++ {
-		z := qm.B1.at(h)
-		row := qm.W1[h]
-		for i := 0; i < qm.In; i++ {
+	for h, a := range hid {
+		out += qm.W2.at(h) * a
+	}
+	return out*qm.Std + qm.Mean
+}
+
+func quantForward(w []QuantVec, b QuantVec, x []float64, act Activation) []float64 {
+	y := make([]float64, len(w))
+	for j, row := range w {
+		z := b.at(j)
+		for i := range x {
 			z += row.at(i) * x[i]
 		}
-		out += qm.W2.at(h) * math.Tanh(z)
+		y[j] = act.apply(z)
 	}
-	return out*qm.Std + qm.Mean
+	return y
 }
 
 // ExportGo renders a QuantModel (from t) as compilable Go source: package vars
 // for the int8 weights/scales and normalization, plus a pure-Go forward-pass
-// loader stub. The stub is UNUSED BY PRODUCTION — Stage 3 adopts it into the
+// loader stub. The vars describe one tanh hidden layer; deeper or ClippedReLU
+// nets ship through ExportBinary only. The stub is UNUSED BY PRODUCTION — Stage 3 adopts it into the
 // int8 inference path inside search.
 func ExportGo(t *Trained, pkg string) string {
 	qm := Quantize(t)
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Objective kinds.
+const (
+	lossMSE = "mse"
+	lossWDL = "wdl"
+)
+
+// Objective is the training loss on the network's normalized output.
+//
+//   - mse: squared error against the z-scored deep score, plus Aux times the
+//     squared error against the outcome sign when the outcome is known (the
+//     original objective).
+//   - wdl: squared error in win-probability space, as Texel tuning and NNUE
+//     trainers do. Prediction and deep score both pass through
+//     sigmoid(raw/Scale); the target is then blended with the game result,
+//     (1-Lambda)·sigmoid(score/Scale) + Lambda·(outcome+1)/2, on samples whose
+//     outcome is known.
+type Objective struct {
+	Kind   string  `json:"kind"`
+	Aux    float64 `json:"aux,omitempty"`
+	Lambda float64 `json:"lambda,omitempty"`
+	// Scale is the sigmoid's width in raw eval units; 0 uses the target Std.
+	Scale float64 `json:"scale,omitempty"`
+}
+
+func (o Objective) check() error {
+	switch o.Kind {
+	case lossMSE:
+	case lossWDL:
+		if o.Lambda < 0 || o.Lambda > 1 {
+			return fmt.Errorf("-lambda %v outside [0, 1]", o.Lambda)
+		}
+		if o.Scale < 0 {
+			return fmt.Errorf("-wdl-scale %v is negative", o.Scale)
+		}
+	default:
+		return fmt.Errorf("unknown loss %q (mse or wdl)", o.Kind)
+	}
+	return nil
+}
+
+// eval returns the loss of output out on s and its slope d(loss)/d(out).
+func (o Objective) eval(out float64, s Sample, st Stats) (loss, slope float64) {
+	if o.Kind == lossWDL {
+		k := o.scale(st)
+		p := sigmoid((out*st.Std + st.Mean) / k)
+		target := sigmoid(s.Score / k)
+		if s.Outcome != 0 {
+			target = (1-o.Lambda)*target + o.Lambda*(s.Outcome+1)/2
+		}
+		d := p - target
+		return d * d, 2 * d * p * (1 - p) * st.Std / k
+	}
+	// primary MSE on normalized score
+	dScore := out - st.norm(s.Score)
+	loss = dScore * dScore
+	// auxiliary: nudge output toward outcome sign when known
+	dAux := 0.0
+	if s.Outcome != 0 {
+		dAux = o.Aux * (out - s.Outcome)
+		loss += o.Aux * (out - s.Outcome) * (out - s.Outcome)
+	}
+	return loss, 2*dScore + 2*dAux
+}
+
+// val is the validation loss: eval's, minus mse's auxiliary term.
+func (o Objective) val(out float64, s Sample, st Stats) float64 {
+	if o.Kind == lossMSE {
+		d := out - st.norm(s.Score)
+		return d * d
+	}
+	loss, _ := o.eval(out, s, st)
+	return loss
+}
+
+func (o Objective) scale(st Stats) float64 {
+	if o.Scale > 0 {
+		return o.Scale
+	}
+	return st.Std
+}
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+
+// Schedule kinds.
+const (
+	scheduleConstant = "constant"
+	scheduleStep     = "step"
+	scheduleCosine   = "cosine"
+)
+
+// Schedule sets the learning rate of each epoch from the base rate.
+//
+//   - constant: the base rate throughout.
+//   - step: multiplied by Gamma every Every epochs.
+//   - cosine: annealed from the base rate towards zero over the run.
+type Schedule struct {
+	Kind  string  `json:"kind"`
+	Every int     `json:"every,omitempty"`
+	Gamma float64 `json:"gamma,omitempty"`
+}
+
+func (s Schedule) check() error {
+	switch s.Kind {
+	case scheduleConstant, scheduleCosine:
+	case scheduleStep:
+		if s.Every < 1 || s.Gamma <= 0 {
+			return fmt.Errorf("step schedule needs -lr-step >= 1 and -lr-gamma > 0, got %d and %v", s.Every, s.Gamma)
+		}
+	default:
+		return fmt.Errorf("unknown schedule %q (constant, step or cosine)", s.Kind)
+	}
+	return nil
+}
+
+// rate is the learning rate of 1-based epoch out of epochs.
+func (s Schedule) rate(base float64, epoch, epochs int) float64 {
+	switch s.Kind {
+	case scheduleStep:
+		return base * math.Pow(s.Gamma, float64((epoch-1)/s.Every))
+	case scheduleCosine:
+		return base * 0.5 * (1 + math.Cos(math.Pi*float64(epoch-1)/float64(epochs)))
+	}
+	return base
+}
@@ -9,14 +9,23 @@
 //
 // Pipeline:
 //   - load every shard-*.jsonl under -data (record schema mirrors nnuegen).
-//   - build a fixed 4×K input vector per position (inactive seats zero-padded).
+//   - build a fixed 4×K input vector per position (inactive seats zero-padded):
+//     the mover's absolute-order view (-perspective mover), or one rotated
+//     vector per active seat, slot 0 the scored seat (-perspective seat,
+//     vs-ai2.66, the layout nnuefeat.SeatInputs builds for 3-4 player search).
 //   - z-score-normalize the deep score into the regression target.
 //   - deterministic 90/10 train/val split by fingerprint hash.
 //   - train a 2-layer MLP (input → hidden → 1) with Adam + MSE, plus a small
 //     game-outcome auxiliary term.
 //   - per epoch report train loss, val loss, and Spearman rank correlation.
 //   - export int8-quantized weights (symmetric per-matrix) to -export as Go
-//     source, with a pure-Go forward-pass loader stub for Stage 3.
+//     source, with a pure-Go forward-pass loader stub for Stage 3, and/or to
+//     -export-bin as a versioned binary weights file search loads at startup.
+//
+// -policy trains the move-ordering policy net on the records' policy labels
+// instead (see policy.go) and writes its binary weights file. -board trains the
+// incrementally updated board-plane net on the records' raw positions (see
+// board.go) and writes its binary weights file.
 package main
 
 import (
@@ -25,10 +34,13 @@ import (
 	"flag"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 // featuresPerSeat is the fixed width of arena.PlayerFeatures.Features().
@@ -54,17 +66,31 @@ type Outcome struct {
 // Record mirrors one nnuegen JSONL line. See nnuegen's package doc for field
 // semantics.
 type Record struct {
-	Fingerprint   string       `json:"fingerprint"`
-	Rows          int          `json:"rows"`
-	Cols          int          `json:"cols"`
-	CurrentPlayer int          `json:"currentPlayer"`
-	Features      [4][]float64 `json:"features"`
-	DeepScore     int          `json:"deepScore"`
-	Budget        uint64       `json:"budget"`
-	Outcome       Outcome      `json:"outcome"`
-	Source        string       `json:"source"`
+	Fingerprint   string        `json:"fingerprint"`
+	Rows          int           `json:"rows"`
+	Cols          int           `json:"cols"`
+	CurrentPlayer int           `json:"currentPlayer"`
+	Features      [4][]float64  `json:"features"`
+	DeepScore     int           `json:"deepScore"`
+	Budget        uint64        `json:"budget"`
+	Outcome       Outcome       `json:"outcome"`
+	SeatScores    []int         `json:"seatScores,omitempty"`
+	Placements    []int         `json:"placements,omitempty"`
+	Policy        *PolicyTarget `json:"policy,omitempty"`
+	Source        string        `json:"source"`
+	Position      struct {
+		Cells     string `json:"cells"`
+		Bases     []int  `json:"bases"`
+		MovesLeft int    `json:"movesLeft"`
+	} `json:"position"`
 }
 
+// Perspectives a net can be trained from.
+const (
+	perspectiveMover = "mover"
+	perspectiveSeat  = "seat"
+)
+
 // Sample is a training row: flattened input, regression target (raw deep score),
 // and the game-outcome auxiliary signal (+1 won, -1 lost, 0 unknown/masked).
 type Sample struct {
@@ -90,6 +116,104 @@ func (r Record) input() ([]float64, error) {
 	return vec, nil
 }
 
+// players is the record's seat count: one base per seat, or the seats with
+// features for records that predate the position block.
+func (r Record) players() int {
+	if n := len(r.Position.Bases); n > 0 {
+		return n
+	}
+	n := 0
+	for seat, f := range r.Features {
+		if len(f) > 0 {
+			n = seat + 1
+		}
+	}
+	return n
+}
+
+// seatInput is the record's input rotated to seat (0-based): slot k holds the
+// features of the seat k places after it in turn order. Mirrors
+// nnuefeat.SeatInputs; keep the two in sync.
+func (r Record) seatInput(seat int) ([]float64, error) {
+	players := r.players()
+	vec := make([]float64, inputDim)
+	for k := 0; k < players; k++ {
+		f := r.Features[(seat+k)%players]
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != featuresPerSeat {
+			return nil, fmt.Errorf("seat %d has %d features, want %d", (seat+k)%players, len(f), featuresPerSeat)
+		}
+		copy(vec[k*featuresPerSeat:], f)
+	}
+	return vec, nil
+}
+
+// seatSamples turns a record into one sample per active seat with a known
+// score: the per-seat deep score of multiplayer records, or ±deepScore in 1v1.
+func (r Record) seatSamples() ([]Sample, error) {
+	players := r.players()
+	var out []Sample
+	for seat := 0; seat < players; seat++ {
+		score, ok := r.seatScore(seat)
+		if !ok {
+			continue
+		}
+		vec, err := r.seatInput(seat)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Sample{
+			Input:   vec,
+			Score:   float64(score),
+			Outcome: r.seatOutcome(seat + 1),
+			Hash:    hashString(r.Fingerprint),
+		})
+	}
+	return out, nil
+}
+
+// seatScore is the deep score of an active seat (0-based): its per-seat score
+// in multiplayer records, ±deepScore in 1v1. ok is false for inactive seats
+// and multiplayer records without per-seat labels.
+func (r Record) seatScore(seat int) (score int, ok bool) {
+	players := r.players()
+	if len(r.Features[seat]) == 0 {
+		return 0, false
+	}
+	switch {
+	case len(r.SeatScores) == players:
+		return r.SeatScores[seat], true
+	case players == 2 && seat+1 == r.CurrentPlayer:
+		return r.DeepScore, true
+	case players == 2:
+		return -r.DeepScore, true
+	}
+	return 0, false
+}
+
+// seatOutcome is seatSamples' auxiliary target: +1 for first place, -1 for
+// last, 0 in between or when the result is unknown.
+func (r Record) seatOutcome(seat int) float64 {
+	if players := r.players(); len(r.Placements) == players {
+		switch r.Placements[seat-1] {
+		case 1:
+			return 1
+		case players:
+			return -1
+		}
+		return 0
+	}
+	switch r.Outcome.Winner {
+	case 0:
+		return 0
+	case seat:
+		return 1
+	}
+	return -1
+}
+
 // outcomeSignal maps the mover's placement to a signed auxiliary target.
 func outcomeSignal(o Outcome) float64 {
 	switch o.Placement {
@@ -102,8 +226,9 @@ func outcomeSignal(o Outcome) float64 {
 	}
 }
 
-// loadShards reads every shard-*.jsonl under dir into Samples.
-func loadShards(dir string) ([]Sample, error) {
+// loadShards reads every shard-*.jsonl under dir into Samples from the given
+// perspective.
+func loadShards(dir, perspective string) ([]Sample, error) {
 	shards, err := filepath.Glob(filepath.Join(dir, "shard-*.jsonl"))
 	if err != nil {
 		return nil, err
@@ -126,6 +251,18 @@ func loadShards(dir string) ([]Sample, error) {
 				file.Close()
 				return nil, fmt.Errorf("%s: %w", path, err)
 			}
+			if rec.Budget == 0 {
+				continue // unsearched (nnuegen -human): a policy label only
+			}
+			if perspective == perspectiveSeat {
+				rows, err := rec.seatSamples()
+				if err != nil {
+					file.Close()
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				samples = append(samples, rows...)
+				continue
+			}
 			vec, err := rec.input()
 			if err != nil {
 				file.Close()
@@ -181,49 +318,135 @@ func normStats(samples []Sample) Stats {
 
 func (st Stats) norm(score float64) float64 { return (score - st.Mean) / st.Std }
 
-// MLP is a 2-layer network: input → hidden (tanh) → 1 (linear).
+// Activation is the hidden-layer nonlinearity. Its values are the weights
+// file's activation codes (nnueweights.ActTanh, ActClippedReLU).
+type Activation uint8
+
+const (
+	// Tanh is the original activation, and the only one the compiled-in
+	// (-export) weights run.
+	Tanh Activation = iota
+	// ClippedReLU is min(max(z, 0), 1), the usual NNUE activation.
+	ClippedReLU
+)
+
+func parseActivation(name string) (Activation, error) {
+	switch name {
+	case "tanh":
+		return Tanh, nil
+	case "crelu":
+		return ClippedReLU, nil
+	}
+	return 0, fmt.Errorf("unknown activation %q (tanh or crelu)", name)
+}
+
+func (a Activation) apply(z float64) float64 {
+	if a == ClippedReLU {
+		return min(max(z, 0), 1)
+	}
+	return math.Tanh(z)
+}
+
+// slope is the activation's derivative, recovered from its output y.
+func (a Activation) slope(y float64) float64 {
+	if a == ClippedReLU {
+		if y > 0 && y < 1 {
+			return 1
+		}
+		return 0
+	}
+	return 1 - y*y
+}
+
+// MLP is a feed-forward network: input → hidden layers → 1 (linear). W1/B1 is
+// the first hidden layer and W2/B2 the output, as in the original 2-layer net;
+// Deep holds any further hidden layers between them.
 type MLP struct {
 	In, Hidden int
 	W1         [][]float64 // Hidden × In
 	B1         []float64   // Hidden
-	W2         []float64   // Hidden (output weights)
+	Deep       []Dense
+	W2         []float64 // last hidden width (output weights)
 	B2         float64
+	Act        Activation
+}
+
+// Dense is a hidden layer after the first.
+type Dense struct {
+	W [][]float64 // width × previous width
+	B []float64
 }
 
+// newMLP is the original single tanh hidden layer net.
 func newMLP(in, hidden int, rng *uint64) *MLP {
-	m := &MLP{In: in, Hidden: hidden, B1: make([]float64, hidden), W2: make([]float64, hidden)}
-	m.W1 = make([][]float64, hidden)
+	return newNet(in, []int{hidden}, Tanh, rng)
+}
+
+// newNet builds a net with one hidden layer per width.
+func newNet(in int, widths []int, act Activation, rng *uint64) *MLP {
+	m := &MLP{In: in, Hidden: widths[0], Act: act}
 	// He-ish init scaled by fan-in; deterministic from the seed.
-	scale1 := math.Sqrt(2.0 / float64(in))
-	for h := 0; h < hidden; h++ {
-		m.W1[h] = make([]float64, in)
-		for i := 0; i < in; i++ {
-			m.W1[h][i] = randNorm(rng) * scale1
-		}
+	m.W1, m.B1 = initLayer(widths[0], in, rng)
+	for l := 1; l < len(widths); l++ {
+		w, b := initLayer(widths[l], widths[l-1], rng)
+		m.Deep = append(m.Deep, Dense{W: w, B: b})
 	}
-	scale2 := math.Sqrt(2.0 / float64(hidden))
-	for h := 0; h < hidden; h++ {
-		m.W2[h] = randNorm(rng) * scale2
+	top := widths[len(widths)-1]
+	m.W2 = make([]float64, top)
+	scale := math.Sqrt(2.0 / float64(top))
+	for h := range m.W2 {
+		m.W2[h] = randNorm(rng) * scale
 	}
 	return m
 }
 
-// forward returns the network output and the hidden activations (for backprop).
-func (m *MLP) forward(x []float64) (float64, []float64) {
-	hid := make([]float64, m.Hidden)
-	for h := 0; h < m.Hidden; h++ {
-		z := m.B1[h]
-		w := m.W1[h]
-		for i := 0; i < m.In; i++ {
-			z += w[i] * x[i]
+func initLayer(width, fanIn int, rng *uint64) ([][]float64, []float64) {
+	w := make([][]float64, width)
+	scale := math.Sqrt(2.0 / float64(fanIn))
+	for j := range w {
+		w[j] = make([]float64, fanIn)
+		for i := range w[j] {
+			w[j][i] = randNorm(rng) * scale
 		}
-		hid[h] = math.Tanh(z)
 	}
+	return w, make([]float64, width)
+}
+
+// widths lists the hidden layer widths.
+func (m *MLP) widths() []int {
+	out := []int{m.Hidden}
+	for _, layer := range m.Deep {
+		out = append(out, len(layer.B))
+	}
+	return out
+}
+
+// forward returns the network output and every hidden layer's activations (for
+// backprop).
+func (m *MLP) forward(x []float64) (float64, [][]float64) {
+	acts := make([][]float64, 1+len(m.Deep))
+	acts[0] = layerForward(m.W1, m.B1, x, m.Act)
+	for l, layer := range m.Deep {
+		acts[l+1] = layerForward(layer.W, layer.B, acts[l], m.Act)
+	}
+	top := acts[len(acts)-1]
 	out := m.B2
-	for h := 0; h < m.Hidden; h++ {
-		out += m.W2[h] * hid[h]
+	for h, w := range m.W2 {
+		out += w * top[h]
+	}
+	return out, acts
+}
+
+func layerForward(w [][]float64, b, x []float64, act Activation) []float64 {
+	y := make([]float64, len(w))
+	for j, row := range w {
+		z := b[j]
+		for i, v := range row {
+			z += v * x[i]
+		}
+		y[j] = act.apply(z)
 	}
-	return out, hid
+	return y
 }
 
 // predict returns just the output.
@@ -232,100 +455,166 @@ func (m *MLP) predict(x []float64) float64 {
 	return out
 }
 
-// Adam holds per-parameter moment estimates, laid out to mirror MLP.
-type adam struct {
-	mW1, vW1 [][]float64
-	mB1, vB1 []float64
-	mW2, vW2 []float64
-	mB2, vB2 float64
-	t        int
-	lr, b1, b2, eps float64
+// backward writes into g (shaped like m) the gradient of a loss whose slope at
+// the output is gOut, for the input x whose forward pass gave acts. Every
+// gradient uses the weights as of the forward pass.
+func (m *MLP) backward(g *MLP, x []float64, acts [][]float64, gOut float64) {
+	top := acts[len(acts)-1]
+	g.B2 = gOut
+	delta := make([]float64, len(top))
+	for h := range top {
+		g.W2[h] = gOut * top[h]
+		delta[h] = gOut * m.W2[h] * m.Act.slope(top[h])
+	}
+	for l := len(m.Deep) - 1; l >= 0; l-- {
+		below, layer, grad := acts[l], m.Deep[l], g.Deep[l]
+		next := make([]float64, len(below))
+		for j, d := range delta {
+			grad.B[j] = d
+			for i, v := range below {
+				grad.W[j][i] = d * v
+				next[i] += d * layer.W[j][i]
+			}
+		}
+		for i := range next {
+			next[i] *= m.Act.slope(below[i])
+		}
+		delta = next
+	}
+	for h, d := range delta {
+		g.B1[h] = d
+		for i, v := range x {
+			g.W1[h][i] = d * v
+		}
+	}
 }
 
-func newAdam(m *MLP, lr float64) *adam {
-	a := &adam{lr: lr, b1: 0.9, b2: 0.999, eps: 1e-8}
-	a.mW1 = zerosLike(m.W1)
-	a.vW1 = zerosLike(m.W1)
-	a.mB1 = make([]float64, m.Hidden)
-	a.vB1 = make([]float64, m.Hidden)
-	a.mW2 = make([]float64, m.Hidden)
-	a.vW2 = make([]float64, m.Hidden)
-	return a
+// vectors lists every weight and bias vector of m except the scalar B2, in a
+// fixed order, so same-shaped models can be walked in step.
+func (m *MLP) vectors() [][]float64 {
+	out := append([][]float64{}, m.W1...)
+	out = append(out, m.B1)
+	for _, layer := range m.Deep {
+		out = append(out, layer.W...)
+		out = append(out, layer.B)
+	}
+	return append(out, m.W2)
+}
+
+// clone is a deep copy of m.
+func (m *MLP) clone() *MLP {
+	c := *m
+	c.W1, c.B1 = cloneRows(m.W1), append([]float64(nil), m.B1...)
+	c.Deep = make([]Dense, len(m.Deep))
+	for l, layer := range m.Deep {
+		c.Deep[l] = Dense{W: cloneRows(layer.W), B: append([]float64(nil), layer.B...)}
+	}
+	c.W2 = append([]float64(nil), m.W2...)
+	return &c
 }
 
-func zerosLike(w [][]float64) [][]float64 {
+func cloneRows(w [][]float64) [][]float64 {
 	out := make([][]float64, len(w))
 	for i := range w {
-		out[i] = make([]float64, len(w[i]))
+		out[i] = append([]float64(nil), w[i]...)
 	}
 	return out
 }
 
-// trainEpoch runs one pass over samples, returning the mean training loss.
-// auxWeight scales the game-outcome auxiliary term.
-func trainEpoch(m *MLP, a *adam, samples []Sample, st Stats, auxWeight float64) float64 {
-	// bias-correction terms; recomputed each optimization step (per sample).
-	var bc1, bc2 float64
+// zeroLike is a model of m's shape with every parameter zero.
+func zeroLike(m *MLP) *MLP {
+	z := m.clone()
+	for _, v := range z.vectors() {
+		clear(v)
+	}
+	z.B2 = 0
+	return z
+}
+
+// dequantized is m as its int8 export computes: every weight vector replaced by
+// its quantize/dequantize round trip (see Quantize). dst, when non-nil, must
+// have m's shape and is overwritten instead of allocating.
+func (m *MLP) dequantized(dst *MLP) *MLP {
+	if dst == nil {
+		dst = m.clone()
+	}
+	out := dst.vectors()
+	for k, v := range m.vectors() {
+		qv := quantVec(v)
+		for i := range v {
+			out[k][i] = qv.at(i)
+		}
+	}
+	dst.B2 = m.B2
+	return dst
+}
+
+// adam holds Adam's moment estimates, shaped like the model they update.
+type adam struct {
+	M, V            *MLP
+	T               int
+	LR, B1, B2, Eps float64
+}
+
+func newAdam(m *MLP, lr float64) *adam {
+	return &adam{M: zeroLike(m), V: zeroLike(m), LR: lr, B1: 0.9, B2: 0.999, Eps: 1e-8}
+}
+
+// step applies one Adam update of gradient g to m.
+func (a *adam) step(m, g *MLP) {
+	// Adam timestep advances once per update step, so bias correction tracks
+	// the number of moment updates (not the epoch count).
+	a.T++
+	bc1 := 1 - math.Pow(a.B1, float64(a.T))
+	bc2 := 1 - math.Pow(a.B2, float64(a.T))
 	upd := func(g float64, mm, vv *float64) float64 {
-		*mm = a.b1*(*mm) + (1-a.b1)*g
-		*vv = a.b2*(*vv) + (1-a.b2)*g*g
+		*mm = a.B1*(*mm) + (1-a.B1)*g
+		*vv = a.B2*(*vv) + (1-a.B2)*g*g
 		mHat := *mm / bc1
 		vHat := *vv / bc2
-		return a.lr * mHat / (math.Sqrt(vHat) + a.eps)
+		return a.LR * mHat / (math.Sqrt(vHat) + a.Eps)
 	}
+	grads, ms, vs := g.vectors(), a.M.vectors(), a.V.vectors()
+	for k, p := range m.vectors() {
+		for i := range p {
+			p[i] -= upd(grads[k][i], &ms[k][i], &vs[k][i])
+		}
+	}
+	m.B2 -= upd(g.B2, &a.M.B2, &a.V.B2)
+}
+
+// trainEpoch runs one pass over samples, returning the mean training loss.
+// With qat set, each step's forward and backward pass runs on the int8 round
+// trip of the weights and the float weights take the update (straight-through),
+// so the net learns around its export quantization.
+func trainEpoch(m *MLP, a *adam, samples []Sample, st Stats, obj Objective, qat bool) float64 {
+	g := zeroLike(m)
+	view := m
+	var quant *MLP
 	var lossSum float64
 	for _, s := range samples {
-		// Adam timestep advances once per update step, so bias correction
-		// tracks the number of moment updates (not the epoch count).
-		a.t++
-		bc1 = 1 - math.Pow(a.b1, float64(a.t))
-		bc2 = 1 - math.Pow(a.b2, float64(a.t))
-		target := st.norm(s.Score)
-		out, hid := m.forward(s.Input)
-		// primary MSE on normalized score
-		dScore := out - target
-		loss := dScore * dScore
-		// auxiliary: nudge output toward outcome sign when known
-		dAux := 0.0
-		if s.Outcome != 0 {
-			dAux = auxWeight * (out - s.Outcome)
-			loss += auxWeight * (out - s.Outcome) * (out - s.Outcome)
+		if qat {
+			quant = m.dequantized(quant)
+			view = quant
 		}
+		out, acts := view.forward(s.Input)
+		loss, gOut := obj.eval(out, s, st)
 		lossSum += loss
-		// dLoss/dOut
-		gOut := 2*dScore + 2*dAux
-		// hidden layer grads first, using the output weights as of the forward
-		// pass (before the output-layer step below mutates W2).
-		for h := 0; h < m.Hidden; h++ {
-			// dLoss/dhid = gOut * W2[h]; tanh' = 1 - hid^2
-			gHid := gOut * m.W2[h] * (1 - hid[h]*hid[h])
-			m.B1[h] -= upd(gHid, &a.mB1[h], &a.vB1[h])
-			w := m.W1[h]
-			mw, vw := a.mW1[h], a.vW1[h]
-			for i := 0; i < m.In; i++ {
-				gW1 := gHid * s.Input[i]
-				w[i] -= upd(gW1, &mw[i], &vw[i])
-			}
-		}
-		// output layer grads
-		m.B2 -= upd(gOut, &a.mB2, &a.vB2)
-		for h := 0; h < m.Hidden; h++ {
-			gW2 := gOut * hid[h]
-			m.W2[h] -= upd(gW2, &a.mW2[h], &a.vW2[h])
-		}
+		view.backward(g, s.Input, acts, gOut)
+		a.step(m, g)
 	}
 	return lossSum / float64(len(samples))
 }
 
-// valLoss returns the mean normalized-score MSE over samples (no aux term).
-func valLoss(m *MLP, samples []Sample, st Stats) float64 {
+// valLoss returns the objective's validation loss over samples (for mse, the
+// normalized-score MSE without the auxiliary term).
+func valLoss(m *MLP, samples []Sample, st Stats, obj Objective) float64 {
 	if len(samples) == 0 {
 		return 0
 	}
 	var sum float64
 	for _, s := range samples {
-		d := m.predict(s.Input) - st.norm(s.Score)
-		sum += d * d
+		sum += obj.val(m.predict(s.Input), s, st)
 	}
 	return sum / float64(len(samples))
 }
@@ -426,61 +715,273 @@ func next(rng *uint64) uint64 {
 type Trained struct {
 	Model *MLP
 	Stats Stats
+	// SeatRelative marks a net trained from the seat perspective.
+	SeatRelative bool
+}
+
+// Config is one training run's settings. It is recorded in the report, and a
+// resumed run must match its checkpoint's network, objective and seed.
+type Config struct {
+	Hidden     []int     `json:"hidden"`
+	Activation string    `json:"activation"`
+	Epochs     int       `json:"epochs"`
+	LR         float64   `json:"lr"`
+	Schedule   Schedule  `json:"schedule"`
+	Objective  Objective `json:"objective"`
+	// Patience stops training after this many epochs without a validation
+	// improvement and restores the best epoch's weights; 0 trains every epoch
+	// and keeps the last.
+	Patience int `json:"patience,omitempty"`
+	// QATEpochs fine-tune on the int8 round trip of the weights after the
+	// float epochs, at QATLR (0: LR/10), keeping whichever epoch's int8 net
+	// validates best.
+	QATEpochs int     `json:"qatEpochs,omitempty"`
+	QATLR     float64 `json:"qatLR,omitempty"`
+	Seed      uint64  `json:"seed"`
+	// Checkpoint, when set, is rewritten after every epoch; Resume continues
+	// from it.
+	Checkpoint string `json:"-"`
+	Resume     bool   `json:"-"`
 }
 
-// Train runs the full training loop and returns the trained model. It prints a
-// per-epoch report to w.
-func Train(samples []Sample, hidden, epochs int, lr, auxWeight float64, seed uint64, w *os.File) (*Trained, error) {
+// defaultConfig is the original trainer: one tanh layer, constant rate, MSE.
+func defaultConfig(hidden, epochs int, lr, aux float64, seed uint64) Config {
+	return Config{
+		Hidden: []int{hidden}, Activation: "tanh", Epochs: epochs, LR: lr,
+		Schedule:  Schedule{Kind: scheduleConstant},
+		Objective: Objective{Kind: lossMSE, Aux: aux},
+		Seed:      seed,
+	}
+}
+
+func (c Config) check() error {
+	if len(c.Hidden) == 0 {
+		return fmt.Errorf("no hidden layers")
+	}
+	for _, width := range c.Hidden {
+		if width < 1 {
+			return fmt.Errorf("hidden width %d", width)
+		}
+	}
+	if _, err := parseActivation(c.Activation); err != nil {
+		return err
+	}
+	if c.Resume && c.Checkpoint == "" {
+		return fmt.Errorf("resuming needs a checkpoint path")
+	}
+	if err := c.Schedule.check(); err != nil {
+		return err
+	}
+	return c.Objective.check()
+}
+
+// Train runs the full training loop and returns the trained model with its
+// report. It prints a per-epoch line to w.
+func Train(samples []Sample, cfg Config, w io.Writer) (*Trained, *Report, error) {
 	if len(samples) == 0 {
-		return nil, fmt.Errorf("no samples")
+		return nil, nil, fmt.Errorf("no samples")
+	}
+	if err := cfg.check(); err != nil {
+		return nil, nil, err
 	}
+	act, _ := parseActivation(cfg.Activation)
 	train, val := split(samples)
-	st := normStats(train)
-	rng := seed | 1
-	m := newMLP(inputDim, hidden, &rng)
-	a := newAdam(m, lr)
-	for e := 1; e <= epochs; e++ {
-		tl := trainEpoch(m, a, train, st, auxWeight)
-		vl := valLoss(m, val, st)
-		sp := spearman(m, val)
+	run := &checkpoint{Stats: normStats(train)}
+	rng := cfg.Seed | 1
+	run.Model = newNet(inputDim, cfg.Hidden, act, &rng)
+	run.Adam = newAdam(run.Model, cfg.LR)
+	run.Report = Report{Train: len(train), Val: len(val)}
+	if cfg.Resume {
+		resumed, err := loadCheckpoint(cfg.Checkpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := resumed.matches(cfg, run); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", cfg.Checkpoint, err)
+		}
+		run = resumed
+	}
+	run.Config = cfg
+	run.Report.Config = cfg
+	st, obj := run.Stats, cfg.Objective
+
+	for !run.Report.StoppedEarly && run.Epoch < cfg.Epochs {
+		e := run.Epoch + 1
+		run.Adam.LR = cfg.Schedule.rate(cfg.LR, e, cfg.Epochs)
+		tl := trainEpoch(run.Model, run.Adam, train, st, obj, false)
+		vl := valLoss(run.Model, val, st, obj)
+		sp := spearman(run.Model, val)
+		run.Report.Epochs = append(run.Report.Epochs, EpochReport{Epoch: e, LR: run.Adam.LR, TrainLoss: tl, ValLoss: vl, Spearman: sp})
 		if w != nil {
-			fmt.Fprintf(w, "epoch %3d  train_loss %.6f  val_loss %.6f  spearman %.4f\n", e, tl, vl, sp)
+			fmt.Fprintf(w, "epoch %3d  train_loss %.6f  val_loss %.6f  spearman %.4f  lr %.3g\n", e, tl, vl, sp, run.Adam.LR)
+		}
+		if run.Best == nil || vl < run.BestLoss {
+			run.Best, run.BestLoss, run.Report.BestEpoch, run.Stale = run.Model.clone(), vl, e, 0
+		} else {
+			run.Stale++
+		}
+		run.Epoch = e
+		if cfg.Patience > 0 && run.Stale >= cfg.Patience {
+			run.Report.StoppedEarly = true
+			if w != nil {
+				fmt.Fprintf(w, "early stop: no val improvement in %d epochs, best epoch %d\n", cfg.Patience, run.Report.BestEpoch)
+			}
+		}
+		if err := run.save(cfg.Checkpoint); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if cfg.QATEpochs > 0 && run.QATBest == nil {
+		if cfg.Patience > 0 && run.Best != nil {
+			run.Model = run.Best.clone()
+		}
+		run.QATBest, run.QATLoss = run.Model.clone(), valLoss(run.Model.dequantized(nil), val, st, obj)
+	}
+	qatLR := cfg.QATLR
+	if qatLR == 0 {
+		qatLR = cfg.LR / 10
+	}
+	for run.QATDone < cfg.QATEpochs {
+		e := run.Epoch + run.QATDone + 1
+		run.Adam.LR = qatLR
+		tl := trainEpoch(run.Model, run.Adam, train, st, obj, true)
+		quant := run.Model.dequantized(nil)
+		vl := valLoss(quant, val, st, obj)
+		sp := spearman(quant, val)
+		run.Report.Epochs = append(run.Report.Epochs, EpochReport{Epoch: e, LR: qatLR, TrainLoss: tl, ValLoss: vl, Spearman: sp, QAT: true})
+		if w != nil {
+			fmt.Fprintf(w, "qat   %3d  train_loss %.6f  val_loss %.6f  spearman %.4f  lr %.3g\n", e, tl, vl, sp, qatLR)
+		}
+		if vl <= run.QATLoss {
+			run.QATBest, run.QATLoss = run.Model.clone(), vl
+		}
+		run.QATDone++
+		if err := run.save(cfg.Checkpoint); err != nil {
+			return nil, nil, err
 		}
 	}
-	return &Trained{Model: m, Stats: st}, nil
+
+	trained := &Trained{Model: run.final(), Stats: st}
+	report := run.Report
+	report.Float = evaluate(trained.Model, val, st, obj)
+	report.Int8 = evaluate(trained.Model.dequantized(nil), val, st, obj)
+	return trained, &report, nil
 }
 
 func main() {
 	data := flag.String("data", "", "directory of shard-*.jsonl (required)")
-	export := flag.String("export", "weights_out.go", "output Go source for int8 weights ('-' for stdout)")
+	export := flag.String("export", "weights_out.go", "output Go source for int8 weights ('-' for stdout, '' to skip)")
+	exportBin := flag.String("export-bin", "", "output binary weights file for VS_NNUE_WEIGHTS ('' to skip)")
 	pkg := flag.String("package", "nnueweights", "package name for the exported weights file")
-	hidden := flag.Int("hidden", 48, "hidden layer width")
+	hidden := flag.String("hidden", "48", "hidden layer widths, comma-separated (e.g. 64,32 for two layers)")
+	activation := flag.String("activation", "tanh", "hidden activation: tanh or crelu (clipped ReLU)")
 	epochs := flag.Int("epochs", 100, "training epochs")
 	lr := flag.Float64("lr", 0.001, "Adam learning rate")
-	aux := flag.Float64("aux", 0.05, "game-outcome auxiliary loss weight")
+	schedule := flag.String("schedule", scheduleConstant, "learning-rate schedule: constant, step or cosine")
+	lrStep := flag.Int("lr-step", 25, "step schedule: epochs between decays")
+	lrGamma := flag.Float64("lr-gamma", 0.5, "step schedule: decay factor")
+	loss := flag.String("loss", lossMSE, "objective: mse (normalized deep score) or wdl (win probability)")
+	aux := flag.Float64("aux", 0.05, "mse: game-outcome auxiliary loss weight")
+	lambda := flag.Float64("lambda", 0.5, "wdl: weight of the game outcome against the deep score")
+	wdlScale := flag.Float64("wdl-scale", 0, "wdl: sigmoid width in eval units (0 = target std)")
+	patience := flag.Int("patience", 0, "stop after this many epochs without a val-loss improvement, keeping the best (0 = off)")
+	qat := flag.Int("qat", 0, "quantization-aware fine-tuning epochs after training")
+	qatLR := flag.Float64("qat-lr", 0, "learning rate for -qat epochs (0 = lr/10)")
+	checkpointPath := flag.String("checkpoint", "", "rewrite this training checkpoint after every epoch")
+	resume := flag.Bool("resume", false, "continue from -checkpoint")
+	reportPath := flag.String("report", "", "write a JSON report of the run to this path")
 	seed := flag.Uint64("seed", 1, "init/shuffle seed")
+	perspective := flag.String("perspective", perspectiveMover, "mover (score the side to move) or seat (score every seat, for 3-4 player search)")
+	policy := flag.String("policy", "", "train the move-ordering policy net on the records' policy labels instead, writing its weights file here (VS_POLICY_WEIGHTS)")
+	policyHidden := flag.Int("policy-hidden", 32, "-policy: hidden layer width")
+	board := flag.String("board", "", "train the board-plane net on the records' positions instead, writing its weights file here (VS_NNUE_BOARD_WEIGHTS)")
+	boardHidden := flag.Int("board-hidden", 32, "-board: accumulator width per seat perspective")
 	flag.Parse()
 	if *data == "" {
 		fmt.Fprintln(os.Stderr, "-data is required")
 		os.Exit(2)
 	}
-	samples, err := loadShards(*data)
+	if *policy != "" {
+		trainPolicyMain(*data, *policy, PolicyConfig{Hidden: *policyHidden, Epochs: *epochs, LR: *lr, Seed: *seed})
+		return
+	}
+	if *board != "" {
+		trainBoardMain(*data, *board, BoardConfig{Hidden: *boardHidden, Epochs: *epochs, LR: *lr, Seed: *seed})
+		return
+	}
+	if *perspective != perspectiveMover && *perspective != perspectiveSeat {
+		fmt.Fprintf(os.Stderr, "-perspective must be %s or %s\n", perspectiveMover, perspectiveSeat)
+		os.Exit(2)
+	}
+	widths, err := parseWidths(*hidden)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg := Config{
+		Hidden: widths, Activation: *activation, Epochs: *epochs, LR: *lr,
+		Schedule:  Schedule{Kind: *schedule},
+		Objective: Objective{Kind: *loss},
+		Patience:  *patience, QATEpochs: *qat, QATLR: *qatLR, Seed: *seed,
+		Checkpoint: *checkpointPath, Resume: *resume,
+	}
+	if cfg.Schedule.Kind == scheduleStep {
+		cfg.Schedule.Every, cfg.Schedule.Gamma = *lrStep, *lrGamma
+	}
+	switch cfg.Objective.Kind {
+	case lossMSE:
+		cfg.Objective.Aux = *aux
+	case lossWDL:
+		cfg.Objective.Lambda, cfg.Objective.Scale = *lambda, *wdlScale
+	}
+	if err := cfg.check(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// The compiled-in package vars describe one tanh layer on the mover
+	// layout; everything else ships as a weights file.
+	if *export != "" && (len(widths) > 1 || *activation != "tanh" || *perspective == perspectiveSeat) {
+		fmt.Fprintln(os.Stderr, "only single-layer tanh mover nets compile in; pass -export '' and ship -export-bin")
+		os.Exit(2)
+	}
+	samples, err := loadShards(*data, *perspective)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 	fmt.Printf("loaded %d samples from %s\n", len(samples), *data)
-	trained, err := Train(samples, *hidden, *epochs, *lr, *aux, *seed, os.Stdout)
+	trained, report, err := Train(samples, cfg, os.Stdout)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	trained.SeatRelative = *perspective == perspectiveSeat
 	if err := checkFinite(trained); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	fmt.Printf("val_loss float %.6f int8 %.6f  spearman float %.4f int8 %.4f\n",
+		report.Float.ValLoss, report.Int8.ValLoss, report.Float.Spearman, report.Int8.Spearman)
+	if *reportPath != "" {
+		if err := writeReport(report, *reportPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("wrote report to %s\n", *reportPath)
+	}
+	if *exportBin != "" {
+		if err := writeBinary(trained, *exportBin); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("wrote binary weights to %s\n", *exportBin)
+	}
 	src := ExportGo(trained, *pkg)
-	if *export == "-" {
+	switch *export {
+	case "":
+		return
+	case "-":
 		fmt.Print(src)
 		return
 	}
@@ -490,3 +991,70 @@ func main() {
 	}
 	fmt.Printf("wrote int8 weights to %s (package %s)\n", *export, *pkg)
 }
+
+// parseWidths parses -hidden.
+func parseWidths(spec string) ([]int, error) {
+	var widths []int
+	for _, part := range strings.Split(spec, ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || width < 1 {
+			return nil, fmt.Errorf("bad -hidden %q: want comma-separated positive widths", spec)
+		}
+		widths = append(widths, width)
+	}
+	return widths, nil
+}
+
+// trainPolicyMain is main for -policy.
+func trainPolicyMain(data, out string, cfg PolicyConfig) {
+	samples, err := loadPolicyShards(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("loaded %d policy samples from %s\n", len(samples), data)
+	model, score, err := TrainPolicy(samples, cfg, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("val_loss %.6f  val_top1 %.4f\n", score.Loss, score.Top1)
+	if err := writePolicy(model, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote policy weights to %s\n", out)
+}
+
+// trainBoardMain is main for -board.
+func trainBoardMain(data, out string, cfg BoardConfig) {
+	samples, err := loadBoardShards(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("loaded %d board samples from %s\n", len(samples), data)
+	model, score, err := TrainBoard(samples, cfg, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("val_loss %.6f  val_spearman %.4f\n", score.Loss, score.Spearman)
+	if err := writeBoard(model, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote board weights to %s\n", out)
+}
+
+func writeBinary(trained *Trained, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := ExportBinary(trained, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -1,16 +1,27 @@
 package main
 
 import (
+	"bytes"
+	"encoding/binary"
+	"flag"
 	"go/ast"
 	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"hash/crc32"
+	"io"
 	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
 
+var update = flag.Bool("update", false, "rewrite the binary weights golden files")
+
 // synth builds a learnable dataset: score is a fixed linear function of a few
 // input features, so a couple epochs must lower the loss.
 func synth(n int) []Sample {
@@ -43,7 +54,7 @@ func TestTrainLossDecreases(t *testing.T) {
 	epochs := 15
 	losses := make([]float64, epochs)
 	for e := 0; e < epochs; e++ {
-		losses[e] = trainEpoch(m, a, train, st, 0.05)
+		losses[e] = trainEpoch(m, a, train, st, Objective{Kind: lossMSE, Aux: 0.05}, false)
 	}
 	for e := 1; e < epochs; e++ {
 		if losses[e] >= losses[e-1] {
@@ -57,7 +68,7 @@ func TestTrainLossDecreases(t *testing.T) {
 
 func TestInt8RoundTrip(t *testing.T) {
 	samples := synth(200)
-	trained, err := Train(samples, 32, 40, 0.01, 0.05, 3, nil)
+	trained, _, err := Train(samples, defaultConfig(32, 40, 0.01, 0.05, 3), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -100,7 +111,7 @@ func typeCheck(t *testing.T, src string) {
 
 func TestExportGoCompiles(t *testing.T) {
 	samples := synth(50)
-	trained, err := Train(samples, 8, 5, 0.01, 0.05, 1, nil)
+	trained, _, err := Train(samples, defaultConfig(8, 5, 0.01, 0.05, 1), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -113,7 +124,7 @@ func TestExportGoCompiles(t *testing.T) {
 	// Degenerate model (epochs=0): zero-init biases/W2 hit quantVec's scale=1
 	// zero-guard, which prints whole-number scales — the case that must still
 	// emit float64-typed vars and type-check.
-	zero, err := Train(samples, 8, 0, 0.01, 0.05, 1, nil)
+	zero, _, err := Train(samples, defaultConfig(8, 0, 0.01, 0.05, 1), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -133,3 +144,597 @@ func TestCheckFinite(t *testing.T) {
 		t.Fatal("non-finite weight not caught")
 	}
 }
+
+// goldenBinary is where the binary export golden lives: in the backend, whose
+// nnueweights tests decode it, so a format drift between the two modules
+// fails on both sides.
+const (
+	goldenBinary     = "../../backend/search/nnueweights/testdata/trainer-golden.nnue"
+	goldenDeepBinary = "../../backend/search/nnueweights/testdata/trainer-golden-deep.nnue"
+)
+
+// goldenModel is a fixed 4-unit net built from integer formulas (no RNG, no
+// training), so its export is bit-stable across platforms.
+func goldenModel() *Trained {
+	m := &MLP{In: inputDim, Hidden: 4, B1: make([]float64, 4), W2: make([]float64, 4), B2: 0.125}
+	m.W1 = make([][]float64, 4)
+	for h := range m.W1 {
+		m.W1[h] = make([]float64, inputDim)
+		for i := range m.W1[h] {
+			m.W1[h][i] = float64((h*31+i*7)%19-9) / 64
+		}
+		m.B1[h] = float64(h-2) / 8
+		m.W2[h] = float64(3-2*h) / 4
+	}
+	return &Trained{Model: m, Stats: Stats{Mean: 250, Std: 1000}}
+}
+
+// goldenProbe is the input both modules evaluate the golden net on.
+func goldenProbe() []float64 {
+	x := make([]float64, inputDim)
+	for i := range x {
+		x[i] = float64(i%7) - 3
+	}
+	return x
+}
+
+// goldenDeepModel extends goldenModel with a 3-unit second layer and
+// ClippedReLU, pinning the format-3 architecture fields.
+func goldenDeepModel() *Trained {
+	trained := goldenModel()
+	m := trained.Model
+	m.Act = ClippedReLU
+	layer := Dense{W: make([][]float64, 3), B: []float64{0.25, -0.125, 0}}
+	for j := range layer.W {
+		layer.W[j] = make([]float64, 4)
+		for i := range layer.W[j] {
+			layer.W[j][i] = float64((j*5+i*3)%7-3) / 4
+		}
+	}
+	m.Deep = []Dense{layer}
+	m.W2 = []float64{0.5, -1, 0.75}
+	return trained
+}
+
+// checkGolden compares t's export with the golden file at path (rewriting it
+// under -update) and returns the export.
+func checkGolden(t *testing.T, trained *Trained, path string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := ExportBinary(trained, &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	predictPath := strings.TrimSuffix(path, ".nnue") + ".predict"
+	prediction := strconv.FormatFloat(Quantize(trained).Predict(goldenProbe()), 'g', -1, 64)
+	if *update {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(predictPath, []byte(prediction+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("%s: binary export drifted from the backend golden; bump the format version or run go test -update", path)
+	}
+	if got, _ := os.ReadFile(predictPath); strings.TrimSpace(string(got)) != prediction {
+		t.Fatalf("golden prediction %q, int8 forward pass gives %s", got, prediction)
+	}
+	return data
+}
+
+func TestExportBinaryGolden(t *testing.T) {
+	data := checkGolden(t, goldenModel(), goldenBinary)
+
+	// Header and trailer spot checks against the documented layout.
+	if string(data[:4]) != binMagic || binary.LittleEndian.Uint16(data[4:]) != binVersion ||
+		binary.LittleEndian.Uint16(data[6:]) != featureSchema || binary.LittleEndian.Uint16(data[8:]) != featuresPerSeat ||
+		binary.LittleEndian.Uint32(data[12:]) != inputDim || binary.LittleEndian.Uint32(data[16:]) != 4 {
+		t.Fatalf("header % x", data[:20])
+	}
+	if binary.LittleEndian.Uint16(data[60:]) != layoutMover || binary.LittleEndian.Uint16(data[62:]) != uint16(Tanh) ||
+		binary.LittleEndian.Uint16(data[64:]) != 0 {
+		t.Fatalf("layout/activation/depth fields % x", data[60:66])
+	}
+	if want := 60 + 6 + 4*8 + 4*inputDim + 2*4 + 4; len(data) != want {
+		t.Fatalf("file is %d bytes, want %d", len(data), want)
+	}
+	deep := checkGolden(t, goldenDeepModel(), goldenDeepBinary)
+	if binary.LittleEndian.Uint16(deep[62:]) != uint16(ClippedReLU) || binary.LittleEndian.Uint16(deep[64:]) != 1 ||
+		binary.LittleEndian.Uint32(deep[66:]) != 3 {
+		t.Fatalf("deep architecture fields % x", deep[60:70])
+	}
+	if want := len(data) + 4 + 3*8 + 3*4 + 3 + 8 - 1; len(deep) != want {
+		t.Fatalf("deep file is %d bytes, want %d", len(deep), want)
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		t.Fatal("checksum trailer does not match the body")
+	}
+}
+
+// TestSeatSamplesRotate: the seat perspective yields one sample per active seat
+// whose slot 0 is that seat, labelled with its own score and placement.
+func TestSeatSamplesRotate(t *testing.T) {
+	var rec Record
+	rec.Fingerprint = "f00d"
+	rec.CurrentPlayer = 2
+	rec.Position.Bases = []int{0, 48, 6, 42}
+	for seat := 0; seat < seats; seat++ {
+		if seat == 2 {
+			continue // eliminated
+		}
+		rec.Features[seat] = make([]float64, featuresPerSeat)
+		rec.Features[seat][0] = float64(seat + 1)
+	}
+	rec.DeepScore = 40
+	rec.SeatScores = []int{-10, 40, -500000000, 7}
+	rec.Placements = []int{2, 1, 4, 3}
+	samples, err := rec.seatSamples()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("got %d samples, want one per active seat", len(samples))
+	}
+	for i, want := range []struct {
+		slots   [seats]float64
+		score   float64
+		outcome float64
+	}{
+		{[seats]float64{1, 2, 0, 4}, -10, 0},
+		{[seats]float64{2, 0, 4, 1}, 40, 1},
+		{[seats]float64{4, 1, 2, 0}, 7, 0},
+	} {
+		got := samples[i]
+		for k, v := range want.slots {
+			if got.Input[k*featuresPerSeat] != v {
+				t.Fatalf("sample %d slot %d = %v, want %v", i, k, got.Input[k*featuresPerSeat], v)
+			}
+		}
+		if got.Score != want.score || got.Outcome != want.outcome {
+			t.Fatalf("sample %d labelled %v/%v, want %v/%v", i, got.Score, got.Outcome, want.score, want.outcome)
+		}
+	}
+
+	// 1v1 records without seat scores mirror the deep score.
+	var duel Record
+	duel.CurrentPlayer, duel.DeepScore, duel.Outcome = 1, 30, Outcome{Winner: 2, Placement: 2}
+	duel.Features[0] = make([]float64, featuresPerSeat)
+	duel.Features[1] = make([]float64, featuresPerSeat)
+	samples, err = duel.seatSamples()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Score != 30 || samples[1].Score != -30 || samples[0].Outcome != -1 || samples[1].Outcome != 1 {
+		t.Fatalf("1v1 seat samples %+v", samples)
+	}
+}
+
+// TestBackwardMatchesFiniteDifference checks backprop through two hidden layers
+// of each activation against central differences of the loss.
+func TestBackwardMatchesFiniteDifference(t *testing.T) {
+	sample := synth(1)[0]
+	st := Stats{Mean: 0.5, Std: 3}
+	for _, obj := range []Objective{{Kind: lossMSE, Aux: 0.05}, {Kind: lossWDL, Lambda: 0.3}} {
+		for _, act := range []Activation{Tanh, ClippedReLU} {
+			rng := uint64(11)
+			m := newNet(inputDim, []int{6, 5}, act, &rng)
+			for _, v := range m.vectors() { // push clipped units into the linear range
+				for i := range v {
+					v[i] *= 0.3
+				}
+			}
+			loss := func() float64 {
+				l, _ := obj.eval(m.predict(sample.Input), sample, st)
+				return l
+			}
+			g := zeroLike(m)
+			out, acts := m.forward(sample.Input)
+			_, slope := obj.eval(out, sample, st)
+			m.backward(g, sample.Input, acts, slope)
+			params, grads := m.vectors(), g.vectors()
+			for _, k := range []int{0, 5, len(params) - 9, len(params) - 2, len(params) - 1} {
+				for _, i := range []int{0, len(params[k]) - 1} {
+					const eps = 1e-6
+					saved := params[k][i]
+					params[k][i] = saved + eps
+					up := loss()
+					params[k][i] = saved - eps
+					down := loss()
+					params[k][i] = saved
+					numeric := (up - down) / (2 * eps)
+					if math.Abs(numeric-grads[k][i]) > 1e-6+1e-4*math.Abs(numeric) {
+						t.Fatalf("%s/%d vector %d[%d]: backprop %v, numeric %v", obj.Kind, act, k, i, grads[k][i], numeric)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestScheduleRates(t *testing.T) {
+	step := Schedule{Kind: scheduleStep, Every: 2, Gamma: 0.5}
+	cosine := Schedule{Kind: scheduleCosine}
+	for _, tc := range []struct {
+		schedule Schedule
+		epoch    int
+		want     float64
+	}{
+		{Schedule{Kind: scheduleConstant}, 7, 0.1},
+		{step, 1, 0.1}, {step, 2, 0.1}, {step, 3, 0.05}, {step, 5, 0.025},
+		{cosine, 1, 0.1}, {cosine, 6, 0.05},
+	} {
+		if got := tc.schedule.rate(0.1, tc.epoch, 10); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("%s epoch %d: rate %v, want %v", tc.schedule.Kind, tc.epoch, got, tc.want)
+		}
+	}
+	if (Schedule{Kind: scheduleStep}).check() == nil {
+		t.Error("step schedule without a period accepted")
+	}
+}
+
+// TestEarlyStopKeepsBest: with patience, a run whose val loss stalls stops and
+// exports the best epoch's weights.
+func TestEarlyStopKeepsBest(t *testing.T) {
+	cfg := defaultConfig(16, 60, 0.05, 0.05, 3)
+	cfg.Patience = 2
+	trained, report, err := Train(synth(200), cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.StoppedEarly || len(report.Epochs) >= 60 {
+		t.Fatalf("ran %d epochs without stopping early", len(report.Epochs))
+	}
+	best := report.Epochs[report.BestEpoch-1].ValLoss
+	for _, epoch := range report.Epochs {
+		if epoch.ValLoss < best {
+			t.Fatalf("epoch %d beat the reported best %d", epoch.Epoch, report.BestEpoch)
+		}
+	}
+	if report.Float.ValLoss != best {
+		t.Fatalf("exported net validates at %v, best epoch at %v", report.Float.ValLoss, best)
+	}
+	if trained.Model == nil || len(report.Epochs) != report.BestEpoch+cfg.Patience {
+		t.Fatalf("stopped at epoch %d with best %d", len(report.Epochs), report.BestEpoch)
+	}
+}
+
+// TestResumeMatchesUninterrupted: a run cut short and resumed from its
+// checkpoint, across the float and QAT phases, ends bit-identical to one that
+// ran straight through.
+func TestResumeMatchesUninterrupted(t *testing.T) {
+	samples := synth(120)
+	cfg := defaultConfig(8, 4, 0.01, 0.05, 5)
+	cfg.Hidden, cfg.Activation = []int{8, 4}, "crelu"
+	cfg.Schedule = Schedule{Kind: scheduleStep, Every: 2, Gamma: 0.5}
+	cfg.QATEpochs = 2
+	straight, straightReport, err := Train(samples, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "run.ckpt")
+	short := cfg
+	short.Epochs, short.QATEpochs, short.Checkpoint = 2, 0, path
+	if _, _, err := Train(samples, short, nil); err != nil {
+		t.Fatal(err)
+	}
+	resumed := cfg
+	resumed.Checkpoint, resumed.Resume = path, true
+	again, againReport, err := Train(samples, resumed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(straight.Model, again.Model) {
+		t.Fatal("resumed run's weights differ from the uninterrupted run's")
+	}
+	againReport.Config.Checkpoint, againReport.Config.Resume = "", false
+	if !reflect.DeepEqual(straightReport, againReport) {
+		t.Fatalf("reports differ:\n%+v\n%+v", straightReport, againReport)
+	}
+	if n := len(againReport.Epochs); n != 6 || !againReport.Epochs[n-1].QAT {
+		t.Fatalf("report epochs %+v", againReport.Epochs)
+	}
+
+	other := resumed
+	other.Hidden = []int{8}
+	if _, _, err := Train(samples, other, nil); err == nil || !strings.Contains(err.Error(), "net") {
+		t.Fatalf("resumed a checkpoint into another architecture: %v", err)
+	}
+}
+
+// TestQATKeepsInt8Close: fine-tuning on the int8 round trip never leaves the
+// exported net validating worse than plain quantization of the float net.
+func TestQATKeepsInt8Close(t *testing.T) {
+	samples := synth(300)
+	_, val := split(samples)
+	cfg := defaultConfig(16, 20, 0.01, 0.05, 9)
+	cfg.Hidden = []int{16, 8}
+	plain, _, err := Train(samples, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := plain.Stats
+	before := valLoss(plain.Model.dequantized(nil), val, st, cfg.Objective)
+	cfg.QATEpochs = 4
+	tuned, report, err := Train(samples, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := valLoss(tuned.Model.dequantized(nil), val, st, cfg.Objective)
+	if after > before || report.Int8.ValLoss != after {
+		t.Fatalf("int8 val loss %v after QAT (reported %v), %v before", after, report.Int8.ValLoss, before)
+	}
+	// The dequantized float model is exactly what the int8 export computes.
+	qm := Quantize(tuned)
+	for _, s := range val {
+		want := tuned.Model.dequantized(nil).predict(s.Input)*st.Std + st.Mean
+		if got := qm.Predict(s.Input); math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+			t.Fatalf("int8 forward %v, dequantized float %v", got, want)
+		}
+	}
+}
+
+// TestPolicyFeaturesOrient: the patch is seen from the mover's corner, like
+// nnuefeat.AppendPolicyFeatures, and the chosen move is the sample's target.
+func TestPolicyFeaturesOrient(t *testing.T) {
+	var rec Record
+	rec.Fingerprint, rec.Rows, rec.Cols, rec.CurrentPlayer = "beef", 3, 3, 2
+	// Seat 2's base at (2,2), an opponent Normal at (1,1), a neutral at (0,0).
+	rec.Position.Cells = "EAA" + "AGA" + "AAM"
+	rec.Position.MovesLeft = 2
+	rec.Policy = &PolicyTarget{Kind: "move", Cells: []int{5}, Moves: []int{2, 5}}
+	sample, ok, err := rec.policySample()
+	if err != nil || !ok {
+		t.Fatalf("policySample: %v, %t", err, ok)
+	}
+	if sample.Head != headMove || sample.Target[0] != 0 || sample.Target[1] != 1 {
+		t.Fatalf("sample %+v", sample)
+	}
+	features := sample.Candidates[1] // cell (1,2)
+	at := func(r, c int) int {       // window slot r,c rows/cols away from the mover's corner
+		return features[(r+policyRadius)*policyWindow+c+policyRadius] % policyChannels
+	}
+	// Seat 2 flips rows and cols: offsets run from (2,2) towards (0,0).
+	if at(0, 0) != 1 || at(-1, 0) != 4 || at(0, 1) != 6 || at(0, -1) != 0 || at(1, 0) != 1 || at(1, 2) != 2 {
+		t.Fatalf("channels: centre %d, own base %d, opponent %d, off-board %d, empty %d, neutral %d",
+			at(0, 0), at(-1, 0), at(0, 1), at(0, -1), at(1, 0), at(1, 2))
+	}
+	if last := features[len(features)-1]; last != policyInputs-2 {
+		t.Fatalf("movesLeft feature %d, want %d", last, policyInputs-2)
+	}
+}
+
+// TestPolicyTrainsAndExports: the net learns to pick the candidate with a
+// telltale patch, and its VNNP export reproduces the float logits.
+func TestPolicyTrainsAndExports(t *testing.T) {
+	var rng uint64 = 99
+	patch := func(telltale bool) []int {
+		features := make([]int, 0, policyWindow*policyWindow+1)
+		for slot := 0; slot < policyWindow*policyWindow; slot++ {
+			channel := 1 + int(next(&rng)%2)
+			if telltale && slot == 7 {
+				channel = 8
+			}
+			features = append(features, slot*policyChannels+channel)
+		}
+		return append(features, policyInputs-3)
+	}
+	samples := make([]PolicySample, 200)
+	for i := range samples {
+		n := 3 + i%4
+		s := PolicySample{Head: i % 2, Target: make([]float64, n), Hash: uint32(i)}
+		pick := int(next(&rng) % uint64(n))
+		for c := 0; c < n; c++ {
+			s.Candidates = append(s.Candidates, patch(c == pick))
+		}
+		s.Target[pick] = 1
+		samples[i] = s
+	}
+	model, score, err := TrainPolicy(samples, PolicyConfig{Hidden: 8, Epochs: 10, LR: 0.01, Seed: 5}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score.Top1 < 0.9 {
+		t.Fatalf("val top-1 %.2f after training", score.Top1)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportPolicy(model, &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[:4]) != policyMagic || binary.LittleEndian.Uint16(data[6:]) != policySchema ||
+		binary.LittleEndian.Uint32(data[8:]) != policyInputs || binary.LittleEndian.Uint32(data[12:]) != 8 {
+		t.Fatalf("header % x", data[:16])
+	}
+	if want := 16 + 4*8 + 4*8 + policyInputs*8 + 2*8 + 4; len(data) != want {
+		t.Fatalf("file is %d bytes, want %d", len(data), want)
+	}
+	if crc32.ChecksumIEEE(data[:len(data)-4]) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		t.Fatal("checksum trailer does not match the body")
+	}
+	// Replay nnueweights.PolicyNet.Logits on the exported bytes.
+	float := func(off int) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(data[off:])) }
+	w1Scale, w2Scale, b2 := float(16), float(24), float(32)
+	b1 := data[48:]
+	w1 := data[48+4*8:]
+	w2 := w1[policyInputs*8:]
+	features := samples[0].Candidates[0]
+	acc := make([]float64, 8)
+	want := model.forward(features, headMove, acc)
+	got := b2
+	for h := 0; h < 8; h++ {
+		sum := int32(binary.LittleEndian.Uint32(b1[4*h:]))
+		for _, f := range features {
+			sum += int32(int8(w1[f*8+h]))
+		}
+		got += float64(int8(w2[h])) * min(max(float64(sum)*w1Scale, 0), 1) * w2Scale
+	}
+	if math.Abs(got-want) > 0.1*math.Max(1, math.Abs(want)) {
+		t.Fatalf("int8 logit %.4f, float %.4f", got, want)
+	}
+}
+
+// TestBoardFeaturesMirrorPlanes: each seat sees its own base top-left and
+// itself as owner 0, like nnuefeat.BoardView.Feature, and a 1v1 record gives
+// one sample per seat with the mirrored deep score.
+func TestBoardFeaturesMirrorPlanes(t *testing.T) {
+	var rec Record
+	rec.Fingerprint, rec.Rows, rec.Cols, rec.CurrentPlayer, rec.DeepScore, rec.Budget = "cafe", 3, 3, 1, 40, 100
+	rec.Features = [4][]float64{make([]float64, featuresPerSeat), make([]float64, featuresPerSeat)}
+	// Seat 1's base at (0,0), seat 2's at (2,2), a seat 2 Normal at (1,1), a
+	// neutral at (0,2).
+	rec.Position.Cells = "HAE" + "ALA" + "AAM"
+	rec.Position.Bases = []int{0, 8}
+	plane := func(p, row, col int) int { return (p*boardSide+row)*boardSide + col }
+	if got, want := rec.boardFeatures(1), []int{plane(1, 0, 0), plane(12, 0, 2), plane(3, 1, 1), plane(4, 2, 2)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("seat 1 features %v, want %v", got, want)
+	}
+	if got, want := rec.boardFeatures(2), []int{plane(4, 2, 2), plane(12, 2, 0), plane(0, 1, 1), plane(1, 0, 0)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("seat 2 features %v, want %v", got, want)
+	}
+	samples, err := rec.boardSamples()
+	if err != nil || len(samples) != 2 {
+		t.Fatalf("boardSamples: %d, %v", len(samples), err)
+	}
+	if samples[0].Score != 40 || samples[1].Score != -40 || !samples[1].InPlay[1] || samples[1].InPlay[2] ||
+		!reflect.DeepEqual(samples[1].Views[0], rec.boardFeatures(2)) || !reflect.DeepEqual(samples[1].Views[1], rec.boardFeatures(1)) {
+		t.Fatalf("samples %+v", samples)
+	}
+}
+
+// goldenBoardBinary is the board-plane export golden, decoded by the
+// backend's nnueweights tests like goldenBinary.
+const goldenBoardBinary = "../../backend/search/nnueweights/testdata/trainer-golden-board.nnue"
+
+// goldenBoardModel is a fixed 2-unit board net built from integer formulas.
+func goldenBoardModel() *BoardModel {
+	m := &BoardModel{
+		Hidden: 2, W1: make([]float64, boardFeatures*2), B1: []float64{0.25, -0.125},
+		W2: make([]float64, seats*2), B2: 0.125, Stats: Stats{Mean: 250, Std: 1000},
+	}
+	for i := range m.W1 {
+		m.W1[i] = float64((i*7)%19-9) / 64
+	}
+	for i := range m.W2 {
+		m.W2[i] = float64(3-i) / 4
+	}
+	return m
+}
+
+// goldenBoardProbe is the sample both modules evaluate the golden net on:
+// two seats in play, the nnueweights test refreshes the same features.
+func goldenBoardProbe() BoardSample {
+	return BoardSample{
+		Views:  [seats][]int{{7, 2600, 12345}, {99, 5100, 30000, 32499}},
+		InPlay: [seats]bool{true, true},
+	}
+}
+
+// boardInt8Predict replays nnueweights.BoardNet.Output on exported bytes.
+func boardInt8Predict(data []byte, s BoardSample) float64 {
+	float := func(off int) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(data[off:])) }
+	hidden := int(binary.LittleEndian.Uint32(data[12:]))
+	mean, std, w1Scale, w2Scale, b2 := float(20), float(28), float(36), float(44), float(52)
+	b1 := data[68:]
+	w1 := b1[4*hidden:]
+	w2 := w1[boardFeatures*hidden:]
+	out := b2
+	for k, features := range s.Views {
+		if !s.InPlay[k] {
+			continue
+		}
+		for h := 0; h < hidden; h++ {
+			sum := int32(binary.LittleEndian.Uint32(b1[4*h:]))
+			for _, f := range features {
+				sum += int32(int8(w1[f*hidden+h]))
+			}
+			out += float64(int8(w2[k*hidden+h])) * w2Scale * min(max(float64(sum)*w1Scale, 0), 1)
+		}
+	}
+	return out*std + mean
+}
+
+func TestExportBoardGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportBoard(goldenBoardModel(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[:4]) != boardMagic || binary.LittleEndian.Uint16(data[4:]) != boardVersion ||
+		binary.LittleEndian.Uint16(data[6:]) != boardSchema || binary.LittleEndian.Uint16(data[8:]) != boardSide ||
+		binary.LittleEndian.Uint16(data[10:]) != boardPlanes || binary.LittleEndian.Uint32(data[12:]) != 2 ||
+		binary.LittleEndian.Uint32(data[16:]) != 0 {
+		t.Fatalf("header % x", data[:20])
+	}
+	if want := 68 + 2*4 + boardFeatures*2 + seats*2 + 4; len(data) != want {
+		t.Fatalf("file is %d bytes, want %d", len(data), want)
+	}
+	if crc32.ChecksumIEEE(data[:len(data)-4]) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		t.Fatal("checksum trailer does not match the body")
+	}
+	predictPath := strings.TrimSuffix(goldenBoardBinary, ".nnue") + ".predict"
+	prediction := strconv.FormatFloat(boardInt8Predict(data, goldenBoardProbe()), 'g', -1, 64)
+	if *update {
+		if err := os.WriteFile(goldenBoardBinary, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(predictPath, []byte(prediction+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenBoardBinary)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("%s: board export drifted from the backend golden; bump the format version or run go test -update", goldenBoardBinary)
+	}
+	if got, _ := os.ReadFile(predictPath); strings.TrimSpace(string(got)) != prediction {
+		t.Fatalf("golden prediction %q, int8 forward pass gives %s", got, prediction)
+	}
+}
+
+// TestBoardTrainsAndExports: the net learns a score carried by one cell, and
+// its VNNB export reproduces the float predictions.
+func TestBoardTrainsAndExports(t *testing.T) {
+	var rng uint64 = 65
+	samples := make([]BoardSample, 300)
+	for i := range samples {
+		s := BoardSample{InPlay: [seats]bool{true, true}, Hash: uint32(i)}
+		for k := 0; k < 2; k++ {
+			for n := 0; n < 6; n++ {
+				s.Views[k] = append(s.Views[k], int(next(&rng)%uint64(boardFeatures)))
+			}
+		}
+		if i%3 == 0 {
+			s.Views[0] = append(s.Views[0], 1234)
+			s.Score = 400
+		}
+		samples[i] = s
+	}
+	model, score, err := TrainBoard(samples, BoardConfig{Hidden: 4, Epochs: 20, LR: 0.01, Seed: 5}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score.Spearman < 0.8 {
+		t.Fatalf("val spearman %.2f after training", score.Spearman)
+	}
+	var buf bytes.Buffer
+	if err := ExportBoard(model, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range samples[:20] {
+		want := model.predict(s)
+		if got := boardInt8Predict(buf.Bytes(), s); math.Abs(got-want) > 0.1*model.Stats.Std {
+			t.Fatalf("int8 prediction %.1f, float %.1f", got, want)
+		}
+	}
+}
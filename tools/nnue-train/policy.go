@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// vs-ai2.69 policy net (-policy): a second, independent model trained on the
+// records' policy labels instead of their scores. It rates one cell at a time
+// from the 5×5 patch around it (nnuefeat.AppendPolicyFeatures) with a move
+// head and a neutral head, and is trained by softmax cross-entropy over the
+// label's candidates: every legal move target for a "move" label, the mover's
+// Normal cells for a "neutral" one (both chosen cells share the target mass).
+// The export is the VNNP file backend/search/nnueweights/policy.go documents,
+// loaded by search through VS_POLICY_WEIGHTS.
+
+// PolicyTarget mirrors nnuegen's policy label; keep in sync.
+type PolicyTarget struct {
+	Kind  string `json:"kind"`
+	Cells []int  `json:"cells"`
+	Moves []int  `json:"moves"`
+	Owned []int  `json:"owned,omitempty"`
+}
+
+// Policy patch layout, mirroring nnuefeat's policy.go; bump policySchema with
+// nnuefeat.PolicySchemaVersion.
+const (
+	policySchema   = 1
+	policyRadius   = 2
+	policyWindow   = 2*policyRadius + 1
+	policyChannels = 9 // off-board, empty, neutral, own N/B/F, opponent N/B/F
+	policyInputs   = policyWindow*policyWindow*policyChannels + 3
+
+	policyMagic   = "VNNP"
+	policyVersion = 1
+)
+
+// Policy heads.
+const (
+	headMove    = 0
+	headNeutral = 1
+)
+
+// PolicySample is one labelled decision: the active features of every
+// candidate cell and the target probability of each.
+type PolicySample struct {
+	Head       int
+	Candidates [][]int
+	Target     []float64
+	Hash       uint32
+}
+
+// policyFeatures is the record's patch around cell for its mover. Mirrors
+// nnuefeat.AppendPolicyFeatures; keep the two in sync.
+func (r Record) policyFeatures(cell int) []int {
+	mover := r.CurrentPlayer
+	dr, dc := 1, 1
+	if mover == 2 || mover == 4 {
+		dr = -1
+	}
+	if mover == 2 || mover == 3 {
+		dc = -1
+	}
+	row, col := cell/r.Cols, cell%r.Cols
+	features := make([]int, 0, policyWindow*policyWindow+1)
+	for i := -policyRadius; i <= policyRadius; i++ {
+		for j := -policyRadius; j <= policyRadius; j++ {
+			channel := 0
+			if rr, cc := row+i*dr, col+j*dc; rr >= 0 && rr < r.Rows && cc >= 0 && cc < r.Cols {
+				code := int(r.Position.Cells[rr*r.Cols+cc] - 'A')
+				owner, kind := code/5, code%5
+				switch {
+				case kind == 0:
+					channel = 1
+				case kind == 4:
+					channel = 2
+				case owner == mover:
+					channel = 3 + kind - 1
+				default:
+					channel = 6 + kind - 1
+				}
+			}
+			slot := (i+policyRadius)*policyWindow + j + policyRadius
+			features = append(features, slot*policyChannels+channel)
+		}
+	}
+	movesLeft := min(max(r.Position.MovesLeft, 1), 3)
+	return append(features, policyWindow*policyWindow*policyChannels+movesLeft-1)
+}
+
+// policySample turns a labelled record into a sample; ok is false for records
+// without a usable label.
+func (r Record) policySample() (PolicySample, bool, error) {
+	p := r.Policy
+	if p == nil {
+		return PolicySample{}, false, nil
+	}
+	if len(r.Position.Cells) != r.Rows*r.Cols {
+		return PolicySample{}, false, fmt.Errorf("%s: %d cells for a %dx%d board", r.Fingerprint, len(r.Position.Cells), r.Rows, r.Cols)
+	}
+	sample := PolicySample{Head: headMove, Hash: hashString(r.Fingerprint)}
+	candidates := p.Moves
+	if p.Kind == "neutral" {
+		sample.Head, candidates = headNeutral, p.Owned
+	}
+	chosen := map[int]bool{}
+	for _, cell := range p.Cells {
+		chosen[cell] = true
+	}
+	hits := 0
+	sample.Target = make([]float64, len(candidates))
+	for i, cell := range candidates {
+		if cell < 0 || cell >= r.Rows*r.Cols {
+			return PolicySample{}, false, fmt.Errorf("%s: policy cell %d off the board", r.Fingerprint, cell)
+		}
+		sample.Candidates = append(sample.Candidates, r.policyFeatures(cell))
+		if chosen[cell] {
+			sample.Target[i] = 1
+			hits++
+		}
+	}
+	// A single candidate teaches nothing, and a label whose choice is not
+	// among its candidates cannot be scored.
+	if len(candidates) < 2 || hits != len(p.Cells) {
+		return PolicySample{}, false, nil
+	}
+	for i := range sample.Target {
+		sample.Target[i] /= float64(hits)
+	}
+	return sample, true, nil
+}
+
+// loadPolicyShards reads every labelled record under dir.
+func loadPolicyShards(dir string) ([]PolicySample, error) {
+	shards, err := filepath.Glob(filepath.Join(dir, "shard-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no shard-*.jsonl files in %s", dir)
+	}
+	sort.Strings(shards)
+	var samples []PolicySample
+	for _, path := range shards {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 1<<20), 1<<24)
+		for scanner.Scan() {
+			var rec Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			sample, ok, err := rec.policySample()
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if ok {
+				samples = append(samples, sample)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			file.Close()
+			return nil, err
+		}
+		file.Close()
+	}
+	return samples, nil
+}
+
+// PolicyModel is the float policy net: W1 feature-major, clipped ReLU, W2 the
+// move head's Hidden block then the neutral head's.
+type PolicyModel struct {
+	Hidden int
+	W1, B1 []float64
+	W2     []float64
+	B2     [2]float64
+}
+
+func newPolicyModel(hidden int, rng *uint64) *PolicyModel {
+	m := &PolicyModel{
+		Hidden: hidden,
+		W1:     make([]float64, policyInputs*hidden),
+		B1:     make([]float64, hidden),
+		W2:     make([]float64, 2*hidden),
+	}
+	// 26 features are on per cell; this keeps the accumulator inside the
+	// clipped ReLU's slope at the start.
+	scale := 0.5 / math.Sqrt(policyWindow*policyWindow+1)
+	for i := range m.W1 {
+		m.W1[i] = randNorm(rng) * scale
+	}
+	for i := range m.B1 {
+		m.B1[i] = 0.5
+	}
+	for i := range m.W2 {
+		m.W2[i] = randNorm(rng) / math.Sqrt(float64(hidden))
+	}
+	return m
+}
+
+// forward returns the head's logit for one candidate, leaving the hidden
+// pre-activations in acc.
+func (m *PolicyModel) forward(features []int, head int, acc []float64) float64 {
+	copy(acc, m.B1)
+	for _, f := range features {
+		for h, w := range m.W1[f*m.Hidden : (f+1)*m.Hidden] {
+			acc[h] += w
+		}
+	}
+	w2 := m.W2[head*m.Hidden : (head+1)*m.Hidden]
+	logit := m.B2[head]
+	for h, a := range acc {
+		logit += w2[h] * min(max(a, 0), 1)
+	}
+	return logit
+}
+
+// logits rates every candidate of s; accs receives each one's pre-activations.
+func (m *PolicyModel) logits(s PolicySample, accs [][]float64) []float64 {
+	out := make([]float64, len(s.Candidates))
+	for i, features := range s.Candidates {
+		out[i] = m.forward(features, s.Head, accs[i])
+	}
+	return out
+}
+
+// policyLoss is the cross-entropy of s's target under the softmax of logits,
+// and whether the top-rated candidate is a chosen one. It overwrites logits
+// with the softmax probabilities.
+func policyLoss(s PolicySample, logits []float64) (loss float64, top bool) {
+	best := 0
+	for i, l := range logits {
+		if l > logits[best] {
+			best = i
+		}
+	}
+	peak, sum := logits[best], 0.0
+	for i, l := range logits {
+		logits[i] = math.Exp(l - peak)
+		sum += logits[i]
+	}
+	for i := range logits {
+		logits[i] /= sum
+		if s.Target[i] > 0 {
+			loss -= s.Target[i] * math.Log(max(logits[i], 1e-300))
+		}
+	}
+	return loss, s.Target[best] > 0
+}
+
+// policyAdam is Adam over a PolicyModel's flattened parameters.
+type policyAdam struct {
+	M, V            []float64
+	T               int
+	LR, B1, B2, Eps float64
+}
+
+// params lists m's parameters in a fixed order, aliasing its storage.
+func (m *PolicyModel) params() [][]float64 {
+	return [][]float64{m.W1, m.B1, m.W2, m.B2[:]}
+}
+
+func (m *PolicyModel) size() int {
+	n := 0
+	for _, p := range m.params() {
+		n += len(p)
+	}
+	return n
+}
+
+// step applies gradient g (flattened like params) to m.
+func (a *policyAdam) step(m *PolicyModel, g []float64) {
+	a.T++
+	bc1 := 1 - math.Pow(a.B1, float64(a.T))
+	bc2 := 1 - math.Pow(a.B2, float64(a.T))
+	k := 0
+	for _, p := range m.params() {
+		for i := range p {
+			a.M[k] = a.B1*a.M[k] + (1-a.B1)*g[k]
+			a.V[k] = a.B2*a.V[k] + (1-a.B2)*g[k]*g[k]
+			p[i] -= a.LR * (a.M[k] / bc1) / (math.Sqrt(a.V[k]/bc2) + a.Eps)
+			k++
+		}
+	}
+}
+
+// backward accumulates the gradient of s's loss into g, given each
+// candidate's softmax probability and pre-activations.
+func (m *PolicyModel) backward(s PolicySample, probs []float64, accs [][]float64, g []float64) {
+	w1, b1 := g[:len(m.W1)], g[len(m.W1):len(m.W1)+m.Hidden]
+	w2 := g[len(m.W1)+m.Hidden : len(m.W1)+3*m.Hidden]
+	b2 := g[len(m.W1)+3*m.Hidden:]
+	head := s.Head * m.Hidden
+	for i, features := range s.Candidates {
+		d := probs[i] - s.Target[i]
+		if d == 0 {
+			continue
+		}
+		b2[s.Head] += d
+		for h, a := range accs[i] {
+			w2[head+h] += d * min(max(a, 0), 1)
+			if a <= 0 || a >= 1 {
+				continue
+			}
+			da := d * m.W2[head+h]
+			b1[h] += da
+			for _, f := range features {
+				w1[f*m.Hidden+h] += da
+			}
+		}
+	}
+}
+
+// PolicyConfig is a -policy run's settings.
+type PolicyConfig struct {
+	Hidden, Epochs int
+	LR             float64
+	Seed           uint64
+}
+
+// PolicyEval is a model's loss and top-1 accuracy over a sample set.
+type PolicyEval struct {
+	Loss, Top1 float64
+}
+
+// evalPolicy scores m on samples.
+func evalPolicy(m *PolicyModel, samples []PolicySample) PolicyEval {
+	if len(samples) == 0 {
+		return PolicyEval{}
+	}
+	var e PolicyEval
+	for _, s := range samples {
+		loss, top := policyLoss(s, m.logits(s, scratch(len(s.Candidates), m.Hidden)))
+		e.Loss += loss
+		if top {
+			e.Top1++
+		}
+	}
+	e.Loss /= float64(len(samples))
+	e.Top1 /= float64(len(samples))
+	return e
+}
+
+func scratch(n, hidden int) [][]float64 {
+	accs := make([][]float64, n)
+	for i := range accs {
+		accs[i] = make([]float64, hidden)
+	}
+	return accs
+}
+
+// TrainPolicy fits a policy net to samples, one Adam step per decision,
+// logging per-epoch train loss and validation loss and top-1 accuracy to w.
+// It returns the model and its final validation score.
+func TrainPolicy(samples []PolicySample, cfg PolicyConfig, w io.Writer) (*PolicyModel, PolicyEval, error) {
+	if len(samples) == 0 {
+		return nil, PolicyEval{}, fmt.Errorf("no samples with a policy label")
+	}
+	if cfg.Hidden < 1 || cfg.Epochs < 1 || cfg.LR <= 0 {
+		return nil, PolicyEval{}, fmt.Errorf("policy: need hidden, epochs and lr > 0")
+	}
+	var train, val []PolicySample
+	for _, s := range samples {
+		if s.Hash%10 == 0 {
+			val = append(val, s)
+		} else {
+			train = append(train, s)
+		}
+	}
+	if len(train) == 0 {
+		train, val = samples, nil
+	}
+	rng := cfg.Seed | 1
+	m := newPolicyModel(cfg.Hidden, &rng)
+	a := &policyAdam{M: make([]float64, m.size()), V: make([]float64, m.size()), LR: cfg.LR, B1: 0.9, B2: 0.999, Eps: 1e-8}
+	g := make([]float64, m.size())
+	order := make([]int, len(train))
+	for i := range order {
+		order[i] = i
+	}
+	var score PolicyEval
+	for e := 1; e <= cfg.Epochs; e++ {
+		for i := len(order) - 1; i > 0; i-- {
+			j := int(next(&rng) % uint64(i+1))
+			order[i], order[j] = order[j], order[i]
+		}
+		total := 0.0
+		for _, i := range order {
+			s := train[i]
+			accs := scratch(len(s.Candidates), m.Hidden)
+			probs := m.logits(s, accs)
+			loss, _ := policyLoss(s, probs)
+			total += loss
+			clear(g)
+			m.backward(s, probs, accs, g)
+			a.step(m, g)
+		}
+		score = evalPolicy(m, val)
+		fmt.Fprintf(w, "policy epoch %3d  train_loss %.4f  val_loss %.4f  val_top1 %.3f\n",
+			e, total/float64(len(train)), score.Loss, score.Top1)
+	}
+	return m, score, nil
+}
+
+// ExportPolicy writes m in the int8 VNNP format: W1 and B1 share one scale so
+// B1 lands in accumulator units, W2 has its own.
+func ExportPolicy(m *PolicyModel, w io.Writer) error {
+	absMax := func(vs []float64) float64 {
+		peak := 0.0
+		for _, v := range vs {
+			peak = max(peak, math.Abs(v))
+		}
+		if peak == 0 {
+			return 1
+		}
+		return peak
+	}
+	w1Scale := absMax(m.W1) / 127
+	w2Scale := absMax(m.W2) / 127
+	quant := func(vs []float64, scale float64) []int8 {
+		q := make([]int8, len(vs))
+		for i, v := range vs {
+			q[i] = int8(min(max(math.Round(v/scale), -127), 127))
+		}
+		return q
+	}
+	b1 := make([]int32, m.Hidden)
+	for h, b := range m.B1 {
+		b1[h] = int32(math.Round(b / w1Scale))
+	}
+	var buf bytes.Buffer
+	put := func(v any) {
+		// Writes to a bytes.Buffer cannot fail for these fixed-size values.
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString(policyMagic)
+	put(uint16(policyVersion))
+	put(uint16(policySchema))
+	put(uint32(policyInputs))
+	put(uint32(m.Hidden))
+	put([]float64{w1Scale, w2Scale, m.B2[0], m.B2[1]})
+	put(b1)
+	put(quant(m.W1, w1Scale))
+	put(quant(m.W2, w2Scale))
+	put(crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writePolicy(m *PolicyModel, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := ExportPolicy(m, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
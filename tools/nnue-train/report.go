@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Report is the JSON summary of one training run (-report), for comparing
+// runs: the settings, every epoch's losses, and how the exported net scores.
+type Report struct {
+	Config       Config        `json:"config"`
+	Train        int           `json:"trainSamples"`
+	Val          int           `json:"valSamples"`
+	Epochs       []EpochReport `json:"epochs"`
+	BestEpoch    int           `json:"bestEpoch"`
+	StoppedEarly bool          `json:"stoppedEarly"`
+	// Float and Int8 score the exported net on the validation set before
+	// and after int8 quantization.
+	Float Metrics `json:"float"`
+	Int8  Metrics `json:"int8"`
+}
+
+// EpochReport is one epoch's line. QAT epochs report their int8 round trip's
+// validation numbers.
+type EpochReport struct {
+	Epoch     int     `json:"epoch"`
+	LR        float64 `json:"lr"`
+	TrainLoss float64 `json:"trainLoss"`
+	ValLoss   float64 `json:"valLoss"`
+	Spearman  float64 `json:"spearman"`
+	QAT       bool    `json:"qat,omitempty"`
+}
+
+// Metrics are validation-set scores of one net.
+type Metrics struct {
+	ValLoss  float64 `json:"valLoss"`
+	Spearman float64 `json:"spearman"`
+}
+
+func evaluate(m *MLP, val []Sample, st Stats, obj Objective) Metrics {
+	return Metrics{ValLoss: valLoss(m, val, st, obj), Spearman: spearman(m, val)}
+}
+
+func writeReport(report *Report, path string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}